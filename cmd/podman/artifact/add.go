@@ -1,7 +1,9 @@
 package artifact
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/containers/common/pkg/completion"
 	"github.com/containers/podman/v5/cmd/podman/common"
@@ -15,22 +17,44 @@ var (
 	addCmd = &cobra.Command{
 		Use:               "add [options] ARTIFACT PATH [...PATH]",
 		Short:             "Add an OCI artifact to the local store",
-		Long:              "Add an OCI artifact to the local store from the local filesystem",
+		Long:              "Add an OCI artifact to the local store from the local filesystem or stdin",
 		RunE:              add,
 		Args:              cobra.MinimumNArgs(2),
 		ValidArgsFunction: common.AutocompleteArtifactAdd,
 		Example: `podman artifact add quay.io/myimage/myartifact:latest /tmp/foobar.txt
 podman artifact add --file-type text/yaml quay.io/myimage/myartifact:latest /tmp/foobar.yaml
-podman artifact add --append quay.io/myimage/myartifact:latest /tmp/foobar.tar.gz`,
+podman artifact add --append quay.io/myimage/myartifact:latest /tmp/foobar.tar.gz
+podman artifact add --type application/spdx+json --subject sha256:<digest> quay.io/myimage/myimage-sbom:latest /tmp/sbom.json
+podman artifact add --config-type application/vnd.example.config.v1+json --config-file config.json quay.io/myimage/myartifact:latest /tmp/foobar.txt
+podman artifact add --recursive --exclude '*.tmp' quay.io/myimage/mymodel:latest /tmp/modeldir
+podman artifact add quay.io/myimage/repackaged:latest oci-archive:/tmp/image.tar
+cat data.json | podman artifact add --file-name data.json quay.io/myimage/myartifact:latest -`,
 		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 )
 
 type artifactAddOptions struct {
-	ArtifactType string
-	Annotations  []string
-	Append       bool
-	FileType     string
+	ArtifactType         string
+	Annotations          []string
+	ManifestAnnotations  []string
+	IndexAnnotations     []string
+	Append               bool
+	Replace              bool
+	FileType             string
+	FileName             string
+	AllowDuplicate       bool
+	AllowDuplicateTitles bool
+	Recursive            bool
+	Exclude              []string
+	FollowSymlinks       bool
+	Subject              string
+	StrictType           bool
+	ConfigFile           string
+	ConfigType           string
+	RecordMode           bool
+	AutoAnnotate         bool
+	Description          string
+	FromArtifact         string
 }
 
 var (
@@ -48,6 +72,18 @@ func init() {
 	flags.StringArrayVar(&addOpts.Annotations, annotationFlagName, nil, "set an `annotation` for the specified files of artifact")
 	_ = addCmd.RegisterFlagCompletionFunc(annotationFlagName, completion.AutocompleteNone)
 
+	manifestAnnotationFlagName := "manifest-annotation"
+	flags.StringArrayVar(&addOpts.ManifestAnnotations, manifestAnnotationFlagName, nil, "set an `annotation` on the manifest of the artifact")
+	_ = addCmd.RegisterFlagCompletionFunc(manifestAnnotationFlagName, completion.AutocompleteNone)
+
+	indexAnnotationFlagName := "index-annotation"
+	flags.StringArrayVar(&addOpts.IndexAnnotations, indexAnnotationFlagName, nil, "set an `annotation` on the index entry of the artifact")
+	_ = addCmd.RegisterFlagCompletionFunc(indexAnnotationFlagName, completion.AutocompleteNone)
+
+	descriptionFlagName := "description"
+	flags.StringVar(&addOpts.Description, descriptionFlagName, "", "Set a one-line `description` of the artifact")
+	_ = addCmd.RegisterFlagCompletionFunc(descriptionFlagName, completion.AutocompleteNone)
+
 	addTypeFlagName := "type"
 	flags.StringVar(&addOpts.ArtifactType, addTypeFlagName, "", "Use type to describe an artifact")
 	_ = addCmd.RegisterFlagCompletionFunc(addTypeFlagName, completion.AutocompleteNone)
@@ -55,9 +91,49 @@ func init() {
 	appendFlagName := "append"
 	flags.BoolVarP(&addOpts.Append, appendFlagName, "a", false, "Append files to an existing artifact")
 
+	flags.BoolVar(&addOpts.Replace, "replace", false, "Replace an existing artifact of the same name")
+
 	fileTypeFlagName := "file-type"
 	flags.StringVarP(&addOpts.FileType, fileTypeFlagName, "", "", "Set file type to use for the artifact (layer)")
 	_ = addCmd.RegisterFlagCompletionFunc(fileTypeFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&addOpts.AllowDuplicate, "allow-duplicate", false, "Add files when appending even if the artifact has a blob with the same content")
+
+	flags.BoolVar(&addOpts.AllowDuplicateTitles, "allow-duplicate-titles", false, "Add files even if the artifact has a blob with the same name")
+
+	flags.BoolVarP(&addOpts.Recursive, "recursive", "r", false, "Add every file below a directory PATH as a blob named by its relative path")
+
+	excludeFlagName := "exclude"
+	flags.StringArrayVar(&addOpts.Exclude, excludeFlagName, nil, "Skip files and directories matching the glob `pattern` when adding a directory")
+	_ = addCmd.RegisterFlagCompletionFunc(excludeFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&addOpts.FollowSymlinks, "follow-symlinks", false, "Add the targets of symlinks found when adding a directory instead of skipping them")
+
+	flags.BoolVar(&addOpts.StrictType, "strict-type", false, "Reject files whose media type is not consistent with the artifact type")
+
+	subjectFlagName := "subject"
+	flags.StringVar(&addOpts.Subject, subjectFlagName, "", "Set the `digest` of the image or artifact manifest the artifact refers to")
+	_ = addCmd.RegisterFlagCompletionFunc(subjectFlagName, completion.AutocompleteNone)
+
+	configFileFlagName := "config-file"
+	flags.StringVar(&addOpts.ConfigFile, configFileFlagName, "", "Use the `file` as the config blob of the artifact")
+	_ = addCmd.RegisterFlagCompletionFunc(configFileFlagName, completion.AutocompleteDefault)
+
+	configTypeFlagName := "config-type"
+	flags.StringVar(&addOpts.ConfigType, configTypeFlagName, "", "Set the media `type` of the config blob of the artifact")
+	_ = addCmd.RegisterFlagCompletionFunc(configTypeFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&addOpts.AutoAnnotate, "auto-annotate", false, "Annotate each blob with the time, the hostname and the Podman version of the add")
+
+	flags.BoolVar(&addOpts.RecordMode, "record-mode", false, "Record the mode and ownership of each file in the annotations of its blob")
+
+	fromArtifactFlagName := "from-artifact"
+	flags.StringVar(&addOpts.FromArtifact, fromArtifactFlagName, "", "Add the blobs of `ARTIFACT` with the titles or digests given as PATH, sharing them instead of copying")
+	_ = addCmd.RegisterFlagCompletionFunc(fromArtifactFlagName, common.AutocompleteArtifacts)
+
+	fileNameFlagName := "file-name"
+	flags.StringVar(&addOpts.FileName, fileNameFlagName, "", "Set the file name of the blob read from stdin when PATH is \"-\"")
+	_ = addCmd.RegisterFlagCompletionFunc(fileNameFlagName, completion.AutocompleteNone)
 }
 
 func add(cmd *cobra.Command, args []string) error {
@@ -68,14 +144,55 @@ func add(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	opts.Annotations = annots
+	if opts.ManifestAnnotations, err = utils.ParseAnnotations(addOpts.ManifestAnnotations); err != nil {
+		return err
+	}
+	if opts.IndexAnnotations, err = utils.ParseAnnotations(addOpts.IndexAnnotations); err != nil {
+		return err
+	}
 	opts.ArtifactType = addOpts.ArtifactType
+	opts.Description = addOpts.Description
 	opts.Append = addOpts.Append
+	opts.Replace = addOpts.Replace
 	opts.FileType = addOpts.FileType
+	opts.StdinName = addOpts.FileName
+	opts.AllowDuplicate = addOpts.AllowDuplicate
+	opts.AllowDuplicateTitles = addOpts.AllowDuplicateTitles
+	opts.Recursive = addOpts.Recursive
+	opts.Exclude = addOpts.Exclude
+	opts.FollowSymlinks = addOpts.FollowSymlinks
+	opts.Subject = addOpts.Subject
+	opts.StrictType = addOpts.StrictType
+	opts.ConfigFile = addOpts.ConfigFile
+	opts.ConfigType = addOpts.ConfigType
+	opts.RecordMode = addOpts.RecordMode
+	opts.AutoAnnotate = addOpts.AutoAnnotate
+	opts.FromArtifact = addOpts.FromArtifact
+	if opts.FromArtifact != "" && (opts.Recursive || opts.StdinName != "") {
+		return errors.New("--from-artifact cannot be used with --recursive or --file-name")
+	}
 
 	report, err := registry.ImageEngine().ArtifactAdd(registry.Context(), args[0], args[1:], opts)
 	if err != nil {
 		return err
 	}
+	for _, dir := range report.EmptyDirectories {
+		fmt.Fprintf(os.Stderr, "Skipping %s: the directory is empty\n", dir)
+	}
+	for _, link := range report.SkippedSymlinks {
+		fmt.Fprintf(os.Stderr, "Skipping %s: symlinks are only added with --follow-symlinks\n", link)
+	}
+	for _, blob := range report.Blobs {
+		if blob.Deduplicated {
+			fmt.Fprintf(os.Stderr, "Skipping %s: the artifact already contains blob %s\n", blob.FileName, blob.Digest.Encoded())
+		}
+		if blob.Shared {
+			fmt.Fprintf(os.Stderr, "Sharing %s: blob %s is already in the store, no content was copied\n", blob.FileName, blob.Digest.Encoded())
+		}
+	}
+	if report.ReplacedDigest != nil {
+		fmt.Fprintf(os.Stderr, "Replaced artifact %s\n", report.ReplacedDigest.Encoded())
+	}
 	fmt.Println(report.ArtifactDigest.Encoded())
 	return nil
 }
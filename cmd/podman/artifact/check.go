@@ -0,0 +1,99 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/cmd/podman/validate"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+// checkOptionsWrapper wraps entities.ArtifactCheckOptions and prevents leaking
+// CLI-only fields into the API types.
+type checkOptionsWrapper struct {
+	entities.ArtifactCheckOptions
+	TLSVerifyCLI bool // CLI only
+}
+
+var (
+	checkOptions     = checkOptionsWrapper{}
+	checkDescription = `Check the blobs of all artifacts in the local store.
+
+  Every manifest and blob is hashed again and compared to its digest. Corrupt and missing blobs are reported and with --repair fetched again from the registries the artifacts are named after.`
+
+	checkCmd = &cobra.Command{
+		Use:               "check [options]",
+		Short:             "Check the integrity of the artifact store",
+		Long:              checkDescription,
+		RunE:              check,
+		Args:              validate.NoArgs,
+		ValidArgsFunction: completion.AutocompleteNone,
+		Example: `podman artifact check
+podman artifact check --repair`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: checkCmd,
+		Parent:  artifactCmd,
+	})
+	flags := checkCmd.Flags()
+
+	authfileFlagName := "authfile"
+	flags.StringVar(&checkOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	_ = checkCmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
+
+	certDirFlagName := "cert-dir"
+	flags.StringVar(&checkOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
+	_ = checkCmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
+
+	flags.BoolVarP(&checkOptions.Repair, "repair", "r", false, "Fetch corrupt and missing blobs again from the registries of the artifacts")
+	flags.BoolVar(&checkOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
+}
+
+func check(cmd *cobra.Command, _ []string) error {
+	if cmd.Flags().Changed("tls-verify") {
+		checkOptions.InsecureSkipTLSVerify = types.NewOptionalBool(!checkOptions.TLSVerifyCLI)
+	}
+	if cmd.Flags().Changed("authfile") {
+		if err := auth.CheckAuthFile(checkOptions.AuthFilePath); err != nil {
+			return err
+		}
+	}
+
+	report, err := registry.ImageEngine().ArtifactCheck(registry.Context(), checkOptions.ArtifactCheckOptions)
+	if err != nil {
+		return err
+	}
+	for _, blob := range report.Damaged {
+		state := "Corrupt"
+		if blob.Missing {
+			state = "Missing"
+		}
+		fmt.Printf("%s blob %s of %s\n", state, blob.Digest, strings.Join(blob.Artifacts, ", "))
+		switch {
+		case blob.Repaired:
+			fmt.Printf("  Repaired\n")
+		case blob.RepairError != "":
+			fmt.Printf("  Repair failed: %s\n", blob.RepairError)
+		}
+	}
+	fmt.Printf("%d healthy, %d corrupt, %d missing blobs", report.Healthy, report.Corrupt, report.Missing)
+	if checkOptions.Repair {
+		fmt.Printf(", %d repaired", report.Repaired)
+	}
+	fmt.Println()
+
+	if report.Repaired < len(report.Damaged) {
+		return errors.New("damage detected in the artifact store")
+	}
+	return nil
+}
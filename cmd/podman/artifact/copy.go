@@ -0,0 +1,170 @@
+package artifact
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/containers/common/libimage/platform"
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// copyOptionsWrapper wraps entities.ArtifactCopyOptions and prevents leaking
+// CLI-only fields into the API types.
+type copyOptionsWrapper struct {
+	entities.ArtifactCopyOptions
+	TLSVerifyCLI       bool // CLI only
+	CredentialsCLI     string
+	SrcCredentialsCLI  string
+	DestCredentialsCLI string
+}
+
+var (
+	copyOptions     = copyOptionsWrapper{}
+	copyDescription = `Copy an artifact from one registry to another.
+
+  The blobs are streamed from the source to the destination registry without storing the artifact locally.`
+
+	copyCmd = &cobra.Command{
+		Use:               "copy [options] SOURCE DESTINATION",
+		Aliases:           []string{"cp"},
+		Short:             "Copy an OCI artifact between registries",
+		Long:              copyDescription,
+		RunE:              artifactCopy,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completion.AutocompleteNone,
+		Example: `podman artifact copy quay.io/myimage/myartifact:latest registry.example.com/mirror/myartifact:latest
+podman artifact copy --arch arm64 quay.io/myimage/myindex:latest registry.example.com/mirror/myartifact:arm64
+podman artifact copy --dest-creds myuser quay.io/myimage/myartifact:latest registry.example.com/mirror/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: copyCmd,
+		Parent:  artifactCmd,
+	})
+	flags := copyCmd.Flags()
+
+	archFlagName := "arch"
+	flags.StringVar(&copyOptions.Architecture, archFlagName, "", "Use `ARCH` instead of the architecture of the machine for choosing an artifact from an index")
+	_ = copyCmd.RegisterFlagCompletionFunc(archFlagName, completion.AutocompleteArch)
+
+	osFlagName := "os"
+	flags.StringVar(&copyOptions.OS, osFlagName, "", "Use `OS` instead of the running OS for choosing an artifact from an index")
+	_ = copyCmd.RegisterFlagCompletionFunc(osFlagName, completion.AutocompleteOS)
+
+	variantFlagName := "variant"
+	flags.StringVar(&copyOptions.Variant, variantFlagName, "", "Use VARIANT instead of the running architecture variant for choosing an artifact from an index")
+	_ = copyCmd.RegisterFlagCompletionFunc(variantFlagName, completion.AutocompleteNone)
+
+	authfileFlagName := "authfile"
+	flags.StringVar(&copyOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	_ = copyCmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
+
+	certDirFlagName := "cert-dir"
+	flags.StringVar(&copyOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
+	_ = copyCmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
+
+	credsFlagName := "creds"
+	flags.StringVar(&copyOptions.CredentialsCLI, credsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to both registries")
+	_ = copyCmd.RegisterFlagCompletionFunc(credsFlagName, completion.AutocompleteNone)
+
+	destCredsFlagName := "dest-creds"
+	flags.StringVar(&copyOptions.DestCredentialsCLI, destCredsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to the destination registry")
+	_ = copyCmd.RegisterFlagCompletionFunc(destCredsFlagName, completion.AutocompleteNone)
+
+	srcCredsFlagName := "src-creds"
+	flags.StringVar(&copyOptions.SrcCredentialsCLI, srcCredsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to the source registry")
+	_ = copyCmd.RegisterFlagCompletionFunc(srcCredsFlagName, completion.AutocompleteNone)
+
+	flags.BoolVarP(&copyOptions.Quiet, "quiet", "q", false, "Suppress output information when copying artifacts")
+
+	retryFlagName := "retry"
+	flags.Uint(retryFlagName, registry.RetryDefault(), "number of times to retry each blob in case of failure")
+	_ = copyCmd.RegisterFlagCompletionFunc(retryFlagName, completion.AutocompleteNone)
+
+	retryDelayFlagName := "retry-delay"
+	flags.String(retryDelayFlagName, registry.RetryDelayDefault(), "delay between retries in case of copy failures")
+	_ = copyCmd.RegisterFlagCompletionFunc(retryDelayFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&copyOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
+
+	if !registry.IsRemote() {
+		signaturePolicyFlagName := "signature-policy"
+		flags.StringVar(&copyOptions.SignaturePolicyPath, signaturePolicyFlagName, "", "Path to a signature-policy file")
+		_ = flags.MarkHidden(signaturePolicyFlagName)
+	}
+}
+
+func artifactCopy(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("tls-verify") {
+		copyOptions.InsecureSkipTLSVerify = types.NewOptionalBool(!copyOptions.TLSVerifyCLI)
+	}
+
+	if cmd.Flags().Changed("retry") {
+		retry, err := cmd.Flags().GetUint("retry")
+		if err != nil {
+			return err
+		}
+		copyOptions.MaxRetries = &retry
+	}
+
+	if cmd.Flags().Changed("retry-delay") {
+		val, err := cmd.Flags().GetString("retry-delay")
+		if err != nil {
+			return err
+		}
+		copyOptions.RetryDelay = val
+	}
+
+	if cmd.Flags().Changed("authfile") {
+		if err := auth.CheckAuthFile(copyOptions.AuthFilePath); err != nil {
+			return err
+		}
+	}
+
+	if copyOptions.CredentialsCLI != "" {
+		creds, err := util.ParseRegistryCreds(copyOptions.CredentialsCLI)
+		if err != nil {
+			return err
+		}
+		copyOptions.Username = creds.Username
+		copyOptions.Password = creds.Password
+	}
+	if copyOptions.SrcCredentialsCLI != "" {
+		creds, err := util.ParseRegistryCreds(copyOptions.SrcCredentialsCLI)
+		if err != nil {
+			return err
+		}
+		copyOptions.SourceUsername = creds.Username
+		copyOptions.SourcePassword = creds.Password
+	}
+	if copyOptions.DestCredentialsCLI != "" {
+		creds, err := util.ParseRegistryCreds(copyOptions.DestCredentialsCLI)
+		if err != nil {
+			return err
+		}
+		copyOptions.DestinationUsername = creds.Username
+		copyOptions.DestinationPassword = creds.Password
+	}
+
+	if !copyOptions.Quiet {
+		copyOptions.Writer = os.Stderr
+	}
+
+	report, err := registry.ImageEngine().ArtifactCopy(registry.Context(), args[0], args[1], copyOptions.ArtifactCopyOptions)
+	if err != nil {
+		return err
+	}
+	if !copyOptions.Quiet && report.Platform != nil {
+		fmt.Fprintf(os.Stderr, "Selected platform %s\n", platform.ToString(report.Platform.OS, report.Platform.Architecture, report.Platform.Variant))
+	}
+	return nil
+}
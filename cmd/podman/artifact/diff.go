@@ -0,0 +1,141 @@
+package artifact
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/common/pkg/report"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/cmd/podman/utils"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/spf13/cobra"
+)
+
+// diffOptionsWrapper wraps entities.ArtifactDiffOptions and prevents leaking
+// CLI-only fields into the API types.
+type diffOptionsWrapper struct {
+	entities.ArtifactDiffOptions
+	TLSVerifyCLI bool // CLI only
+}
+
+var (
+	diffOptions     = diffOptionsWrapper{}
+	diffFormat      string
+	diffDescription = `Compare two artifacts and show the blobs and annotations which differ.
+
+  Blobs are matched by their title, blobs without a title by their digest. With --remote the second artifact is read from its registry instead of the local store.`
+
+	diffCmd = &cobra.Command{
+		Use:               "diff [options] ARTIFACT ARTIFACT",
+		Short:             "Show the differences between two artifacts",
+		Long:              diffDescription,
+		RunE:              diff,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example: `podman artifact diff quay.io/myimage/myartifact:v1 quay.io/myimage/myartifact:v2
+podman artifact diff --remote quay.io/myimage/myartifact:latest quay.io/myimage/myartifact:latest
+podman artifact diff --format json quay.io/myimage/myartifact:v1 quay.io/myimage/myartifact:v2`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: diffCmd,
+		Parent:  artifactCmd,
+	})
+	flags := diffCmd.Flags()
+
+	authfileFlagName := "authfile"
+	flags.StringVar(&diffOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	_ = diffCmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
+
+	certDirFlagName := "cert-dir"
+	flags.StringVar(&diffOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
+	_ = diffCmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
+
+	formatFlagName := "format"
+	flags.StringVarP(&diffFormat, formatFlagName, "f", "", "Format output using JSON or a Go template")
+	_ = diffCmd.RegisterFlagCompletionFunc(formatFlagName, common.AutocompleteFormat(&entities.ArtifactDiffReport{}))
+
+	flags.BoolVar(&diffOptions.Remote, "remote", false, "Read the second artifact from its registry")
+	flags.BoolVar(&diffOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
+}
+
+func diff(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("tls-verify") {
+		diffOptions.InsecureSkipTLSVerify = types.NewOptionalBool(!diffOptions.TLSVerifyCLI)
+	}
+	if cmd.Flags().Changed("authfile") {
+		if err := auth.CheckAuthFile(diffOptions.AuthFilePath); err != nil {
+			return err
+		}
+	}
+
+	diffReport, err := registry.ImageEngine().ArtifactDiff(registry.Context(), args[0], args[1], diffOptions.ArtifactDiffOptions)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case report.IsJSON(diffFormat):
+		return utils.PrintGenericJSON(diffReport)
+	case diffFormat != "":
+		rpt, err := report.New(os.Stdout, cmd.Name()).Parse(report.OriginUser, diffFormat)
+		if err != nil {
+			return err
+		}
+		defer rpt.Flush()
+		return rpt.Execute([]*entities.ArtifactDiffReport{diffReport})
+	}
+
+	for _, field := range diffReport.Fields {
+		printValueDiff("", "", field)
+	}
+	for _, annotation := range diffReport.Annotations {
+		printValueDiff("", "annotation ", annotation)
+	}
+	for _, blob := range diffReport.Blobs {
+		name := blob.Title
+		if name == "" {
+			name = "(untitled)"
+		}
+		switch blob.Kind {
+		case libartTypes.DiffAdded:
+			fmt.Printf("added blob %s %s\n", name, blob.NewDigest)
+		case libartTypes.DiffRemoved:
+			fmt.Printf("removed blob %s %s\n", name, blob.OldDigest)
+		default:
+			if blob.OldDigest != blob.NewDigest {
+				fmt.Printf("changed blob %s %s -> %s\n", name, blob.OldDigest, blob.NewDigest)
+			} else {
+				fmt.Printf("changed blob %s %s\n", name, blob.NewDigest)
+			}
+			if blob.MediaType != nil {
+				printValueDiff("  ", "", *blob.MediaType)
+			}
+			for _, annotation := range blob.Annotations {
+				printValueDiff("  ", "annotation ", annotation)
+			}
+		}
+	}
+	return nil
+}
+
+// printValueDiff prints a line describing the difference of a value, indented
+// by indent and its key prefixed by what.
+func printValueDiff(indent, what string, value libartTypes.ValueDiff) {
+	switch value.Kind {
+	case libartTypes.DiffAdded:
+		fmt.Printf("%sadded %s%s: %s\n", indent, what, value.Key, value.NewValue)
+	case libartTypes.DiffRemoved:
+		fmt.Printf("%sremoved %s%s: %s\n", indent, what, value.Key, value.OldValue)
+	default:
+		fmt.Printf("%schanged %s%s: %s -> %s\n", indent, what, value.Key, value.OldValue, value.NewValue)
+	}
+}
@@ -0,0 +1,46 @@
+package artifact
+
+import (
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/libpod/define"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportDescription = `Export an artifact with all its blobs to an OCI image layout.
+
+  The layout is written as a tar archive, or as a directory with --format oci-dir.
+  The manifest is exported unchanged so the artifact keeps its digest.`
+	exportCmd = &cobra.Command{
+		Use:               "export [options] ARTIFACT PATH",
+		Short:             "Export an OCI artifact to an OCI image layout",
+		Long:              exportDescription,
+		RunE:              export,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: common.AutocompleteArtifactAdd,
+		Example: `podman artifact export quay.io/myimage/myartifact:latest myartifact.tar
+podman artifact export --format oci-dir quay.io/myimage/myartifact:latest /tmp/myartifact`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+var exportOpts entities.ArtifactExportOptions
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: exportCmd,
+		Parent:  artifactCmd,
+	})
+	flags := exportCmd.Flags()
+
+	formatFlagName := "format"
+	flags.StringVar(&exportOpts.Format, formatFlagName, define.OCIArchive, "Export to an oci-archive or an oci-dir")
+	_ = exportCmd.RegisterFlagCompletionFunc(formatFlagName, common.AutocompleteArtifactExportFormat)
+}
+
+func export(cmd *cobra.Command, args []string) error {
+	_, err := registry.ImageEngine().ArtifactExport(registry.Context(), args[0], args[1], exportOpts)
+	return err
+}
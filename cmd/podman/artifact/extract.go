@@ -1,29 +1,41 @@
 package artifact
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/containers/common/pkg/completion"
 	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/parse"
 	"github.com/containers/podman/v5/cmd/podman/registry"
 	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
 	extractCmd = &cobra.Command{
-		Use:               "extract [options] ARTIFACT PATH",
+		Use:               "extract [options] ARTIFACT [PATH]",
 		Short:             "Extract an OCI artifact to a local path",
-		Long:              "Extract the blobs of an OCI artifact to a local file or directory",
+		Long:              "Extract the blobs of an OCI artifact to a local file or directory, or a single blob to stdout if PATH is \"-\" or omitted. With --tar the selected blobs are written as a tar archive instead",
 		RunE:              extract,
-		Args:              cobra.ExactArgs(2),
+		Args:              cobra.RangeArgs(1, 2),
 		ValidArgsFunction: common.AutocompleteArtifactAdd,
 		Example: `podman artifact Extract quay.io/myimage/myartifact:latest /tmp/foobar.txt
-podman artifact Extract quay.io/myimage/myartifact:latest /home/paul/mydir`,
+podman artifact Extract quay.io/myimage/myartifact:latest /home/paul/mydir
+podman artifact Extract --all quay.io/myimage/myartifact:latest /home/paul/newdir
+podman artifact Extract --filter annotation=role=weights quay.io/myimage/myartifact:latest /home/paul/mydir
+podman artifact Extract --title config.json quay.io/myimage/myartifact:latest | jq .
+podman artifact Extract --tar quay.io/myimage/myartifact:latest | tar -t`,
 		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 )
 
 var (
-	extractOpts entities.ArtifactExtractOptions
+	extractOpts   entities.ArtifactExtractOptions
+	extractIndex  int
+	extractFilter []string
+	extractTar    bool
 )
 
 func init() {
@@ -40,13 +52,89 @@ func init() {
 	titleFlagName := "title"
 	flags.StringVar(&extractOpts.Title, titleFlagName, "", "Only extract blob with the given title")
 	_ = extractCmd.RegisterFlagCompletionFunc(titleFlagName, completion.AutocompleteNone)
+
+	filterFlagName := "filter"
+	flags.StringArrayVar(&extractFilter, filterFlagName, []string{}, "Extract all blobs matching the given conditions")
+	_ = extractCmd.RegisterFlagCompletionFunc(filterFlagName, common.AutocompleteArtifactBlobFilters)
+
+	flags.BoolVar(&extractOpts.ExtractAll, "all", false, "Extract all blobs into the target directory, creating it if needed")
+	flags.BoolVar(&extractOpts.Overwrite, "overwrite", false, "Allow blobs with the same name to overwrite each other")
+	flags.BoolVar(&extractOpts.Decompress, "decompress", false, "Decompress gzip and zstd compressed blobs according to their media type")
+	flags.BoolVar(&extractOpts.PreserveMode, "preserve-mode", false, "Restore the permission bits recorded in the annotations of each blob")
+	flags.BoolVar(&extractOpts.PreserveOwner, "preserve-owner", false, "Restore the ownership recorded in the annotations of each blob")
+	flags.BoolVar(&extractOpts.Verify, "verify", false, "Verify the digest of each blob while extracting it")
+	flags.BoolVar(&extractTar, "tar", false, "Write the selected blobs as a tar archive to PATH or stdout")
+
+	onConflictFlagName := "on-conflict"
+	flags.StringVar(&extractOpts.OnConflict, onConflictFlagName, "fail", "What to do with existing files: skip, overwrite or fail")
+	_ = extractCmd.RegisterFlagCompletionFunc(onConflictFlagName, common.AutocompleteArtifactExtractConflict)
+
+	pathPrefixFlagName := "path-prefix"
+	flags.StringVar(&extractOpts.PathPrefix, pathPrefixFlagName, "", "Remove the leading `directory` from the names of the extracted blobs")
+	_ = extractCmd.RegisterFlagCompletionFunc(pathPrefixFlagName, completion.AutocompleteNone)
+
+	stripComponentsFlagName := "strip-components"
+	flags.IntVar(&extractOpts.StripComponents, stripComponentsFlagName, 0, "Remove `number` leading path elements from the names of the extracted blobs")
+	_ = extractCmd.RegisterFlagCompletionFunc(stripComponentsFlagName, completion.AutocompleteNone)
+
+	indexFlagName := "index"
+	flags.IntVar(&extractIndex, indexFlagName, 0, "Only extract blob with the given index in the artifact manifest")
+	_ = extractCmd.RegisterFlagCompletionFunc(indexFlagName, completion.AutocompleteNone)
 }
 
 func extract(cmd *cobra.Command, args []string) error {
-	err := registry.ImageEngine().ArtifactExtract(registry.Context(), args[0], args[1], &extractOpts)
+	if cmd.Flags().Changed("index") {
+		extractOpts.Index = &extractIndex
+	}
+	if len(extractFilter) > 0 {
+		filters, err := parse.FilterArgumentsIntoFilters(extractFilter)
+		if err != nil {
+			return err
+		}
+		extractOpts.Filters = filters
+	}
+	target := "-"
+	if len(args) > 1 {
+		target = args[1]
+	}
+	if extractTar {
+		return extractToTar(args[0], target)
+	}
+	if target == "-" {
+		extractOpts.Writer = os.Stdout
+		target = ""
+	}
+	report, err := registry.ImageEngine().ArtifactExtract(registry.Context(), args[0], target, &extractOpts)
 	if err != nil {
 		return err
 	}
-
+	for _, file := range report.SkippedFiles {
+		fmt.Fprintf(os.Stderr, "Skipped existing file %s\n", file)
+	}
 	return nil
 }
+
+// extractToTar writes the selected blobs as a tar archive to stdout if target
+// is "-", or to the file target otherwise, which is removed again on failure.
+func extractToTar(artifact, target string) (retErr error) {
+	extractOpts.TarOutput = os.Stdout
+	if target != "-" {
+		file, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := file.Close(); retErr == nil {
+				retErr = err
+			}
+			if retErr != nil {
+				if err := os.Remove(target); err != nil {
+					logrus.Errorf("Removing partially written archive %s: %v", target, err)
+				}
+			}
+		}()
+		extractOpts.TarOutput = file
+	}
+	_, err := registry.ImageEngine().ArtifactExtract(registry.Context(), artifact, "", &extractOpts)
+	return err
+}
@@ -0,0 +1,56 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/cmd/podman/validate"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcOptions     = entities.ArtifactGCOptions{}
+	gcDescription = `Remove the blobs of the local store which no artifact references.
+
+  Interrupted adds, pulls and removals can leave blobs behind which no manifest refers to. The removed blobs are listed with the space reclaimed.`
+
+	gcCmd = &cobra.Command{
+		Use:               "gc [options]",
+		Short:             "Remove unreferenced blobs from the artifact store",
+		Long:              gcDescription,
+		RunE:              gc,
+		Args:              validate.NoArgs,
+		ValidArgsFunction: completion.AutocompleteNone,
+		Example: `podman artifact gc
+podman artifact gc --dry-run`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: gcCmd,
+		Parent:  artifactCmd,
+	})
+	flags := gcCmd.Flags()
+	flags.BoolVar(&gcOptions.DryRun, "dry-run", false, "Only print the blobs which would be removed")
+}
+
+func gc(_ *cobra.Command, _ []string) error {
+	report, err := registry.ImageEngine().ArtifactGC(registry.Context(), gcOptions)
+	if err != nil {
+		return err
+	}
+	for _, blob := range report.Blobs {
+		fmt.Println(blob.Digest)
+	}
+	if gcOptions.DryRun {
+		fmt.Printf("Total reclaimable space: %s\n", units.HumanSize(float64(report.ReclaimedSize)))
+	} else {
+		fmt.Printf("Total reclaimed space: %s\n", units.HumanSize(float64(report.ReclaimedSize)))
+	}
+	return nil
+}
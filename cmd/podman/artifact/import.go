@@ -0,0 +1,50 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importDescription = `Import an artifact from an OCI image layout into the local artifact store.
+
+  The layout can be a directory or a tar archive of one, such as written by podman artifact export.`
+	importCmd = &cobra.Command{
+		Use:               "import [options] PATH NAME",
+		Short:             "Import an OCI artifact from an OCI image layout",
+		Long:              importDescription,
+		RunE:              importArtifact,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completion.AutocompleteDefault,
+		Example: `podman artifact import myartifact.tar quay.io/myimage/myartifact:latest
+podman artifact import --digest sha256:e7b417f49fc24fc7ead6485da0ebd5bc4419d8a3f394c169fee5a6f38faa4056 /tmp/layout quay.io/myimage/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+var importOpts entities.ArtifactImportOptions
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: importCmd,
+		Parent:  artifactCmd,
+	})
+	flags := importCmd.Flags()
+
+	digestFlagName := "digest"
+	flags.StringVar(&importOpts.Digest, digestFlagName, "", "Import the manifest with this digest if the layout contains more than one")
+	_ = importCmd.RegisterFlagCompletionFunc(digestFlagName, completion.AutocompleteNone)
+}
+
+func importArtifact(cmd *cobra.Command, args []string) error {
+	report, err := registry.ImageEngine().ArtifactImport(registry.Context(), args[0], args[1], importOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.ArtifactDigest.Encoded())
+	return nil
+}
@@ -1,23 +1,46 @@
 package artifact
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/common/pkg/report"
+	"github.com/containers/image/v5/types"
 	"github.com/containers/podman/v5/cmd/podman/common"
 	"github.com/containers/podman/v5/cmd/podman/registry"
 	"github.com/containers/podman/v5/cmd/podman/utils"
 	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/util"
 	"github.com/spf13/cobra"
 )
 
+// inspectOptionsWrapper wraps entities.ArtifactInspectOptions and prevents
+// leaking CLI-only fields into the API types.
+type inspectOptionsWrapper struct {
+	entities.ArtifactInspectOptions
+	TLSVerifyCLI   bool // CLI only
+	CredentialsCLI string
+}
+
 var (
+	inspectOptions inspectOptionsWrapper
+	inspectFormat  string
+	inspectIgnore  bool
+
 	inspectCmd = &cobra.Command{
-		Use:               "inspect [ARTIFACT...]",
+		Use:               "inspect [options] ARTIFACT [ARTIFACT...]",
 		Short:             "Inspect an OCI artifact",
-		Long:              "Provide details on an OCI artifact",
+		Long:              "Provide details on one or more OCI artifacts",
 		RunE:              inspect,
-		Args:              cobra.MinimumNArgs(1),
+		Args:              checkLatestAndArgs,
 		ValidArgsFunction: common.AutocompleteArtifacts,
-		Example:           `podman artifact inspect quay.io/myimage/myartifact:latest`,
-		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+		Example: `podman artifact inspect quay.io/myimage/myartifact:latest
+podman artifact inspect --latest
+podman artifact inspect --remote quay.io/myimage/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 )
 
@@ -26,26 +49,112 @@ func init() {
 		Command: inspectCmd,
 		Parent:  artifactCmd,
 	})
+	flags := inspectCmd.Flags()
+
+	flags.BoolVarP(&inspectIgnore, "ignore", "i", false, "Do not fail for artifacts which do not exist")
+
+	flags.BoolVarP(&inspectOptions.Latest, "latest", "l", false, "Inspect the artifact most recently stored in the local store")
+
+	flags.BoolVar(&inspectOptions.Verify, "verify", false, "Verify the digests of all blobs in the local store")
+
+	flags.BoolVar(&inspectOptions.Remote, "remote", false, "Inspect the artifact in its registry, only the manifest is fetched")
 
-	// TODO When things firm up on inspect looks, we can do a format implementation
-	// flags := inspectCmd.Flags()
-	// formatFlagName := "format"
-	// flags.StringVar(&inspectFlag.format, formatFlagName, "", "Format volume output using JSON or a Go template")
+	authfileFlagName := "authfile"
+	flags.StringVar(&inspectOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	_ = inspectCmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
 
-	// This is something we wanted to do but did not seem important enough for initial PR
-	// remoteFlagName := "remote"
-	// flags.BoolVar(&inspectFlag.remote, remoteFlagName, false, "Inspect the image on a container image registry")
+	certDirFlagName := "cert-dir"
+	flags.StringVar(&inspectOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
+	_ = inspectCmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
 
-	// TODO When the inspect structure has been defined, we need to uncomment and redirect this.  Reminder, this
-	// will also need to be reflected in the podman-artifact-inspect man page
-	// _ = inspectCmd.RegisterFlagCompletionFunc(formatFlagName, common.AutocompleteFormat(&machine.InspectInfo{}))
+	credsFlagName := "creds"
+	flags.StringVar(&inspectOptions.CredentialsCLI, credsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to a registry")
+	_ = inspectCmd.RegisterFlagCompletionFunc(credsFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&inspectOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
+
+	digestAlgorithmFlagName := "digest-algorithm"
+	flags.StringVar(&inspectOptions.DigestAlgorithm, digestAlgorithmFlagName, "", "Also compute the digests with `ALGORITHM` (sha256, sha512)")
+	_ = inspectCmd.RegisterFlagCompletionFunc(digestAlgorithmFlagName, common.AutocompleteDigestAlgorithm)
+
+	formatFlagName := "format"
+	flags.StringVarP(&inspectFormat, formatFlagName, "f", "json", "Format output using JSON or a Go template")
+	_ = inspectCmd.RegisterFlagCompletionFunc(formatFlagName, common.AutocompleteFormat(&entities.ArtifactInspectReport{}))
+}
+
+// checkLatestAndArgs checks that either --latest or at least one artifact is
+// given, but not both.
+func checkLatestAndArgs(c *cobra.Command, args []string) error {
+	latest, _ := c.Flags().GetBool("latest")
+	if latest && len(args) > 0 {
+		return errors.New("--latest and artifact names or digests cannot be used together")
+	}
+	if !latest && len(args) < 1 {
+		return errors.New("at least one artifact name or digest or --latest must be specified")
+	}
+	return nil
 }
 
 func inspect(cmd *cobra.Command, args []string) error {
-	artifactOptions := entities.ArtifactInspectOptions{}
-	inspectData, err := registry.ImageEngine().ArtifactInspect(registry.Context(), args[0], artifactOptions)
+	for _, name := range []string{"authfile", "cert-dir", "creds", "tls-verify"} {
+		if cmd.Flags().Changed(name) && !inspectOptions.Remote {
+			return fmt.Errorf("--%s requires --remote", name)
+		}
+	}
+	if inspectOptions.Remote && inspectOptions.Latest {
+		return errors.New("--remote and --latest cannot be used together")
+	}
+	if inspectOptions.Remote && inspectOptions.Verify {
+		return errors.New("--remote and --verify cannot be used together")
+	}
+	if cmd.Flags().Changed("tls-verify") {
+		inspectOptions.InsecureSkipTLSVerify = types.NewOptionalBool(!inspectOptions.TLSVerifyCLI)
+	}
+	if cmd.Flags().Changed("authfile") {
+		if err := auth.CheckAuthFile(inspectOptions.AuthFilePath); err != nil {
+			return err
+		}
+	}
+	if inspectOptions.CredentialsCLI != "" {
+		creds, err := util.ParseRegistryCreds(inspectOptions.CredentialsCLI)
+		if err != nil {
+			return err
+		}
+		inspectOptions.Username = creds.Username
+		inspectOptions.Password = creds.Password
+	}
+
+	inspectData, errs, err := registry.ImageEngine().ArtifactInspect(registry.Context(), args, inspectOptions.ArtifactInspectOptions)
 	if err != nil {
 		return err
 	}
-	return utils.PrintGenericJSON(inspectData)
+
+	if report.IsJSON(inspectFormat) || inspectFormat == "" {
+		err = utils.PrintGenericJSON(inspectData)
+	} else {
+		var rpt *report.Formatter
+		rpt, err = report.New(os.Stdout, cmd.Name()).Parse(report.OriginUser, inspectFormat)
+		if err != nil {
+			return err
+		}
+		defer rpt.Flush()
+		err = rpt.Execute(inspectData)
+	}
+	if err != nil {
+		return err
+	}
+
+	if inspectIgnore {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		return nil
+	}
+	if len(errs) > 0 {
+		for _, err := range errs[1:] {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		return errs[0]
+	}
+	return nil
 }
@@ -1,16 +1,28 @@
 package artifact
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	tmplparse "text/template/parse"
+	"time"
 
 	"github.com/containers/common/pkg/completion"
 	"github.com/containers/common/pkg/report"
 	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/parse"
 	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/cmd/podman/utils"
 	"github.com/containers/podman/v5/cmd/podman/validate"
 	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/libartifact/types"
 	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
@@ -24,27 +36,49 @@ var (
 		RunE:              list,
 		Args:              validate.NoArgs,
 		ValidArgsFunction: completion.AutocompleteNone,
-		Example:           `podman artifact ls`,
-		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+		Example: `podman artifact ls
+podman artifact ls --filter type=application/vnd.example+type
+podman artifact ls --sort created
+podman artifact ls --referrers quay.io/example/image:latest
+podman artifact ls --stream`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 	listFlag = listFlagType{}
 )
 
 type listFlagType struct {
+	filter    []string
 	format    string
 	noHeading bool
 	noTrunc   bool
+	quiet     bool
+	referrers string
+	sort      string
+	stream    bool
 }
 
 type artifactListOutput struct {
-	Digest     string
-	Repository string
-	Size       string
-	Tag        string
+	ArtifactType    string
+	Created         string
+	CreatedAt       string
+	Description     string
+	Digest          string
+	DigestAlgorithm string
+	Kind            string
+	Repository      string
+	Size            string
+	Tag             string
+	TotalSize       int64
 }
 
 var (
 	defaultArtifactListOutputFormat = "{{range .}}{{.Repository}}\t{{.Tag}}\t{{.Digest}}\t{{.Size}}\n{{end -}}"
+
+	listSortFields = entities.NewStringSet(
+		"created",
+		"digest",
+		"repository",
+		"size")
 )
 
 func init() {
@@ -53,82 +87,298 @@ func init() {
 		Parent:  artifactCmd,
 	})
 	flags := listCmd.Flags()
+	filterFlagName := "filter"
+	flags.StringArrayVarP(&listFlag.filter, filterFlagName, "f", []string{}, "Filter output based on conditions given")
+	_ = listCmd.RegisterFlagCompletionFunc(filterFlagName, common.AutocompleteArtifactFilters)
+
 	formatFlagName := "format"
-	flags.StringVar(&listFlag.format, formatFlagName, defaultArtifactListOutputFormat, "Format volume output using JSON or a Go template")
+	flags.StringVar(&listFlag.format, formatFlagName, defaultArtifactListOutputFormat, "Format the output as a table, as JSON or using a Go template")
 	_ = listCmd.RegisterFlagCompletionFunc(formatFlagName, common.AutocompleteFormat(&artifactListOutput{}))
 	flags.BoolVarP(&listFlag.noHeading, "noheading", "n", false, "Do not print column headings")
 	flags.BoolVar(&listFlag.noTrunc, "no-trunc", false, "Do not truncate output")
+	flags.BoolVarP(&listFlag.quiet, "quiet", "q", false, "Print only the artifact digests")
+
+	referrersFlagName := "referrers"
+	flags.StringVar(&listFlag.referrers, referrersFlagName, "", "List the artifacts which refer to this image or artifact as their subject")
+	_ = listCmd.RegisterFlagCompletionFunc(referrersFlagName, common.AutocompleteImages)
+
+	sortFlagName := "sort"
+	flags.StringVar(&listFlag.sort, sortFlagName, "", "Sort by "+listSortFields.String())
+	_ = listCmd.RegisterFlagCompletionFunc(sortFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&listFlag.stream, "stream", false, "Print each artifact as a line of JSON as soon as it is read from the store")
 }
 
 func list(cmd *cobra.Command, _ []string) error {
-	reports, err := registry.ImageEngine().ArtifactList(registry.Context(), entities.ArtifactListOptions{})
+	if listFlag.quiet && cmd.Flag("format").Changed {
+		return errors.New("quiet and format flags cannot be used together")
+	}
+	if listFlag.sort != "" && !listSortFields.Contains(listFlag.sort) {
+		return fmt.Errorf("%q is not a valid field for sorting. Choose from: %s", listFlag.sort, listSortFields.String())
+	}
+	if listFlag.stream && (listFlag.sort != "" || cmd.Flag("format").Changed) {
+		return errors.New("stream cannot be used together with the sort and format flags")
+	}
+	if cmd.Flag("format").Changed && !isListFormatAlias(listFlag.format) {
+		if err := checkListFormat(listFlag.format); err != nil {
+			return err
+		}
+	}
+	filters, err := parse.FilterArgumentsIntoFilters(listFlag.filter)
+	if err != nil {
+		return err
+	}
+	listOptions := entities.ArtifactListOptions{
+		Filters: filters,
+		NoTrunc: listFlag.noTrunc,
+		// Sorting needs the fields a quiet list does not compute.
+		Quiet:   listFlag.quiet && listFlag.sort == "",
+		Subject: listFlag.referrers,
+	}
+	if listFlag.stream {
+		return outputStream(listOptions)
+	}
+	reports, err := registry.ImageEngine().ArtifactList(registry.Context(), listOptions)
 	if err != nil {
 		return err
 	}
+	if err := sortArtifacts(reports); err != nil {
+		return err
+	}
 
-	return outputTemplate(cmd, reports)
+	switch {
+	case listFlag.quiet:
+		return outputQuiet(reports, listOptions)
+	case report.IsJSON(listFlag.format):
+		return outputJSON(reports, listOptions)
+	}
+	return outputTemplate(cmd, reports, listOptions)
 }
 
-func outputTemplate(cmd *cobra.Command, lrs []*entities.ArtifactListReport) error {
-	var err error
-	artifacts := make([]artifactListOutput, 0)
+// isListFormatAlias returns true for the formats which name an output rather
+// than being a template: "table" for the default table and "json".
+func isListFormatAlias(format string) bool {
+	return strings.TrimSpace(format) == "table" || report.IsJSON(format)
+}
+
+// checkListFormat returns an error naming the first field of the template
+// format which is not a field of the listed artifacts, so that a mistyped
+// field fails before anything is printed rather than in the middle of the
+// output.  Only the fields of the artifacts are checked, the ones of values
+// bound by with or range, or of variables, are left to the template.
+func checkListFormat(format string) error {
+	text, _ := strings.CutPrefix(format, "table ")
+	tmpl, err := template.New("format").Funcs(template.FuncMap(report.DefaultFuncs)).Parse(text)
+	if err != nil {
+		return err
+	}
+	// A template without a range over the list is run for each artifact.
+	inArtifact := report.EnforceRange(text) != text
+	outputType := reflect.TypeOf(artifactListOutput{})
+	fields := make([]string, 0, outputType.NumField())
+	for i := range outputType.NumField() {
+		fields = append(fields, outputType.Field(i).Name)
+	}
+	return checkFormatNode(tmpl.Root, inArtifact, fields)
+}
+
+// checkFormatNode checks the fields of dot used by node, inArtifact is true if
+// dot is a listed artifact rather than the list itself.
+func checkFormatNode(node tmplparse.Node, inArtifact bool, fields []string) error {
+	switch n := node.(type) {
+	case *tmplparse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkFormatNode(child, inArtifact, fields); err != nil {
+				return err
+			}
+		}
+	case *tmplparse.ActionNode:
+		return checkFormatNode(n.Pipe, inArtifact, fields)
+	case *tmplparse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, command := range n.Cmds {
+			for _, arg := range command.Args {
+				if err := checkFormatNode(arg, inArtifact, fields); err != nil {
+					return err
+				}
+			}
+		}
+	case *tmplparse.FieldNode:
+		if inArtifact && !slices.Contains(fields, n.Ident[0]) {
+			return fmt.Errorf("%q is not a valid field for the format. Choose from: %s", n.Ident[0], strings.Join(fields, ", "))
+		}
+	case *tmplparse.ChainNode:
+		return checkFormatNode(n.Node, inArtifact, fields)
+	case *tmplparse.IfNode:
+		return checkFormatBranch(&n.BranchNode, inArtifact, inArtifact, fields)
+	case *tmplparse.RangeNode:
+		// Ranging over the list binds dot to each artifact.
+		return checkFormatBranch(&n.BranchNode, inArtifact, !inArtifact && isDotPipe(n.Pipe), fields)
+	case *tmplparse.WithNode:
+		return checkFormatBranch(&n.BranchNode, inArtifact, inArtifact && isDotPipe(n.Pipe), fields)
+	case *tmplparse.TemplateNode:
+		return checkFormatNode(n.Pipe, inArtifact, fields)
+	}
+	return nil
+}
+
+// checkFormatBranch checks the pipeline of branch with the dot of the
+// enclosing node and its list with the dot the branch binds.
+func checkFormatBranch(branch *tmplparse.BranchNode, inArtifact, listInArtifact bool, fields []string) error {
+	if err := checkFormatNode(branch.Pipe, inArtifact, fields); err != nil {
+		return err
+	}
+	if err := checkFormatNode(branch.List, listInArtifact, fields); err != nil {
+		return err
+	}
+	return checkFormatNode(branch.ElseList, inArtifact, fields)
+}
+
+// isDotPipe returns true if pipe is dot itself.
+func isDotPipe(pipe *tmplparse.PipeNode) bool {
+	if len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := pipe.Cmds[0].Args[0].(*tmplparse.DotNode)
+	return ok
+}
+
+// outputJSON prints the listed artifacts as a JSON array of the fields
+// available to a template.
+func outputJSON(lrs []*entities.ArtifactListReport, opts entities.ArtifactListOptions) error {
+	artifacts := make([]artifactListOutput, 0, len(lrs))
 	for _, lr := range lrs {
-		var (
-			tag string
-		)
-		artifactName, err := lr.Artifact.GetName()
+		output, err := listOutput(lr, opts)
 		if err != nil {
 			return err
 		}
-		repo, err := reference.Parse(artifactName)
+		artifacts = append(artifacts, output)
+	}
+	return utils.PrintGenericJSON(artifacts)
+}
+
+// sortArtifacts sorts the reports by the field given with --sort, the newest
+// artifacts first when sorting by creation time.  Without --sort the order of
+// the store is kept.
+func sortArtifacts(lrs []*entities.ArtifactListReport) error {
+	if listFlag.sort == "" {
+		return nil
+	}
+	digests := make(map[*entities.ArtifactListReport]string, len(lrs))
+	for _, lr := range lrs {
+		artifactDigest, err := lr.Artifact.GetDigest()
 		if err != nil {
 			return err
 		}
-		named, ok := repo.(reference.Named)
-		if !ok {
-			return fmt.Errorf("%q is an invalid artifact name", artifactName)
-		}
-		if tagged, ok := named.(reference.Tagged); ok {
-			tag = tagged.Tag()
+		digests[lr] = artifactDigest.Encoded()
+	}
+	slices.SortStableFunc(lrs, func(a, b *entities.ArtifactListReport) int {
+		switch listFlag.sort {
+		case "created":
+			return b.Created.Compare(a.Created)
+		case "digest":
+			return cmp.Compare(digests[a], digests[b])
+		case "repository":
+			return cmp.Compare(a.Name, b.Name)
+		case "size":
+			return cmp.Compare(a.TotalSize, b.TotalSize)
+		default:
+			return 0
 		}
+	})
+	return nil
+}
 
-		// Note: Right now we only support things that are single manifests
-		// We should certainly expand this support for things like arch, etc
-		// as we move on
+func outputQuiet(lrs []*entities.ArtifactListReport, opts entities.ArtifactListOptions) error {
+	for _, lr := range lrs {
 		artifactDigest, err := lr.Artifact.GetDigest()
 		if err != nil {
 			return err
 		}
+		fmt.Println(displayDigest(artifactDigest.Encoded(), opts.NoTrunc))
+	}
+	return nil
+}
 
-		artifactHash := artifactDigest.Encoded()[0:12]
-		// If the user does not want truncated hashes
-		if listFlag.noTrunc {
-			artifactHash = artifactDigest.Encoded()
+// displayDigest returns the encoded digest as it is listed, shortened to 12
+// characters unless noTrunc is set.  The same value is used in the table and
+// for {{.Digest}} in a custom format, independent of the width of the terminal.
+func displayDigest(encoded string, noTrunc bool) string {
+	if noTrunc || len(encoded) <= 12 {
+		return encoded
+	}
+	return encoded[:12]
+}
+
+// outputStream prints each artifact as soon as it is listed, its digest if
+// --quiet is set and otherwise a line of JSON.
+func outputStream(opts entities.ArtifactListOptions) error {
+	reportChan := make(chan entities.ArtifactListStreamReport, 1)
+	opts.Stream = true
+	opts.ReportChan = reportChan
+	if _, err := registry.ImageEngine().ArtifactList(registry.Context(), opts); err != nil {
+		return err
+	}
+	for r := range reportChan {
+		if r.Error != nil {
+			return r.Error
+		}
+		if listFlag.quiet {
+			if err := outputQuiet([]*entities.ArtifactListReport{r.Report}, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		output, err := listOutput(r.Report, opts)
+		if err != nil {
+			return err
+		}
+		jsonOutput, err := json.Marshal(output)
+		if err != nil {
+			return err
 		}
+		fmt.Println(string(jsonOutput))
+	}
+	return nil
+}
 
-		artifacts = append(artifacts, artifactListOutput{
-			Digest:     artifactHash,
-			Repository: named.Name(),
-			Size:       units.HumanSize(float64(lr.Artifact.TotalSizeBytes())),
-			Tag:        tag,
-		})
+func outputTemplate(cmd *cobra.Command, lrs []*entities.ArtifactListReport, opts entities.ArtifactListOptions) error {
+	var err error
+	artifacts := make([]artifactListOutput, 0, len(lrs))
+	for _, lr := range lrs {
+		output, err := listOutput(lr, opts)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, output)
 	}
 
 	headers := report.Headers(artifactListOutput{}, map[string]string{
-		"REPOSITORY": "REPOSITORY",
-		"Tag":        "TAG",
-		"Size":       "SIZE",
-		"Digest":     "DIGEST",
+		"ArtifactType":    "ARTIFACT TYPE",
+		"CreatedAt":       "CREATED AT",
+		"Description":     "DESCRIPTION",
+		"REPOSITORY":      "REPOSITORY",
+		"Tag":             "TAG",
+		"Size":            "SIZE",
+		"Digest":          "DIGEST",
+		"DigestAlgorithm": "DIGEST ALGORITHM",
+		"Kind":            "KIND",
+		"TotalSize":       "TOTAL SIZE",
 	})
 
 	rpt := report.New(os.Stdout, cmd.Name())
 	defer rpt.Flush()
 
 	switch {
-	case cmd.Flag("format").Changed:
+	case cmd.Flag("format").Changed && strings.TrimSpace(listFlag.format) != "table":
 		rpt, err = rpt.Parse(report.OriginUser, listFlag.format)
 	default:
-		rpt, err = rpt.Parse(report.OriginPodman, listFlag.format)
+		rpt, err = rpt.Parse(report.OriginPodman, defaultArtifactListOutputFormat)
 	}
 	if err != nil {
 		return err
@@ -141,3 +391,57 @@ func outputTemplate(cmd *cobra.Command, lrs []*entities.ArtifactListReport) erro
 	}
 	return rpt.Execute(artifacts)
 }
+
+// listOutput returns the fields of the listed artifact.
+func listOutput(lr *entities.ArtifactListReport, opts entities.ArtifactListOptions) (artifactListOutput, error) {
+	var (
+		repository = "<none>"
+		tag        = "<none>"
+	)
+	artifactName, err := lr.Artifact.GetName()
+	if err != nil && !errors.Is(err, types.ErrArtifactUnamed) {
+		return artifactListOutput{}, err
+	}
+	if err == nil {
+		repo, err := reference.Parse(artifactName)
+		if err != nil {
+			return artifactListOutput{}, err
+		}
+		named, ok := repo.(reference.Named)
+		if !ok {
+			return artifactListOutput{}, fmt.Errorf("%q is an invalid artifact name", artifactName)
+		}
+		repository = named.Name()
+		tag = ""
+		if tagged, ok := named.(reference.Tagged); ok {
+			tag = tagged.Tag()
+		}
+	}
+
+	// Note: Right now we only support things that are single manifests
+	// We should certainly expand this support for things like arch, etc
+	// as we move on
+	artifactDigest, err := lr.Artifact.GetDigest()
+	if err != nil {
+		return artifactListOutput{}, err
+	}
+
+	var created, createdAt string
+	if !lr.Created.IsZero() {
+		created = units.HumanDuration(time.Since(lr.Created)) + " ago"
+		createdAt = lr.Created.String()
+	}
+	return artifactListOutput{
+		ArtifactType:    lr.ArtifactType,
+		Created:         created,
+		CreatedAt:       createdAt,
+		Description:     lr.Description,
+		Digest:          displayDigest(artifactDigest.Encoded(), opts.NoTrunc),
+		DigestAlgorithm: lr.DigestAlgorithm.String(),
+		Kind:            lr.Kind,
+		Repository:      repository,
+		Size:            units.HumanSize(float64(lr.TotalSize)),
+		Tag:             tag,
+		TotalSize:       lr.TotalSize,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountCmd = &cobra.Command{
+		Use:               "mount ARTIFACT",
+		Short:             "Mount an OCI artifact",
+		Long:              "Lay out the blobs of an OCI artifact in a read-only directory on the host and print its path",
+		RunE:              mount,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example:           `podman artifact mount quay.io/myimage/myartifact:latest`,
+		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: mountCmd,
+		Parent:  artifactCmd,
+	})
+}
+
+func mount(cmd *cobra.Command, args []string) error {
+	report, err := registry.ImageEngine().ArtifactMount(registry.Context(), args[0], entities.ArtifactMountOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.Path)
+	return nil
+}
@@ -0,0 +1,111 @@
+package artifact
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/parse"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/cmd/podman/validate"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDescription = `Removes dangling or unused artifacts from local storage.
+
+  Artifacts mounted by a container are never removed. The command prompts for confirmation
+  which can be overridden with the --force flag.`
+	pruneCmd = &cobra.Command{
+		Use:               "prune [options]",
+		Args:              validate.NoArgs,
+		Short:             "Remove unused artifacts",
+		Long:              pruneDescription,
+		RunE:              prune,
+		ValidArgsFunction: completion.AutocompleteNone,
+		Example: `podman artifact prune
+podman artifact prune --all --filter until=24h
+podman artifact prune --all --filter 'size=>1GB'
+podman artifact prune --all --dry-run`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+
+	pruneOpts   = entities.ArtifactPruneOptions{}
+	pruneFilter = []string{}
+	pruneForce  bool
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: pruneCmd,
+		Parent:  artifactCmd,
+	})
+
+	flags := pruneCmd.Flags()
+	flags.BoolVarP(&pruneOpts.All, "all", "a", false, "Remove all artifacts not in use by containers, not just dangling ones")
+	flags.BoolVar(&pruneOpts.DryRun, "dry-run", false, "Only print the artifacts which would be removed")
+	flags.BoolVarP(&pruneForce, "force", "f", false, "Do not prompt for confirmation")
+	flags.BoolVar(&pruneOpts.IncludeUndated, "include-undated", false, "Use the time in the local store for the until filter if an artifact has no creation time")
+
+	filterFlagName := "filter"
+	flags.StringArrayVar(&pruneFilter, filterFlagName, []string{}, "Provide filter values (e.g. 'until=<timestamp>')")
+	_ = pruneCmd.RegisterFlagCompletionFunc(filterFlagName, common.AutocompleteArtifactPruneFilters)
+}
+
+func prune(cmd *cobra.Command, args []string) error {
+	var err error
+	pruneOpts.Filter, err = parse.FilterArgumentsIntoFilters(pruneFilter)
+	if err != nil {
+		return err
+	}
+
+	if !pruneForce && !pruneOpts.DryRun {
+		dryRunOpts := pruneOpts
+		dryRunOpts.DryRun = true
+		candidates, err := registry.ImageEngine().ArtifactPrune(registry.Context(), dryRunOpts)
+		if err != nil {
+			return err
+		}
+		if len(candidates.ArtifactDigests) == 0 {
+			return nil
+		}
+		if pruneOpts.All {
+			fmt.Println("WARNING! This command removes all artifacts not used by a container. The following artifacts will be removed:")
+		} else {
+			fmt.Println("WARNING! This command removes all dangling artifacts. The following artifacts will be removed:")
+		}
+		for _, d := range candidates.ArtifactDigests {
+			fmt.Println(d.Encoded())
+		}
+		fmt.Print("Are you sure you want to continue? [y/N] ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(answer)[0] != 'y' {
+			return nil
+		}
+	}
+
+	report, err := registry.ImageEngine().ArtifactPrune(registry.Context(), pruneOpts)
+	if err != nil {
+		return err
+	}
+	for _, d := range report.ArtifactDigests {
+		fmt.Println(d.Encoded())
+	}
+	if len(report.ArtifactDigests) == 0 {
+		return nil
+	}
+	if pruneOpts.DryRun {
+		fmt.Printf("Total reclaimable space: %s\n", units.HumanSize(float64(report.ReclaimedSize)))
+	} else {
+		fmt.Printf("Total reclaimed space: %s\n", units.HumanSize(float64(report.ReclaimedSize)))
+	}
+	return nil
+}
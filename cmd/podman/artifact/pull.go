@@ -1,10 +1,13 @@
 package artifact
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/containers/buildah/pkg/cli"
+	"github.com/containers/common/libimage/platform"
 	"github.com/containers/common/pkg/auth"
 	"github.com/containers/common/pkg/completion"
 	"github.com/containers/image/v5/types"
@@ -12,6 +15,7 @@ import (
 	"github.com/containers/podman/v5/cmd/podman/registry"
 	"github.com/containers/podman/v5/pkg/domain/entities"
 	"github.com/containers/podman/v5/pkg/util"
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +26,8 @@ type pullOptionsWrapper struct {
 	TLSVerifyCLI   bool // CLI only
 	CredentialsCLI string
 	DecryptionKeys []string
+	RateLimitCLI   string
+	MaxSizeCLI     string
 }
 
 var (
@@ -35,8 +41,9 @@ var (
 		RunE:              artifactPull,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: common.AutocompleteArtifacts,
-		Example:           `podman artifact pull quay.io/myimage/myartifact:latest`,
-		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+		Example: `podman artifact pull quay.io/myimage/myartifact:latest
+podman artifact pull --extract-to ./model --no-store quay.io/myimage/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 )
 
@@ -52,6 +59,18 @@ func init() {
 func pullFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 
+	archFlagName := "arch"
+	flags.StringVar(&pullOptions.Architecture, archFlagName, "", "Use `ARCH` instead of the architecture of the machine for choosing an artifact from an index")
+	_ = cmd.RegisterFlagCompletionFunc(archFlagName, completion.AutocompleteArch)
+
+	osFlagName := "os"
+	flags.StringVar(&pullOptions.OS, osFlagName, "", "Use `OS` instead of the running OS for choosing an artifact from an index")
+	_ = cmd.RegisterFlagCompletionFunc(osFlagName, completion.AutocompleteOS)
+
+	variantFlagName := "variant"
+	flags.StringVar(&pullOptions.Variant, variantFlagName, "", "Use VARIANT instead of the running architecture variant for choosing an artifact from an index")
+	_ = cmd.RegisterFlagCompletionFunc(variantFlagName, completion.AutocompleteNone)
+
 	credsFlagName := "creds"
 	flags.StringVar(&pullOptions.CredentialsCLI, credsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to a registry")
 	_ = cmd.RegisterFlagCompletionFunc(credsFlagName, completion.AutocompleteNone)
@@ -74,12 +93,54 @@ func pullFlags(cmd *cobra.Command) {
 	flags.String(retryDelayFlagName, registry.RetryDelayDefault(), "delay between retries in case of pull failures")
 	_ = cmd.RegisterFlagCompletionFunc(retryDelayFlagName, completion.AutocompleteNone)
 
+	titleFlagName := "title"
+	flags.StringArrayVar(&pullOptions.Titles, titleFlagName, nil, "Only pull the blob with `TITLE`, the other blobs are fetched when needed")
+	_ = cmd.RegisterFlagCompletionFunc(titleFlagName, completion.AutocompleteNone)
+
+	digestFlagName := "digest"
+	flags.StringArrayVar(&pullOptions.Digests, digestFlagName, nil, "Only pull the blob with `DIGEST`, the other blobs are fetched when needed")
+	_ = cmd.RegisterFlagCompletionFunc(digestFlagName, completion.AutocompleteNone)
+
+	extractToFlagName := "extract-to"
+	flags.StringVar(&pullOptions.ExtractTo, extractToFlagName, "", "Extract all blobs of the artifact to `DIRECTORY` after pulling it")
+	_ = cmd.RegisterFlagCompletionFunc(extractToFlagName, completion.AutocompleteDefault)
+
+	flags.BoolVar(&pullOptions.NoStore, "no-store", false, "Do not keep the artifact in the local store, only extract it")
+
+	flags.BoolVar(&pullOptions.Anonymous, "anonymous", false, "Store the artifact without its name, it is only found by its digest")
+
+	maxParallelDownloadsFlagName := "max-parallel-downloads"
+	flags.UintVar(&pullOptions.MaxParallelDownloads, maxParallelDownloadsFlagName, 0, "Maximum number of blobs downloaded in parallel (default 3)")
+	_ = cmd.RegisterFlagCompletionFunc(maxParallelDownloadsFlagName, completion.AutocompleteNone)
+
+	maxSizeFlagName := "max-size"
+	flags.StringVar(&pullOptions.MaxSizeCLI, maxSizeFlagName, "", "Refuse to pull an artifact whose blobs are larger than `SIZE` bytes together, e.g. 2g (default no limit)")
+	_ = cmd.RegisterFlagCompletionFunc(maxSizeFlagName, completion.AutocompleteNone)
+
+	rateLimitFlagName := "rate-limit"
+	flags.StringVar(&pullOptions.RateLimitCLI, rateLimitFlagName, "", "Limit the download of all blobs together to `RATE` bytes per second, e.g. 10m (default no limit)")
+	_ = cmd.RegisterFlagCompletionFunc(rateLimitFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&pullOptions.RequireDigest, "require-digest", false, "Refuse to pull an artifact name without a manifest digest")
+
+	flags.BoolVar(&pullOptions.Resume, "resume", false, "Resume the download of blobs an interrupted pull downloaded partially")
+
+	timeoutFlagName := "timeout"
+	flags.DurationVar(&pullOptions.Timeout, timeoutFlagName, 0, "Fail the pull if it does not complete within `DURATION`, including retries (default no timeout)")
+	_ = cmd.RegisterFlagCompletionFunc(timeoutFlagName, completion.AutocompleteNone)
+
 	if registry.IsRemote() {
 		_ = flags.MarkHidden(decryptionKeysFlagName)
 	} else {
 		certDirFlagName := "cert-dir"
 		flags.StringVar(&pullOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
 		_ = cmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
+
+		flags.BoolVar(&pullOptions.LogPolicy, "log-policy", false, "Print the signature policy requirements the artifact is checked against")
+
+		signaturePolicyFlagName := "signature-policy"
+		flags.StringVar(&pullOptions.SignaturePolicyPath, signaturePolicyFlagName, "", "Path to a signature-policy file")
+		_ = flags.MarkHidden(signaturePolicyFlagName)
 	}
 }
 
@@ -125,6 +186,20 @@ func artifactPull(cmd *cobra.Command, args []string) error {
 		pullOptions.Password = creds.Password
 	}
 
+	rateLimit, err := parseRateLimit(pullOptions.RateLimitCLI)
+	if err != nil {
+		return err
+	}
+	pullOptions.RateLimitBytesPerSec = rateLimit
+
+	if pullOptions.MaxSizeCLI != "" {
+		maxSize, err := units.RAMInBytes(pullOptions.MaxSizeCLI)
+		if err != nil {
+			return fmt.Errorf("invalid maximum size %q: %w", pullOptions.MaxSizeCLI, err)
+		}
+		pullOptions.MaxSizeBytes = maxSize
+	}
+
 	decConfig, err := cli.DecryptConfig(pullOptions.DecryptionKeys)
 	if err != nil {
 		return fmt.Errorf("unable to obtain decryption config: %w", err)
@@ -135,6 +210,51 @@ func artifactPull(cmd *cobra.Command, args []string) error {
 		pullOptions.Writer = os.Stdout
 	}
 
-	_, err = registry.ImageEngine().ArtifactPull(registry.Context(), args[0], pullOptions.ArtifactPullOptions)
-	return err
+	if pullOptions.NoStore && pullOptions.ExtractTo == "" {
+		return errors.New("--no-store requires --extract-to")
+	}
+	if pullOptions.Resume && pullOptions.NoStore {
+		return errors.New("--resume and --no-store cannot be used together")
+	}
+
+	pullReport, err := registry.ImageEngine().ArtifactPull(registry.Context(), args[0], pullOptions.ArtifactPullOptions)
+	if err != nil {
+		return err
+	}
+	if !pullOptions.Quiet && pullReport.Mirror != "" {
+		fmt.Fprintf(os.Stderr, "Pulled from mirror %s\n", pullReport.Mirror)
+	}
+	if !pullOptions.Quiet && pullReport.PinnedDigest != "" {
+		fmt.Fprintf(os.Stderr, "Verified pinned digest %s\n", pullReport.PinnedDigest)
+	}
+	if !pullOptions.Quiet && pullReport.RateLimitWait > 0 {
+		fmt.Fprintf(os.Stderr, "Waited %s on registry rate limits\n", pullReport.RateLimitWait.Round(time.Millisecond))
+	}
+	if !pullOptions.Quiet && pullReport.BlobsSkipped > 0 {
+		fmt.Fprintf(os.Stderr, "Fetched %d blobs, skipped %d (%s) already in the local store\n", pullReport.BlobsFetched, pullReport.BlobsSkipped, units.HumanSize(float64(pullReport.BytesSkipped)))
+	}
+	if !pullOptions.Quiet && pullReport.Platform != nil {
+		fmt.Fprintf(os.Stderr, "Selected platform %s\n", platform.ToString(pullReport.Platform.OS, pullReport.Platform.Architecture, pullReport.Platform.Variant))
+	}
+	for _, file := range pullReport.ExtractedFiles {
+		fmt.Println(file)
+	}
+	// The digest is the only way to refer to an anonymous artifact.
+	if pullOptions.Anonymous && !pullOptions.NoStore {
+		fmt.Println(pullReport.ArtifactDigest.Encoded())
+	}
+	return nil
+}
+
+// parseRateLimit parses the value of a --rate-limit flag, a size like 10m in
+// bytes per second.  An empty value or 0 is no limit.
+func parseRateLimit(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	rateLimit, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate limit %q: %w", value, err)
+	}
+	return rateLimit, nil
 }
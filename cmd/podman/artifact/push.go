@@ -3,6 +3,7 @@ package artifact
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/containers/buildah/pkg/cli"
 	"github.com/containers/common/pkg/auth"
@@ -11,7 +12,9 @@ import (
 	"github.com/containers/podman/v5/cmd/podman/common"
 	"github.com/containers/podman/v5/cmd/podman/registry"
 	"github.com/containers/podman/v5/pkg/domain/entities"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
 	"github.com/containers/podman/v5/pkg/util"
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
 
@@ -25,22 +28,27 @@ type pushOptionsWrapper struct {
 	SignBySigstoreParamFileCLI string
 	EncryptionKeys             []string
 	EncryptLayers              []int
-	DigestFile                 string
+	PlatformAll                bool
+	RateLimitCLI               string
 }
 
 var (
 	pushOptions     = pushOptionsWrapper{}
-	pushDescription = `Push an OCI artifact from local storage to an image registry`
+	pushDescription = `Push an OCI artifact from local storage to an image registry, or to a local oci, oci-archive or dir destination`
 
 	pushCmd = &cobra.Command{
-		Use:               "push [options] ARTIFACT.",
+		Use:               "push [options] ARTIFACT [DESTINATION]",
 		Short:             "Push an OCI artifact",
 		Long:              pushDescription,
 		RunE:              artifactPush,
-		Args:              cobra.ExactArgs(1),
+		Args:              pushArgs,
 		ValidArgsFunction: common.AutocompleteArtifacts,
-		Example:           `podman artifact push quay.io/myimage/myartifact:latest`,
-		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+		Example: `podman artifact push quay.io/myimage/myartifact:latest
+podman artifact push --additional-tag v1.0 quay.io/myimage/myartifact:latest
+podman artifact push --dry-run quay.io/myimage/myartifact:latest
+podman artifact push quay.io/myimage/myartifact:latest oci-archive:/tmp/myartifact.tar
+podman artifact push --platform-all quay.io/myimage/myartifact:latest linux/amd64=quay.io/myimage/myartifact:amd64 linux/arm64=quay.io/myimage/myartifact:arm64`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 )
 
@@ -58,12 +66,16 @@ func pushFlags(cmd *cobra.Command) {
 
 	// For now default All flag to true, for pushing of manifest lists
 	pushOptions.All = true
+	additionalTagFlagName := "additional-tag"
+	flags.StringArrayVar(&pushOptions.AdditionalTags, additionalTagFlagName, nil, "Also push the artifact to this `tag` of the destination repository")
+	_ = cmd.RegisterFlagCompletionFunc(additionalTagFlagName, completion.AutocompleteNone)
+
 	authfileFlagName := "authfile"
-	flags.StringVar(&pushOptions.Authfile, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	flags.StringVar(&pushOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
 	_ = cmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
 
 	certDirFlagName := "cert-dir"
-	flags.StringVar(&pushOptions.CertDir, certDirFlagName, "", "Path to a directory containing TLS certificates and keys")
+	flags.StringVar(&pushOptions.CertDir, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
 	_ = cmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
 
 	// This is a flag I didn't wire up but could be considered
@@ -77,6 +89,14 @@ func pushFlags(cmd *cobra.Command) {
 	flags.StringVar(&pushOptions.DigestFile, digestfileFlagName, "", "Write the digest of the pushed image to the specified file")
 	_ = cmd.RegisterFlagCompletionFunc(digestfileFlagName, completion.AutocompleteDefault)
 
+	flags.BoolVar(&pushOptions.DryRun, "dry-run", false, "Only print the blobs which would be uploaded and the ones already present at the destination")
+
+	mountFromFlagName := "mount-from"
+	flags.StringArrayVar(&pushOptions.MountFrom, mountFromFlagName, nil, "Mount blobs missing at the destination from this `repository` of the same registry instead of uploading them")
+	_ = cmd.RegisterFlagCompletionFunc(mountFromFlagName, completion.AutocompleteNone)
+
+	flags.BoolVar(&pushOptions.PlatformAll, "platform-all", false, "Push the PLATFORM=ARTIFACT arguments and an index referring to them for their platforms to ARTIFACT")
+
 	flags.BoolVarP(&pushOptions.Quiet, "quiet", "q", false, "Suppress output information when pushing images")
 
 	retryFlagName := "retry"
@@ -87,6 +107,16 @@ func pushFlags(cmd *cobra.Command) {
 	flags.String(retryDelayFlagName, registry.RetryDelayDefault(), "delay between retries in case of push failures")
 	_ = cmd.RegisterFlagCompletionFunc(retryDelayFlagName, completion.AutocompleteNone)
 
+	flags.BoolVar(&pushOptions.RetryBackoff, "retry-backoff", false, "Double the delay between retries after each failed attempt")
+
+	maxParallelUploadsFlagName := "max-parallel-uploads"
+	flags.UintVar(&pushOptions.MaxParallelUploads, maxParallelUploadsFlagName, 0, "Maximum number of blobs uploaded in parallel (default 3)")
+	_ = cmd.RegisterFlagCompletionFunc(maxParallelUploadsFlagName, completion.AutocompleteNone)
+
+	rateLimitFlagName := "rate-limit"
+	flags.StringVar(&pushOptions.RateLimitCLI, rateLimitFlagName, "", "Limit the upload of all blobs together to `RATE` bytes per second, e.g. 10m (default no limit)")
+	_ = cmd.RegisterFlagCompletionFunc(rateLimitFlagName, completion.AutocompleteNone)
+
 	signByFlagName := "sign-by"
 	flags.StringVar(&pushOptions.SignBy, signByFlagName, "", "Add a signature at the destination using the specified key")
 	_ = cmd.RegisterFlagCompletionFunc(signByFlagName, completion.AutocompleteNone)
@@ -103,19 +133,21 @@ func pushFlags(cmd *cobra.Command) {
 	flags.StringVar(&pushOptions.SignPassphraseFileCLI, signPassphraseFileFlagName, "", "Read a passphrase for signing an image from `PATH`")
 	_ = cmd.RegisterFlagCompletionFunc(signPassphraseFileFlagName, completion.AutocompleteDefault)
 
+	timeoutFlagName := "timeout"
+	flags.DurationVar(&pushOptions.Timeout, timeoutFlagName, 0, "Fail the push if it does not complete within `DURATION`, including retries (default no timeout)")
+	_ = cmd.RegisterFlagCompletionFunc(timeoutFlagName, completion.AutocompleteNone)
+
 	flags.BoolVar(&pushOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
 
-	// TODO I think these two can be removed?
-	/*
-		compFormat := "compression-format"
-		flags.StringVar(&pushOptions.CompressionFormat, compFormat, compressionFormat(), "compression format to use")
-		_ = cmd.RegisterFlagCompletionFunc(compFormat, common.AutocompleteCompressionFormat)
+	compFormat := "compression-format"
+	flags.StringVar(&pushOptions.CompressionFormat, compFormat, "", "Compress blobs which are not compressed yet with this `format` (gzip, zstd or none)")
+	_ = cmd.RegisterFlagCompletionFunc(compFormat, common.AutocompleteArtifactCompressionFormat)
 
-		compLevel := "compression-level"
-		flags.Int(compLevel, compressionLevel(), "compression level to use")
-		_ = cmd.RegisterFlagCompletionFunc(compLevel, completion.AutocompleteNone)
+	compLevel := "compression-level"
+	flags.Int(compLevel, 0, "compression level to use")
+	_ = cmd.RegisterFlagCompletionFunc(compLevel, completion.AutocompleteNone)
 
-	*/
+	flags.BoolVar(&pushOptions.ForceCompression, "compress-force", false, "Recompress blobs which are compressed already with the --compression-format")
 
 	// Potential options that could be wired up if deemed necessary
 	// encryptionKeysFlagName := "encryption-key"
@@ -141,10 +173,21 @@ func pushFlags(cmd *cobra.Command) {
 	}
 }
 
+// pushArgs requires an artifact and optionally its destination, or the index
+// destination followed by at least one PLATFORM=ARTIFACT argument with
+// --platform-all.
+func pushArgs(cmd *cobra.Command, args []string) error {
+	if pushOptions.PlatformAll {
+		return cobra.MinimumNArgs(2)(cmd, args)
+	}
+	return cobra.RangeArgs(1, 2)(cmd, args)
+}
+
 func artifactPush(cmd *cobra.Command, args []string) error {
 	source := args[0]
-	// Should we just make destination == origin ?
-	// destination := args[len(args)-1]
+	if len(args) > 1 && !pushOptions.PlatformAll {
+		pushOptions.Destination = args[1]
+	}
 
 	// TLS verification in c/image is controlled via a `types.OptionalBool`
 	// which allows for distinguishing among set-true, set-false, unspecified
@@ -155,7 +198,7 @@ func artifactPush(cmd *cobra.Command, args []string) error {
 	}
 
 	if cmd.Flags().Changed("authfile") {
-		if err := auth.CheckAuthFile(pushOptions.Authfile); err != nil {
+		if err := auth.CheckAuthFile(pushOptions.AuthFilePath); err != nil {
 			return err
 		}
 	}
@@ -193,7 +236,7 @@ func artifactPush(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		pushOptions.Retry = &retry
+		pushOptions.MaxRetries = &retry
 	}
 
 	if cmd.Flags().Changed("retry-delay") {
@@ -205,25 +248,78 @@ func artifactPush(cmd *cobra.Command, args []string) error {
 		pushOptions.RetryDelay = val
 	}
 
-	// TODO If not compression options are supported, we do not need the following
-	/*
-		if cmd.Flags().Changed("compression-level") {
-			val, err := cmd.Flags().GetInt("compression-level")
-			if err != nil {
-				return err
-			}
-			pushOptions.CompressionLevel = &val
+	if cmd.Flags().Changed("compression-level") {
+		val, err := cmd.Flags().GetInt("compression-level")
+		if err != nil {
+			return err
 		}
+		pushOptions.CompressionLevel = &val
+	}
+
+	rateLimit, err := parseRateLimit(pushOptions.RateLimitCLI)
+	if err != nil {
+		return err
+	}
+	pushOptions.RateLimitBytesPerSec = rateLimit
+
+	if pushOptions.PlatformAll {
+		return artifactPushIndex(source, args[1:])
+	}
+
+	report, err := registry.ImageEngine().ArtifactPush(registry.Context(), source, pushOptions.ArtifactPushOptions)
+	if err != nil {
+		return err
+	}
+	if pushOptions.DryRun {
+		printPushPlan(report)
+	} else if len(pushOptions.MountFrom) > 0 && !pushOptions.Quiet {
+		fmt.Printf("%s mounted, %s uploaded\n", blobCount(report.MountedBlobs), blobCount(report.UploadedBlobs))
+	}
+	if pushOptions.ForceCompression && !pushOptions.Quiet {
+		fmt.Printf("%s recompressed\n", blobCount(report.RecompressedBlobs))
+	}
+	return nil
+}
 
-			if cmd.Flags().Changed("compression-format") {
-				if !cmd.Flags().Changed("force-compression") {
-					// If `compression-format` is set and no value for `--force-compression`
-					// is selected then defaults to `true`.
-					pushOptions.ForceCompressionFormat = true
-				}
-			}
-	*/
+// blobCount returns n followed by "blob" or "blobs".
+func blobCount(n int) string {
+	if n == 1 {
+		return "1 blob"
+	}
+	return fmt.Sprintf("%d blobs", n)
+}
 
-	_, err = registry.ImageEngine().ArtifactPush(registry.Context(), source, pushOptions.ArtifactPushOptions)
+// printPushPlan prints the blobs a dry run of the push would upload and the
+// ones it would skip, followed by their totals.
+func printPushPlan(report *entities.ArtifactPushReport) {
+	printBlobs := func(action string, blobs []libartTypes.PushBlob) int64 {
+		var total int64
+		for _, b := range blobs {
+			fmt.Println(strings.TrimSpace(fmt.Sprintf("%-6s %s %s %s", action, b.Digest, units.HumanSize(float64(b.Size)), b.Title)))
+			total += b.Size
+		}
+		return total
+	}
+	uploadSize := printBlobs("Upload", report.UploadBlobs)
+	skippedSize := printBlobs("Skip", report.SkippedBlobs)
+	fmt.Printf("%s to upload (%s), %s already present (%s)\n",
+		blobCount(len(report.UploadBlobs)), units.HumanSize(float64(uploadSize)), blobCount(len(report.SkippedBlobs)), units.HumanSize(float64(skippedSize)))
+}
+
+// artifactPushIndex pushes the artifacts of the PLATFORM=ARTIFACT arguments
+// and an index referring to them to dest.
+func artifactPushIndex(dest string, args []string) error {
+	indexOptions := entities.ArtifactIndexOptions{ArtifactPushOptions: pushOptions.ArtifactPushOptions}
+	for _, arg := range args {
+		platform, artifact, ok := strings.Cut(arg, "=")
+		if !ok || platform == "" || artifact == "" {
+			return fmt.Errorf("invalid argument %q, must be PLATFORM=ARTIFACT", arg)
+		}
+		indexOptions.Artifacts = append(indexOptions.Artifacts, entities.ArtifactIndexArtifact{
+			Artifact: artifact,
+			Platform: platform,
+		})
+	}
+	_, err := registry.ImageEngine().ArtifactPushIndex(registry.Context(), dest, indexOptions)
 	return err
 }
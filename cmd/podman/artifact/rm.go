@@ -12,9 +12,9 @@ import (
 
 var (
 	rmCmd = &cobra.Command{
-		Use:     "rm [options] ARTIFACT",
-		Short:   "Remove an OCI artifact",
-		Long:    "Remove an OCI artifact from local storage",
+		Use:     "rm [options] ARTIFACT [ARTIFACT...]",
+		Short:   "Remove one or more OCI artifacts",
+		Long:    "Remove one or more OCI artifacts from local storage. A shell glob pattern removes all artifacts with a matching name",
 		RunE:    rm,
 		Aliases: []string{"remove"},
 		Args: func(cmd *cobra.Command, args []string) error { //nolint: gocritic
@@ -22,7 +22,11 @@ var (
 		},
 		ValidArgsFunction: common.AutocompleteArtifacts,
 		Example: `podman artifact rm quay.io/myimage/myartifact:latest
-podman artifact rm -a`,
+podman artifact rm 'quay.io/models/llama-*'
+podman artifact rm --ignore quay.io/myimage/myartifact:v1 quay.io/myimage/myartifact:v2
+podman artifact rm --latest
+podman artifact rm -a
+podman artifact rm -a --dry-run`,
 		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
 	}
 
@@ -32,6 +36,9 @@ podman artifact rm -a`,
 func rmFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 	flags.BoolVarP(&rmOptions.All, "all", "a", false, "Remove all artifacts")
+	flags.BoolVar(&rmOptions.DryRun, "dry-run", false, "Only print the artifacts which would be removed")
+	flags.BoolVarP(&rmOptions.Ignore, "ignore", "i", false, "Ignore errors if a specified artifact does not exist")
+	flags.BoolVarP(&rmOptions.Latest, "latest", "l", false, "Remove the artifact most recently stored in the local store")
 }
 func init() {
 	registry.Commands = append(registry.Commands, registry.CliCommand{
@@ -42,11 +49,7 @@ func init() {
 }
 
 func rm(cmd *cobra.Command, args []string) error {
-	var nameOrID string
-	if len(args) > 0 {
-		nameOrID = args[0]
-	}
-	artifactRemoveReport, err := registry.ImageEngine().ArtifactRm(registry.Context(), nameOrID, rmOptions)
+	artifactRemoveReport, err := registry.ImageEngine().ArtifactRm(registry.Context(), args, rmOptions)
 	if err != nil {
 		return err
 	}
@@ -56,23 +59,24 @@ func rm(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// checkAllAndArgs takes a cobra command and args and checks if
-// all is used, then no args can be passed. note: this was created
-// as an unexported local func for now and could be moved to pkg
-// validate.  if we add "--latest" to the command, then perhaps
-// one of the existing plg validate funcs would be appropriate.
+// checkAllAndArgs takes a cobra command and args and checks that
+// exactly one of all, latest or artifact names or digests is used.
+// note: the validate funcs for --all and --latest are not used, as
+// their errors talk about containers and pods.
 func checkAllAndArgs(c *cobra.Command, args []string) error {
 	all, _ := c.Flags().GetBool("all")
+	latest, _ := c.Flags().GetBool("latest")
+	if all && latest {
+		return errors.New("--all and --latest cannot be used together")
+	}
 	if all && len(args) > 0 {
 		return fmt.Errorf("when using the --all switch, you may not pass any artifact names or digests")
 	}
-	if !all {
-		if len(args) < 1 {
-			return errors.New("a single artifact name or digest must be specified")
-		}
-		if len(args) > 1 {
-			return errors.New("too many arguments: only accepts one artifact name or digest ")
-		}
+	if latest {
+		return checkLatestAndArgs(c, args)
+	}
+	if !all && len(args) < 1 {
+		return errors.New("at least one artifact name or digest must be specified")
 	}
 	return nil
 }
@@ -0,0 +1,46 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagCmd = &cobra.Command{
+		Use:               "tag [options] ARTIFACT NAME",
+		Short:             "Add a name to an OCI artifact",
+		Long:              "Add a name to an OCI artifact in the local store without copying its blobs",
+		RunE:              tag,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example: `podman artifact tag quay.io/myimage/myartifact:latest quay.io/myimage/myartifact:v1
+podman artifact tag --remove-old quay.io/myimage/myartifact:latest quay.io/other/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+var tagOpts entities.ArtifactTagOptions
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: tagCmd,
+		Parent:  artifactCmd,
+	})
+	flags := tagCmd.Flags()
+
+	flags.BoolVarP(&tagOpts.Force, "force", "f", false, "Replace another artifact which already has the new name")
+	flags.BoolVar(&tagOpts.RemoveOld, "remove-old", false, "Remove the old name of the artifact")
+}
+
+func tag(_ *cobra.Command, args []string) error {
+	report, err := registry.ImageEngine().ArtifactTag(registry.Context(), args[0], args[1], tagOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.NewName)
+	return nil
+}
@@ -0,0 +1,40 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unmountCmd = &cobra.Command{
+		Use:               "unmount ARTIFACT",
+		Aliases:           []string{"umount"},
+		Short:             "Unmount an OCI artifact",
+		Long:              "Remove the directory created by podman artifact mount",
+		RunE:              unmount,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example:           `podman artifact unmount quay.io/myimage/myartifact:latest`,
+		Annotations:       map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: unmountCmd,
+		Parent:  artifactCmd,
+	})
+}
+
+func unmount(cmd *cobra.Command, args []string) error {
+	report, err := registry.ImageEngine().ArtifactUnmount(registry.Context(), args[0], entities.ArtifactUnmountOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.Name)
+	return nil
+}
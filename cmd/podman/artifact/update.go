@@ -0,0 +1,83 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/domain/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateCmd = &cobra.Command{
+		Use:               "update [options] ARTIFACT",
+		Short:             "Update the annotations of an OCI artifact",
+		Long:              "Set or remove annotations of an OCI artifact or one of its blobs without adding the blobs again",
+		RunE:              update,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example: `podman artifact update --annotation org.opencontainers.image.description=model quay.io/myimage/myartifact:latest
+podman artifact update --title foobar.txt --remove-annotation com.example.key quay.io/myimage/myartifact:latest
+podman artifact update --index 0 --annotation org.opencontainers.image.title=foobar.txt quay.io/myimage/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+var (
+	updateOpts        entities.ArtifactUpdateOptions
+	updateAnnotations []string
+	updateIndex       int
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: updateCmd,
+		Parent:  artifactCmd,
+	})
+	flags := updateCmd.Flags()
+
+	annotationFlagName := "annotation"
+	flags.StringArrayVar(&updateAnnotations, annotationFlagName, nil, "Set an `annotation`, replacing an existing value of the same key")
+	_ = updateCmd.RegisterFlagCompletionFunc(annotationFlagName, completion.AutocompleteNone)
+
+	removeAnnotationFlagName := "remove-annotation"
+	flags.StringArrayVar(&updateOpts.RemoveAnnotations, removeAnnotationFlagName, nil, "Remove the annotation with the given `key`")
+	_ = updateCmd.RegisterFlagCompletionFunc(removeAnnotationFlagName, completion.AutocompleteNone)
+
+	digestFlagName := "digest"
+	flags.StringVar(&updateOpts.Digest, digestFlagName, "", "Update the annotations of the blob with the given digest")
+	_ = updateCmd.RegisterFlagCompletionFunc(digestFlagName, completion.AutocompleteNone)
+
+	titleFlagName := "title"
+	flags.StringVar(&updateOpts.Title, titleFlagName, "", "Update the annotations of the blob with the given title")
+	_ = updateCmd.RegisterFlagCompletionFunc(titleFlagName, completion.AutocompleteNone)
+
+	indexFlagName := "index"
+	flags.IntVar(&updateIndex, indexFlagName, 0, "Update the annotations of the blob with the given index in the artifact manifest")
+	_ = updateCmd.RegisterFlagCompletionFunc(indexFlagName, completion.AutocompleteNone)
+}
+
+func update(cmd *cobra.Command, args []string) error {
+	if len(updateAnnotations) == 0 && len(updateOpts.RemoveAnnotations) == 0 {
+		return errors.New("at least one of --annotation or --remove-annotation must be specified")
+	}
+	annots, err := utils.ParseAnnotations(updateAnnotations)
+	if err != nil {
+		return err
+	}
+	updateOpts.SetAnnotations = annots
+	if cmd.Flags().Changed("index") {
+		updateOpts.Index = &updateIndex
+	}
+
+	report, err := registry.ImageEngine().ArtifactUpdate(registry.Context(), args[0], updateOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.NewDigest.Encoded())
+	return nil
+}
@@ -0,0 +1,138 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/common/pkg/completion"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/cmd/podman/common"
+	"github.com/containers/podman/v5/cmd/podman/registry"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// verifyOptionsWrapper wraps entities.ArtifactVerifyOptions and prevents
+// leaking CLI-only fields into the API types.
+type verifyOptionsWrapper struct {
+	entities.ArtifactVerifyOptions
+	TLSVerifyCLI   bool // CLI only
+	CredentialsCLI string
+}
+
+var (
+	verifyOptions     = verifyOptionsWrapper{}
+	verifyDescription = `Check the signatures of an artifact in the local store.
+
+  The signatures of the stored manifest are read from the registry the artifact is named after and checked against the signature policy, or against the given keys. Only the manifest and its signatures are fetched.`
+
+	verifyCmd = &cobra.Command{
+		Use:               "verify [options] ARTIFACT",
+		Short:             "Verify the signatures of an artifact",
+		Long:              verifyDescription,
+		RunE:              verify,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: common.AutocompleteArtifacts,
+		Example: `podman artifact verify quay.io/myimage/myartifact:latest
+podman artifact verify --sigstore-key /etc/pki/cosign.pub quay.io/myimage/myartifact:latest`,
+		Annotations: map[string]string{registry.EngineMode: registry.ABIMode},
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: verifyCmd,
+		Parent:  artifactCmd,
+	})
+	flags := verifyCmd.Flags()
+
+	authfileFlagName := "authfile"
+	flags.StringVar(&verifyOptions.AuthFilePath, authfileFlagName, auth.GetDefaultAuthFile(), "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	_ = verifyCmd.RegisterFlagCompletionFunc(authfileFlagName, completion.AutocompleteDefault)
+
+	certDirFlagName := "cert-dir"
+	flags.StringVar(&verifyOptions.CertDirPath, certDirFlagName, "", "`Pathname` of a directory containing TLS certificates and keys")
+	_ = verifyCmd.RegisterFlagCompletionFunc(certDirFlagName, completion.AutocompleteDefault)
+
+	credsFlagName := "creds"
+	flags.StringVar(&verifyOptions.CredentialsCLI, credsFlagName, "", "`Credentials` (USERNAME:PASSWORD) to use for authenticating to a registry")
+	_ = verifyCmd.RegisterFlagCompletionFunc(credsFlagName, completion.AutocompleteNone)
+
+	gpgKeyFlagName := "gpg-key"
+	flags.StringArrayVar(&verifyOptions.GPGKeys, gpgKeyFlagName, nil, "Require a simple signature made with a key of the GPG keyring at `PATH`")
+	_ = verifyCmd.RegisterFlagCompletionFunc(gpgKeyFlagName, completion.AutocompleteDefault)
+
+	referenceFlagName := "reference"
+	flags.StringVar(&verifyOptions.Reference, referenceFlagName, "", "Read the signatures from registry repository `NAME` instead of the one the artifact is named after")
+	_ = verifyCmd.RegisterFlagCompletionFunc(referenceFlagName, completion.AutocompleteNone)
+
+	signaturePolicyFlagName := "signature-policy"
+	flags.StringVar(&verifyOptions.SignaturePolicyPath, signaturePolicyFlagName, "", "Check the signatures against the signature policy at `PATH` instead of the default one")
+	_ = verifyCmd.RegisterFlagCompletionFunc(signaturePolicyFlagName, completion.AutocompleteDefault)
+
+	sigstoreKeyFlagName := "sigstore-key"
+	flags.StringArrayVar(&verifyOptions.SigstoreKeys, sigstoreKeyFlagName, nil, "Require a sigstore signature made with the public key at `PATH`")
+	_ = verifyCmd.RegisterFlagCompletionFunc(sigstoreKeyFlagName, completion.AutocompleteDefault)
+
+	flags.BoolVar(&verifyOptions.TLSVerifyCLI, "tls-verify", true, "Require HTTPS and verify certificates when contacting registries")
+}
+
+func verify(cmd *cobra.Command, args []string) error {
+	if verifyOptions.SignaturePolicyPath != "" && (len(verifyOptions.SigstoreKeys) > 0 || len(verifyOptions.GPGKeys) > 0) {
+		return errors.New("--signature-policy cannot be used together with --sigstore-key or --gpg-key")
+	}
+	if cmd.Flags().Changed("tls-verify") {
+		verifyOptions.InsecureSkipTLSVerify = types.NewOptionalBool(!verifyOptions.TLSVerifyCLI)
+	}
+	if cmd.Flags().Changed("authfile") {
+		if err := auth.CheckAuthFile(verifyOptions.AuthFilePath); err != nil {
+			return err
+		}
+	}
+	if verifyOptions.CredentialsCLI != "" {
+		creds, err := util.ParseRegistryCreds(verifyOptions.CredentialsCLI)
+		if err != nil {
+			return err
+		}
+		verifyOptions.Username = creds.Username
+		verifyOptions.Password = creds.Password
+	}
+
+	report, err := registry.ImageEngine().ArtifactVerify(registry.Context(), args[0], verifyOptions.ArtifactVerifyOptions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verifying %s against the %s\n", report.Reference, report.Scope)
+	if len(report.Signatures) == 0 {
+		fmt.Printf("No signatures found\n")
+	}
+	for _, sig := range report.Signatures {
+		switch {
+		case sig.KeyID != "":
+			fmt.Printf("Found %s signature by key %s\n", sig.Format, sig.KeyID)
+		case sig.Digest != "":
+			fmt.Printf("Found %s signature %s\n", sig.Format, sig.Digest)
+		default:
+			fmt.Printf("Found %s signature\n", sig.Format)
+		}
+	}
+	for _, req := range report.Requirements {
+		if req.Satisfied {
+			fmt.Printf("Requirement %s: met\n", req.Requirement)
+		} else {
+			fmt.Printf("Requirement %s: not met: %s\n", req.Requirement, req.Error)
+		}
+	}
+
+	if report.Allowed {
+		return nil
+	}
+	for _, req := range report.Requirements {
+		if !req.Satisfied {
+			return fmt.Errorf("%s: signature policy requirement %s of the %s is not met: %s", report.Reference, req.Requirement, report.Scope, req.Error)
+		}
+	}
+	return fmt.Errorf("%s is not allowed by the %s", report.Reference, report.Scope)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/containers/podman/v5/libpod/events"
 	"github.com/containers/podman/v5/pkg/domain/entities"
 	"github.com/containers/podman/v5/pkg/inspect"
+	"github.com/containers/podman/v5/pkg/libartifact"
 	"github.com/containers/podman/v5/pkg/signal"
 	systemdDefine "github.com/containers/podman/v5/pkg/systemd/define"
 	"github.com/containers/podman/v5/pkg/util"
@@ -566,6 +567,10 @@ func getBoolCompletion(_ string) ([]string, cobra.ShellCompDirective) {
 	return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
 }
 
+func getArtifactKindCompletion(_ string) ([]string, cobra.ShellCompDirective) {
+	return libartifact.Kinds(), cobra.ShellCompDirectiveNoFileComp
+}
+
 /* Autocomplete Functions for cobra ValidArgsFunction */
 
 func AutocompleteArtifacts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -1560,7 +1565,7 @@ func getMethodNames(f reflect.Value, prefix string) []formatSuggestion {
 // -> "container=", "event=", "image=", "pod=", "volume=", "type="
 func AutocompleteEventFilter(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	event := func(_ string) ([]string, cobra.ShellCompDirective) {
-		return []string{events.Attach.String(), events.AutoUpdate.String(), events.Checkpoint.String(), events.Cleanup.String(),
+		return []string{events.Add.String(), events.Attach.String(), events.AutoUpdate.String(), events.Checkpoint.String(), events.Cleanup.String(),
 			events.Commit.String(), events.Create.String(), events.Exec.String(), events.ExecDied.String(),
 			events.Exited.String(), events.Export.String(), events.Import.String(), events.Init.String(), events.Kill.String(),
 			events.LoadFromArchive.String(), events.Mount.String(), events.NetworkConnect.String(),
@@ -1574,6 +1579,7 @@ func AutocompleteEventFilter(cmd *cobra.Command, args []string, toComplete strin
 	eventTypes := func(_ string) ([]string, cobra.ShellCompDirective) {
 		return []string{events.Container.String(), events.Image.String(), events.Network.String(),
 			events.Pod.String(), events.System.String(), events.Volume.String(), events.Secret.String(),
+			events.Artifact.String(),
 		}, cobra.ShellCompDirectiveNoFileComp
 	}
 	kv := keyValueCompletion{
@@ -1601,6 +1607,13 @@ func AutocompleteTrustType(cmd *cobra.Command, args []string, toComplete string)
 	return types, cobra.ShellCompDirectiveNoFileComp
 }
 
+// AutocompleteDigestAlgorithm - Autocomplete digest algorithm options.
+// -> "sha256", "sha512"
+func AutocompleteDigestAlgorithm(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	algorithms := []string{"sha256", "sha512"}
+	return algorithms, cobra.ShellCompDirectiveNoFileComp
+}
+
 // AutocompleteImageSort - Autocomplete images sort options.
 // -> "created", "id", "repository", "size", "tag"
 func AutocompleteImageSort(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -1862,6 +1875,38 @@ func AutocompleteVolumeFilters(cmd *cobra.Command, args []string, toComplete str
 	return completeKeyValues(toComplete, kv)
 }
 
+// AutocompleteArtifactFilters - Autocomplete artifact ls --filter options.
+func AutocompleteArtifactFilters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kv := keyValueCompletion{
+		"annotation=": nil,
+		"dangling=":   getBoolCompletion,
+		"kind=":       getArtifactKindCompletion,
+		"type=":       nil,
+	}
+	return completeKeyValues(toComplete, kv)
+}
+
+// AutocompleteArtifactBlobFilters - Autocomplete artifact extract --filter options.
+func AutocompleteArtifactBlobFilters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kv := keyValueCompletion{
+		"annotation=": nil,
+	}
+	return completeKeyValues(toComplete, kv)
+}
+
+// AutocompleteArtifactPruneFilters - Autocomplete artifact prune --filter options.
+func AutocompleteArtifactPruneFilters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kv := keyValueCompletion{
+		"annotation=": nil,
+		"dangling=":   getBoolCompletion,
+		"kind=":       getArtifactKindCompletion,
+		"size=":       nil,
+		"type=":       nil,
+		"until=":      nil,
+	}
+	return completeKeyValues(toComplete, kv)
+}
+
 // AutocompleteSecretFilters - Autocomplete secret ls --filter options.
 func AutocompleteSecretFilters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	kv := keyValueCompletion{
@@ -1884,6 +1929,24 @@ func AutocompleteCompressionFormat(cmd *cobra.Command, args []string, toComplete
 	return types, cobra.ShellCompDirectiveNoFileComp
 }
 
+// AutocompleteArtifactCompressionFormat - Autocomplete compression format options for artifact push.
+func AutocompleteArtifactCompressionFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	types := []string{"gzip", "zstd", "none"}
+	return types, cobra.ShellCompDirectiveNoFileComp
+}
+
+// AutocompleteArtifactExportFormat - Autocomplete the formats of artifact export.
+func AutocompleteArtifactExportFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	formats := []string{define.OCIArchive, define.OCIManifestDir}
+	return formats, cobra.ShellCompDirectiveNoFileComp
+}
+
+// AutocompleteArtifactExtractConflict - Autocomplete the conflict policies of artifact extract.
+func AutocompleteArtifactExtractConflict(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	policies := []string{"fail", "overwrite", "skip"}
+	return policies, cobra.ShellCompDirectiveNoFileComp
+}
+
 // AutocompleteClone - Autocomplete container and image names
 func AutocompleteClone(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if !validCurrentCmdLine(cmd, args, toComplete) {
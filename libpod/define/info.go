@@ -121,6 +121,8 @@ type OCIRuntimeInfo struct {
 // StoreInfo describes the container storage and its
 // attributes
 type StoreInfo struct {
+	// ArtifactStore is nil if the artifact store could not be opened.
+	ArtifactStore   *ArtifactStoreInfo     `json:"artifactStore,omitempty"`
 	ConfigFile      string                 `json:"configFile"`
 	ContainerStore  ContainerStore         `json:"containerStore"`
 	GraphDriverName string                 `json:"graphDriverName"`
@@ -138,6 +140,15 @@ type StoreInfo struct {
 	TransientStore  bool              `json:"transientStore"`
 }
 
+// ArtifactStoreInfo describes the format version of the artifact store.
+type ArtifactStoreInfo struct {
+	// Version is the format version of the store on disk.
+	Version int `json:"version"`
+	// SupportedVersion is the newest format version podman can use, older
+	// stores are migrated to it when they are opened.
+	SupportedVersion int `json:"supportedVersion"`
+}
+
 // ImageStore describes the image store.  Right now only the number
 // of images present
 type ImageStore struct {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/containers/podman/v5/libpod/define"
 	"github.com/containers/podman/v5/libpod/events"
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 )
 
@@ -201,6 +202,18 @@ func (r *Runtime) NewSecretEvent(status events.Status, secretID string) {
 	}
 }
 
+// NewArtifactEvent creates a new event for an OCI artifact.  The ID of the
+// event is the encoded digest of the artifact manifest.
+func (r *Runtime) NewArtifactEvent(status events.Status, name string, artifactDigest digest.Digest) {
+	e := events.NewEvent(status)
+	e.ID = artifactDigest.Encoded()
+	e.Name = name
+	e.Type = events.Artifact
+	if err := r.eventer.Write(e); err != nil {
+		logrus.Errorf("Unable to write artifact event: %q", err)
+	}
+}
+
 // Events is a wrapper function for everyone to begin tailing the events log
 // with options
 func (r *Runtime) Events(ctx context.Context, options events.ReadOptions) error {
@@ -133,7 +133,11 @@ const (
 	Machine Type = "machine"
 	// Secret - event is related to secrets
 	Secret Type = "secret"
+	// Artifact - event is related to OCI artifacts
+	Artifact Type = "artifact"
 
+	// Add ...
+	Add Status = "add"
 	// Attach ...
 	Attach Status = "attach"
 	// AutoUpdate ...
@@ -94,6 +94,8 @@ func (e *Event) ToHumanReadable(truncate bool) string {
 		humanFormat = fmt.Sprintf("%s %s %s %s", e.Time, e.Type, e.Status, e.Name)
 	case Secret:
 		humanFormat = fmt.Sprintf("%s %s %s %s", e.Time, e.Type, e.Status, id)
+	case Artifact:
+		humanFormat = fmt.Sprintf("%s %s %s %s %s", e.Time, e.Type, e.Status, id, e.Name)
 	}
 	return humanFormat
 }
@@ -127,6 +129,8 @@ func StringToType(name string) (Type, error) {
 		return Volume, nil
 	case Secret.String():
 		return Secret, nil
+	case Artifact.String():
+		return Artifact, nil
 	case "":
 		return "", ErrEventTypeBlank
 	}
@@ -136,6 +140,8 @@ func StringToType(name string) (Type, error) {
 // StringToStatus converts a string to an Event Status
 func StringToStatus(name string) (Status, error) {
 	switch name {
+	case Add.String():
+		return Add, nil
 	case Attach.String():
 		return Attach, nil
 	case AutoUpdate.String():
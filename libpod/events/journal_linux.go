@@ -77,6 +77,9 @@ func (e EventJournalD) Write(ee Event) error {
 		m["PODMAN_NETWORK_NAME"] = ee.Network
 	case Volume:
 		m["PODMAN_NAME"] = ee.Name
+	case Artifact:
+		m["PODMAN_NAME"] = ee.Name
+		m["PODMAN_ID"] = ee.ID
 	}
 
 	// starting with commit 7e6e267329 we set LogLevel=notice for the systemd healthcheck unit
@@ -256,6 +259,8 @@ func newEventFromJournalEntry(entry *sdjournal.JournalEntry) (*Event, error) {
 		if val, ok := entry.Fields["ERROR"]; ok {
 			newEvent.Error = val
 		}
+	case Artifact:
+		newEvent.ID = entry.Fields["PODMAN_ID"]
 	}
 	return &newEvent, nil
 }
@@ -287,9 +287,29 @@ func (r *Runtime) storeInfo() (*define.StoreInfo, error) {
 		status[pair[0]] = pair[1]
 	}
 	info.GraphStatus = status
+	info.ArtifactStore = r.artifactStoreInfo()
 	return &info, nil
 }
 
+// artifactStoreInfo returns the format version of the artifact store, nil if
+// it cannot be opened, e.g. because it was written by a newer podman.
+func (r *Runtime) artifactStoreInfo() *define.ArtifactStoreInfo {
+	if r.ArtifactStore == nil {
+		return nil
+	}
+	artifactStore, err := r.ArtifactStore()
+	if err != nil {
+		logrus.Warnf("Failed to open the artifact store: %v", err)
+		return nil
+	}
+	version, err := artifactStore.Version()
+	if err != nil {
+		logrus.Warnf("Failed to read the artifact store version: %v", err)
+		return nil
+	}
+	return &define.ArtifactStoreInfo{Version: version.OnDisk, SupportedVersion: version.Current}
+}
+
 // GetHostDistributionInfo returns a map containing the host's distribution and version
 func (r *Runtime) GetHostDistributionInfo() define.DistributionInfo {
 	// Populate values in case we cannot find the values
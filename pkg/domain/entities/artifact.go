@@ -2,18 +2,112 @@ package entities
 
 import (
 	"io"
+	"time"
 
 	"github.com/containers/image/v5/types"
 	encconfig "github.com/containers/ocicrypt/config"
 	"github.com/containers/podman/v5/pkg/libartifact"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
 	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 type ArtifactAddOptions struct {
-	Annotations  map[string]string
+	// Annotations are set on each added blob.
+	Annotations map[string]string
+	// ManifestAnnotations are set on the manifest of the artifact.
+	ManifestAnnotations map[string]string
+	// IndexAnnotations are set on the descriptor of the artifact in the
+	// index of the store and in an index the artifact is pushed in.
+	IndexAnnotations map[string]string
+	// ArtifactType must be empty or the type of the existing artifact
+	// when appending.
 	ArtifactType string
 	Append       bool
-	FileType     string
+	// FileType is the media type of all blobs added, the blobs of an
+	// artifact appended to keep theirs.
+	FileType string
+	// StdinName is the blob name used for the content read from Stdin
+	// when "-" is given as a path.  Required when reading from Stdin.
+	StdinName string
+	// Stdin is the stream read for the "-" path.  Defaults to os.Stdin.
+	Stdin io.Reader
+	// AllowDuplicate stores a blob again when appending even if the
+	// artifact already has a blob with the same digest.
+	AllowDuplicate bool
+	// AllowDuplicateTitles adds blobs even if another blob of the artifact
+	// has the same title.
+	AllowDuplicateTitles bool
+	// Recursive adds every file below a directory path as a blob, named
+	// by its path relative to the directory.
+	Recursive bool
+	// Exclude are glob patterns of files and directories skipped when
+	// adding a directory, matched against the relative path and the name.
+	Exclude []string
+	// FollowSymlinks adds the targets of symlinks found when adding a
+	// directory instead of skipping them.
+	FollowSymlinks bool
+	// Subject is the digest of the manifest of an image or artifact in
+	// local storage the new artifact refers to, e.g. as its signature or
+	// SBOM.  Not compatible with Append.
+	Subject string
+	// StrictType rejects blobs whose media type is not consistent with
+	// ArtifactType, e.g. an application/octet-stream blob in a Helm chart.
+	StrictType bool
+	// ConfigFile is the path of a file used as the config blob of the
+	// artifact, whose media type ConfigType must be given.  ConfigType
+	// alone sets the media type of the empty config.  Not compatible with
+	// Append.
+	ConfigFile string
+	ConfigType string
+	// RecordMode records the mode and ownership of each added file in the
+	// annotations of its blob.
+	RecordMode bool
+	// AutoAnnotate records the time, the hostname and the Podman version
+	// of the add in the annotations of each blob, unless Annotations sets
+	// the same keys.
+	AutoAnnotate bool
+	// Replace replaces an existing artifact of the same name instead of
+	// failing. Conflicts with Append.
+	Replace bool
+	// Description is a one-line human-readable description of the
+	// artifact, stored as the org.opencontainers.image.description
+	// annotation of the manifest.  It takes precedence over the same key
+	// in ManifestAnnotations.
+	Description string
+	// FromArtifact is an artifact in the local store whose blobs are
+	// added instead of files.  The paths are the titles or digests of
+	// the blobs, which are shared by both artifacts rather than copied.
+	FromArtifact string
+}
+
+// ArtifactCopyOptions are the options for copying an artifact from one
+// registry to another. The same TLS settings apply to both. Username and
+// Password are used for both registries unless the source or destination
+// credentials are set; a registry without explicit credentials uses the
+// ones of its host in the auth file or its credential helper.
+type ArtifactCopyOptions struct {
+	// Architecture, OS and Variant select the manifest to copy from a
+	// multi-arch index. Empty values default to the host.
+	Architecture          string
+	AuthFilePath          string
+	CertDirPath           string
+	DestinationPassword   string
+	DestinationUsername   string
+	InsecureSkipTLSVerify types.OptionalBool
+	// MaxRetries is the number of times each blob is retried.
+	MaxRetries          *uint
+	OS                  string
+	Password            string
+	Quiet               bool
+	RetryDelay          string
+	SignaturePolicyPath string
+	SourcePassword      string
+	SourceUsername      string
+	Titles              []string
+	Username            string
+	Variant             string
+	Writer              io.Writer
 }
 
 type ArtifactExtractOptions struct {
@@ -23,62 +117,571 @@ type ArtifactExtractOptions struct {
 	// Digest of the blob to extract.
 	// Conflicts with Title. Optional.
 	Digest string
+	// Index of the blob to extract in manifest layer order.
+	// Conflicts with Title and Digest. Optional.
+	Index *int
+	// ExtractAll extracts all blobs into the target directory.
+	// Conflicts with Title, Digest and Index. Optional.
+	ExtractAll bool
+	// Filters select all blobs whose annotations match. The only supported
+	// key is "annotation". Conflicts with Index. Optional.
+	Filters map[string][]string
+	// Overwrite allows blobs with the same name to overwrite each other
+	// instead of failing. Optional.
+	Overwrite bool
+	// Verify fails the extraction of a blob whose content does not match
+	// the digest of the manifest. Optional.
+	Verify bool
+	// Decompress decompresses gzip and zstd compressed blobs, as declared
+	// by their media type, while extracting them. Optional.
+	Decompress bool
+	// PreserveMode restores the permission bits recorded in the
+	// annotations of each blob. Optional.
+	PreserveMode bool
+	// PreserveOwner restores the ownership recorded in the annotations of
+	// each blob, where permitted. Optional.
+	PreserveOwner bool
+	// Writer receives the content of the single selected blob instead of
+	// the target path, which must be empty. Conflicts with ExtractAll.
+	// Optional.
+	Writer io.Writer
+	// TarOutput receives all selected blobs as a tar stream, named by
+	// their titles, instead of the target path, which must be empty.
+	// Conflicts with Writer and Decompress. Optional.
+	TarOutput io.Writer
+	// PathPrefix is a leading directory removed from the names of the
+	// blobs extracted to a directory or a tar stream. Optional.
+	PathPrefix string
+	// StripComponents is the number of leading path elements removed from
+	// the names of the blobs extracted to a directory or a tar stream,
+	// after PathPrefix. Optional.
+	StripComponents int
+	// OnConflict is what happens to a file which exists already where a
+	// blob is extracted to: "skip", "overwrite" or "fail", the default.
+	// Optional.
+	OnConflict string
+}
+
+// ArtifactExtractReport describes the outcome of an artifact extract.
+type ArtifactExtractReport struct {
+	// SkippedFiles are the existing files which were left unchanged as
+	// OnConflict is "skip".
+	SkippedFiles []string
 }
 
 type ArtifactInspectOptions struct {
+	// AuthFilePath, CertDirPath, InsecureSkipTLSVerify and the
+	// credentials are used to access the registry with Remote.
+	AuthFilePath          string
+	CertDirPath           string
+	InsecureSkipTLSVerify types.OptionalBool
+	Username              string
+	Password              string
+	// Latest inspects the artifact most recently stored in the local
+	// store instead of the given names.
+	Latest bool
+	// Remote inspects the artifacts in their registries, only their
+	// manifests are fetched and the local store is not used.  Conflicts
+	// with Latest and Verify.
 	Remote bool
+	// Verify re-hashes every blob in the local store and fails if any
+	// does not match the digest of the manifest.
+	Verify bool
+	// DigestAlgorithm is either "sha256" or "sha512". When it differs
+	// from the algorithm used by the manifest, the digests are computed
+	// with both algorithms.
+	DigestAlgorithm string
 }
 
 type ArtifactListOptions struct {
-	ImagePushOptions
+	// Filters to apply to the artifacts in the store. Supported keys are
+	// "annotation", "dangling" and "type". Unknown keys are an error.
+	Filters map[string][]string
+	// NoTrunc prints the full digests of the artifacts instead of the
+	// short form used by the default table output.
+	NoTrunc bool
+	// Quiet only returns the artifacts without computing the
+	// additional report fields such as sizes.
+	Quiet bool
+	// Subject only lists the artifacts whose manifest refers to this image
+	// or artifact as its subject.  It is a manifest digest, a reference
+	// with a digest or the name of an image or artifact in local storage.
+	Subject string
+	// Stream sends each report to ReportChan as soon as the artifact is
+	// read from the store instead of returning all of them.  The channel is
+	// closed when the store is read completely or an error occurred.
+	Stream     bool
+	ReportChan chan ArtifactListStreamReport
 }
 
 type ArtifactPullOptions struct {
-	Architecture          string
-	AuthFilePath          string
-	CertDirPath           string
+	// Anonymous stores the artifact without its name, it is found by its
+	// digest only and removed by prune.
+	Anonymous    bool
+	Architecture string
+	// AuthFilePath is the authentication file to read the credentials
+	// of the registry from. Empty uses REGISTRY_AUTH_FILE if set or the
+	// default locations otherwise.
+	AuthFilePath string
+	CertDirPath  string
+	// Digests and Titles select the blobs to pull, the other blobs are
+	// only fetched when the artifact is extracted or mounted.  All blobs
+	// are pulled when both are empty.
+	Digests []string
+	// ExtractTo is a directory all blobs of the pulled artifact are
+	// extracted to, named by their title annotations.
+	ExtractTo             string
 	InsecureSkipTLSVerify types.OptionalBool
-	MaxRetries            *uint
-	OciDecryptConfig      *encconfig.DecryptConfig
-	Password              string
-	Quiet                 bool
-	RetryDelay            string
-	SignaturePolicyPath   string
-	Username              string
-	Writer                io.Writer
+	// LogPolicy writes the signature policy requirements the artifact
+	// is checked against to stderr.
+	LogPolicy bool
+	// MaxParallelDownloads is the maximum number of blobs downloaded at
+	// the same time. Zero uses the default of 3.
+	MaxParallelDownloads uint
+	MaxRetries           *uint
+	// MaxSizeBytes is the largest total size of the blobs the pull
+	// downloads. Zero means no limit.
+	MaxSizeBytes int64
+	// NoStore does not keep the artifact in the local store, it is only
+	// extracted to ExtractTo.
+	NoStore          bool
+	OciDecryptConfig *encconfig.DecryptConfig
+	// OS and Variant, together with Architecture, select the manifest to
+	// pull from a multi-arch index. Empty values default to the host.
+	OS       string
+	Password string
+	// RateLimitBytesPerSec is the maximum number of bytes per second
+	// all blobs are downloaded with together. Zero means no limit.
+	RateLimitBytesPerSec int64
+	// RequireDigest refuses to pull a name without a manifest digest,
+	// e.g. quay.io/artifact@sha256:... or quay.io/artifact:v1@sha256:...
+	RequireDigest bool
+	// ProgressChan, if set, receives progress events with the bytes
+	// downloaded per blob.  It is closed once the pull completes or
+	// fails.  Callers must drain it as the pull blocks on sending.
+	ProgressChan chan types.ProgressProperties
+	Quiet        bool
+	// Resume continues the download of blobs an interrupted pull left
+	// behind in the store instead of downloading them again.
+	Resume              bool
+	RetryDelay          string
+	SignaturePolicyPath string
+	// Timeout, if not zero, is the time the whole pull, including its
+	// retries, may take.  The blobs it stored are removed when it expires.
+	Timeout  time.Duration
+	Titles   []string
+	Username string
+	Variant  string
+	Writer   io.Writer
 }
 
 type ArtifactPushOptions struct {
 	ImagePushOptions
-	CredentialsCLI             string
-	DigestFile                 string
-	EncryptLayers              []int
-	EncryptionKeys             []string
+	// AdditionalTags are tags of the destination repository the artifact
+	// is pushed to as well, without uploading its blobs again.
+	AdditionalTags []string
+	// AuthFilePath is the authentication file, the same as the one of
+	// ArtifactPullOptions. It takes precedence over Authfile of
+	// ImagePushOptions.
+	AuthFilePath   string
+	CredentialsCLI string
+	// Destination is where the artifact is pushed to, the name of the
+	// artifact by default.  It is a registry reference unless prefixed
+	// with the oci, oci-archive or dir transport, e.g.
+	// oci-archive:/tmp/artifact.tar.
+	Destination string
+	DigestFile  string
+	// DryRun only reports which blobs would be uploaded and which exist in
+	// the destination repository already, nothing is transferred.
+	DryRun         bool
+	EncryptLayers  []int
+	EncryptionKeys []string
+	// ForceCompression recompresses the blobs which are compressed
+	// already with CompressionFormat instead of pushing them unchanged.
+	ForceCompression bool
+	// MaxParallelUploads is the maximum number of blobs uploaded at the same
+	// time. Zero uses the default of 3.
+	MaxParallelUploads uint
+	// MaxRetries is the number of times a failed push is retried and
+	// RetryDelay the delay before each retry, a duration string like the
+	// one of ArtifactPullOptions.  They take precedence over Retry and
+	// RetryDelay of ImagePushOptions.
+	MaxRetries *uint
+	// MountFrom are repositories on the registry of the destination the
+	// blobs missing in the destination are mounted from instead of being
+	// uploaded, if the registry allows it.
+	MountFrom []string
+	// RateLimitBytesPerSec is the maximum number of bytes per second all
+	// blobs are uploaded with together. Zero means no limit.
+	RateLimitBytesPerSec int64
+	// RetryBackoff doubles the delay after each failed attempt instead of
+	// waiting RetryDelay before every retry.
+	RetryBackoff               bool
+	RetryDelay                 string
 	SignBySigstoreParamFileCLI string
 	SignPassphraseFileCLI      string
-	TLSVerifyCLI               bool // CLI only
+	// Timeout, if not zero, is the time the whole push, including its
+	// retries, may take.
+	Timeout time.Duration
+}
+
+// ArtifactIndexOptions are the options for pushing local artifacts together
+// with an OCI index referring to each of them for its platform.
+type ArtifactIndexOptions struct {
+	ArtifactPushOptions
+	// Artifacts are the local artifacts in the index, each for another
+	// platform.
+	Artifacts []ArtifactIndexArtifact
+}
+
+// ArtifactIndexArtifact is a local artifact referenced by an index.
+type ArtifactIndexArtifact struct {
+	// Artifact is the name of the local artifact.
+	Artifact string
+	// Platform is the platform of the artifact as os/arch[/variant].
+	Platform string
 }
 
 type ArtifactRemoveOptions struct {
 	// Remove all artifacts
 	All bool
+	// DryRun reports the digests of the artifacts which would be removed
+	// without removing them.
+	DryRun bool
+	// Ignore skips names, digests and patterns which match no artifact
+	// instead of failing.  The report only lists the removed artifacts.
+	Ignore bool
+	// Latest removes the artifact most recently stored in the local
+	// store instead of the given names.
+	Latest bool
 }
 
-type ArtifactPullReport struct{}
+type ArtifactCheckOptions struct {
+	AuthFilePath          string
+	CertDirPath           string
+	InsecureSkipTLSVerify types.OptionalBool
+	// Repair fetches the corrupt and missing blobs again from the
+	// registries the artifacts using them are named after.
+	Repair bool
+}
+
+type ArtifactVerifyOptions struct {
+	AuthFilePath          string
+	CertDirPath           string
+	InsecureSkipTLSVerify types.OptionalBool
+	Username              string
+	Password              string
+	// SignaturePolicyPath is the policy the signatures are checked
+	// against instead of the default one.
+	SignaturePolicyPath string
+	// Reference, SigstoreKeys and GPGKeys are passed to the store, see
+	// libartTypes.SignatureVerifyOptions.
+	Reference    string
+	SigstoreKeys []string
+	GPGKeys      []string
+}
+
+type ArtifactDiffOptions struct {
+	AuthFilePath          string
+	CertDirPath           string
+	InsecureSkipTLSVerify types.OptionalBool
+	// Remote compares the first artifact from the local store against
+	// the second one fetched from its registry instead of the local store.
+	Remote bool
+}
+
+type ArtifactExportOptions struct {
+	// Format of the export, "oci-archive" (the default) for a tar archive
+	// of an OCI image layout or "oci-dir" for the layout directory.
+	Format string
+}
+
+type ArtifactImportOptions struct {
+	// Digest selects the manifest to import if the layout contains more
+	// than one.
+	Digest string
+}
+
+type ArtifactTagOptions struct {
+	// Force replaces another artifact which already has the new name.
+	Force bool
+	// RemoveOld removes the old name, so the artifact is renamed.
+	RemoveOld bool
+}
 
-type ArtifactPushReport struct{}
+type ArtifactUpdateOptions struct {
+	// SetAnnotations are added to the annotations, replacing existing
+	// values of the same keys.
+	SetAnnotations map[string]string
+	// RemoveAnnotations are the keys of annotations to remove.
+	RemoveAnnotations []string
+	// Title, Digest and Index select the blob whose annotations are
+	// changed, like for extract. Without them the annotations of the
+	// manifest are changed.
+	Title  string
+	Digest string
+	Index  *int
+}
+
+// ArtifactMountOptions is meant for future growth of artifact mount.
+type ArtifactMountOptions struct{}
+
+// ArtifactUnmountOptions is meant for future growth of artifact unmount.
+type ArtifactUnmountOptions struct{}
+
+type ArtifactMountReport struct {
+	Name string
+	// Path of the read-only directory holding the blobs of the artifact,
+	// each named after its title annotation.
+	Path string
+}
+
+type ArtifactUnmountReport struct {
+	Name string
+	Path string
+}
+
+// ArtifactGCOptions are the options for removing the blobs of the local store
+// which no artifact references.
+type ArtifactGCOptions struct {
+	// DryRun only reports the blobs which would be removed.
+	DryRun bool
+}
+
+type ArtifactPruneOptions struct {
+	// All prunes all artifacts not used by a container, not only the
+	// dangling ones.
+	All bool
+	// Filter limits the pruned artifacts to the ones matching all
+	// filters. Supported keys are "annotation", "dangling", "size",
+	// "type" and "until".
+	Filter map[string][]string
+	// DryRun only reports what would be pruned.
+	DryRun bool
+	// IncludeUndated lets the "until" filter match artifacts without a
+	// creation time annotation by the time they were stored locally.
+	IncludeUndated bool
+}
+
+type ArtifactPruneReport struct {
+	ArtifactDigests []*digest.Digest
+	// ReclaimedSize is the number of bytes freed by removing the blobs and
+	// manifests not shared with any remaining artifact.
+	ReclaimedSize int64
+}
+
+// ArtifactGCReport lists the removed blobs.
+type ArtifactGCReport struct {
+	// Blobs are the removed blobs, sorted by digest.
+	Blobs []libartTypes.StoredBlob
+	// ReclaimedSize is the number of bytes freed by removing the blobs.
+	ReclaimedSize int64
+}
+
+type ArtifactPullReport struct {
+	// Reference is the fully-qualified reference the artifact was pulled
+	// from. A short name is resolved using registries.conf.
+	Reference string
+	// ArtifactDigest is the digest of the pulled manifest.
+	ArtifactDigest *digest.Digest
+	// Platform of the manifest selected from a multi-arch index, nil
+	// if the artifact is a single manifest.
+	Platform *specV1.Platform
+	// BlobsFetched is the number of blobs downloaded, including the
+	// config. Blobs which are already in the local store are not fetched.
+	BlobsFetched int
+	// BlobsSkipped is the number of blobs which were already in the local
+	// store and not downloaded again, and BytesSkipped their size.
+	BlobsSkipped int
+	BytesSkipped int64
+	// BytesTransferred is the total size of the downloaded blobs.
+	BytesTransferred int64
+	// Mirror is the location of the registries.conf mirror which served
+	// the artifact, empty if it was pulled from the registry itself.
+	Mirror string `json:",omitempty"`
+	// ExtractedFiles are the paths of the files written to the directory
+	// of ExtractTo.
+	ExtractedFiles []string
+	// PinnedDigest is the manifest digest of the pulled name, which the
+	// registry was verified to serve. Empty if the name has no digest.
+	PinnedDigest digest.Digest `json:",omitempty"`
+	// RateLimitWait is the time spent waiting on a registry which rate
+	// limited the pull, including the waits for its Retry-After.
+	RateLimitWait time.Duration `json:",omitempty"`
+}
+
+type ArtifactPushReport struct {
+	// ArtifactDigest is the digest of the manifest written to the registry.
+	ArtifactDigest *digest.Digest
+	// Tags are the references the artifact was pushed to, the destination
+	// first. On error, only the references written before the error.
+	Tags []string
+	// Retries is the number of times the push was retried after a failure.
+	Retries int
+	// MountedBlobs and UploadedBlobs are the numbers of blobs mounted from
+	// the repositories of MountFrom and uploaded by the push.
+	MountedBlobs  int
+	UploadedBlobs int
+	// RecompressedBlobs is the number of compressed blobs recompressed
+	// due to ForceCompression.
+	RecompressedBlobs int
+	// UploadBlobs are the blobs a dry run would upload and SkippedBlobs
+	// the ones which exist in the destination repository already.
+	UploadBlobs  []libartTypes.PushBlob `json:",omitempty"`
+	SkippedBlobs []libartTypes.PushBlob `json:",omitempty"`
+}
+
+// ArtifactIndexReport describes an index pushed with its artifacts.
+type ArtifactIndexReport struct {
+	// IndexDigest is the digest of the index written to the registry.
+	IndexDigest digest.Digest
+	// Manifests are the descriptors of the artifacts in the index.
+	Manifests []specV1.Descriptor
+	// Tags are the references the index was written to, the destination
+	// first. On error, only the references written before the error.
+	Tags []string
+	// Retries is the number of times a push was retried after a failure.
+	Retries int
+}
 
 type ArtifactInspectReport struct {
 	*libartifact.Artifact
-	Digest string
+	// Manifest is the parsed OCI manifest of the artifact, including the
+	// config descriptor and the annotations of every descriptor.  It
+	// takes precedence over the manifest of the embedded Artifact.
+	Manifest *specV1.Manifest
+	Digest   string
+	// Config is the config descriptor of the artifact, the empty JSON
+	// object unless a custom config was added.
+	Config specV1.Descriptor
+	// Description is the org.opencontainers.image.description annotation
+	// of the manifest, empty if it has none.
+	Description string `json:",omitempty"`
+	// AlternateDigest is the manifest digest computed with the requested
+	// DigestAlgorithm when it differs from the one of Digest.
+	AlternateDigest string `json:",omitempty"`
+	// Fingerprint identifies the content of the artifact independently of
+	// the encoding of its manifest and its creation time, see
+	// libartifact.Artifact.Fingerprint.
+	Fingerprint digest.Digest
+	// BlobCount is the number of blobs of the artifact and MediaTypes the
+	// sorted distinct media types of its blobs.
+	BlobCount  int
+	MediaTypes []string
+	// Blobs are the verified blobs, only set when Verify was requested.
+	Blobs []libartTypes.BlobDigest `json:",omitempty"`
+	// Partial is set when the artifact was pulled partially and some of
+	// its blobs, listed in MissingBlobs, are not in the local store yet.
+	Partial      bool            `json:",omitempty"`
+	MissingBlobs []digest.Digest `json:",omitempty"`
+	// Remote is set when the report was built from the manifest in the
+	// registry, none of the blobs were downloaded and the local store was
+	// not looked at.
+	Remote bool `json:",omitempty"`
+}
+
+// ArtifactListStreamReport is sent for each listed artifact by a streaming
+// artifact list.
+type ArtifactListStreamReport struct {
+	// Error from reading the store.  It is the last report sent.
+	Error error
+	// Report of the artifact, set when there is no error.
+	Report *ArtifactListReport
 }
 
 type ArtifactListReport struct {
 	*libartifact.Artifact
+	// Digest is the digest of the manifest as recorded in the local
+	// store, and DigestAlgorithm the algorithm it was computed with.
+	Digest          digest.Digest
+	DigestAlgorithm digest.Algorithm
+	// ArtifactType is the artifactType of the manifest, empty if it has
+	// none.
+	ArtifactType string
+	// Kind classifies the artifact by its artifact type or config media
+	// type, e.g. helm-chart, sbom or model, see libartifact.Artifact.Kind.
+	Kind string
+	// Description is the org.opencontainers.image.description annotation
+	// of the manifest, empty if it has none.
+	Description string
+	// TotalSize is the sum of the sizes of all blobs in bytes, as
+	// declared by the manifest descriptors.
+	TotalSize int64
+	// LayerSizes are the sizes of the blobs in bytes, in manifest order.
+	LayerSizes []int64
+	// Created is the time recorded in the org.opencontainers.image.created
+	// annotation of the manifest, or if the artifact has none, the time
+	// it was stored locally.
+	Created time.Time
 }
 
 type ArtifactAddReport struct {
 	ArtifactDigest *digest.Digest
+	// ReplacedDigest is the digest of the artifact replaced by the new
+	// one, nil if no artifact was replaced.
+	ReplacedDigest *digest.Digest
+	// Blobs tells for every given path whether its blob was stored or
+	// deduplicated against a blob already in the artifact.
+	Blobs []libartTypes.AddedBlob
+	// EmptyDirectories are the directories without any entries found
+	// when adding recursively.
+	EmptyDirectories []string
+	// SkippedSymlinks are the symlinks found when adding recursively
+	// without following symlinks.
+	SkippedSymlinks []string
+}
+
+type ArtifactCopyReport struct {
+	// ArtifactDigest is the digest of the manifest written to the
+	// destination.
+	ArtifactDigest *digest.Digest
+	// Platform of the manifest selected from a multi-arch index, nil
+	// if the source is a single manifest.
+	Platform *specV1.Platform
+}
+
+type ArtifactExportReport struct {
+	ArtifactDigest *digest.Digest
+}
+
+type ArtifactImportReport struct {
+	ArtifactDigest *digest.Digest
+}
+
+type ArtifactCheckReport struct {
+	// Healthy, Corrupt and Missing are the numbers of blobs in each state
+	// before the repair, each blob counted once.
+	Healthy int
+	Corrupt int
+	Missing int
+	// Repaired is the number of blobs fetched again.
+	Repaired int
+	// Damaged are the corrupt and missing blobs.
+	Damaged []libartTypes.CheckedBlob
+}
+
+type ArtifactVerifyReport struct {
+	libartTypes.SignatureVerifyResult
+}
+
+type ArtifactDiffReport struct {
+	libartTypes.DiffResult
+}
+
+type ArtifactTagReport struct {
+	// ArtifactDigest is the digest of the manifest both names refer to.
+	ArtifactDigest *digest.Digest
+	// OldName is the name of the tagged artifact, empty if it has none.
+	OldName string
+	// NewName is the added name.
+	NewName string
+}
+
+type ArtifactUpdateReport struct {
+	// OldDigest is the digest of the manifest before the update.
+	OldDigest *digest.Digest
+	// NewDigest is the digest of the updated manifest.
+	NewDigest *digest.Digest
 }
 
 type ArtifactRemoveReport struct {
@@ -10,12 +10,25 @@ import (
 
 type ImageEngine interface { //nolint:interfacebloat
 	ArtifactAdd(ctx context.Context, name string, paths []string, opts *ArtifactAddOptions) (*ArtifactAddReport, error)
-	ArtifactExtract(ctx context.Context, name string, target string, opts *ArtifactExtractOptions) error
-	ArtifactInspect(ctx context.Context, name string, opts ArtifactInspectOptions) (*ArtifactInspectReport, error)
+	ArtifactCheck(ctx context.Context, opts ArtifactCheckOptions) (*ArtifactCheckReport, error)
+	ArtifactCopy(ctx context.Context, source string, destination string, opts ArtifactCopyOptions) (*ArtifactCopyReport, error)
+	ArtifactDiff(ctx context.Context, first string, second string, opts ArtifactDiffOptions) (*ArtifactDiffReport, error)
+	ArtifactExtract(ctx context.Context, name string, target string, opts *ArtifactExtractOptions) (*ArtifactExtractReport, error)
+	ArtifactExport(ctx context.Context, name string, path string, opts ArtifactExportOptions) (*ArtifactExportReport, error)
+	ArtifactGC(ctx context.Context, opts ArtifactGCOptions) (*ArtifactGCReport, error)
+	ArtifactImport(ctx context.Context, path string, name string, opts ArtifactImportOptions) (*ArtifactImportReport, error)
+	ArtifactInspect(ctx context.Context, namesOrDigests []string, opts ArtifactInspectOptions) ([]*ArtifactInspectReport, []error, error)
 	ArtifactList(ctx context.Context, opts ArtifactListOptions) ([]*ArtifactListReport, error)
+	ArtifactMount(ctx context.Context, name string, opts ArtifactMountOptions) (*ArtifactMountReport, error)
+	ArtifactPrune(ctx context.Context, opts ArtifactPruneOptions) (*ArtifactPruneReport, error)
 	ArtifactPull(ctx context.Context, name string, opts ArtifactPullOptions) (*ArtifactPullReport, error)
 	ArtifactPush(ctx context.Context, name string, opts ArtifactPushOptions) (*ArtifactPushReport, error)
-	ArtifactRm(ctx context.Context, name string, opts ArtifactRemoveOptions) (*ArtifactRemoveReport, error)
+	ArtifactPushIndex(ctx context.Context, name string, opts ArtifactIndexOptions) (*ArtifactIndexReport, error)
+	ArtifactRm(ctx context.Context, namesOrDigests []string, opts ArtifactRemoveOptions) (*ArtifactRemoveReport, error)
+	ArtifactTag(ctx context.Context, name string, newName string, opts ArtifactTagOptions) (*ArtifactTagReport, error)
+	ArtifactUnmount(ctx context.Context, name string, opts ArtifactUnmountOptions) (*ArtifactUnmountReport, error)
+	ArtifactUpdate(ctx context.Context, name string, opts ArtifactUpdateOptions) (*ArtifactUpdateReport, error)
+	ArtifactVerify(ctx context.Context, name string, opts ArtifactVerifyOptions) (*ArtifactVerifyReport, error)
 	Build(ctx context.Context, containerFiles []string, opts BuildOptions) (*BuildReport, error)
 	Config(ctx context.Context) (*config.Config, error)
 	Exists(ctx context.Context, nameOrID string) (*BoolReport, error)
@@ -0,0 +1,220 @@
+//go:build !remote
+
+package filters
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/containers/common/pkg/filters"
+	"github.com/containers/podman/v5/pkg/libartifact"
+	"github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/docker/go-units"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// SupportedArtifactFilters lists the filter keys accepted by GenerateArtifactFilters.
+var SupportedArtifactFilters = []string{"annotation", "dangling", "kind", "type"}
+
+// SupportedArtifactBlobFilters lists the filter keys accepted by GenerateArtifactBlobFilters.
+var SupportedArtifactBlobFilters = []string{"annotation"}
+
+// SupportedArtifactPruneFilters lists the filter keys accepted by GenerateArtifactPruneFilters.
+var SupportedArtifactPruneFilters = []string{"annotation", "dangling", "kind", "size", "type", "until"}
+
+// GenerateArtifactPruneFilters returns the filter function for prune, which
+// supports the "size" and "until" filters on top of the ones of
+// GenerateArtifactFilters.  Artifacts without a creation time only match
+// "until" if includeUndated is set, their time in the local store is used
+// instead.
+func GenerateArtifactPruneFilters(filter string, filterValues []string, includeUndated bool) (libartifact.ArtifactFilter, error) {
+	switch filter {
+	case "until":
+		until, err := filters.ComputeUntilTimestamp(filterValues)
+		if err != nil {
+			return nil, err
+		}
+		return func(a *libartifact.Artifact) bool {
+			created, ok := a.CreatedTime()
+			if !ok {
+				if !includeUndated || a.StoredTime().IsZero() {
+					return false
+				}
+				created = a.StoredTime()
+			}
+			return created.Before(until)
+		}, nil
+	case "size":
+		matchers := make([]func(int64) bool, 0, len(filterValues))
+		for _, val := range filterValues {
+			matcher, err := parseSizeFilter(val)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, matcher)
+		}
+		return func(a *libartifact.Artifact) bool {
+			// All size filters must match, so they can select a range.
+			size := a.TotalSizeBytes()
+			for _, matcher := range matchers {
+				if !matcher(size) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "annotation", "dangling", "kind", "type":
+		return GenerateArtifactFilters(filter, filterValues)
+	}
+	return nil, fmt.Errorf("%q is an invalid artifact prune filter, supported filters are: %s", filter, strings.Join(SupportedArtifactPruneFilters, ", "))
+}
+
+func GenerateArtifactFilters(filter string, filterValues []string) (libartifact.ArtifactFilter, error) {
+	switch filter {
+	case "type":
+		return func(a *libartifact.Artifact) bool {
+			for _, val := range filterValues {
+				if a.Manifest.ArtifactType == val {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "kind":
+		return func(a *libartifact.Artifact) bool {
+			return slices.Contains(filterValues, a.Kind())
+		}, nil
+	case "annotation":
+		if err := checkAnnotationFilters(filterValues); err != nil {
+			return nil, err
+		}
+		return func(a *libartifact.Artifact) bool {
+			// All annotation filters must match, either on the manifest
+			// or on any of the blobs of the artifact.
+			for _, val := range filterValues {
+				if !matchArtifactAnnotation(a, val) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "dangling":
+		for _, val := range filterValues {
+			switch strings.ToLower(val) {
+			case "true", "1", "false", "0":
+			default:
+				return nil, fmt.Errorf("%q is not a valid value for the \"dangling\" filter - must be true or false", val)
+			}
+		}
+		return func(a *libartifact.Artifact) bool {
+			for _, val := range filterValues {
+				dangling := a.Name == ""
+				switch strings.ToLower(val) {
+				case "false", "0":
+					dangling = !dangling
+				}
+				if dangling {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, fmt.Errorf("%q is an invalid artifact filter, supported filters are: %s", filter, strings.Join(SupportedArtifactFilters, ", "))
+}
+
+// GenerateArtifactBlobFilters returns the filter function selecting the blobs
+// of an artifact, e.g. for extract.  Unlike the "annotation" artifact filter,
+// only the annotations of the blob itself are matched.
+func GenerateArtifactBlobFilters(filter string, filterValues []string) (types.BlobFilter, error) {
+	switch filter {
+	case "annotation":
+		if err := checkAnnotationFilters(filterValues); err != nil {
+			return nil, err
+		}
+		return func(desc specV1.Descriptor) bool {
+			for _, val := range filterValues {
+				if !matchAnnotation(val, desc.Annotations) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("%q is an invalid artifact blob filter, supported filters are: %s", filter, strings.Join(SupportedArtifactBlobFilters, ", "))
+}
+
+// parseSizeFilter parses a value of the "size" filter, a size like "1GB"
+// prefixed with one of the comparison operators "<", "<=", ">" or ">=".
+func parseSizeFilter(value string) (func(int64) bool, error) {
+	var (
+		op   string
+		size string
+	)
+	for _, prefix := range []string{"<=", ">=", "<", ">"} {
+		if rest, ok := strings.CutPrefix(value, prefix); ok {
+			op, size = prefix, rest
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("%q is not a valid value for the \"size\" filter - must be a size prefixed with <, <=, > or >=", value)
+	}
+	limit, err := units.FromHumanSize(strings.TrimSpace(size))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid value for the \"size\" filter: %w", value, err)
+	}
+	switch op {
+	case "<":
+		return func(s int64) bool { return s < limit }, nil
+	case "<=":
+		return func(s int64) bool { return s <= limit }, nil
+	case ">":
+		return func(s int64) bool { return s > limit }, nil
+	default:
+		return func(s int64) bool { return s >= limit }, nil
+	}
+}
+
+func matchArtifactAnnotation(a *libartifact.Artifact, filterValue string) bool {
+	if matchAnnotation(filterValue, a.Manifest.Annotations) {
+		return true
+	}
+	for _, layer := range a.Manifest.Layers {
+		if matchAnnotation(filterValue, layer.Annotations) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAnnotationFilters returns an error for a value of the "annotation"
+// filter without a key.
+func checkAnnotationFilters(filterValues []string) error {
+	for _, val := range filterValues {
+		if key, _, _ := strings.Cut(val, "="); key == "" {
+			return fmt.Errorf("%q is not a valid value for the \"annotation\" filter - must be key or key=value", val)
+		}
+	}
+	return nil
+}
+
+// matchAnnotation returns true if annotations match the value of an
+// "annotation" filter: "key" matches any value of the key, "key=value" only
+// the value itself and "key=" only an empty value.  A key with a "*" is a
+// pattern, as for the label filters.
+func matchAnnotation(filterValue string, annotations map[string]string) bool {
+	key, value, hasValue := strings.Cut(filterValue, "=")
+	if !hasValue || value != "" {
+		return filters.MatchLabelFilters([]string{filterValue}, annotations)
+	}
+	// The label filters match any value for "key=".
+	empty := make(map[string]string)
+	for k, v := range annotations {
+		if v == "" {
+			empty[k] = v
+		}
+	}
+	return filters.MatchLabelFilters([]string{key}, empty)
+}
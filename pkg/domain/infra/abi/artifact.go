@@ -4,72 +4,427 @@ package abi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/containers/buildah/pkg/parse"
 	"github.com/containers/common/libimage"
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/compression"
+	imageTypes "github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/libpod/define"
+	"github.com/containers/podman/v5/libpod/events"
 	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/domain/filters"
+	"github.com/containers/podman/v5/pkg/libartifact"
+	"github.com/containers/podman/v5/pkg/libartifact/store"
 	"github.com/containers/podman/v5/pkg/libartifact/types"
 	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
 )
 
-func (ir *ImageEngine) ArtifactInspect(ctx context.Context, name string, _ entities.ArtifactInspectOptions) (*entities.ArtifactInspectReport, error) {
+func (ir *ImageEngine) ArtifactInspect(ctx context.Context, namesOrDigests []string, opts entities.ArtifactInspectOptions) ([]*entities.ArtifactInspectReport, []error, error) {
+	algorithm := digest.Canonical
+	switch opts.DigestAlgorithm {
+	case "":
+	case string(digest.SHA256), string(digest.SHA512):
+		algorithm = digest.Algorithm(opts.DigestAlgorithm)
+	default:
+		return nil, nil, fmt.Errorf("unsupported digest algorithm %q, must be %s or %s", opts.DigestAlgorithm, digest.SHA256, digest.SHA512)
+	}
+
 	artStore, err := ir.Libpod.ArtifactStore()
 	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Remote {
+		return inspectRemoteArtifacts(ctx, artStore, namesOrDigests, algorithm, &opts)
+	}
+	if opts.Latest {
+		latest, err := latestArtifact(ctx, artStore)
+		if err != nil {
+			if errors.Is(err, types.ErrArtifactNotExist) {
+				return []*entities.ArtifactInspectReport{}, []error{err}, nil
+			}
+			return nil, nil, err
+		}
+		namesOrDigests = []string{latest}
+	}
+	reports := []*entities.ArtifactInspectReport{}
+	errs := []error{}
+	for _, name := range namesOrDigests {
+		art, err := artStore.Inspect(ctx, name)
+		if err != nil {
+			// A missing artifact is not fatal, the others are still inspected.
+			if errors.Is(err, types.ErrArtifactNotExist) {
+				errs = append(errs, err)
+				continue
+			}
+			return nil, nil, err
+		}
+		artInspectReport, err := inspectArtifact(ctx, artStore, art, name, algorithm, opts.Verify)
+		if err != nil {
+			return nil, nil, err
+		}
+		reports = append(reports, artInspectReport)
+	}
+	return reports, errs, nil
+}
+
+// inspectRemoteArtifacts returns the inspect reports of the artifacts the names
+// refer to in their registries.  Only the manifests are fetched.
+func inspectRemoteArtifacts(ctx context.Context, artStore *store.ArtifactStore, names []string, algorithm digest.Algorithm, opts *entities.ArtifactInspectOptions) ([]*entities.ArtifactInspectReport, []error, error) {
+	if opts.Latest {
+		return nil, nil, errors.New("the latest artifact of the local store cannot be inspected in its registry")
+	}
+	if opts.Verify {
+		return nil, nil, errors.New("verifying the blobs requires the artifact in the local store")
+	}
+	copyOptions, err := artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, nil, err
+	}
+	copyOptions.Username = opts.Username
+	copyOptions.Password = opts.Password
+	reports := make([]*entities.ArtifactInspectReport, 0, len(names))
+	for _, name := range names {
+		art, err := artStore.InspectRemote(ctx, name, copyOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		artInspectReport, err := newArtifactInspectReport(art, algorithm)
+		if err != nil {
+			return nil, nil, err
+		}
+		artInspectReport.Remote = true
+		if artInspectReport.Fingerprint, err = art.Fingerprint(); err != nil {
+			return nil, nil, err
+		}
+		reports = append(reports, artInspectReport)
+	}
+	return reports, []error{}, nil
+}
+
+// inspectArtifact returns the inspect report of art, which was looked up by name.
+func inspectArtifact(ctx context.Context, artStore *store.ArtifactStore, art *libartifact.Artifact, name string, algorithm digest.Algorithm, verify bool) (*entities.ArtifactInspectReport, error) {
+	artInspectReport, err := newArtifactInspectReport(art, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if verify {
+		verifyOptions := types.VerifyOptions{
+			DigestAlgorithm: algorithm,
+		}
+		blobs, err := artStore.VerifyBlobs(ctx, name, &verifyOptions)
+		if err != nil {
+			return nil, err
+		}
+		artInspectReport.Blobs = blobs
+	}
+	if artInspectReport.Fingerprint, err = artStore.Fingerprint(ctx, name); err != nil {
 		return nil, err
 	}
-	art, err := artStore.Inspect(ctx, name)
+	missing, err := artStore.MissingBlobs(ctx, name)
 	if err != nil {
 		return nil, err
 	}
+	if len(missing) > 0 {
+		artInspectReport.Partial = true
+		artInspectReport.MissingBlobs = missing
+	}
+	return artInspectReport, nil
+}
+
+// newArtifactInspectReport builds the inspect report of an artifact from its
+// manifest alone so that it does not depend on where the manifest was read.
+func newArtifactInspectReport(art *libartifact.Artifact, algorithm digest.Algorithm) (*entities.ArtifactInspectReport, error) {
 	artDigest, err := art.GetDigest()
 	if err != nil {
 		return nil, err
 	}
-	artInspectReport := entities.ArtifactInspectReport{
-		Artifact: art,
-		Digest:   artDigest.String(),
+	report := entities.ArtifactInspectReport{
+		Artifact:    art,
+		Manifest:    &art.Manifest.Manifest,
+		Digest:      artDigest.String(),
+		Config:      art.Manifest.Config,
+		Description: art.Description(),
+		BlobCount:   len(art.Manifest.Layers),
+		MediaTypes:  []string{},
+	}
+	for _, layer := range art.Manifest.Layers {
+		if !slices.Contains(report.MediaTypes, layer.MediaType) {
+			report.MediaTypes = append(report.MediaTypes, layer.MediaType)
+		}
+	}
+	slices.Sort(report.MediaTypes)
+	if algorithm != artDigest.Algorithm() {
+		alternateDigest, err := art.GetDigestWithAlgorithm(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		report.AlternateDigest = alternateDigest.String()
 	}
-	return &artInspectReport, nil
+	return &report, nil
 }
 
-func (ir *ImageEngine) ArtifactList(ctx context.Context, _ entities.ArtifactListOptions) ([]*entities.ArtifactListReport, error) {
-	reports := make([]*entities.ArtifactListReport, 0)
+func (ir *ImageEngine) ArtifactList(ctx context.Context, opts entities.ArtifactListOptions) ([]*entities.ArtifactListReport, error) {
+	artifactFilters := make([]libartifact.ArtifactFilter, 0, len(opts.Filters))
+	for filter, value := range opts.Filters {
+		filterFunc, err := filters.GenerateArtifactFilters(filter, value)
+		if err != nil {
+			return nil, err
+		}
+		artifactFilters = append(artifactFilters, filterFunc)
+	}
+
 	artStore, err := ir.Libpod.ArtifactStore()
 	if err != nil {
 		return nil, err
 	}
+	if opts.Subject != "" {
+		subjectDigest, err := ir.subjectDigest(ctx, artStore, opts.Subject)
+		if err != nil {
+			return nil, err
+		}
+		artifactFilters = append(artifactFilters, func(a *libartifact.Artifact) bool {
+			return a.Manifest.Subject != nil && a.Manifest.Subject.Digest == subjectDigest
+		})
+	}
+
+	if opts.Stream {
+		go func() {
+			defer close(opts.ReportChan)
+			err := artStore.ForEach(ctx, func(lr *libartifact.Artifact) error {
+				if !matchArtifactFilters(lr, artifactFilters) {
+					return nil
+				}
+				report, err := artifactListReport(lr, opts.Quiet)
+				if err != nil {
+					return err
+				}
+				select {
+				case opts.ReportChan <- entities.ArtifactListStreamReport{Report: report}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil && !errors.Is(err, context.Canceled) {
+				opts.ReportChan <- entities.ArtifactListStreamReport{Error: err}
+			}
+		}()
+		return nil, nil
+	}
+
+	reports := make([]*entities.ArtifactListReport, 0)
 	lrs, err := artStore.List(ctx)
 	if err != nil {
 		return nil, err
 	}
 	for _, lr := range lrs {
-		artListReport := entities.ArtifactListReport{
-			Artifact: lr,
+		if !matchArtifactFilters(lr, artifactFilters) {
+			continue
 		}
-		reports = append(reports, &artListReport)
+		report, err := artifactListReport(lr, opts.Quiet)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
 	}
 	return reports, nil
 }
 
+// artifactListReport returns the list report of the artifact.  A quiet report
+// only holds the artifact.
+func artifactListReport(lr *libartifact.Artifact, quiet bool) (*entities.ArtifactListReport, error) {
+	if quiet {
+		return &entities.ArtifactListReport{Artifact: lr}, nil
+	}
+	artifactDigest, err := lr.StoredDigest()
+	if err != nil {
+		return nil, err
+	}
+	layerSizes := make([]int64, 0, len(lr.Manifest.Layers))
+	for _, layer := range lr.Manifest.Layers {
+		layerSizes = append(layerSizes, layer.Size)
+	}
+	created, ok := lr.CreatedTime()
+	if !ok {
+		created = lr.StoredTime()
+	}
+	return &entities.ArtifactListReport{
+		Artifact:        lr,
+		Digest:          artifactDigest,
+		DigestAlgorithm: artifactDigest.Algorithm(),
+		ArtifactType:    lr.Manifest.ArtifactType,
+		Kind:            lr.Kind(),
+		Description:     lr.Description(),
+		TotalSize:       lr.TotalSizeBytes(),
+		LayerSizes:      layerSizes,
+		Created:         created,
+	}, nil
+}
+
+// parseRetryDelay parses the retry delay of a pull, push or copy.  The empty
+// string selects the default delay.
+func parseRetryDelay(delay string) (*time.Duration, error) {
+	if delay == "" {
+		return nil, nil
+	}
+	duration, err := time.ParseDuration(delay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry delay %q: %w", delay, err)
+	}
+	return &duration, nil
+}
+
+// artifactRegistryCopyOptions returns the copy options the artifact commands
+// access registries with, so that pull, push, copy, check and diff treat the
+// authentication file, certificate directory and TLS verification the same
+// way.  An empty authFile is REGISTRY_AUTH_FILE if set, as for the CLI, or
+// else the default locations of c/image.  An empty certDir and an undefined
+// skipTLSVerify leave the certs.d directory of each registry and its insecure
+// setting in registries.conf in effect.  A certificate directory which does
+// not exist is an error, c/image would silently ignore it.
+func artifactRegistryCopyOptions(authFile, certDir string, skipTLSVerify imageTypes.OptionalBool) (libimage.CopyOptions, error) {
+	if authFile == "" {
+		authFile = auth.GetDefaultAuthFile()
+	}
+	if certDir != "" {
+		st, err := os.Stat(certDir)
+		if err != nil {
+			return libimage.CopyOptions{}, fmt.Errorf("certificate directory: %w", err)
+		}
+		if !st.IsDir() {
+			return libimage.CopyOptions{}, fmt.Errorf("certificate directory %s is not a directory", certDir)
+		}
+	}
+	return libimage.CopyOptions{
+		AuthFilePath:          authFile,
+		CertDirPath:           certDir,
+		InsecureSkipTLSVerify: skipTLSVerify,
+	}, nil
+}
+
+// subjectDigest returns the manifest digest of subject, the image or
+// artifact other artifacts refer to.  Names are looked up in the artifacts
+// first and then in the local images.
+func (ir *ImageEngine) subjectDigest(ctx context.Context, artStore *store.ArtifactStore, subject string) (digest.Digest, error) {
+	if d, err := digest.Parse(subject); err == nil {
+		return d, nil
+	}
+	if named, err := reference.ParseNormalizedNamed(subject); err == nil {
+		if digested, ok := named.(reference.Digested); ok {
+			return digested.Digest(), nil
+		}
+	}
+	if arty, err := artStore.Inspect(ctx, subject); err == nil {
+		artifactDigest, err := arty.GetDigest()
+		if err != nil {
+			return "", err
+		}
+		return *artifactDigest, nil
+	}
+	img, _, err := ir.Libpod.LibimageRuntime().LookupImage(subject, nil)
+	if err != nil {
+		return "", fmt.Errorf("looking up the subject: %w", err)
+	}
+	return img.Digest(), nil
+}
+
+// subjectDescriptor returns the descriptor of the manifest with the given
+// digest, which an added artifact refers to as its subject.  The descriptor
+// needs the size and media type of the manifest, so it has to be the manifest
+// of an artifact or image in local storage.
+func (ir *ImageEngine) subjectDescriptor(ctx context.Context, artStore *store.ArtifactStore, subject string) (*specV1.Descriptor, error) {
+	subjectDigest, err := digest.Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject %q, must be a manifest digest: %w", subject, err)
+	}
+	artifacts, err := artStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, arty := range artifacts {
+		rawManifest, err := json.Marshal(arty.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		if subjectDigest.Algorithm().FromBytes(rawManifest) != subjectDigest {
+			continue
+		}
+		mediaType := arty.Manifest.MediaType
+		if mediaType == "" {
+			mediaType = specV1.MediaTypeImageManifest
+		}
+		return &specV1.Descriptor{MediaType: mediaType, Digest: subjectDigest, Size: int64(len(rawManifest))}, nil
+	}
+
+	images, err := ir.Libpod.LibimageRuntime().ListImages(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range images {
+		if !slices.Contains(img.Digests(), subjectDigest) {
+			continue
+		}
+		rawManifest, mimeType, err := img.Manifest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if manifestDigest, err := manifest.Digest(rawManifest); err != nil || manifestDigest != subjectDigest {
+			continue
+		}
+		return &specV1.Descriptor{MediaType: mimeType, Digest: subjectDigest, Size: int64(len(rawManifest))}, nil
+	}
+	return nil, fmt.Errorf("subject %s is not the manifest of an image or artifact in local storage, pull it first", subjectDigest)
+}
+
+func matchArtifactFilters(artifact *libartifact.Artifact, artifactFilters []libartifact.ArtifactFilter) bool {
+	for _, filter := range artifactFilters {
+		if !filter(artifact) {
+			return false
+		}
+	}
+	return true
+}
+
 func (ir *ImageEngine) ArtifactPull(ctx context.Context, name string, opts entities.ArtifactPullOptions) (*entities.ArtifactPullReport, error) {
-	pullOptions := &libimage.CopyOptions{}
-	pullOptions.AuthFilePath = opts.AuthFilePath
-	pullOptions.CertDirPath = opts.CertDirPath
+	if opts.ProgressChan != nil {
+		defer close(opts.ProgressChan)
+	}
+	registryOptions, err := artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	pullOptions := &registryOptions
 	pullOptions.Username = opts.Username
 	pullOptions.Password = opts.Password
 	pullOptions.SignaturePolicyPath = opts.SignaturePolicyPath
-	pullOptions.InsecureSkipTLSVerify = opts.InsecureSkipTLSVerify
 	pullOptions.Writer = opts.Writer
 	pullOptions.OciDecryptConfig = opts.OciDecryptConfig
+	pullOptions.Progress = opts.ProgressChan
 	pullOptions.MaxRetries = opts.MaxRetries
-	if opts.RetryDelay != "" {
-		duration, err := time.ParseDuration(opts.RetryDelay)
-		if err != nil {
-			return nil, err
-		}
-		pullOptions.RetryDelay = &duration
+	pullOptions.Architecture = opts.Architecture
+	pullOptions.OS = opts.OS
+	pullOptions.Variant = opts.Variant
+	retryDelay, err := parseRetryDelay(opts.RetryDelay)
+	if err != nil {
+		return nil, err
 	}
+	pullOptions.RetryDelay = retryDelay
 
 	if !opts.Quiet && pullOptions.Writer == nil {
 		pullOptions.Writer = os.Stderr
@@ -78,12 +433,95 @@ func (ir *ImageEngine) ArtifactPull(ctx context.Context, name string, opts entit
 	if err != nil {
 		return nil, err
 	}
-	return nil, artStore.Pull(ctx, name, *pullOptions)
+	artifactPullOptions := types.PullOptions{
+		MaxParallelDownloads: opts.MaxParallelDownloads,
+		RateLimitBytesPerSec: opts.RateLimitBytesPerSec,
+		MaxSizeBytes:         opts.MaxSizeBytes,
+		Titles:               opts.Titles,
+		ExtractTo:            opts.ExtractTo,
+		NoStore:              opts.NoStore,
+		Resume:               opts.Resume,
+		Timeout:              opts.Timeout,
+		RequireDigest:        opts.RequireDigest,
+		Anonymous:            opts.Anonymous,
+	}
+	for _, d := range opts.Digests {
+		blobDigest, err := digest.Parse(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob digest %q: %w", d, err)
+		}
+		artifactPullOptions.Digests = append(artifactPullOptions.Digests, blobDigest)
+	}
+	if opts.LogPolicy {
+		artifactPullOptions.PolicyWriter = os.Stderr
+	}
+	pullResult, err := artStore.Pull(ctx, name, *pullOptions, artifactPullOptions)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoStore {
+		ir.Libpod.NewArtifactEvent(events.Pull, pullResult.Reference, pullResult.ManifestDigest)
+	}
+	return &entities.ArtifactPullReport{
+		Reference:        pullResult.Reference,
+		ArtifactDigest:   &pullResult.ManifestDigest,
+		Platform:         pullResult.Platform,
+		BlobsFetched:     pullResult.BlobsFetched,
+		BlobsSkipped:     pullResult.BlobsSkipped,
+		BytesSkipped:     pullResult.BytesSkipped,
+		BytesTransferred: pullResult.BytesTransferred,
+		Mirror:           pullResult.Mirror,
+		ExtractedFiles:   pullResult.ExtractedFiles,
+		PinnedDigest:     pullResult.PinnedDigest,
+		RateLimitWait:    pullResult.RateLimitWait,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactCopy(ctx context.Context, source string, destination string, opts entities.ArtifactCopyOptions) (*entities.ArtifactCopyReport, error) {
+	copyOptions, err := artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	copyOptions.Username = opts.Username
+	copyOptions.Password = opts.Password
+	copyOptions.SignaturePolicyPath = opts.SignaturePolicyPath
+	copyOptions.Writer = opts.Writer
+	copyOptions.MaxRetries = opts.MaxRetries
+	copyOptions.Architecture = opts.Architecture
+	copyOptions.OS = opts.OS
+	copyOptions.Variant = opts.Variant
+	retryDelay, err := parseRetryDelay(opts.RetryDelay)
+	if err != nil {
+		return nil, err
+	}
+	copyOptions.RetryDelay = retryDelay
+	if !opts.Quiet && copyOptions.Writer == nil {
+		copyOptions.Writer = os.Stderr
+	}
+
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	artifactCopyOptions := types.CopyOptions{
+		SourceUsername:      opts.SourceUsername,
+		SourcePassword:      opts.SourcePassword,
+		DestinationUsername: opts.DestinationUsername,
+		DestinationPassword: opts.DestinationPassword,
+	}
+	result, err := artStore.Copy(ctx, source, destination, copyOptions, artifactCopyOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactCopyReport{
+		ArtifactDigest: &result.ManifestDigest,
+		Platform:       result.Platform,
+	}, nil
 }
 
-func (ir *ImageEngine) ArtifactRm(ctx context.Context, name string, opts entities.ArtifactRemoveOptions) (*entities.ArtifactRemoveReport, error) {
+func (ir *ImageEngine) ArtifactRm(ctx context.Context, namesOrDigests []string, opts entities.ArtifactRemoveOptions) (*entities.ArtifactRemoveReport, error) {
 	var (
-		namesOrDigests []string
+		toRemove []string
 	)
 	artStore, err := ir.Libpod.ArtifactStore()
 	if err != nil {
@@ -102,21 +540,53 @@ func (ir *ImageEngine) ArtifactRm(ctx context.Context, name string, opts entitie
 			if err != nil {
 				return nil, err
 			}
-			namesOrDigests = append(namesOrDigests, manifestDigest.Encoded())
+			toRemove = append(toRemove, manifestDigest.Encoded())
 		}
 	}
 
-	if name != "" {
-		namesOrDigests = append(namesOrDigests, name)
+	if opts.Latest {
+		latest, err := latestArtifact(ctx, artStore)
+		if err != nil {
+			if opts.Ignore && errors.Is(err, types.ErrArtifactNotExist) {
+				return &entities.ArtifactRemoveReport{ArtifactDigests: []*digest.Digest{}}, nil
+			}
+			return nil, err
+		}
+		namesOrDigests = []string{latest}
 	}
 
-	artifactDigests := make([]*digest.Digest, 0, len(namesOrDigests))
-	for _, namesOrDigest := range namesOrDigests {
-		artifactDigest, err := artStore.Remove(ctx, namesOrDigest)
+	for _, name := range namesOrDigests {
+		if !libartifact.IsNamePattern(name) {
+			toRemove = append(toRemove, name)
+			continue
+		}
+		allArtifacts, err := artStore.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := allArtifacts.GetByNamePattern(name)
+		if err != nil {
+			if opts.Ignore && errors.Is(err, types.ErrArtifactNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, art := range matches {
+			toRemove = append(toRemove, art.Name)
+		}
+	}
+
+	// All artifacts are looked up first, so a name which does not exist
+	// fails the removal before any artifact is removed.
+	artifactDigests, err := lookupArtifactDigests(ctx, artStore, toRemove, opts.Ignore)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.DryRun {
+		artifactDigests, err = ir.removeArtifacts(ctx, artStore, toRemove, opts.Ignore)
 		if err != nil {
 			return nil, err
 		}
-		artifactDigests = append(artifactDigests, artifactDigest)
 	}
 	artifactRemoveReport := entities.ArtifactRemoveReport{
 		ArtifactDigests: artifactDigests,
@@ -124,97 +594,941 @@ func (ir *ImageEngine) ArtifactRm(ctx context.Context, name string, opts entitie
 	return &artifactRemoveReport, err
 }
 
-func (ir *ImageEngine) ArtifactPush(ctx context.Context, name string, opts entities.ArtifactPushOptions) (*entities.ArtifactPushReport, error) {
-	var retryDelay *time.Duration
-
-	artStore, err := ir.Libpod.ArtifactStore()
+// latestArtifact returns the name of the artifact whose manifest was stored
+// last in the local store, or its digest if it has no name.  Of artifacts
+// stored at the same time the one added to the index last is returned.
+func latestArtifact(ctx context.Context, artStore *store.ArtifactStore) (string, error) {
+	artifacts, err := artStore.List(ctx)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	var latest *libartifact.Artifact
+	for _, art := range artifacts {
+		if latest == nil || !art.StoredTime().Before(latest.StoredTime()) {
+			latest = art
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no latest artifact, the local store is empty: %w", types.ErrArtifactNotExist)
+	}
+	if latest.Name != "" {
+		return latest.Name, nil
 	}
+	latestDigest, err := latest.GetDigest()
+	if err != nil {
+		return "", err
+	}
+	return latestDigest.Encoded(), nil
+}
 
-	if opts.RetryDelay != "" {
-		rd, err := time.ParseDuration(opts.RetryDelay)
+// removeArtifacts removes the given artifacts from the store and returns their
+// digests.  With ignore, artifacts which do not exist are skipped.
+func (ir *ImageEngine) removeArtifacts(ctx context.Context, artStore *store.ArtifactStore, namesOrDigests []string, ignore bool) ([]*digest.Digest, error) {
+	artifactDigests := make([]*digest.Digest, 0, len(namesOrDigests))
+	for _, namesOrDigest := range namesOrDigests {
+		// Look up the name first, the event should name the artifact even
+		// when it is removed by its digest.
+		art, err := artStore.Inspect(ctx, namesOrDigest)
+		if err != nil {
+			if ignore && errors.Is(err, types.ErrArtifactNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		artifactDigest, err := artStore.Remove(ctx, namesOrDigest)
 		if err != nil {
+			if ignore && errors.Is(err, types.ErrArtifactNotExist) {
+				continue
+			}
 			return nil, err
 		}
-		retryDelay = &rd
+		ir.Libpod.NewArtifactEvent(events.Remove, art.Name, *artifactDigest)
+		artifactDigests = append(artifactDigests, artifactDigest)
 	}
+	return artifactDigests, nil
+}
 
-	copyOpts := libimage.CopyOptions{
-		SystemContext:                    nil,
-		SourceLookupReferenceFunc:        nil,
-		DestinationLookupReferenceFunc:   nil,
-		CompressionFormat:                nil,
-		CompressionLevel:                 nil,
-		ForceCompressionFormat:           false,
-		AuthFilePath:                     opts.Authfile,
-		BlobInfoCacheDirPath:             "",
-		CertDirPath:                      opts.CertDir,
-		DirForceCompress:                 false,
-		ImageListSelection:               0,
-		InsecureSkipTLSVerify:            opts.SkipTLSVerify,
-		MaxRetries:                       opts.Retry,
-		RetryDelay:                       retryDelay,
-		ManifestMIMEType:                 "",
-		OciAcceptUncompressedLayers:      false,
-		OciEncryptConfig:                 nil,
-		OciEncryptLayers:                 opts.OciEncryptLayers,
-		OciDecryptConfig:                 nil,
-		Progress:                         nil,
-		PolicyAllowStorage:               false,
-		SignaturePolicyPath:              opts.SignaturePolicy,
-		Signers:                          opts.Signers,
-		SignBy:                           opts.SignBy,
-		SignPassphrase:                   opts.SignPassphrase,
-		SignBySigstorePrivateKeyFile:     opts.SignBySigstorePrivateKeyFile,
-		SignSigstorePrivateKeyPassphrase: opts.SignSigstorePrivateKeyPassphrase,
-		RemoveSignatures:                 opts.RemoveSignatures,
-		Architecture:                     "",
-		OS:                               "",
-		Variant:                          "",
-		Username:                         "",
-		Password:                         "",
-		Credentials:                      opts.CredentialsCLI,
-		IdentityToken:                    "",
-		Writer:                           opts.Writer,
+// lookupArtifactDigests returns the digests removeArtifacts would return
+// for the given artifacts without removing them.
+func lookupArtifactDigests(ctx context.Context, artStore *store.ArtifactStore, namesOrDigests []string, ignore bool) ([]*digest.Digest, error) {
+	artifactDigests := make([]*digest.Digest, 0, len(namesOrDigests))
+	for _, namesOrDigest := range namesOrDigests {
+		art, err := artStore.Inspect(ctx, namesOrDigest)
+		if err != nil {
+			if ignore && errors.Is(err, types.ErrArtifactNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		artifactDigest, err := art.GetDigest()
+		if err != nil {
+			return nil, err
+		}
+		artifactDigests = append(artifactDigests, artifactDigest)
 	}
-
-	err = artStore.Push(ctx, name, name, copyOpts)
-	return &entities.ArtifactPushReport{}, err
+	return artifactDigests, nil
 }
-func (ir *ImageEngine) ArtifactAdd(ctx context.Context, name string, paths []string, opts *entities.ArtifactAddOptions) (*entities.ArtifactAddReport, error) {
+
+func (ir *ImageEngine) ArtifactPrune(ctx context.Context, opts entities.ArtifactPruneOptions) (*entities.ArtifactPruneReport, error) {
+	artifactFilters := make([]libartifact.ArtifactFilter, 0, len(opts.Filter)+1)
+	for filter, value := range opts.Filter {
+		filterFunc, err := filters.GenerateArtifactPruneFilters(filter, value, opts.IncludeUndated)
+		if err != nil {
+			return nil, err
+		}
+		artifactFilters = append(artifactFilters, filterFunc)
+	}
+	if !opts.All {
+		danglingFilter, err := filters.GenerateArtifactFilters("dangling", []string{"true"})
+		if err != nil {
+			return nil, err
+		}
+		artifactFilters = append(artifactFilters, danglingFilter)
+	}
+
 	artStore, err := ir.Libpod.ArtifactStore()
 	if err != nil {
 		return nil, err
 	}
+	artifacts, err := artStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inUse, err := ir.artifactsInUse(artifacts)
+	if err != nil {
+		return nil, err
+	}
 
-	addOptions := types.AddOptions{
-		Annotations:  opts.Annotations,
-		ArtifactType: opts.ArtifactType,
-		Append:       opts.Append,
-		FileType:     opts.FileType,
+	var (
+		pruned, kept   libartifact.ArtifactList
+		namesOrDigests []string
+	)
+	for _, art := range artifacts {
+		artDigest, err := art.GetDigest()
+		if err != nil {
+			return nil, err
+		}
+		if inUse[*artDigest] || !matchArtifactFilters(art, artifactFilters) {
+			kept = append(kept, art)
+			continue
+		}
+		pruned = append(pruned, art)
+		// Remove by name when possible, another artifact with the
+		// same digest may be kept.
+		if art.Name != "" {
+			namesOrDigests = append(namesOrDigests, art.Name)
+		} else {
+			namesOrDigests = append(namesOrDigests, artDigest.Encoded())
+		}
+	}
+
+	report := entities.ArtifactPruneReport{}
+	report.ReclaimedSize, err = reclaimableSize(pruned, kept)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		for _, art := range pruned {
+			artDigest, err := art.GetDigest()
+			if err != nil {
+				return nil, err
+			}
+			report.ArtifactDigests = append(report.ArtifactDigests, artDigest)
+		}
+		return &report, nil
+	}
+	report.ArtifactDigests, err = ir.removeArtifacts(ctx, artStore, namesOrDigests, false)
+	if err != nil {
+		return nil, err
 	}
+	return &report, nil
+}
 
-	artifactDigest, err := artStore.Add(ctx, name, paths, &addOptions)
+// artifactsInUse returns the digests of the artifacts mounted by any container.
+func (ir *ImageEngine) artifactsInUse(artifacts libartifact.ArtifactList) (map[digest.Digest]bool, error) {
+	ctrs, err := ir.Libpod.GetAllContainers()
 	if err != nil {
 		return nil, err
 	}
-	return &entities.ArtifactAddReport{
-		ArtifactDigest: artifactDigest,
+	inUse := make(map[digest.Digest]bool)
+	for _, ctr := range ctrs {
+		for _, vol := range ctr.Config().ArtifactVolumes {
+			art, _, err := artifacts.GetByNameOrDigest(vol.Source)
+			if err != nil {
+				// The artifact of the container no longer exists.
+				continue
+			}
+			artDigest, err := art.GetDigest()
+			if err != nil {
+				return nil, err
+			}
+			inUse[*artDigest] = true
+		}
+	}
+	return inUse, nil
+}
+
+// reclaimableSize returns the size of the manifests and blobs of the pruned
+// artifacts which are not shared with any of the kept artifacts.
+func reclaimableSize(pruned, kept libartifact.ArtifactList) (int64, error) {
+	keptBlobs := make(map[digest.Digest]struct{})
+	for _, art := range kept {
+		artDigest, err := art.GetDigest()
+		if err != nil {
+			return 0, err
+		}
+		keptBlobs[*artDigest] = struct{}{}
+		keptBlobs[art.Manifest.Config.Digest] = struct{}{}
+		for _, layer := range art.Manifest.Layers {
+			keptBlobs[layer.Digest] = struct{}{}
+		}
+	}
+
+	var size int64
+	add := func(d digest.Digest, s int64) {
+		if _, ok := keptBlobs[d]; ok {
+			return
+		}
+		// Count each freed blob once.
+		keptBlobs[d] = struct{}{}
+		size += s
+	}
+	for _, art := range pruned {
+		rawManifest, err := json.Marshal(art.Manifest)
+		if err != nil {
+			return 0, err
+		}
+		add(digest.FromBytes(rawManifest), int64(len(rawManifest)))
+		add(art.Manifest.Config.Digest, art.Manifest.Config.Size)
+		for _, layer := range art.Manifest.Layers {
+			add(layer.Digest, layer.Size)
+		}
+	}
+	return size, nil
+}
+
+func (ir *ImageEngine) ArtifactPush(ctx context.Context, name string, opts entities.ArtifactPushOptions) (*entities.ArtifactPushReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+
+	copyOpts, err := artifactPushCopyOptions(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pushOpts := types.PushOptions{
+		MaxParallelUploads:   opts.MaxParallelUploads,
+		RateLimitBytesPerSec: opts.RateLimitBytesPerSec,
+		AdditionalTags:       opts.AdditionalTags,
+		RetryBackoff:         opts.RetryBackoff,
+		DryRun:               opts.DryRun,
+		MountFrom:            opts.MountFrom,
+		Timeout:              opts.Timeout,
+		ForceCompression:     opts.ForceCompression,
+	}
+	dest := opts.Destination
+	if dest == "" {
+		dest = name
+	}
+	result, err := artStore.Push(ctx, name, dest, copyOpts, pushOpts)
+	if err != nil {
+		if result != nil {
+			return &entities.ArtifactPushReport{ArtifactDigest: &result.ManifestDigest, Tags: result.Tags, Retries: result.Retries}, err
+		}
+		return nil, err
+	}
+	if opts.DryRun {
+		return &entities.ArtifactPushReport{
+			ArtifactDigest: &result.ManifestDigest,
+			Tags:           result.Tags,
+			UploadBlobs:    result.UploadBlobs,
+			SkippedBlobs:   result.SkippedBlobs,
+		}, nil
+	}
+	ir.Libpod.NewArtifactEvent(events.Push, name, result.ManifestDigest)
+	if opts.DigestFile != "" {
+		if err := os.WriteFile(opts.DigestFile, []byte(result.ManifestDigest.String()), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return &entities.ArtifactPushReport{
+		ArtifactDigest:    &result.ManifestDigest,
+		Retries:           result.Retries,
+		Tags:              result.Tags,
+		MountedBlobs:      result.MountedBlobs,
+		UploadedBlobs:     result.UploadedBlobs,
+		RecompressedBlobs: result.RecompressedBlobs,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactPushIndex(ctx context.Context, name string, opts entities.ArtifactIndexOptions) (*entities.ArtifactIndexReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]types.IndexEntry, 0, len(opts.Artifacts))
+	for _, a := range opts.Artifacts {
+		platformOS, arch, variant, err := parse.Platform(a.Platform)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Artifact, err)
+		}
+		entries = append(entries, types.IndexEntry{
+			Artifact: a.Artifact,
+			Platform: specV1.Platform{OS: platformOS, Architecture: arch, Variant: variant},
+		})
+	}
+	copyOpts, err := artifactPushCopyOptions(&opts.ArtifactPushOptions)
+	if err != nil {
+		return nil, err
+	}
+	pushOpts := types.PushOptions{
+		MaxParallelUploads:   opts.MaxParallelUploads,
+		RateLimitBytesPerSec: opts.RateLimitBytesPerSec,
+		AdditionalTags:       opts.AdditionalTags,
+		RetryBackoff:         opts.RetryBackoff,
+		DryRun:               opts.DryRun,
+		MountFrom:            opts.MountFrom,
+		Timeout:              opts.Timeout,
+		ForceCompression:     opts.ForceCompression,
+	}
+	result, err := artStore.PushIndex(ctx, name, entries, copyOpts, pushOpts)
+	if err != nil {
+		if result != nil {
+			return &entities.ArtifactIndexReport{IndexDigest: result.IndexDigest, Manifests: result.Manifests, Tags: result.Tags, Retries: result.Retries}, err
+		}
+		return nil, err
+	}
+	ir.Libpod.NewArtifactEvent(events.Push, name, result.IndexDigest)
+	if opts.DigestFile != "" {
+		if err := os.WriteFile(opts.DigestFile, []byte(result.IndexDigest.String()), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return &entities.ArtifactIndexReport{
+		IndexDigest: result.IndexDigest,
+		Manifests:   result.Manifests,
+		Tags:        result.Tags,
+		Retries:     result.Retries,
 	}, nil
 }
 
-func (ir *ImageEngine) ArtifactExtract(ctx context.Context, name string, target string, opts *entities.ArtifactExtractOptions) error {
+// artifactPushCopyOptions returns the copy options of a push of artifacts.
+func artifactPushCopyOptions(opts *entities.ArtifactPushOptions) (libimage.CopyOptions, error) {
+	maxRetries := opts.MaxRetries
+	if maxRetries == nil {
+		maxRetries = opts.Retry
+	}
+	delay := opts.RetryDelay
+	if delay == "" {
+		delay = opts.ImagePushOptions.RetryDelay
+	}
+	retryDelay, err := parseRetryDelay(delay)
+	if err != nil {
+		return libimage.CopyOptions{}, err
+	}
+
+	authFile := opts.AuthFilePath
+	if authFile == "" {
+		authFile = opts.Authfile
+	}
+	registryOptions, err := artifactRegistryCopyOptions(authFile, opts.CertDir, opts.SkipTLSVerify)
+	if err != nil {
+		return libimage.CopyOptions{}, err
+	}
+
+	var compressionFormat *compression.Algorithm
+	switch opts.CompressionFormat {
+	case "", "none":
+	case compression.Gzip.Name(), compression.Zstd.Name():
+		algorithm, err := compression.AlgorithmByName(opts.CompressionFormat)
+		if err != nil {
+			return libimage.CopyOptions{}, err
+		}
+		compressionFormat = &algorithm
+	default:
+		return libimage.CopyOptions{}, fmt.Errorf("unsupported compression format %q for artifacts, must be gzip, zstd or none", opts.CompressionFormat)
+	}
+	if opts.CompressionLevel != nil && compressionFormat == nil {
+		return libimage.CopyOptions{}, errors.New("compression level requires a compression format")
+	}
+
+	copyOpts := libimage.CopyOptions{
+		SystemContext:                    nil,
+		SourceLookupReferenceFunc:        nil,
+		DestinationLookupReferenceFunc:   nil,
+		CompressionFormat:                compressionFormat,
+		CompressionLevel:                 opts.CompressionLevel,
+		ForceCompressionFormat:           false,
+		AuthFilePath:                     registryOptions.AuthFilePath,
+		BlobInfoCacheDirPath:             "",
+		CertDirPath:                      registryOptions.CertDirPath,
+		DirForceCompress:                 false,
+		ImageListSelection:               0,
+		InsecureSkipTLSVerify:            registryOptions.InsecureSkipTLSVerify,
+		MaxRetries:                       maxRetries,
+		RetryDelay:                       retryDelay,
+		ManifestMIMEType:                 "",
+		OciAcceptUncompressedLayers:      false,
+		OciEncryptConfig:                 nil,
+		OciEncryptLayers:                 opts.OciEncryptLayers,
+		OciDecryptConfig:                 nil,
+		Progress:                         nil,
+		PolicyAllowStorage:               false,
+		SignaturePolicyPath:              opts.SignaturePolicy,
+		Signers:                          opts.Signers,
+		SignBy:                           opts.SignBy,
+		SignPassphrase:                   opts.SignPassphrase,
+		SignBySigstorePrivateKeyFile:     opts.SignBySigstorePrivateKeyFile,
+		SignSigstorePrivateKeyPassphrase: opts.SignSigstorePrivateKeyPassphrase,
+		RemoveSignatures:                 opts.RemoveSignatures,
+		Architecture:                     "",
+		OS:                               "",
+		Variant:                          "",
+		Username:                         opts.Username,
+		Password:                         opts.Password,
+		Credentials:                      opts.CredentialsCLI,
+		IdentityToken:                    "",
+		Writer:                           opts.Writer,
+	}
+	return copyOpts, nil
+}
+
+func (ir *ImageEngine) ArtifactAdd(ctx context.Context, name string, paths []string, opts *entities.ArtifactAddOptions) (*entities.ArtifactAddReport, error) {
 	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+
+	manifestAnnotations, err := descriptionAnnotations(opts.ManifestAnnotations, opts.Description)
+	if err != nil {
+		return nil, err
+	}
+	addOptions := types.AddOptions{
+		Annotations:          opts.Annotations,
+		ManifestAnnotations:  manifestAnnotations,
+		IndexAnnotations:     opts.IndexAnnotations,
+		ArtifactType:         opts.ArtifactType,
+		Append:               opts.Append,
+		Replace:              opts.Replace,
+		FileType:             opts.FileType,
+		AllowDuplicate:       opts.AllowDuplicate,
+		AllowDuplicateTitles: opts.AllowDuplicateTitles,
+		StrictType:           opts.StrictType,
+		ConfigFile:           opts.ConfigFile,
+		ConfigType:           opts.ConfigType,
+		RecordMode:           opts.RecordMode,
+		AutoAnnotate:         opts.AutoAnnotate,
+	}
+	if opts.Subject != "" {
+		subject, err := ir.subjectDescriptor(ctx, artStore, opts.Subject)
+		if err != nil {
+			return nil, err
+		}
+		addOptions.Subject = subject
+	}
+
+	// The blobs of transport sources are read from them by the add.
+	var closeSources []func() error
+	defer func() {
+		for _, closeSource := range closeSources {
+			if err := closeSource(); err != nil {
+				logrus.Errorf("Closing artifact source: %v", err)
+			}
+		}
+	}()
+	sourceBlobs := func(source string) ([]types.ArtifactBlob, error) {
+		blobs, closeSource, err := artStore.SourceBlobs(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		closeSources = append(closeSources, closeSource)
+		return blobs, nil
+	}
+
+	var (
+		artifactBlobs []types.ArtifactBlob
+		walker        *artifactDirWalker
+	)
+	if opts.FromArtifact != "" {
+		artifactBlobs, err = storedArtifactBlobs(ctx, artStore, opts.FromArtifact, paths)
+		walker = &artifactDirWalker{}
+	} else {
+		artifactBlobs, walker, err = artifactBlobsFromPaths(paths, opts, sourceBlobs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	addResult, err := artStore.Add(ctx, name, artifactBlobs, &addOptions)
+	if err != nil {
+		return nil, err
+	}
+	ir.Libpod.NewArtifactEvent(events.Add, name, addResult.ManifestDigest)
+	report := &entities.ArtifactAddReport{
+		ArtifactDigest:   &addResult.ManifestDigest,
+		Blobs:            addResult.Blobs,
+		EmptyDirectories: walker.emptyDirs,
+		SkippedSymlinks:  walker.skippedSymlinks,
+	}
+	if addResult.ReplacedDigest != "" {
+		report.ReplacedDigest = &addResult.ReplacedDigest
+	}
+	return report, nil
+}
+
+// storedArtifactBlobs returns the blobs of the artifact source selected by
+// blobs, each the digest or the title of a blob, to add them by reference.
+func storedArtifactBlobs(ctx context.Context, artStore *store.ArtifactStore, source string, blobs []string) ([]types.ArtifactBlob, error) {
+	artifactBlobs := make([]types.ArtifactBlob, 0, len(blobs))
+	for _, blob := range blobs {
+		filter := &types.FilterBlobOptions{Title: blob}
+		if _, err := digest.Parse(blob); err == nil {
+			filter = &types.FilterBlobOptions{Digest: blob}
+		}
+		artifactBlob, err := artStore.StoredBlob(ctx, source, filter)
+		if err != nil {
+			return nil, err
+		}
+		artifactBlobs = append(artifactBlobs, *artifactBlob)
+	}
+	return artifactBlobs, nil
+}
+
+// descriptionAnnotations returns the manifest annotations with the
+// description set as the org.opencontainers.image.description annotation.
+// The description must be a single line.
+func descriptionAnnotations(annotations map[string]string, description string) (map[string]string, error) {
+	if description == "" {
+		return annotations, nil
+	}
+	if strings.ContainsAny(description, "\r\n") {
+		return nil, errors.New("the artifact description must be a single line")
+	}
+	annotations = maps.Clone(annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[specV1.AnnotationDescription] = description
+	return annotations, nil
+}
+
+// artifactBlobsFromPaths converts the given paths into artifact blobs.  The
+// path "-" denotes that the blob content is read from stdin.  With
+// opts.Recursive, every file below a directory becomes a blob, see
+// artifactDirWalker.  A path prefixed with a transport like
+// "oci-archive:foo.tar" which is not an existing file is converted by
+// sourceBlobs.
+func artifactBlobsFromPaths(paths []string, opts *entities.ArtifactAddOptions, sourceBlobs func(string) ([]types.ArtifactBlob, error)) ([]types.ArtifactBlob, *artifactDirWalker, error) {
+	walker, err := newArtifactDirWalker(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	readStdin := false
+	for _, path := range paths {
+		if path != "-" {
+			st, err := os.Stat(path)
+			if err != nil && store.IsBlobSource(path) {
+				blobs, err := sourceBlobs(path)
+				if err != nil {
+					return nil, nil, err
+				}
+				walker.blobs = append(walker.blobs, blobs...)
+				continue
+			}
+			if err == nil && st.IsDir() {
+				if !opts.Recursive {
+					return nil, nil, fmt.Errorf("%s is a directory, use --recursive to add the files it contains", path)
+				}
+				if err := walker.walk(path, ""); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			walker.blobs = append(walker.blobs, types.ArtifactBlob{
+				BlobFilePath: path,
+				FileName:     filepath.Base(path),
+			})
+			continue
+		}
+		if readStdin {
+			return nil, nil, errors.New("stdin (\"-\") can only be used once as a path")
+		}
+		if len(opts.StdinName) == 0 {
+			return nil, nil, errors.New("a file name must be provided when reading a blob from stdin")
+		}
+		if filepath.Base(opts.StdinName) != opts.StdinName {
+			return nil, nil, fmt.Errorf("invalid file name %q: must not contain a path", opts.StdinName)
+		}
+		stdin := opts.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		walker.blobs = append(walker.blobs, types.ArtifactBlob{
+			BlobReader: stdin,
+			FileName:   opts.StdinName,
+		})
+		readStdin = true
+	}
+	if !readStdin && len(opts.StdinName) > 0 {
+		return nil, nil, errors.New("a file name for stdin was provided but stdin (\"-\") is not used as a path")
+	}
+	if len(walker.blobs) == 0 && opts.Recursive {
+		return nil, nil, errors.New("no files to add, the given directories are empty or all files are excluded")
+	}
+	return walker.blobs, walker, nil
+}
+
+// artifactDirWalker collects the files below directories added with
+// --recursive.  Each file is named by its path relative to the added
+// directory, using "/" as separator.
+type artifactDirWalker struct {
+	exclude        []string
+	followSymlinks bool
+	blobs          []types.ArtifactBlob
+	// emptyDirs and skippedSymlinks are reported to the caller, as
+	// neither can be represented by a blob.
+	emptyDirs       []string
+	skippedSymlinks []string
+	// walking holds the resolved paths of the directories being walked,
+	// to detect symlink loops.
+	walking map[string]struct{}
+}
+
+func newArtifactDirWalker(opts *entities.ArtifactAddOptions) (*artifactDirWalker, error) {
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return &artifactDirWalker{
+		exclude:        opts.Exclude,
+		followSymlinks: opts.FollowSymlinks,
+		walking:        map[string]struct{}{},
+	}, nil
+}
+
+// excluded returns true if an exclude pattern matches the relative path or
+// the name of a file or directory.
+func (w *artifactDirWalker) excluded(relPath string) bool {
+	for _, pattern := range w.exclude {
+		// The patterns were validated, so errors cannot happen.
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// walk adds all files below dir, whose path relative to the added directory
+// is relDir.  Entries are visited in lexical order, so the order of the blobs
+// does not depend on the file system.
+func (w *artifactDirWalker) walk(dir, relDir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
 	if err != nil {
 		return err
 	}
+	if _, ok := w.walking[resolved]; ok {
+		return fmt.Errorf("symlink loop detected at %s", dir)
+	}
+	w.walking[resolved] = struct{}{}
+	defer delete(w.walking, resolved)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		w.emptyDirs = append(w.emptyDirs, dir)
+		return nil
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+		relPath := path.Join(relDir, entry.Name())
+		if w.excluded(relPath) {
+			continue
+		}
+		mode := entry.Type()
+		if mode&fs.ModeSymlink != 0 {
+			if !w.followSymlinks {
+				w.skippedSymlinks = append(w.skippedSymlinks, entryPath)
+				continue
+			}
+			st, err := os.Stat(entryPath)
+			if err != nil {
+				return err
+			}
+			mode = st.Mode()
+		}
+		switch {
+		case mode.IsDir():
+			if err := w.walk(entryPath, relPath); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			w.blobs = append(w.blobs, types.ArtifactBlob{
+				BlobFilePath: entryPath,
+				FileName:     relPath,
+			})
+		default:
+			return fmt.Errorf("%s is not a regular file or directory", entryPath)
+		}
+	}
+	return nil
+}
+
+func (ir *ImageEngine) ArtifactExtract(ctx context.Context, name string, target string, opts *entities.ArtifactExtractOptions) (*entities.ArtifactExtractReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	blobFilters := make([]types.BlobFilter, 0, len(opts.Filters))
+	for filter, value := range opts.Filters {
+		filterFunc, err := filters.GenerateArtifactBlobFilters(filter, value)
+		if err != nil {
+			return nil, err
+		}
+		blobFilters = append(blobFilters, filterFunc)
+	}
 	extractOpt := &types.ExtractOptions{
 		FilterBlobOptions: types.FilterBlobOptions{
 			Digest: opts.Digest,
 			Title:  opts.Title,
+			Index:  opts.Index,
+		},
+		BlobFilters:     blobFilters,
+		ExtractAll:      opts.ExtractAll,
+		Overwrite:       opts.Overwrite,
+		Verify:          opts.Verify,
+		Decompress:      opts.Decompress,
+		PreserveMode:    opts.PreserveMode,
+		PreserveOwner:   opts.PreserveOwner,
+		Writer:          opts.Writer,
+		TarOutput:       opts.TarOutput,
+		PathPrefix:      opts.PathPrefix,
+		StripComponents: opts.StripComponents,
+		OnConflict:      types.ExtractConflictPolicy(opts.OnConflict),
+	}
+
+	result, err := artStore.Extract(ctx, name, target, extractOpt)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactExtractReport{SkippedFiles: result.Skipped}, nil
+}
+
+func (ir *ImageEngine) ArtifactExport(ctx context.Context, name string, path string, opts entities.ArtifactExportOptions) (*entities.ArtifactExportReport, error) {
+	exportOpts := &types.ExportOptions{}
+	switch opts.Format {
+	case "", define.OCIArchive:
+		exportOpts.Archive = true
+	case define.OCIManifestDir:
+	default:
+		return nil, fmt.Errorf("unsupported export format %q, must be %s or %s", opts.Format, define.OCIArchive, define.OCIManifestDir)
+	}
+
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	artifactDigest, err := artStore.Export(ctx, name, path, exportOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactExportReport{ArtifactDigest: &artifactDigest}, nil
+}
+
+func (ir *ImageEngine) ArtifactImport(ctx context.Context, path string, name string, opts entities.ArtifactImportOptions) (*entities.ArtifactImportReport, error) {
+	importOpts := &types.ImportOptions{}
+	if opts.Digest != "" {
+		manifestDigest, err := digest.Parse(opts.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest %q: %w", opts.Digest, err)
+		}
+		importOpts.Digest = manifestDigest
+	}
+
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	artifactDigest, err := artStore.Import(ctx, path, name, importOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactImportReport{ArtifactDigest: &artifactDigest}, nil
+}
+
+func (ir *ImageEngine) ArtifactUpdate(ctx context.Context, name string, opts entities.ArtifactUpdateOptions) (*entities.ArtifactUpdateReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	updateOpts := &types.UpdateOptions{
+		FilterBlobOptions: types.FilterBlobOptions{
+			Digest: opts.Digest,
+			Title:  opts.Title,
+			Index:  opts.Index,
 		},
+		SetAnnotations:    opts.SetAnnotations,
+		RemoveAnnotations: opts.RemoveAnnotations,
+	}
+	result, err := artStore.Update(ctx, name, updateOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactUpdateReport{
+		OldDigest: &result.OldDigest,
+		NewDigest: &result.NewDigest,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactCheck(ctx context.Context, opts entities.ArtifactCheckOptions) (*entities.ArtifactCheckReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	copyOptions, err := artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	result, err := artStore.Check(ctx, copyOptions, types.CheckOptions{Repair: opts.Repair})
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactCheckReport{
+		Healthy:  result.Healthy,
+		Corrupt:  result.Corrupt,
+		Missing:  result.Missing,
+		Repaired: result.Repaired,
+		Damaged:  result.Damaged,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactVerify(ctx context.Context, name string, opts entities.ArtifactVerifyOptions) (*entities.ArtifactVerifyReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	copyOptions, err := artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	copyOptions.Username = opts.Username
+	copyOptions.Password = opts.Password
+	copyOptions.SignaturePolicyPath = opts.SignaturePolicyPath
+	result, err := artStore.VerifySignatures(ctx, name, copyOptions, types.SignatureVerifyOptions{
+		Reference:    opts.Reference,
+		SigstoreKeys: opts.SigstoreKeys,
+		GPGKeys:      opts.GPGKeys,
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &entities.ArtifactVerifyReport{SignatureVerifyResult: *result}, nil
+}
 
-	return artStore.Extract(ctx, name, target, extractOpt)
+func (ir *ImageEngine) ArtifactGC(ctx context.Context, opts entities.ArtifactGCOptions) (*entities.ArtifactGCReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	result, err := artStore.GarbageCollect(ctx, &types.GCOptions{DryRun: opts.DryRun})
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactGCReport{
+		Blobs:         result.Blobs,
+		ReclaimedSize: result.ReclaimedSize,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactDiff(ctx context.Context, first string, second string, opts entities.ArtifactDiffOptions) (*entities.ArtifactDiffReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	oldArtifact, err := artStore.Inspect(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	var newArtifact *libartifact.Artifact
+	if opts.Remote {
+		var copyOptions libimage.CopyOptions
+		copyOptions, err = artifactRegistryCopyOptions(opts.AuthFilePath, opts.CertDirPath, opts.InsecureSkipTLSVerify)
+		if err != nil {
+			return nil, err
+		}
+		newArtifact, err = artStore.InspectRemote(ctx, second, copyOptions)
+	} else {
+		newArtifact, err = artStore.Inspect(ctx, second)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result, err := libartifact.Diff(oldArtifact, newArtifact)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactDiffReport{DiffResult: *result}, nil
+}
+
+func (ir *ImageEngine) ArtifactTag(ctx context.Context, name string, newName string, opts entities.ArtifactTagOptions) (*entities.ArtifactTagReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	tagOpts := &types.TagOptions{
+		Force:     opts.Force,
+		RemoveOld: opts.RemoveOld,
+	}
+	result, err := artStore.Tag(ctx, name, newName, tagOpts)
+	if err != nil {
+		return nil, err
+	}
+	if result.OldName != result.NewName {
+		ir.Libpod.NewArtifactEvent(events.Tag, result.NewName, result.Digest)
+		if opts.RemoveOld && result.OldName != "" {
+			ir.Libpod.NewArtifactEvent(events.Untag, result.OldName, result.Digest)
+		}
+	}
+	return &entities.ArtifactTagReport{
+		ArtifactDigest: &result.Digest,
+		OldName:        result.OldName,
+		NewName:        result.NewName,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactMount(ctx context.Context, name string, _ entities.ArtifactMountOptions) (*entities.ArtifactMountReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	path, err := artStore.Mount(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactMountReport{
+		Name: name,
+		Path: path,
+	}, nil
+}
+
+func (ir *ImageEngine) ArtifactUnmount(ctx context.Context, name string, _ entities.ArtifactUnmountOptions) (*entities.ArtifactUnmountReport, error) {
+	artStore, err := ir.Libpod.ArtifactStore()
+	if err != nil {
+		return nil, err
+	}
+	path, err := artStore.Unmount(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ArtifactUnmountReport{
+		Name: name,
+		Path: path,
+	}, nil
 }
@@ -9,23 +9,27 @@ import (
 
 // TODO For now, no remote support has been added. We need the API to firm up first.
 
-func (ir *ImageEngine) ArtifactExtract(ctx context.Context, name string, target string, opts *entities.ArtifactExtractOptions) error {
-	return fmt.Errorf("not implemented")
+func (ir *ImageEngine) ArtifactExtract(ctx context.Context, name string, target string, opts *entities.ArtifactExtractOptions) (*entities.ArtifactExtractReport, error) {
+	return nil, fmt.Errorf("not implemented")
 }
 
-func (ir *ImageEngine) ArtifactInspect(ctx context.Context, name string, opts entities.ArtifactInspectOptions) (*entities.ArtifactInspectReport, error) {
-	return nil, fmt.Errorf("not implemented")
+func (ir *ImageEngine) ArtifactInspect(ctx context.Context, namesOrDigests []string, opts entities.ArtifactInspectOptions) ([]*entities.ArtifactInspectReport, []error, error) {
+	return nil, nil, fmt.Errorf("not implemented")
 }
 
 func (ir *ImageEngine) ArtifactList(ctx context.Context, opts entities.ArtifactListOptions) ([]*entities.ArtifactListReport, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (ir *ImageEngine) ArtifactPrune(ctx context.Context, opts entities.ArtifactPruneOptions) (*entities.ArtifactPruneReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (ir *ImageEngine) ArtifactPull(ctx context.Context, name string, opts entities.ArtifactPullOptions) (*entities.ArtifactPullReport, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (ir *ImageEngine) ArtifactRm(ctx context.Context, name string, opts entities.ArtifactRemoveOptions) (*entities.ArtifactRemoveReport, error) {
+func (ir *ImageEngine) ArtifactRm(ctx context.Context, namesOrDigests []string, opts entities.ArtifactRemoveOptions) (*entities.ArtifactRemoveReport, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
@@ -33,6 +37,54 @@ func (ir *ImageEngine) ArtifactPush(ctx context.Context, name string, opts entit
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (ir *ImageEngine) ArtifactPushIndex(ctx context.Context, name string, opts entities.ArtifactIndexOptions) (*entities.ArtifactIndexReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (ir *ImageEngine) ArtifactAdd(ctx context.Context, name string, paths []string, opts *entities.ArtifactAddOptions) (*entities.ArtifactAddReport, error) {
 	return nil, fmt.Errorf("not implemented")
 }
+
+func (ir *ImageEngine) ArtifactMount(ctx context.Context, name string, opts entities.ArtifactMountOptions) (*entities.ArtifactMountReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactUnmount(ctx context.Context, name string, opts entities.ArtifactUnmountOptions) (*entities.ArtifactUnmountReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactDiff(ctx context.Context, first string, second string, opts entities.ArtifactDiffOptions) (*entities.ArtifactDiffReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactCheck(ctx context.Context, opts entities.ArtifactCheckOptions) (*entities.ArtifactCheckReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactVerify(ctx context.Context, name string, opts entities.ArtifactVerifyOptions) (*entities.ArtifactVerifyReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactTag(ctx context.Context, name string, newName string, opts entities.ArtifactTagOptions) (*entities.ArtifactTagReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactUpdate(ctx context.Context, name string, opts entities.ArtifactUpdateOptions) (*entities.ArtifactUpdateReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactExport(ctx context.Context, name string, path string, opts entities.ArtifactExportOptions) (*entities.ArtifactExportReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactImport(ctx context.Context, path string, name string, opts entities.ArtifactImportOptions) (*entities.ArtifactImportReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactCopy(ctx context.Context, source string, destination string, opts entities.ArtifactCopyOptions) (*entities.ArtifactCopyReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ir *ImageEngine) ArtifactGC(ctx context.Context, opts entities.ArtifactGCOptions) (*entities.ArtifactGCReport, error) {
+	return nil, fmt.Errorf("not implemented")
+}
@@ -3,11 +3,14 @@ package libartifact
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/containers/image/v5/manifest"
 	"github.com/containers/podman/v5/pkg/libartifact/types"
 	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 type Artifact struct {
@@ -15,6 +18,15 @@ type Artifact struct {
 	// In a valid artifact the Manifest is guaranteed to not be nil.
 	Manifest *manifest.OCI1
 	Name     string
+	// IndexAnnotations are the annotations of the descriptor of the
+	// manifest in the index of the local store, including the
+	// org.opencontainers.image.ref.name annotation holding the name.
+	IndexAnnotations map[string]string `json:",omitempty"`
+	// storedTime is when the manifest was written to the local store.
+	storedTime time.Time
+	// storedDigest is the digest of the manifest in the index of the
+	// local store.
+	storedDigest digest.Digest
 }
 
 // TotalSizeBytes returns the total bytes of the all the artifact layers
@@ -26,6 +38,58 @@ func (a *Artifact) TotalSizeBytes() int64 {
 	return s
 }
 
+// CreatedTime returns the creation time recorded in the
+// org.opencontainers.image.created annotation of the manifest.  The boolean
+// is false if the annotation is missing or not a valid RFC 3339 timestamp.
+func (a *Artifact) CreatedTime() (time.Time, bool) {
+	value, ok := a.Manifest.Annotations[specV1.AnnotationCreated]
+	if !ok {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// Description returns the human-readable description recorded in the
+// org.opencontainers.image.description annotation of the manifest, if any.
+func (a *Artifact) Description() string {
+	return a.Manifest.Annotations[specV1.AnnotationDescription]
+}
+
+// StoredTime returns when the manifest of the artifact was written to the
+// local store, the zero time if it is not known.
+func (a *Artifact) StoredTime() time.Time {
+	return a.storedTime
+}
+
+// SetStoredTime sets the time the manifest was written to the local store.
+func (a *Artifact) SetStoredTime(t time.Time) {
+	a.storedTime = t
+}
+
+// StoredDigest returns the digest of the manifest as recorded in the index of
+// the local store, which also tells the algorithm the store uses for it.  If
+// it is not known, the digest is computed with the canonical algorithm.
+func (a *Artifact) StoredDigest() (digest.Digest, error) {
+	if a.storedDigest != "" {
+		return a.storedDigest, nil
+	}
+	artifactDigest, err := a.GetDigest()
+	if err != nil {
+		return "", err
+	}
+	return *artifactDigest, nil
+}
+
+// SetStoredDigest sets the digest of the manifest in the index of the local
+// store.
+func (a *Artifact) SetStoredDigest(d digest.Digest) {
+	a.storedDigest = d
+}
+
 // GetName returns the "name" or "image reference" of the artifact
 func (a *Artifact) GetName() (string, error) {
 	if a.Name != "" {
@@ -45,16 +109,30 @@ func (a *Artifact) SetName(name string) {
 }
 
 func (a *Artifact) GetDigest() (*digest.Digest, error) {
+	return a.GetDigestWithAlgorithm(digest.Canonical)
+}
+
+// GetDigestWithAlgorithm returns the digest of the artifact's manifest
+// computed with the given algorithm.
+func (a *Artifact) GetDigestWithAlgorithm(algorithm digest.Algorithm) (*digest.Digest, error) {
+	if !algorithm.Available() {
+		return nil, fmt.Errorf("digest algorithm %q is not available", algorithm)
+	}
 	b, err := json.Marshal(a.Manifest)
 	if err != nil {
 		return nil, err
 	}
-	artifactDigest := digest.FromBytes(b)
+	artifactDigest := algorithm.FromBytes(b)
 	return &artifactDigest, nil
 }
 
 type ArtifactList []*Artifact
 
+// ArtifactFilter is a function to determine whether an artifact is included
+// in command output. Artifacts to be outputted are tested using the function.
+// A true return will include the artifact, a false return will exclude it.
+type ArtifactFilter func(*Artifact) bool
+
 // GetByNameOrDigest returns an artifact, if present, by a given name
 // Returns an error if not found
 func (al ArtifactList) GetByNameOrDigest(nameOrDigest string) (*Artifact, bool, error) {
@@ -77,3 +155,39 @@ func (al ArtifactList) GetByNameOrDigest(nameOrDigest string) (*Artifact, bool,
 	}
 	return nil, false, fmt.Errorf("%s: %w", nameOrDigest, types.ErrArtifactNotExist)
 }
+
+// IsNamePattern returns whether the given name contains shell glob
+// characters and is thus meant to be used with GetByNamePattern.
+func IsNamePattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// GetByNamePattern returns all artifacts whose name matches the shell glob
+// pattern, see path.Match. The pattern is matched against the full name and
+// against the name without its registry, so `models/llama-*` matches
+// `quay.io/models/llama-2:latest`. An error is returned if nothing matches.
+func (al ArtifactList) GetByNamePattern(pattern string) (ArtifactList, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid artifact name pattern %q: %w", pattern, err)
+	}
+	var matches ArtifactList
+	for _, artifact := range al {
+		if artifact.Name == "" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, artifact.Name); ok {
+			matches = append(matches, artifact)
+			continue
+		}
+		registry, remainder, found := strings.Cut(artifact.Name, "/")
+		if found && (strings.ContainsAny(registry, ".:") || registry == "localhost") {
+			if ok, _ := path.Match(pattern, remainder); ok {
+				matches = append(matches, artifact)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no artifacts matched %q: %w", pattern, types.ErrArtifactNotExist)
+	}
+	return matches, nil
+}
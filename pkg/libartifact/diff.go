@@ -0,0 +1,130 @@
+package libartifact
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/containers/podman/v5/pkg/libartifact/types"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Diff compares the artifact a to the artifact b.  Added entries are only in
+// b, removed ones only in a.  Blobs are matched by their title, or by their
+// digest if they have none, so a blob whose content changed keeps its title
+// and is reported as changed.  If several blobs share a title they are
+// matched in the order of the manifests.
+func Diff(a, b *Artifact) (*types.DiffResult, error) {
+	aDigest, err := a.GetDigest()
+	if err != nil {
+		return nil, err
+	}
+	bDigest, err := b.GetDigest()
+	if err != nil {
+		return nil, err
+	}
+	result := &types.DiffResult{OldDigest: *aDigest, NewDigest: *bDigest}
+	if *aDigest == *bDigest {
+		return result, nil
+	}
+
+	fields := []struct {
+		key      string
+		old, new string
+	}{
+		{"artifactType", a.Manifest.ArtifactType, b.Manifest.ArtifactType},
+		{"config.mediaType", a.Manifest.Config.MediaType, b.Manifest.Config.MediaType},
+		{"config.digest", a.Manifest.Config.Digest.String(), b.Manifest.Config.Digest.String()},
+	}
+	for _, f := range fields {
+		if diff := diffValue(f.key, f.old, f.new); diff != nil {
+			result.Fields = append(result.Fields, *diff)
+		}
+	}
+	result.Annotations = diffAnnotations(a.Manifest.Annotations, b.Manifest.Annotations)
+
+	// unmatched are the layers of a not matched by a layer of b yet.
+	unmatched := slices.Clone(a.Manifest.Layers)
+	for _, l := range b.Manifest.Layers {
+		key := blobDiffKey(l)
+		i := slices.IndexFunc(unmatched, func(o specV1.Descriptor) bool { return blobDiffKey(o) == key })
+		if i < 0 {
+			result.Blobs = append(result.Blobs, types.BlobDiff{
+				Kind:      types.DiffAdded,
+				Title:     l.Annotations[specV1.AnnotationTitle],
+				NewDigest: l.Digest,
+			})
+			continue
+		}
+		old := unmatched[i]
+		unmatched = slices.Delete(unmatched, i, i+1)
+		blob := types.BlobDiff{
+			Kind:        types.DiffChanged,
+			Title:       l.Annotations[specV1.AnnotationTitle],
+			OldDigest:   old.Digest,
+			NewDigest:   l.Digest,
+			MediaType:   diffValue("mediaType", old.MediaType, l.MediaType),
+			Annotations: diffAnnotations(withoutTitle(old.Annotations), withoutTitle(l.Annotations)),
+		}
+		if blob.OldDigest != blob.NewDigest || blob.MediaType != nil || len(blob.Annotations) > 0 {
+			result.Blobs = append(result.Blobs, blob)
+		}
+	}
+	for _, l := range unmatched {
+		result.Blobs = append(result.Blobs, types.BlobDiff{
+			Kind:      types.DiffRemoved,
+			Title:     l.Annotations[specV1.AnnotationTitle],
+			OldDigest: l.Digest,
+		})
+	}
+	return result, nil
+}
+
+// blobDiffKey returns the key blobs are matched by in a diff.
+func blobDiffKey(l specV1.Descriptor) string {
+	if title := l.Annotations[specV1.AnnotationTitle]; title != "" {
+		return "title:" + title
+	}
+	return "digest:" + l.Digest.String()
+}
+
+// withoutTitle returns the annotations apart from the title, which blobs are
+// matched by.
+func withoutTitle(annotations map[string]string) map[string]string {
+	annotations = maps.Clone(annotations)
+	delete(annotations, specV1.AnnotationTitle)
+	return annotations
+}
+
+// diffValue returns the difference of the value named key, nil if it did not
+// change.
+func diffValue(key, oldValue, newValue string) *types.ValueDiff {
+	switch {
+	case oldValue == newValue:
+		return nil
+	case oldValue == "":
+		return &types.ValueDiff{Kind: types.DiffAdded, Key: key, NewValue: newValue}
+	case newValue == "":
+		return &types.ValueDiff{Kind: types.DiffRemoved, Key: key, OldValue: oldValue}
+	}
+	return &types.ValueDiff{Kind: types.DiffChanged, Key: key, OldValue: oldValue, NewValue: newValue}
+}
+
+// diffAnnotations returns the differences between the annotations oldValues
+// and newValues sorted by key.  An annotation with an empty value is not told
+// apart from a missing one.
+func diffAnnotations(oldValues, newValues map[string]string) []types.ValueDiff {
+	keys := make(map[string]struct{}, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys[key] = struct{}{}
+	}
+	for key := range newValues {
+		keys[key] = struct{}{}
+	}
+	var diffs []types.ValueDiff
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if diff := diffValue(key, oldValues[key], newValues[key]); diff != nil {
+			diffs = append(diffs, *diff)
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,88 @@
+package libartifact
+
+import (
+	"encoding/json"
+	"maps"
+
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// FingerprintVersion is the version of the canonical form Fingerprint hashes.
+// It is part of the canonical form, so fingerprints of different versions
+// never match.  The form of a version never changes.
+const FingerprintVersion = 1
+
+// fingerprintDescriptor is the canonical form of a descriptor of the manifest.
+type fingerprintDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      digest.Digest     `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// fingerprintForm is the canonical form of an artifact hashed by Fingerprint.
+type fingerprintForm struct {
+	Version      int                     `json:"fingerprintVersion"`
+	ArtifactType string                  `json:"artifactType,omitempty"`
+	Config       fingerprintDescriptor   `json:"config"`
+	Layers       []fingerprintDescriptor `json:"layers"`
+	Subject      digest.Digest           `json:"subject,omitempty"`
+	Annotations  map[string]string       `json:"annotations,omitempty"`
+}
+
+// Fingerprint returns a digest of the content of the artifact which, unlike
+// the manifest digest, does not depend on how the manifest is encoded or when
+// the artifact was created.  Artifacts with the same fingerprint have the same
+// type, config, blobs and annotations.  The fingerprint of an artifact is the
+// same for every version of podman.
+//
+// It is the sha256 digest of the JSON encoding, without whitespace and with
+// the keys of the annotations sorted, of an object with these members:
+//
+//   - fingerprintVersion: FingerprintVersion
+//   - artifactType: the artifact type of the manifest, omitted if empty
+//   - config: the mediaType, digest and size of the config descriptor
+//   - layers: the mediaType, digest, size and annotations, omitted if
+//     empty, of each layer in manifest order
+//   - subject: the digest of the subject, omitted if there is none
+//   - annotations: the annotations of the manifest without
+//     org.opencontainers.image.created, omitted if empty
+//
+// Everything else, e.g. the media type of the manifest, the order of the
+// annotations in it, data embedded in a descriptor, the name of the artifact
+// and the annotations of its index entry, does not change the fingerprint.
+func (a *Artifact) Fingerprint() (digest.Digest, error) {
+	form := fingerprintForm{
+		Version:      FingerprintVersion,
+		ArtifactType: a.Manifest.ArtifactType,
+		Config:       newFingerprintDescriptor(a.Manifest.Config),
+		Layers:       make([]fingerprintDescriptor, 0, len(a.Manifest.Layers)),
+		Annotations:  maps.Clone(a.Manifest.Annotations),
+	}
+	// The config carries no annotations, the empty config of podman
+	// artifact add would otherwise differ from the one of other tools.
+	form.Config.Annotations = nil
+	for _, layer := range a.Manifest.Layers {
+		form.Layers = append(form.Layers, newFingerprintDescriptor(layer))
+	}
+	if a.Manifest.Subject != nil {
+		form.Subject = a.Manifest.Subject.Digest
+	}
+	delete(form.Annotations, specV1.AnnotationCreated)
+
+	data, err := json.Marshal(form)
+	if err != nil {
+		return "", err
+	}
+	return digest.SHA256.FromBytes(data), nil
+}
+
+func newFingerprintDescriptor(desc specV1.Descriptor) fingerprintDescriptor {
+	return fingerprintDescriptor{
+		MediaType:   desc.MediaType,
+		Digest:      desc.Digest,
+		Size:        desc.Size,
+		Annotations: desc.Annotations,
+	}
+}
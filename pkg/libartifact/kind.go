@@ -0,0 +1,74 @@
+package libartifact
+
+import (
+	"slices"
+	"strings"
+
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// KindGeneric is the kind of artifacts with neither an artifact type
+	// nor a config, like the ones podman artifact add creates by default.
+	KindGeneric = "generic"
+	// KindUnknown is the kind of artifacts whose type is not in the
+	// table of known types.
+	KindUnknown = "unknown"
+)
+
+// artifactKinds maps the artifact types and config media types used by
+// common tools to the kind of the artifacts they describe.
+var artifactKinds = map[string]string{
+	// Helm charts
+	"application/vnd.cncf.helm.config.v1+json": "helm-chart",
+	// Software bills of materials
+	"application/spdx+json":          "sbom",
+	"application/vnd.cyclonedx+json": "sbom",
+	"application/vnd.cyclonedx+xml":  "sbom",
+	"application/vnd.syft+json":      "sbom",
+	// Signatures and attestations
+	"application/vnd.cncf.notary.signature":            "signature",
+	"application/vnd.dev.cosign.artifact.sig.v1+json":  "signature",
+	"application/vnd.dev.cosign.simplesigning.v1+json": "signature",
+	"application/vnd.dev.sigstore.bundle.v0.3+json":    "signature",
+	"application/vnd.dsse.envelope.v1+json":            "attestation",
+	"application/vnd.in-toto+json":                     "attestation",
+	// Models
+	"application/vnd.cncf.model.config.v1+json":        "model",
+	"application/vnd.cncf.model.manifest.v1+json":      "model",
+	"application/vnd.docker.ai.model.config.v0.1+json": "model",
+	// WebAssembly modules
+	"application/vnd.wasm.config.v0+json": "wasm",
+}
+
+// Kinds returns the sorted kinds Kind can return.
+func Kinds() []string {
+	kinds := []string{KindGeneric, KindUnknown}
+	for _, kind := range artifactKinds {
+		if !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// Kind returns the kind of the artifact, e.g. helm-chart, sbom or model,
+// derived from its artifact type or, without one, the media type of its
+// config.  It is KindGeneric for an artifact with neither and KindUnknown for
+// types not in the table of known types.
+func (a *Artifact) Kind() string {
+	mediaType := a.Manifest.ArtifactType
+	if mediaType == "" {
+		mediaType = a.Manifest.Config.MediaType
+	}
+	if mediaType == "" || mediaType == specV1.MediaTypeEmptyJSON {
+		return KindGeneric
+	}
+	// Parameters like a charset do not change the kind.
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	if kind, ok := artifactKinds[strings.TrimSpace(mediaType)]; ok {
+		return kind
+	}
+	return KindUnknown
+}
@@ -0,0 +1,139 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/containers/podman/v5/pkg/libartifact"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// ArtifactsWithBlob returns the artifacts in the store whose manifest
+// references the blob with the given digest, either as the config, as one of
+// the layers or as the manifest itself.  An artifact with several names is
+// listed once per name.  The store is locked, so the result does not miss
+// artifacts which are being added concurrently.
+func (as ArtifactStore) ArtifactsWithBlob(ctx context.Context, blobDigest digest.Digest) (libartifact.ArtifactList, error) {
+	if err := blobDigest.Validate(); err != nil {
+		return nil, err
+	}
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return artifactsWithBlob(artifacts, blobDigest)
+}
+
+// artifactsWithBlob returns those of the artifacts whose manifest references
+// the blob with the given digest.
+func artifactsWithBlob(artifacts libartifact.ArtifactList, blobDigest digest.Digest) (libartifact.ArtifactList, error) {
+	var al libartifact.ArtifactList
+	for _, arty := range artifacts {
+		artifactDigest, err := arty.StoredDigest()
+		if err != nil {
+			return nil, err
+		}
+		if artifactDigest == blobDigest || arty.Manifest.Config.Digest == blobDigest ||
+			slices.ContainsFunc(arty.Manifest.Layers, func(l specV1.Descriptor) bool { return l.Digest == blobDigest }) {
+			al = append(al, arty)
+		}
+	}
+	return al, nil
+}
+
+// deleteManifests removes the entries selected by match from the index of the
+// store and then deletes the manifests and blobs of the removed entries which
+// no remaining artifact references.  The index is written first, so an
+// interrupted removal leaves unused blobs behind rather than artifacts with
+// missing blobs.  The caller must hold the store lock.
+func (as ArtifactStore) deleteManifests(ctx context.Context, match func(specV1.Descriptor) bool) error {
+	index, err := as.readIndex()
+	if err != nil {
+		return err
+	}
+	var removed []specV1.Descriptor
+	index.Manifests = slices.DeleteFunc(index.Manifests, func(desc specV1.Descriptor) bool {
+		if match(desc) {
+			removed = append(removed, desc)
+			return true
+		}
+		return false
+	})
+	if len(removed) == 0 {
+		return nil
+	}
+
+	// The blobs to delete are read from the manifests before the index no
+	// longer references them.
+	var candidates []digest.Digest
+	for _, desc := range removed {
+		blobs, err := as.manifestBlobs(desc.Digest)
+		if err != nil {
+			return err
+		}
+		for _, d := range blobs {
+			if !slices.Contains(candidates, d) {
+				candidates = append(candidates, d)
+			}
+		}
+	}
+
+	if err := as.writeIndex(index); err != nil {
+		return err
+	}
+
+	remaining, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, d := range candidates {
+		users, err := artifactsWithBlob(remaining, d)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			logrus.Debugf("Keeping blob %s, it is used by %d other artifacts", d, len(users))
+			continue
+		}
+		logrus.Debugf("Deleting blob %s", d)
+		if err := os.Remove(as.blobPath(d)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestBlobs returns the digest of the manifest with the given digest in
+// the store together with the digests of its config and layers.
+func (as ArtifactStore) manifestBlobs(manifestDigest digest.Digest) ([]digest.Digest, error) {
+	if err := manifestDigest.Validate(); err != nil {
+		return nil, err
+	}
+	rawData, err := os.ReadFile(as.blobPath(manifestDigest))
+	if err != nil {
+		return nil, err
+	}
+	var mani specV1.Manifest
+	if err := json.Unmarshal(rawData, &mani); err != nil {
+		return nil, err
+	}
+	blobs := make([]digest.Digest, 0, len(mani.Layers)+2)
+	blobs = append(blobs, manifestDigest, mani.Config.Digest)
+	for _, l := range mani.Layers {
+		blobs = append(blobs, l.Digest)
+	}
+	for _, d := range blobs[1:] {
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
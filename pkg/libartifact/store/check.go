@@ -0,0 +1,258 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/ioutils"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// checkedBlob is a blob of the store found by a check.
+type checkedBlob struct {
+	desc       specV1.Descriptor
+	isManifest bool
+	// names are the names of the artifacts using the blob, the registries
+	// it can be fetched from again.
+	names  []string
+	result libartTypes.CheckedBlob
+	err    error
+}
+
+// Check re-hashes the manifests and blobs of all artifacts in the store and
+// reports the ones whose content does not match their digest and the missing
+// ones.  The blobs a partial pull skipped are reported as missing.  The
+// layers of a corrupt manifest are unknown, so they are not checked.
+//
+// With checkOpts.Repair the damaged blobs are fetched again from the
+// registries the artifacts using them are named after, accessed with opts.
+// A blob is only replaced by fetched content which matches its digest, blobs
+// which cannot be fetched are left untouched.
+func (as ArtifactStore) Check(ctx context.Context, opts libimage.CopyOptions, checkOpts libartTypes.CheckOptions) (*libartTypes.CheckResult, error) {
+	blobs, err := as.checkBlobs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &libartTypes.CheckResult{}
+	var damaged []*checkedBlob
+	for _, b := range blobs {
+		switch {
+		case b.err == nil:
+			result.Healthy++
+			continue
+		case b.result.Missing:
+			result.Missing++
+		default:
+			result.Corrupt++
+		}
+		logrus.Debugf("Blob %s of artifact %s is damaged: %v", b.desc.Digest, b.result.Artifacts[0], b.err)
+		damaged = append(damaged, b)
+	}
+
+	if checkOpts.Repair && len(damaged) > 0 {
+		if err := as.repairBlobs(ctx, as.registrySystemContext(&opts), damaged, result); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range damaged {
+		result.Damaged = append(result.Damaged, b.result)
+	}
+	return result, nil
+}
+
+// checkBlobs returns the manifests, configs and layers of all artifacts in the
+// store, each checked once, in the order of the index.
+func (as ArtifactStore) checkBlobs() ([]*checkedBlob, error) {
+	as.lock.RLock()
+	defer as.lock.Unlock()
+
+	index, err := as.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	var blobs []*checkedBlob
+	byDigest := map[digest.Digest]*checkedBlob{}
+	// note records that the artifact uses the blob and checks it when it
+	// is found the first time.
+	note := func(desc specV1.Descriptor, isManifest bool, artifact, name string) (*checkedBlob, error) {
+		if err := desc.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("artifact %s: %w", artifact, err)
+		}
+		b, ok := byDigest[desc.Digest]
+		if !ok {
+			b = &checkedBlob{desc: desc, isManifest: isManifest}
+			b.result.Digest = desc.Digest
+			b.err = verifyBlobFile(as.blobPath(desc.Digest), desc.Digest)
+			b.result.Missing = errors.Is(b.err, fs.ErrNotExist)
+			byDigest[desc.Digest] = b
+			blobs = append(blobs, b)
+		}
+		if !slices.Contains(b.result.Artifacts, artifact) {
+			b.result.Artifacts = append(b.result.Artifacts, artifact)
+		}
+		if name != "" && !slices.Contains(b.names, name) {
+			b.names = append(b.names, name)
+		}
+		return b, nil
+	}
+
+	for _, desc := range index.Manifests {
+		name := desc.Annotations[specV1.AnnotationRefName]
+		artifact := name
+		if artifact == "" {
+			artifact = desc.Digest.String()
+		}
+		b, err := note(desc, true, artifact, name)
+		if err != nil {
+			return nil, err
+		}
+		if b.err != nil {
+			continue
+		}
+		rawData, err := os.ReadFile(as.blobPath(desc.Digest))
+		if err != nil {
+			return nil, err
+		}
+		var mani specV1.Manifest
+		if err := json.Unmarshal(rawData, &mani); err != nil {
+			return nil, fmt.Errorf("artifact %s: %w", artifact, err)
+		}
+		for _, l := range append([]specV1.Descriptor{mani.Config}, mani.Layers...) {
+			if _, err := note(l, false, artifact, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return blobs, nil
+}
+
+// verifyBlobFile returns an error if the file at path does not exist or its
+// content does not match the expected digest.
+func verifyBlobFile(path string, expected digest.Digest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	verifier := expected.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("%w: the content in the store does not match %s", libartTypes.ErrBlobDigestMismatch, expected)
+	}
+	return nil
+}
+
+// repairBlobs fetches the damaged blobs to a download directory and then
+// replaces them in the store, so only replacing them holds the store lock.
+func (as ArtifactStore) repairBlobs(ctx context.Context, sys *types.SystemContext, damaged []*checkedBlob, result *libartTypes.CheckResult) error {
+	dir, err := as.newDownloadDir()
+	if err != nil {
+		return err
+	}
+	defer removeDownloadDir(dir)
+	var fetched []*checkedBlob
+	for _, b := range damaged {
+		if err := repairBlob(ctx, sys, dir, b); err != nil {
+			b.result.RepairError = err.Error()
+			continue
+		}
+		fetched = append(fetched, b)
+	}
+	if len(fetched) == 0 {
+		return nil
+	}
+
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	if err := as.storeDownloadedBlobs(dir, true); err != nil {
+		return err
+	}
+	for _, b := range fetched {
+		b.result.Repaired = true
+		result.Repaired++
+	}
+	return nil
+}
+
+// repairBlob fetches the blob from the registry of one of the artifacts using
+// it to the download directory dir.
+func repairBlob(ctx context.Context, sys *types.SystemContext, dir string, b *checkedBlob) error {
+	if len(b.names) == 0 {
+		return errors.New("the blob is only used by unnamed artifacts, its registry is unknown")
+	}
+	var errs []error
+	for _, name := range b.names {
+		err := fetchDamagedBlob(ctx, sys, dir, name, b)
+		if err == nil {
+			logrus.Debugf("Repaired blob %s from %s", b.desc.Digest, name)
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("fetching from %s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// fetchDamagedBlob fetches the blob from the registry the artifact name
+// refers to into the download directory dir.
+func fetchDamagedBlob(ctx context.Context, sys *types.SystemContext, dir, name string, b *checkedBlob) error {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return err
+	}
+	srcRef, err := docker.NewReference(reference.TagNameOnly(named))
+	if err != nil {
+		return err
+	}
+	imgSrc, err := srcRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer imgSrc.Close()
+
+	if b.isManifest {
+		rawManifest, _, err := imgSrc.GetManifest(ctx, &b.desc.Digest)
+		if err != nil {
+			return err
+		}
+		if actual := b.desc.Digest.Algorithm().FromBytes(rawManifest); actual != b.desc.Digest {
+			return fmt.Errorf("%w: the fetched manifest has digest %s", libartTypes.ErrBlobDigestMismatch, actual)
+		}
+		path := downloadedBlobPath(dir, b.desc.Digest)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return ioutils.AtomicWriteFile(path, rawManifest, 0o644)
+	}
+
+	destRef, err := layout.NewReference(dir, "")
+	if err != nil {
+		return err
+	}
+	imageDest, err := destRef.NewImageDestination(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer imageDest.Close()
+	return fetchBlob(ctx, imgSrc, imageDest, b.desc)
+}
@@ -0,0 +1,70 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/common/libimage"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeFiles returns the content of each file in the store by its path.
+func storeFiles(t *testing.T, as *ArtifactStore) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(as.storePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[path] = string(content)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	addTestArtifact(t, as, "localhost/test/check",
+		testBlob{name: "corrupt", content: "corrupt blob"},
+		testBlob{name: "missing", content: "missing blob"},
+		testBlob{name: "healthy", content: "healthy blob"})
+
+	result, err := as.Check(ctx, libimage.CopyOptions{}, libartTypes.CheckOptions{})
+	require.NoError(t, err)
+	// The manifest, the config and the three layers.
+	assert.Equal(t, &libartTypes.CheckResult{Healthy: 5}, result)
+
+	corruptDigest := digest.FromString("corrupt blob")
+	missingDigest := digest.FromString("missing blob")
+	require.NoError(t, os.WriteFile(as.blobPath(corruptDigest), []byte("tampered blob"), 0o644))
+	require.NoError(t, os.Remove(as.blobPath(missingDigest)))
+	before := storeFiles(t, as)
+
+	result, err = as.Check(ctx, libimage.CopyOptions{}, libartTypes.CheckOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Healthy)
+	assert.Equal(t, 1, result.Corrupt)
+	assert.Equal(t, 1, result.Missing)
+	assert.Zero(t, result.Repaired)
+	assert.Equal(t, []libartTypes.CheckedBlob{
+		{Digest: corruptDigest, Artifacts: []string{"localhost/test/check"}},
+		{Digest: missingDigest, Artifacts: []string{"localhost/test/check"}, Missing: true},
+	}, result.Damaged)
+
+	// Checking never deletes, or otherwise changes, data on its own.
+	assert.Equal(t, before, storeFiles(t, as))
+}
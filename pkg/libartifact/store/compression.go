@@ -0,0 +1,242 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/containers/image/v5/types"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// pushCompressed compresses the blobs of the artifact src with algorithm into a
+// temporary OCI layout and calls push with the reference of that layout.  The containers/image
+// copy never changes the compression of artifact blobs by itself, as it only
+// does so for image layers.
+//
+// Blobs which are already compressed, with any algorithm, are pushed unchanged
+// so that opaque data is not compressed twice, unless force recompresses them,
+// see copyBlob.  The number of recompressed blobs is returned.
+func (as ArtifactStore) pushCompressed(ctx context.Context, src string, algorithm compression.Algorithm, level *int, force bool, push func(srcRef types.ImageReference) error) (int, error) {
+	// Use the store directory rather than the system temporary directory,
+	// artifacts can be large and /tmp is often a tmpfs.
+	tmpDir, err := os.MkdirTemp(as.storePath, ".push-")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("Removing temporary artifact push directory %s: %v", tmpDir, err)
+		}
+	}()
+
+	srcRef, err := layout.NewReference(as.storePath, src)
+	if err != nil {
+		return 0, err
+	}
+	imgSrc, err := srcRef.NewImageSource(ctx, as.SystemContext)
+	if err != nil {
+		return 0, err
+	}
+	defer imgSrc.Close()
+
+	mani, err := getManifest(ctx, imgSrc)
+	if err != nil {
+		return 0, err
+	}
+	artifactManifest := mani.Manifest
+
+	tmpRef, err := layout.NewReference(tmpDir, src)
+	if err != nil {
+		return 0, err
+	}
+	imageDest, err := tmpRef.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return 0, err
+	}
+	defer imageDest.Close()
+
+	if _, _, err := copyBlob(ctx, imgSrc, imageDest, artifactManifest.Config, true, nil); err != nil {
+		return 0, err
+	}
+	blobCompression := &blobCompression{algorithm: algorithm, level: level, force: force}
+	recompressed := 0
+	for i, layer := range artifactManifest.Layers {
+		newLayer, wasRecompressed, err := copyBlob(ctx, imgSrc, imageDest, layer, false, blobCompression)
+		if err != nil {
+			return 0, err
+		}
+		if wasRecompressed {
+			recompressed++
+		}
+		artifactManifest.Layers[i] = newLayer
+	}
+
+	rawData, err := json.Marshal(artifactManifest)
+	if err != nil {
+		return 0, err
+	}
+	if err := imageDest.PutManifest(ctx, rawData, nil); err != nil {
+		return 0, err
+	}
+	if err := imageDest.Commit(ctx, newUnparsedArtifactImage(tmpRef, artifactManifest)); err != nil {
+		return 0, err
+	}
+	return recompressed, push(tmpRef)
+}
+
+// blobCompression is the compression copyBlob applies to a blob.
+type blobCompression struct {
+	algorithm compression.Algorithm
+	level     *int
+	// force recompresses blobs which are compressed already, unless they
+	// are compressed with algorithm and no level is set.
+	force bool
+}
+
+// copyBlob copies the blob described by desc from imgSrc to imageDest and
+// returns the descriptor of the copy.  If comp is set and the blob is not
+// compressed yet, the blob is compressed and its media type adjusted.  A blob
+// which is compressed already is copied unchanged unless comp.force is set, it
+// is then decompressed and compressed again while it is streamed, and true is
+// returned.
+func copyBlob(ctx context.Context, imgSrc types.ImageSource, imageDest types.ImageDestination, desc specV1.Descriptor, isConfig bool, comp *blobCompression) (specV1.Descriptor, bool, error) {
+	reader, _, err := imgSrc.GetBlob(ctx, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache)
+	if err != nil {
+		return desc, false, err
+	}
+	defer reader.Close()
+
+	if comp == nil {
+		_, err := imageDest.PutBlob(ctx, reader, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache, isConfig)
+		return desc, false, err
+	}
+
+	detected, decompressor, stream, err := compression.DetectCompressionFormat(reader)
+	if err != nil {
+		return desc, false, err
+	}
+	mediaType := desc.MediaType
+	recompress := false
+	if detected.Name() != "" {
+		if !comp.force || (detected.Name() == comp.algorithm.Name() && comp.level == nil) {
+			logrus.Debugf("Blob %s is already compressed with %s, pushing it unchanged", desc.Digest, detected.Name())
+			_, err := imageDest.PutBlob(ctx, stream, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache, isConfig)
+			return desc, false, err
+		}
+		logrus.Debugf("Recompressing blob %s compressed with %s", desc.Digest, detected.Name())
+		decompressed, err := decompressor(stream)
+		if err != nil {
+			return desc, false, err
+		}
+		defer decompressed.Close()
+		stream = decompressed
+		mediaType = uncompressedMediaType(mediaType)
+		recompress = true
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		compressor, err := compression.CompressStream(pipeWriter, comp.algorithm, comp.level)
+		if err != nil {
+			pipeWriter.CloseWithError(err)
+			return
+		}
+		_, err = io.Copy(compressor, stream)
+		if closeErr := compressor.Close(); err == nil {
+			err = closeErr
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	info, err := imageDest.PutBlob(ctx, pipeReader, types.BlobInfo{Size: -1}, none.NoCache, isConfig)
+	// Unblock the compression if the destination stopped reading early.
+	pipeReader.CloseWithError(err)
+	if err != nil {
+		return desc, false, err
+	}
+
+	desc.Digest = info.Digest
+	desc.Size = info.Size
+	if mediaType == "" {
+		// The type of the decompressed content is unknown.
+		desc.MediaType = "application/" + comp.algorithm.Name()
+	} else {
+		desc.MediaType = compressedMediaType(mediaType, comp.algorithm)
+	}
+	return desc, recompress, nil
+}
+
+// compressedMediaType returns the media type of a blob of type mediaType after
+// it was compressed with algorithm.  OCI layer types use their compressed
+// variant, any other type gets the algorithm appended as structured syntax
+// suffix, e.g. "text/plain; charset=utf-8" becomes "text/plain+gzip; charset=utf-8".
+func compressedMediaType(mediaType string, algorithm compression.Algorithm) string {
+	if mediaType == specV1.MediaTypeImageLayer {
+		switch algorithm.Name() {
+		case compression.Gzip.Name():
+			return specV1.MediaTypeImageLayerGzip
+		case compression.Zstd.Name():
+			return specV1.MediaTypeImageLayerZstd
+		}
+	}
+	mediaTypeBase, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return mediaType + "+" + algorithm.Name()
+	}
+	return mime.FormatMediaType(mediaTypeBase+"+"+algorithm.Name(), params)
+}
+
+// uncompressedMediaType returns the media type of the content of a compressed
+// blob of type mediaType, the inverse of compressedMediaType.  It is empty if
+// mediaType is the type of the compressed data itself, e.g. application/gzip,
+// or of an unknown compression as the type of the content is not known then.
+func uncompressedMediaType(mediaType string) string {
+	switch mediaType {
+	case specV1.MediaTypeImageLayerGzip, specV1.MediaTypeImageLayerZstd:
+		return specV1.MediaTypeImageLayer
+	}
+	mediaTypeBase, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return ""
+	}
+	for _, suffix := range []string{"+" + compression.Gzip.Name(), "+" + compression.Zstd.Name()} {
+		if base, ok := strings.CutSuffix(mediaTypeBase, suffix); ok {
+			return mime.FormatMediaType(base, params)
+		}
+	}
+	if mediaTypeDecompressor(mediaType) != nil {
+		return ""
+	}
+	// The media type does not declare the compression of the content.
+	return mediaType
+}
+
+// mediaTypeDecompressor returns the decompressor for blobs of type mediaType
+// if the type declares gzip or zstd compression, either as one of the
+// compressed OCI layer types, as structured syntax suffix or as the type of
+// the compressed data itself.  Other types return nil.
+func mediaTypeDecompressor(mediaType string) compression.DecompressorFunc {
+	mediaTypeBase, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		mediaTypeBase = mediaType
+	}
+	switch {
+	case mediaTypeBase == specV1.MediaTypeImageLayerGzip, mediaTypeBase == "application/gzip",
+		mediaTypeBase == "application/x-gzip", strings.HasSuffix(mediaTypeBase, "+gzip"):
+		return compression.GzipDecompressor
+	case mediaTypeBase == specV1.MediaTypeImageLayerZstd, mediaTypeBase == "application/zstd",
+		strings.HasSuffix(mediaTypeBase, "+zstd"):
+		return compression.ZstdDecompressor
+	}
+	return nil
+}
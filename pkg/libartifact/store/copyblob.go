@@ -0,0 +1,85 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/fileutils"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// StoredBlob returns the blob of the artifact selected by options as a blob
+// to add to another artifact by reference, see ArtifactBlob.StoredBlob.  It is
+// named after its title annotation, or its digest if it has none.
+func (as ArtifactStore) StoredBlob(ctx context.Context, nameOrDigest string, options *libartTypes.FilterBlobOptions) (*libartTypes.ArtifactBlob, error) {
+	if len(nameOrDigest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	if len(options.Digest) > 0 && len(options.Title) > 0 {
+		return nil, errors.New("cannot specify both digest and title")
+	}
+	arty, err := as.Inspect(ctx, nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findLayerIndex(arty, options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", nameOrDigest, err)
+	}
+	layer := arty.Manifest.Layers[i]
+	fileName, err := generateArtifactBlobName(layer.Annotations[specV1.AnnotationTitle], layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &libartTypes.ArtifactBlob{
+		FileName:   fileName,
+		StoredBlob: &layer,
+	}, nil
+}
+
+// CopyBlob adds the blob with the digest blobDigest of the artifact src to the
+// artifact dest the same way Add adds a file, so options.Append adds it to an
+// existing artifact.  The blob is shared by both artifacts in the store, only
+// the manifest of dest is written and none of the content is read or copied.
+func (as ArtifactStore) CopyBlob(ctx context.Context, src string, blobDigest digest.Digest, dest string, options *libartTypes.AddOptions) (*libartTypes.AddResult, error) {
+	blob, err := as.StoredBlob(ctx, src, &libartTypes.FilterBlobOptions{Digest: blobDigest.String()})
+	if err != nil {
+		return nil, err
+	}
+	return as.Add(ctx, dest, []libartTypes.ArtifactBlob{*blob}, options)
+}
+
+// checkStoredBlob returns the digest, size and media type of the stored blob
+// of an ArtifactBlob added by Add.  The blob must be in the store, a blob a
+// partial pull did not fetch cannot be shared.  The caller must hold the store
+// lock, so the blob is not removed before the new manifest references it.
+func (as ArtifactStore) checkStoredBlob(blob libartTypes.ArtifactBlob, fileType string, checkMediaType func(string) error) (digest.Digest, int64, string, error) {
+	stored := blob.StoredBlob
+	if err := stored.Digest.Validate(); err != nil {
+		return "", -1, "", fmt.Errorf("%s: %w", blob.FileName, err)
+	}
+	if err := fileutils.Exists(as.blobPath(stored.Digest)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", -1, "", fmt.Errorf("%s: blob %s is not in the store, pull all blobs of its artifact first", blob.FileName, stored.Digest)
+		}
+		return "", -1, "", err
+	}
+	mediaType := stored.MediaType
+	if len(blob.MediaType) > 0 {
+		mediaType = blob.MediaType
+	} else if len(fileType) > 0 {
+		mediaType = fileType
+	}
+	if checkMediaType != nil {
+		if err := checkMediaType(mediaType); err != nil {
+			return "", -1, "", fmt.Errorf("%s: %w", blob.FileName, err)
+		}
+	}
+	return stored.Digest, stored.Size, mediaType, nil
+}
@@ -0,0 +1,78 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/types"
+)
+
+// copyCredentials returns the credentials given explicitly in opts, nil if
+// there are none.  Without explicit credentials the credentials of each
+// registry are looked up by its host in the auth file and the credential
+// helpers configured in it.
+func copyCredentials(opts *libimage.CopyOptions) *types.DockerAuthConfig {
+	if opts.Username != "" {
+		return &types.DockerAuthConfig{Username: opts.Username, Password: opts.Password}
+	}
+	if opts.Credentials != "" {
+		username, password, _ := strings.Cut(opts.Credentials, ":")
+		return &types.DockerAuthConfig{Username: username, Password: password}
+	}
+	return nil
+}
+
+// withoutCredentials returns a copy of opts without explicit credentials, so
+// the credentials of all registries are looked up in the auth file.
+func withoutCredentials(opts libimage.CopyOptions) *libimage.CopyOptions {
+	opts.Username = ""
+	opts.Password = ""
+	opts.Credentials = ""
+	opts.IdentityToken = ""
+	return &opts
+}
+
+// credentialsReference is an ImageReference whose image sources and
+// destinations authenticate with the given credentials rather than the ones
+// of the system context of the copy.  A nil auth looks the credentials up in
+// the auth file.  A copy between two registries uses this to authenticate at
+// each of them with its own credentials.
+type credentialsReference struct {
+	types.ImageReference
+	auth *types.DockerAuthConfig
+}
+
+// newCredentialsLookup returns a function suitable for
+// libimage.CopyOptions.SourceLookupReferenceFunc and
+// DestinationLookupReferenceFunc which wraps the reference to use auth and
+// then passes it to next, if set.
+func newCredentialsLookup(auth *types.DockerAuthConfig, next func(types.ImageReference) (types.ImageReference, error)) func(types.ImageReference) (types.ImageReference, error) {
+	return func(ref types.ImageReference) (types.ImageReference, error) {
+		var wrapped types.ImageReference = &credentialsReference{ImageReference: ref, auth: auth}
+		if next == nil {
+			return wrapped, nil
+		}
+		return next(wrapped)
+	}
+}
+
+func (r *credentialsReference) NewImageSource(ctx context.Context, sys *types.SystemContext) (types.ImageSource, error) {
+	return r.ImageReference.NewImageSource(ctx, r.systemContext(sys))
+}
+
+func (r *credentialsReference) NewImageDestination(ctx context.Context, sys *types.SystemContext) (types.ImageDestination, error) {
+	return r.ImageReference.NewImageDestination(ctx, r.systemContext(sys))
+}
+
+// systemContext returns a copy of sys with the credentials of r.
+func (r *credentialsReference) systemContext(sys *types.SystemContext) *types.SystemContext {
+	sysCopy := types.SystemContext{}
+	if sys != nil {
+		sysCopy = *sys
+	}
+	sysCopy.DockerAuthConfig = r.auth
+	return &sysCopy
+}
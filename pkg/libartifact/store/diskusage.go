@@ -0,0 +1,90 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+
+	"github.com/containers/image/v5/oci/layout"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// DiskUsage returns the space used by the artifacts in the store.  Artifacts
+// may share blobs, and several names may refer to the same manifest, so the
+// size of an artifact is split into the part only it uses and the part it
+// shares with others.  Shared blobs are accounted to every artifact using them
+// rather than divided between them.
+func (as ArtifactStore) DiskUsage(ctx context.Context) (*libartTypes.DiskUsage, error) {
+	as.lock.RLock()
+	defer as.lock.Unlock()
+
+	lrs, err := layout.List(as.storePath)
+	if err != nil {
+		return nil, err
+	}
+
+	type artifactBlobs struct {
+		usage libartTypes.ArtifactDiskUsage
+		blobs map[digest.Digest]int64
+	}
+	var (
+		artifacts = make([]artifactBlobs, 0, len(lrs))
+		users     = map[digest.Digest]int{}
+		sizes     = map[digest.Digest]int64{}
+	)
+	for _, l := range lrs {
+		imgSrc, err := l.Reference.NewImageSource(ctx, as.SystemContext)
+		if err != nil {
+			return nil, err
+		}
+		mani, err := getManifest(ctx, imgSrc)
+		imgSrc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// A blob listed more than once in the same manifest is stored once.
+		blobs := map[digest.Digest]int64{
+			l.ManifestDescriptor.Digest: l.ManifestDescriptor.Size,
+			mani.Config.Digest:          mani.Config.Size,
+		}
+		for _, layer := range mani.Layers {
+			blobs[layer.Digest] = layer.Size
+		}
+		for d, size := range blobs {
+			users[d]++
+			sizes[d] = size
+		}
+		artifacts = append(artifacts, artifactBlobs{
+			usage: libartTypes.ArtifactDiskUsage{
+				Name:   l.ManifestDescriptor.Annotations[specV1.AnnotationRefName],
+				Digest: l.ManifestDescriptor.Digest,
+			},
+			blobs: blobs,
+		})
+	}
+
+	report := &libartTypes.DiskUsage{
+		Artifacts: make([]libartTypes.ArtifactDiskUsage, 0, len(artifacts)),
+	}
+	for d, size := range sizes {
+		report.TotalSize += size
+		if users[d] > 1 {
+			report.SharedSize += size
+		}
+	}
+	for _, a := range artifacts {
+		for d, size := range a.blobs {
+			a.usage.Size += size
+			if users[d] > 1 {
+				a.usage.SharedSize += size
+			} else {
+				a.usage.UniqueSize += size
+			}
+		}
+		report.Artifacts = append(report.Artifacts, a.usage)
+	}
+	return report, nil
+}
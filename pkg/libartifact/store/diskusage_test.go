@@ -0,0 +1,77 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsageSharedBlobs(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+
+	shared := testBlob{name: "shared", content: "shared content"}
+	firstDigest := addTestArtifact(t, as, "localhost/test/first", shared, testBlob{name: "first", content: "first"})
+	secondDigest := addTestArtifact(t, as, "localhost/test/second", shared, testBlob{name: "second", content: "second artifact"})
+
+	first, err := as.Inspect(ctx, "localhost/test/first")
+	require.NoError(t, err)
+	second, err := as.Inspect(ctx, "localhost/test/second")
+	require.NoError(t, err)
+	// Both artifacts use the same config, the empty JSON object.
+	require.Equal(t, first.Manifest.Config.Digest, second.Manifest.Config.Digest)
+	configSize := first.Manifest.Config.Size
+
+	firstManifest, _, err := as.ManifestBytes(ctx, firstDigest.Encoded())
+	require.NoError(t, err)
+	secondManifest, _, err := as.ManifestBytes(ctx, secondDigest.Encoded())
+	require.NoError(t, err)
+
+	sharedSize := configSize + int64(len(shared.content))
+	firstUnique := int64(len(firstManifest)) + int64(len("first"))
+	secondUnique := int64(len(secondManifest)) + int64(len("second artifact"))
+
+	usage, err := as.DiskUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, sharedSize, usage.SharedSize)
+	assert.Equal(t, sharedSize+firstUnique+secondUnique, usage.TotalSize)
+
+	byName := map[string]libartTypes.ArtifactDiskUsage{}
+	for _, a := range usage.Artifacts {
+		byName[a.Name] = a
+	}
+	require.Len(t, byName, 2)
+	for name, expected := range map[string]libartTypes.ArtifactDiskUsage{
+		"localhost/test/first": {
+			Digest:     firstDigest,
+			Size:       sharedSize + firstUnique,
+			UniqueSize: firstUnique,
+			SharedSize: sharedSize,
+		},
+		"localhost/test/second": {
+			Digest:     secondDigest,
+			Size:       sharedSize + secondUnique,
+			UniqueSize: secondUnique,
+			SharedSize: sharedSize,
+		},
+	} {
+		expected.Name = name
+		assert.Equal(t, expected, byName[name], name)
+	}
+
+	// Once the second artifact is gone nothing is shared anymore.
+	_, err = as.Remove(ctx, "localhost/test/second")
+	require.NoError(t, err)
+	usage, err = as.DiskUsage(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, usage.SharedSize)
+	assert.Equal(t, sharedSize+firstUnique, usage.TotalSize)
+	require.Len(t, usage.Artifacts, 1)
+	assert.Equal(t, sharedSize+firstUnique, usage.Artifacts[0].UniqueSize)
+	assert.Zero(t, usage.Artifacts[0].SharedSize)
+}
@@ -0,0 +1,150 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/types"
+	"github.com/containers/storage/pkg/fileutils"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// newDownloadDir creates a private directory for blobs downloaded from a
+// registry.  Downloads do not hold the store lock, so listing the store and
+// other changes do not wait for them: the blobs are downloaded to the OCI
+// layout in the directory first and storeDownloadedBlobs moves them to the
+// store, with the lock held, once all of them are complete.  Like the
+// temporary store of a pull which is not stored, the directory is next to
+// the blob directories, so the blobs are renamed rather than copied.
+func (as ArtifactStore) newDownloadDir() (string, error) {
+	return os.MkdirTemp(as.storePath, ".download-")
+}
+
+// removeDownloadDir removes the download directory dir and the blobs in it
+// which were not stored.
+func removeDownloadDir(dir string) {
+	if err := os.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.Errorf("Removing download directory %s: %v", dir, err)
+	}
+}
+
+// downloadedBlobPath is the path of the blob with the given digest in the
+// download directory dir.
+func downloadedBlobPath(dir string, blobDigest digest.Digest) string {
+	return filepath.Join(dir, specV1.ImageBlobsDir, blobDigest.Algorithm().String(), blobDigest.Encoded())
+}
+
+// storeDownloadedBlobs moves the blobs in the download directory dir to the
+// store.  A blob already in the store is kept unless replace is set, which is
+// how a damaged blob is repaired.  The caller must hold the store lock.
+func (as ArtifactStore) storeDownloadedBlobs(dir string, replace bool) error {
+	blobsDir := filepath.Join(dir, specV1.ImageBlobsDir)
+	algorithms, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, algorithm := range algorithms {
+		entries, err := os.ReadDir(filepath.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			blobDigest := digest.NewDigestFromEncoded(digest.Algorithm(algorithm.Name()), entry.Name())
+			if !entry.Type().IsRegular() || blobDigest.Validate() != nil {
+				continue
+			}
+			target := as.blobPath(blobDigest)
+			if !replace {
+				if err := fileutils.Exists(target); err == nil {
+					continue
+				}
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := os.Rename(downloadedBlobPath(dir, blobDigest), target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// downloadReference is the ImageReference of a download directory whose
+// image destinations reuse the blobs already in the store.
+type downloadReference struct {
+	types.ImageReference
+	as  ArtifactStore
+	dir string
+}
+
+// newDownloadLookup returns a function suitable for
+// libimage.CopyOptions.DestinationLookupReferenceFunc which wraps the
+// reference of the download directory dir, so the copy does not download the
+// blobs the store has already.
+func (as ArtifactStore) newDownloadLookup(dir string) func(types.ImageReference) (types.ImageReference, error) {
+	return func(ref types.ImageReference) (types.ImageReference, error) {
+		return &downloadReference{ImageReference: ref, as: as, dir: dir}, nil
+	}
+}
+
+func (r *downloadReference) NewImageDestination(ctx context.Context, sys *types.SystemContext) (types.ImageDestination, error) {
+	dest, err := r.ImageReference.NewImageDestination(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	return &downloadDestination{ImageDestination: dest, as: r.as, dir: r.dir}, nil
+}
+
+type downloadDestination struct {
+	types.ImageDestination
+	as  ArtifactStore
+	dir string
+}
+
+// TryReusingBlob links a blob which is in the store into the download
+// directory, so the store keeps it even if the artifacts using it are removed
+// before the download is stored.  A blob which cannot be linked is downloaded.
+func (d *downloadDestination) TryReusingBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache, canSubstitute bool) (bool, types.BlobInfo, error) {
+	if info.Digest.Validate() == nil {
+		path := downloadedBlobPath(d.dir, info.Digest)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, types.BlobInfo{}, err
+		}
+		err := os.Link(d.as.blobPath(info.Digest), path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrExist) {
+			logrus.Debugf("Not reusing blob %s of the store: %v", info.Digest, err)
+		}
+	}
+	return d.ImageDestination.TryReusingBlob(ctx, info, cache, canSubstitute)
+}
+
+// storeDownload moves the blobs of the artifact downloaded to the download
+// directory dir to the store and adds its manifest rawManifest to the index
+// under the name of destRef, a reference to the store.  As for a copy to the
+// store, the name is removed from the artifact which had it before.  The
+// caller must hold the store lock.
+func (as ArtifactStore) storeDownload(ctx context.Context, dir string, destRef types.ImageReference, rawManifest []byte) error {
+	if err := as.storeDownloadedBlobs(dir, false); err != nil {
+		return err
+	}
+	imageDest, err := destRef.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return err
+	}
+	defer imageDest.Close()
+	if err := imageDest.PutManifest(ctx, rawManifest, nil); err != nil {
+		return err
+	}
+	return imageDest.Commit(ctx, nil)
+}
@@ -0,0 +1,88 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/archive"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+)
+
+// Export writes the manifest and all blobs of the artifact to an OCI image
+// layout at path, or to a tar archive of one if options.Archive is set.  The
+// manifest is copied unchanged, so the exported artifact keeps its digest,
+// annotations and media types.  The artifact name, if any, is used as the
+// reference name in the index of the layout.
+func (as ArtifactStore) Export(ctx context.Context, nameOrDigest, path string, options *libartTypes.ExportOptions) (digest.Digest, error) {
+	if len(nameOrDigest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	if len(path) == 0 {
+		return "", errors.New("export path cannot be empty")
+	}
+	as.lock.RLock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	artifactDigest, err := arty.StoredDigest()
+	if err != nil {
+		return "", err
+	}
+	srcRef, err := as.layoutReference(artifactDigest)
+	if err != nil {
+		return "", err
+	}
+
+	var destRef types.ImageReference
+	if options.Archive {
+		destRef, err = archive.NewReference(path, arty.Name)
+	} else {
+		destRef, err = layout.NewReference(path, arty.Name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	copyer, err := libimage.NewCopier(&libimage.CopyOptions{}, as.SystemContext)
+	if err != nil {
+		return "", err
+	}
+	rawManifest, err := copyer.Copy(ctx, srcRef, destRef)
+	if err != nil {
+		return "", err
+	}
+	if err := copyer.Close(); err != nil {
+		return "", err
+	}
+	return manifest.Digest(rawManifest)
+}
+
+// layoutReference returns the reference of the manifest with the given digest
+// in the store, which also works for artifacts without a name.
+func (as ArtifactStore) layoutReference(artifactDigest digest.Digest) (types.ImageReference, error) {
+	lrs, err := layout.List(as.storePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lrs {
+		if l.ManifestDescriptor.Digest == artifactDigest {
+			return l.Reference, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", artifactDigest, libartTypes.ErrArtifactNotExist)
+}
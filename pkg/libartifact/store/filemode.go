@@ -0,0 +1,112 @@
+//go:build !remote
+
+package store
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"syscall"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/sirupsen/logrus"
+)
+
+// recordFileMode adds the mode and ownership of the file at path to the
+// annotations of its blob.
+func recordFileMode(path string, annotations map[string]string) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	sys, ok := st.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("%s: the file mode cannot be recorded on this platform", path)
+	}
+	annotations[libartTypes.AnnotationFileMode] = fmt.Sprintf("%04o", sys.Mode&0o7777)
+	annotations[libartTypes.AnnotationFileUID] = strconv.FormatUint(uint64(sys.Uid), 10)
+	annotations[libartTypes.AnnotationFileGID] = strconv.FormatUint(uint64(sys.Gid), 10)
+	return nil
+}
+
+// recordedMode is the mode and ownership recorded in the annotations of a blob.
+type recordedMode struct {
+	// uid and gid are -1 if not recorded.
+	uid, gid int
+	perm     *fs.FileMode
+}
+
+// parseRecordedMode returns the mode and ownership recorded in the annotations
+// of a blob.
+func parseRecordedMode(annotations map[string]string) (*recordedMode, error) {
+	uid, err := fileIDAnnotation(annotations, libartTypes.AnnotationFileUID)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := fileIDAnnotation(annotations, libartTypes.AnnotationFileGID)
+	if err != nil {
+		return nil, err
+	}
+	m := &recordedMode{uid: uid, gid: gid}
+	value, ok := annotations[libartTypes.AnnotationFileMode]
+	if !ok {
+		return m, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o7777 {
+		return nil, fmt.Errorf("invalid file mode %q in the %s annotation", value, libartTypes.AnnotationFileMode)
+	}
+	// The annotations are chosen by whoever pushed the artifact, so the
+	// setuid, setgid and sticky bits are never restored.
+	perm := fs.FileMode(mode) & fs.ModePerm
+	m.perm = &perm
+	return m, nil
+}
+
+// restore sets the recorded mode and ownership on the file target.  Ownership
+// which cannot be set without privileges or is not mapped in the user
+// namespace, as is common rootless, is left as it is.
+func (m *recordedMode) restore(target string) error {
+	if m.uid >= 0 || m.gid >= 0 {
+		if err := os.Lchown(target, m.uid, m.gid); err != nil {
+			if !errors.Is(err, fs.ErrPermission) && !errors.Is(err, syscall.EINVAL) {
+				return err
+			}
+			logrus.Debugf("Not restoring the ownership %d:%d of %s: %v", m.uid, m.gid, target, err)
+		}
+	}
+	if m.perm == nil {
+		return nil
+	}
+	return os.Chmod(target, *m.perm)
+}
+
+// setTarHeader sets the recorded mode and ownership in the tar header hdr.
+func (m *recordedMode) setTarHeader(hdr *tar.Header) {
+	if m.uid >= 0 {
+		hdr.Uid = m.uid
+	}
+	if m.gid >= 0 {
+		hdr.Gid = m.gid
+	}
+	if m.perm != nil {
+		hdr.Mode = int64(m.perm.Perm())
+	}
+}
+
+// fileIDAnnotation returns the numeric owner or group recorded in the
+// annotation, -1 if there is none.
+func fileIDAnnotation(annotations map[string]string, annotation string) (int, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return -1, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return -1, fmt.Errorf("invalid id %q in the %s annotation", value, annotation)
+	}
+	return int(id), nil
+}
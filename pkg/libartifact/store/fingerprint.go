@@ -0,0 +1,105 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/containers/podman/v5/pkg/libartifact"
+	"github.com/containers/storage/pkg/ioutils"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// Fingerprint returns the fingerprint of the artifact, see
+// libartifact.Artifact.Fingerprint.  It is computed once per stored manifest
+// and cached in the store, the manifest of a digest never changes.
+func (as ArtifactStore) Fingerprint(ctx context.Context, nameOrDigest string) (digest.Digest, error) {
+	if len(nameOrDigest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	manifestDigest, err := arty.StoredDigest()
+	if err != nil {
+		return "", err
+	}
+
+	cachePath := as.fingerprintPath(manifestDigest)
+	if cached, err := os.ReadFile(cachePath); err == nil {
+		if fingerprint, err := digest.Parse(string(cached)); err == nil {
+			return fingerprint, nil
+		}
+		logrus.Debugf("Ignoring invalid cached fingerprint of %s", manifestDigest)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		logrus.Debugf("Reading the cached fingerprint of %s: %v", manifestDigest, err)
+	}
+
+	fingerprint, err := arty.Fingerprint()
+	if err != nil {
+		return "", err
+	}
+	// A store which cannot be written to still returns the fingerprint,
+	// concurrent readers write the same content.
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+		err = ioutils.AtomicWriteFile(cachePath, []byte(fingerprint.String()), 0o644)
+	}
+	if err != nil {
+		logrus.Debugf("Caching the fingerprint of %s: %v", manifestDigest, err)
+	}
+	return fingerprint, nil
+}
+
+// fingerprintsPath is the directory of the cached fingerprints of the version
+// computed by this package.
+func (as ArtifactStore) fingerprintsPath() string {
+	return filepath.Join(as.storePath, "fingerprints", "v"+strconv.Itoa(libartifact.FingerprintVersion))
+}
+
+// fingerprintPath is the file caching the fingerprint of the manifest with the
+// given digest.
+func (as ArtifactStore) fingerprintPath(manifestDigest digest.Digest) string {
+	return filepath.Join(as.fingerprintsPath(), manifestDigest.Algorithm().String(), manifestDigest.Encoded())
+}
+
+// removeUnreferencedFingerprints removes the cached fingerprints of manifests
+// which are not referenced.  The caller must hold the store lock.
+func (as ArtifactStore) removeUnreferencedFingerprints(referenced map[digest.Digest]struct{}) {
+	algorithms, err := os.ReadDir(as.fingerprintsPath())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logrus.Debugf("Reading the cached fingerprints: %v", err)
+		}
+		return
+	}
+	for _, algorithm := range algorithms {
+		dir := filepath.Join(as.fingerprintsPath(), algorithm.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			logrus.Debugf("Reading the cached fingerprints: %v", err)
+			continue
+		}
+		for _, entry := range entries {
+			manifestDigest := digest.NewDigestFromEncoded(digest.Algorithm(algorithm.Name()), entry.Name())
+			if _, ok := referenced[manifestDigest]; ok {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				logrus.Debugf("Removing the cached fingerprint of %s: %v", manifestDigest, err)
+			}
+		}
+	}
+}
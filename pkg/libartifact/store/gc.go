@@ -0,0 +1,142 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// GarbageCollect removes the blobs in the store which are not referenced by
+// any artifact, e.g. the ones left behind by an interrupted add, pull or
+// removal.  A blob is referenced if it is the manifest of an entry of the
+// index or the config or a layer of such a manifest.  The store is locked for
+// writing, so the blobs of an add in progress, which holds the lock, are never
+// found unreferenced, and a pull only moves its blobs to the store together
+// with its manifest.  With options.DryRun the orphaned blobs are only
+// reported.
+//
+// If a manifest of the index cannot be read, the blobs it references are
+// unknown and nothing is removed.  Files in the blob directories whose names
+// are not digests are left untouched, as is the staging directory of
+// resumable pulls.  The cached fingerprints of manifests no longer in the
+// index are removed as well.
+func (as ArtifactStore) GarbageCollect(ctx context.Context, options *libartTypes.GCOptions) (*libartTypes.GCResult, error) {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	referenced, err := as.referencedBlobs()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := as.storedBlobs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &libartTypes.GCResult{}
+	for _, blob := range stored {
+		if _, ok := referenced[blob.Digest]; ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if !options.DryRun {
+			logrus.Debugf("Deleting orphaned blob %s", blob.Digest)
+			if err := os.Remove(as.blobPath(blob.Digest)); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return result, err
+			}
+		}
+		result.Blobs = append(result.Blobs, blob)
+		result.ReclaimedSize += blob.Size
+	}
+	if !options.DryRun {
+		as.removeUnreferencedFingerprints(referenced)
+	}
+	return result, nil
+}
+
+// referencedBlobs returns the digests of the manifests of all entries of the
+// index of the store and of their configs and layers.  The caller must hold
+// the store lock.
+func (as ArtifactStore) referencedBlobs() (map[digest.Digest]struct{}, error) {
+	index, err := as.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	referenced := map[digest.Digest]struct{}{}
+	for _, desc := range index.Manifests {
+		if _, ok := referenced[desc.Digest]; ok {
+			continue
+		}
+		blobs, err := as.manifestBlobs(desc.Digest)
+		if err != nil {
+			artifact := desc.Annotations[specV1.AnnotationRefName]
+			if artifact == "" {
+				artifact = desc.Digest.String()
+			}
+			return nil, fmt.Errorf("reading the manifest of artifact %s, the blobs it references are unknown: %w", artifact, err)
+		}
+		for _, d := range blobs {
+			referenced[d] = struct{}{}
+		}
+	}
+	return referenced, nil
+}
+
+// storedBlobs returns the blobs in the blob directories of the store, sorted
+// by digest.  The caller must hold the store lock.
+func (as ArtifactStore) storedBlobs() ([]libartTypes.StoredBlob, error) {
+	blobsDir := filepath.Join(as.storePath, specV1.ImageBlobsDir)
+	algorithms, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var blobs []libartTypes.StoredBlob
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			blobDigest := digest.NewDigestFromEncoded(digest.Algorithm(algorithm.Name()), entry.Name())
+			if !entry.Type().IsRegular() || blobDigest.Validate() != nil {
+				logrus.Debugf("Skipping %s in the blob directory, it is not a blob", filepath.Join(algorithm.Name(), entry.Name()))
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			blobs = append(blobs, libartTypes.StoredBlob{Digest: blobDigest, Size: info.Size()})
+		}
+	}
+	slices.SortFunc(blobs, func(a, b libartTypes.StoredBlob) int {
+		return strings.Compare(a.Digest.String(), b.Digest.String())
+	})
+	return blobs, nil
+}
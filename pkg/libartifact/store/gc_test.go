@@ -0,0 +1,78 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOrphanBlob writes a blob no artifact references to the store.
+func writeOrphanBlob(t *testing.T, as *ArtifactStore, content string) digest.Digest {
+	t.Helper()
+	orphanDigest := digest.FromString(content)
+	require.NoError(t, os.WriteFile(as.blobPath(orphanDigest), []byte(content), 0o644))
+	return orphanDigest
+}
+
+func TestGarbageCollect(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	shared := testBlob{name: "shared", content: "shared content"}
+	addTestArtifact(t, as, "localhost/test/first", shared)
+	addTestArtifact(t, as, "localhost/test/second", shared, testBlob{name: "own", content: "own content"})
+	orphanDigest := writeOrphanBlob(t, as, "orphaned content")
+
+	// Neither files in the blob directories whose names are not digests
+	// nor the staging directory are blobs.
+	notBlob := filepath.Join(filepath.Dir(as.blobPath(orphanDigest)), "not-a-blob")
+	require.NoError(t, os.WriteFile(notBlob, []byte("not a blob"), 0o644))
+	staged := stagedBlobPath(as.stagingPath(), digest.FromString("staged content"))
+	require.NoError(t, os.MkdirAll(as.stagingPath(), 0o700))
+	require.NoError(t, os.WriteFile(staged, []byte("staged"), 0o600))
+
+	orphan := []libartTypes.StoredBlob{{Digest: orphanDigest, Size: int64(len("orphaned content"))}}
+	before := storeFiles(t, as)
+	result, err := as.GarbageCollect(ctx, &libartTypes.GCOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, orphan, result.Blobs)
+	assert.Equal(t, int64(len("orphaned content")), result.ReclaimedSize)
+	assert.Equal(t, before, storeFiles(t, as), "a dry run removes nothing")
+
+	result, err = as.GarbageCollect(ctx, &libartTypes.GCOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, orphan, result.Blobs)
+	assert.NoFileExists(t, as.blobPath(orphanDigest))
+	assert.FileExists(t, as.blobPath(digest.FromString(shared.content)))
+	assert.FileExists(t, notBlob)
+	assert.FileExists(t, staged)
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/first", "shared"))
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/second", "shared"))
+	assert.Equal(t, "own content", readTestBlob(t, as, "localhost/test/second", "own"))
+
+	result, err = as.GarbageCollect(ctx, &libartTypes.GCOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Blobs)
+}
+
+func TestGarbageCollectUnreadableManifest(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	manifestDigest := addTestArtifact(t, as, "localhost/test/unreadable", testBlob{name: "blob", content: "blob content"})
+	orphanDigest := writeOrphanBlob(t, as, "orphaned content")
+	require.NoError(t, os.WriteFile(as.blobPath(manifestDigest), []byte("not a manifest"), 0o644))
+
+	// The blobs the manifest references are unknown, so nothing is removed.
+	before := storeFiles(t, as)
+	_, err := as.GarbageCollect(ctx, &libartTypes.GCOptions{})
+	assert.ErrorContains(t, err, "reading the manifest of artifact localhost/test/unreadable, the blobs it references are unknown")
+	assert.Equal(t, before, storeFiles(t, as))
+	assert.FileExists(t, as.blobPath(orphanDigest))
+}
@@ -0,0 +1,160 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/archive"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// Import adds the artifact in the OCI image layout at path, a directory or a
+// tar archive of one, to the store under the name dest.  The layout must hold
+// a single artifact manifest unless options.Digest selects one of several.
+// The manifest is copied unchanged, so the artifact keeps its digest.
+func (as ArtifactStore) Import(ctx context.Context, path, dest string, options *libartTypes.ImportOptions) (digest.Digest, error) {
+	if len(dest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := artifacts.GetByNameOrDigest(dest); err == nil {
+		return "", fmt.Errorf("%s: %w", dest, libartTypes.ErrArtifactAlreadyExists)
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	layoutDir := path
+	if !st.IsDir() {
+		// Unpack the archive next to the store rather than in the system
+		// temporary directory, artifacts can be large and /tmp is often a tmpfs.
+		layoutDir, err = os.MkdirTemp(as.storePath, ".import-")
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			if err := os.RemoveAll(layoutDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logrus.Errorf("Removing temporary artifact import directory %s: %v", layoutDir, err)
+			}
+		}()
+		if err := untarLayout(path, layoutDir); err != nil {
+			return "", err
+		}
+	}
+
+	srcRef, err := selectLayoutManifest(layoutDir, path, options.Digest)
+	if err != nil {
+		return "", err
+	}
+	if err := checkArtifactManifest(ctx, srcRef, as.SystemContext); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	destRef, err := layout.NewReference(as.storePath, dest)
+	if err != nil {
+		return "", err
+	}
+	copyer, err := libimage.NewCopier(&libimage.CopyOptions{}, as.SystemContext)
+	if err != nil {
+		return "", err
+	}
+	rawManifest, err := copyer.Copy(ctx, srcRef, destRef)
+	if err != nil {
+		return "", err
+	}
+	if err := copyer.Close(); err != nil {
+		return "", err
+	}
+	manifestDigest, err := manifest.Digest(rawManifest)
+	if err != nil {
+		return "", err
+	}
+	changes = append(changes, ArtifactEvent{Operation: ArtifactAdded, Reference: dest, Digest: manifestDigest})
+	return manifestDigest, nil
+}
+
+// untarLayout unpacks the tar archive at path into dir.
+func untarLayout(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := archive.NewDefaultArchiver().Untar(f, dir, &archive.TarOptions{NoLchown: true}); err != nil {
+		return fmt.Errorf("unpacking %s: %w", path, err)
+	}
+	return nil
+}
+
+// selectLayoutManifest returns the reference of the manifest to import from
+// the layout in dir, which was read from path.  Without a digest the layout
+// must not contain different manifests.
+func selectLayoutManifest(dir, path string, manifestDigest digest.Digest) (types.ImageReference, error) {
+	lrs, err := layout.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		selected types.ImageReference
+		digests  = map[digest.Digest]struct{}{}
+	)
+	for _, l := range lrs {
+		if manifestDigest != "" && l.ManifestDescriptor.Digest != manifestDigest {
+			continue
+		}
+		if manifest.MIMETypeIsMultiImage(l.ManifestDescriptor.MediaType) {
+			return nil, fmt.Errorf("manifest %s in %s is an index, only artifact manifests can be imported", l.ManifestDescriptor.Digest, path)
+		}
+		if selected == nil {
+			selected = l.Reference
+		}
+		digests[l.ManifestDescriptor.Digest] = struct{}{}
+	}
+	switch {
+	case len(digests) > 1:
+		return nil, fmt.Errorf("%s contains %d manifests, select the one to import by its digest", path, len(digests))
+	case selected == nil && manifestDigest != "":
+		return nil, fmt.Errorf("%s does not contain a manifest with digest %s", path, manifestDigest)
+	case selected == nil:
+		return nil, fmt.Errorf("%s does not contain any manifest", path)
+	}
+	return selected, nil
+}
+
+// checkArtifactManifest returns an error if the manifest referenced by
+// ref describes a container image rather than an artifact.
+func checkArtifactManifest(ctx context.Context, ref types.ImageReference, sys *types.SystemContext) error {
+	imgSrc, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer imgSrc.Close()
+	mani, err := getManifest(ctx, imgSrc)
+	if err != nil {
+		return err
+	}
+	if mani.Config.MediaType == specV1.MediaTypeImageConfig {
+		return errors.New("the manifest is a container image, not an artifact")
+	}
+	return nil
+}
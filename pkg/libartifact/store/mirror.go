@@ -0,0 +1,124 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+	"github.com/sirupsen/logrus"
+)
+
+// pullMirror is a mirror of registries.conf which served the manifest of a
+// registry reference.
+type pullMirror struct {
+	// location is the location of the mirror in registries.conf.
+	location string
+	// ref is the source reference rewritten for the mirror.
+	ref reference.Named
+	// insecure is set if registries.conf allows to access the mirror
+	// without TLS verification.
+	insecure bool
+}
+
+// openPullSource opens the image source of the registry reference srcRef.
+// Like the docker transport does, the mirrors registries.conf configures for
+// its registry are tried in order before the registry itself, and the first
+// one serving the manifest is used.  Unlike the transport, it returns which
+// mirror that is so that the blobs are copied from the same one and the pull
+// can report it.  The mirror is nil if none of them served the manifest, the
+// source is then opened by the transport as usual.
+//
+// The source of a mirror reports srcRef as its reference, so the signature
+// policy is evaluated for the name the user asked for, as the transport does.
+func openPullSource(ctx context.Context, sys *types.SystemContext, srcRef types.ImageReference) (types.ImageSource, *pullMirror, error) {
+	named := srcRef.DockerReference()
+	if srcRef.Transport().Name() != docker.Transport.Name() || named == nil {
+		src, err := srcRef.NewImageSource(ctx, sys)
+		return src, nil, err
+	}
+	registry, err := sysregistriesv2.FindRegistry(sys, named.Name())
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading registries configuration: %w", err)
+	}
+	if registry != nil {
+		pullSources, err := registry.PullSourcesFromReference(named)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The last source is the registry itself.
+		for _, pullSource := range pullSources[:len(pullSources)-1] {
+			mirror := &pullMirror{
+				location: pullSource.Endpoint.Location,
+				ref:      pullSource.Reference,
+				insecure: pullSource.Endpoint.Insecure,
+			}
+			logrus.Debugf("Trying to access mirror %q", pullSource.Reference)
+			src, err := mirror.openImageSource(ctx, sys, named)
+			if err != nil {
+				logrus.Debugf("Accessing mirror %q failed: %v", pullSource.Reference, err)
+				continue
+			}
+			return &mirrorImageSource{ImageSource: src, ref: srcRef}, mirror, nil
+		}
+	}
+	src, err := srcRef.NewImageSource(ctx, sys)
+	return src, nil, err
+}
+
+// openImageSource opens the source of the mirror reference and checks that
+// it serves the manifest.
+func (m *pullMirror) openImageSource(ctx context.Context, sys *types.SystemContext, logical reference.Named) (types.ImageSource, error) {
+	ref, err := docker.NewReference(m.ref)
+	if err != nil {
+		return nil, err
+	}
+	mirrorSys := *sys
+	// The credentials given for the registry are not meant for a mirror
+	// on another host.
+	if reference.Domain(m.ref) != reference.Domain(logical) {
+		mirrorSys.DockerAuthConfig = nil
+		mirrorSys.DockerBearerRegistryToken = ""
+	}
+	if m.insecure && mirrorSys.DockerInsecureSkipTLSVerify == types.OptionalBoolUndefined {
+		mirrorSys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+	}
+	src, err := ref.NewImageSource(ctx, &mirrorSys)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := src.GetManifest(ctx, nil); err != nil {
+		src.Close()
+		return nil, err
+	}
+	return src, nil
+}
+
+// copyOptions returns the copy options to read the blobs from the mirror
+// with: like for the image source, the credentials for another host are not
+// used and registries.conf may allow to skip the TLS verification.
+func (m *pullMirror) copyOptions(opts libimage.CopyOptions, logical reference.Named) libimage.CopyOptions {
+	if reference.Domain(m.ref) != reference.Domain(logical) {
+		opts = *withoutCredentials(opts)
+	}
+	if m.insecure && opts.InsecureSkipTLSVerify == types.OptionalBoolUndefined {
+		opts.InsecureSkipTLSVerify = types.OptionalBoolTrue
+	}
+	return opts
+}
+
+// mirrorImageSource is the image source of a mirror which reports the
+// reference the mirror was looked up for.
+type mirrorImageSource struct {
+	types.ImageSource
+	ref types.ImageReference
+}
+
+func (s *mirrorImageSource) Reference() types.ImageReference {
+	return s.ref
+}
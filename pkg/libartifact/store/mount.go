@@ -0,0 +1,196 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/podman/v5/pkg/libartifact"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/fileutils"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// Mount lays out all blobs of the artifact, named by their title annotation,
+// in a read-only directory and returns its path.  The blobs are reflinked
+// where the file system supports it, so mounting does not use additional disk
+// space there, and copied otherwise.  The files in the store are not changed.
+// Mounting an artifact which is already mounted returns the existing
+// mountpoint.
+func (as ArtifactStore) Mount(ctx context.Context, nameOrDigest string) (string, error) {
+	artifactDigest, err := as.lookupDigest(ctx, nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	mountPoint := as.mountPointPath(artifactDigest)
+	if err := fileutils.Exists(mountPoint); err == nil {
+		return mountPoint, nil
+	}
+
+	blobPaths, err := as.BlobMountPaths(ctx, nameOrDigest, &libartTypes.BlobMountPathOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	// The mountpoint is created with the store locked, so a concurrent
+	// removal of the artifact does not leave it behind.
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	if d, err := artifactDigestOf(artifacts, nameOrDigest); err != nil {
+		return "", err
+	} else if d != artifactDigest {
+		return "", fmt.Errorf("artifact %s was replaced while mounting it", nameOrDigest)
+	}
+	if err := fileutils.Exists(mountPoint); err == nil {
+		return mountPoint, nil
+	}
+	if err := os.MkdirAll(as.mountsPath(), 0o700); err != nil {
+		return "", err
+	}
+	// Populate a temporary directory first so a mountpoint never exists
+	// with only a part of the blobs.
+	tmpDir, err := os.MkdirTemp(as.mountsPath(), ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("Removing temporary artifact mount directory %s: %v", tmpDir, err)
+		}
+	}()
+
+	for _, blob := range blobPaths {
+		target := filepath.Join(tmpDir, blob.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return "", fmt.Errorf("creating the directory of blob %q: %w", blob.Name, err)
+		}
+		if err := copyBlobReadOnly(blob.SourcePath, target); err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return "", fmt.Errorf("%w %q, cannot mount the artifact", libartTypes.ErrDuplicateBlobName, blob.Name)
+			}
+			return "", err
+		}
+	}
+	if err := setDirPermissions(tmpDir, 0o555); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, mountPoint); err != nil {
+		return "", err
+	}
+	return mountPoint, nil
+}
+
+// Unmount removes the mountpoint of the artifact created by Mount and returns
+// its path.  The blobs in the store are not affected.
+func (as ArtifactStore) Unmount(ctx context.Context, nameOrDigest string) (string, error) {
+	if len(nameOrDigest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	artifactDigest, err := artifactDigestOf(artifacts, nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	mountPoint := as.mountPointPath(artifactDigest)
+	if err := fileutils.Exists(mountPoint); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("artifact %s is not mounted", nameOrDigest)
+		}
+		return "", err
+	}
+	return mountPoint, removeMountPoint(mountPoint)
+}
+
+// lookupDigest returns the manifest digest of the artifact with the given
+// name or digest.
+func (as ArtifactStore) lookupDigest(ctx context.Context, nameOrDigest string) (digest.Digest, error) {
+	if len(nameOrDigest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return "", err
+	}
+	return artifactDigestOf(artifacts, nameOrDigest)
+}
+
+// artifactDigestOf returns the manifest digest of the artifact with the given
+// name or digest in artifacts.
+func artifactDigestOf(artifacts libartifact.ArtifactList, nameOrDigest string) (digest.Digest, error) {
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	artifactDigest, err := arty.StoredDigest()
+	if err != nil {
+		return "", err
+	}
+	return artifactDigest, nil
+}
+
+// copyBlobReadOnly reflinks or copies the blob at source to the new read-only
+// file target.
+func copyBlobReadOnly(source, target string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		return err
+	}
+	if err := fileutils.ReflinkOrCopy(src, dest); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
+// removeMountPoint deletes the mountpoint, which Mount made read-only.
+func removeMountPoint(mountPoint string) error {
+	if err := setDirPermissions(mountPoint, 0o700); err != nil {
+		return err
+	}
+	return os.RemoveAll(mountPoint)
+}
+
+// setDirPermissions changes the permissions of dir and all directories below
+// it to perm.  The blobs are not changed.
+func setDirPermissions(dir string, perm fs.FileMode) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return os.Chmod(path, perm)
+	})
+}
+
+// mountsPath is the directory holding the mountpoints of all mounted artifacts.
+func (as ArtifactStore) mountsPath() string {
+	return filepath.Join(as.storePath, "mounts")
+}
+
+// mountPointPath is the mountpoint of the artifact with the given manifest digest.
+// Artifacts with the same manifest share their mountpoint.
+func (as ArtifactStore) mountPointPath(artifactDigest digest.Digest) string {
+	return filepath.Join(as.mountsPath(), artifactDigest.Encoded())
+}
@@ -0,0 +1,102 @@
+//go:build !remote
+
+package store
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// ArtifactOperation is the change of the store an ArtifactEvent reports.
+type ArtifactOperation string
+
+const (
+	// ArtifactAdded reports that the name refers to the artifact with
+	// the digest, e.g. after an add, pull, import, tag or update.  A name
+	// which referred to another artifact before is not reported as
+	// removed first.
+	ArtifactAdded ArtifactOperation = "add"
+	// ArtifactRemoved reports that the name no longer exists in the store.
+	ArtifactRemoved ArtifactOperation = "remove"
+)
+
+// ArtifactEvent describes a change of the artifacts of a store.
+type ArtifactEvent struct {
+	Operation ArtifactOperation
+	// Reference is the name of the artifact which was added or removed.
+	Reference string
+	// Digest is the manifest digest of the artifact.
+	Digest digest.Digest
+}
+
+// ArtifactObserver is notified of the artifacts added to and removed from a
+// store, see ArtifactStore.AddObserver.
+type ArtifactObserver interface {
+	ArtifactChanged(event ArtifactEvent)
+}
+
+// ArtifactObserverFunc is a function used as an ArtifactObserver.
+type ArtifactObserverFunc func(event ArtifactEvent)
+
+func (f ArtifactObserverFunc) ArtifactChanged(event ArtifactEvent) {
+	f(event)
+}
+
+// artifactObservers are the observers of a store and all copies of it.
+type artifactObservers struct {
+	lock      sync.Mutex
+	observers []*ArtifactObserver
+}
+
+// AddObserver registers observer to be called for every artifact added to or
+// removed from the store by this process, through this store or any copy of
+// it, and returns the function unregistering it.  Changes by other processes
+// or other stores of the same path are not observed.
+//
+// Observers are called once the change was written to the index of the store
+// and the store lock was released, so they can use the store themselves.  They
+// are called synchronously, in the order they were added, by the goroutine
+// which made the change.  An observer which panics is logged and does not
+// affect the store, the operation or the other observers.
+func (as ArtifactStore) AddObserver(observer ArtifactObserver) func() {
+	as.observers.lock.Lock()
+	defer as.observers.lock.Unlock()
+	entry := &observer
+	as.observers.observers = append(as.observers.observers, entry)
+	return func() {
+		as.observers.lock.Lock()
+		defer as.observers.lock.Unlock()
+		as.observers.observers = slices.DeleteFunc(as.observers.observers, func(o *ArtifactObserver) bool { return o == entry })
+	}
+}
+
+// notifyObservers calls the observers of the store for each of the events.
+// It takes a pointer so that it can be deferred before the store lock is
+// taken, and then reports the changes recorded while the lock was held once
+// it is released.
+func (as ArtifactStore) notifyObservers(events *[]ArtifactEvent) {
+	if as.observers == nil || len(*events) == 0 {
+		return
+	}
+	as.observers.lock.Lock()
+	observers := slices.Clone(as.observers.observers)
+	as.observers.lock.Unlock()
+	for _, event := range *events {
+		for _, observer := range observers {
+			callObserver(*observer, event)
+		}
+	}
+}
+
+// callObserver calls observer for event, recovering from a panic of it.
+func callObserver(observer ArtifactObserver, event ArtifactEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Artifact observer panicked on %s of %s: %v", event.Operation, event.Reference, r)
+		}
+	}()
+	observer.ArtifactChanged(event)
+}
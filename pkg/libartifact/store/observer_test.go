@@ -0,0 +1,57 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserverPanic(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+
+	panicked := 0
+	as.AddObserver(ArtifactObserverFunc(func(ArtifactEvent) {
+		panicked++
+		panic("observer failure")
+	}))
+	var events []ArtifactEvent
+	unregister := as.AddObserver(ArtifactObserverFunc(func(event ArtifactEvent) {
+		// The lock was released before the observers are called.
+		require.NoError(t, as.lock.TryLock())
+		as.lock.Unlock()
+		events = append(events, event)
+	}))
+
+	name := "localhost/test/observed"
+	artifactDigest := addTestArtifact(t, as, name, testBlob{name: "blob", content: "observed"})
+	art, err := as.Inspect(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, name, art.Name)
+	artifacts, err := as.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, artifacts, 1)
+
+	removed, err := as.Remove(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, artifactDigest, *removed)
+	artifacts, err = as.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, artifacts)
+
+	assert.Equal(t, 2, panicked)
+	assert.Equal(t, []ArtifactEvent{
+		{Operation: ArtifactAdded, Reference: name, Digest: artifactDigest},
+		{Operation: ArtifactRemoved, Reference: name, Digest: artifactDigest},
+	}, events)
+
+	// An unregistered observer is not called anymore.
+	unregister()
+	addTestArtifact(t, as, name, testBlob{name: "blob", content: "observed"})
+	assert.Equal(t, 3, panicked)
+	assert.Len(t, events, 2)
+}
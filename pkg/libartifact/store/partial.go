@@ -0,0 +1,252 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/pkg/libartifact"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/fileutils"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// isPartialPull returns true if pullOpts select only some of the blobs.
+func isPartialPull(pullOpts *libartTypes.PullOptions) bool {
+	return len(pullOpts.Titles) > 0 || len(pullOpts.Digests) > 0
+}
+
+// isSelectedBlob returns true if the blob described by info is one of the
+// blobs selected by pullOpts.
+func isSelectedBlob(pullOpts *libartTypes.PullOptions, info types.BlobInfo) bool {
+	return slices.Contains(pullOpts.Digests, info.Digest) || slices.Contains(pullOpts.Titles, info.Annotations[specV1.AnnotationTitle])
+}
+
+// checkBlobSelection returns an error if one of the titles or digests of
+// pullOpts does not select any blob of the artifact manifest rawManifest.
+func checkBlobSelection(rawManifest []byte, pullOpts *libartTypes.PullOptions) error {
+	mani, err := manifest.OCI1FromManifest(rawManifest)
+	if err != nil {
+		return err
+	}
+	for _, title := range pullOpts.Titles {
+		if !slices.ContainsFunc(mani.Layers, func(l specV1.Descriptor) bool { return l.Annotations[specV1.AnnotationTitle] == title }) {
+			return fmt.Errorf("%w with title %q in the artifact", libartTypes.ErrBlobNotExist, title)
+		}
+	}
+	for _, d := range pullOpts.Digests {
+		if !slices.ContainsFunc(mani.Layers, func(l specV1.Descriptor) bool { return l.Digest == d }) {
+			return fmt.Errorf("%w with digest %s in the artifact", libartTypes.ErrBlobNotExist, d)
+		}
+	}
+	return nil
+}
+
+// partialPullReference is an ImageReference whose image destinations only
+// store the blobs selected by the pull options.
+type partialPullReference struct {
+	types.ImageReference
+	pullOpts *libartTypes.PullOptions
+}
+
+// newPartialPullLookup returns a function suitable for
+// libimage.CopyOptions.DestinationLookupReferenceFunc which wraps the
+// destination reference to skip all blobs not selected by pullOpts.
+func newPartialPullLookup(pullOpts *libartTypes.PullOptions) func(types.ImageReference) (types.ImageReference, error) {
+	return func(ref types.ImageReference) (types.ImageReference, error) {
+		return &partialPullReference{ImageReference: ref, pullOpts: pullOpts}, nil
+	}
+}
+
+func (r *partialPullReference) NewImageDestination(ctx context.Context, sys *types.SystemContext) (types.ImageDestination, error) {
+	dest, err := r.ImageReference.NewImageDestination(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	return &partialPullDestination{ImageDestination: dest, pullOpts: r.pullOpts}, nil
+}
+
+type partialPullDestination struct {
+	types.ImageDestination
+	pullOpts *libartTypes.PullOptions
+}
+
+// TryReusingBlob reports the blobs which are not selected as present, so the
+// copy neither downloads nor stores them.  The manifest still references them.
+func (d *partialPullDestination) TryReusingBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache, canSubstitute bool) (bool, types.BlobInfo, error) {
+	if !isSelectedBlob(d.pullOpts, info) {
+		logrus.Debugf("Skipping blob %s, it is not selected for the partial pull", info.Digest)
+		return true, info, nil
+	}
+	return d.ImageDestination.TryReusingBlob(ctx, info, cache, canSubstitute)
+}
+
+// MissingBlobs returns the digests of the blobs of the artifact which are not
+// in the store because the artifact was pulled partially.  They are fetched
+// from the registry when they are extracted or mounted.
+func (as ArtifactStore) MissingBlobs(ctx context.Context, nameOrDigest string) ([]digest.Digest, error) {
+	if len(nameOrDigest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := as.missingLayers(arty.Manifest.Layers)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]digest.Digest, 0, len(missing))
+	for _, l := range missing {
+		digests = append(digests, l.Digest)
+	}
+	return digests, nil
+}
+
+// missingLayers returns those of the layers which are not in the store.
+// Blobs shared with other artifacts count as present.
+func (as ArtifactStore) missingLayers(layers []specV1.Descriptor) ([]specV1.Descriptor, error) {
+	var missing []specV1.Descriptor
+	for _, l := range layers {
+		if err := l.Digest.Validate(); err != nil {
+			return nil, err
+		}
+		err := fileutils.Exists(as.blobPath(l.Digest))
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if slices.ContainsFunc(missing, func(m specV1.Descriptor) bool { return m.Digest == l.Digest }) {
+			continue
+		}
+		missing = append(missing, l)
+	}
+	return missing, nil
+}
+
+// layersWithDigest returns the layers of the artifact with the given digest.
+func layersWithDigest(arty *libartifact.Artifact, blobDigest digest.Digest) []specV1.Descriptor {
+	var layers []specV1.Descriptor
+	for _, l := range arty.Manifest.Layers {
+		if l.Digest == blobDigest {
+			layers = append(layers, l)
+		}
+	}
+	return layers
+}
+
+// fetchMissingBlobs downloads those of the layers of the artifact which are
+// not in the store from the registry the artifact was pulled from.
+func (as ArtifactStore) fetchMissingBlobs(ctx context.Context, arty *libartifact.Artifact, layers []specV1.Descriptor) error {
+	return as.fetchMissingBlobsWithContext(ctx, as.SystemContext, arty, layers)
+}
+
+// fetchMissingBlobsWithContext is fetchMissingBlobs accessing the registry
+// with the given system context.  The blobs are fetched to a download
+// directory and only storing them holds the store lock.
+func (as ArtifactStore) fetchMissingBlobsWithContext(ctx context.Context, sys *types.SystemContext, arty *libartifact.Artifact, layers []specV1.Descriptor) error {
+	missing, err := as.missingLayers(layers)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+	if arty.Name == "" {
+		return fmt.Errorf("blob %s is not in the store and the artifact has no name to fetch it from a registry", missing[0].Digest)
+	}
+	named, err := reference.ParseNormalizedNamed(arty.Name)
+	if err != nil {
+		return err
+	}
+	srcRef, err := docker.NewReference(reference.TagNameOnly(named))
+	if err != nil {
+		return err
+	}
+	imgSrc, err := srcRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return fmt.Errorf("fetching the blobs missing from the partially pulled artifact %s: %w", arty.Name, err)
+	}
+	defer imgSrc.Close()
+
+	dir, err := as.newDownloadDir()
+	if err != nil {
+		return err
+	}
+	defer removeDownloadDir(dir)
+	destRef, err := layout.NewReference(dir, "")
+	if err != nil {
+		return err
+	}
+	imageDest, err := destRef.NewImageDestination(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer imageDest.Close()
+
+	for _, l := range missing {
+		logrus.Debugf("Fetching blob %s of the partially pulled artifact %s", l.Digest, arty.Name)
+		if err := fetchBlob(ctx, imgSrc, imageDest, l); err != nil {
+			return fmt.Errorf("fetching blob %s of the partially pulled artifact %s: %w", l.Digest, arty.Name, err)
+		}
+	}
+
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	return as.storeDownloadedBlobs(dir, false)
+}
+
+// fetchBlob copies the blob described by desc from imgSrc to imageDest.  The
+// blob is only stored if its content matches the digest.
+func fetchBlob(ctx context.Context, imgSrc types.ImageSource, imageDest types.ImageDestination, desc specV1.Descriptor) error {
+	reader, _, err := imgSrc.GetBlob(ctx, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	verified := &verifyingReader{reader: reader, expected: desc.Digest, verifier: desc.Digest.Verifier()}
+	_, err = imageDest.PutBlob(ctx, verified, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache, false)
+	return err
+}
+
+// verifyingReader returns an error instead of io.EOF at the end of the stream
+// if the content read does not match the expected digest.  Destinations do not
+// make a blob available until they read io.EOF.
+type verifyingReader struct {
+	reader   io.Reader
+	expected digest.Digest
+	verifier digest.Verifier
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		_, _ = r.verifier.Write(p[:n])
+	}
+	if errors.Is(err, io.EOF) && !r.verifier.Verified() {
+		return n, fmt.Errorf("%w: the fetched content does not match %s", libartTypes.ErrBlobDigestMismatch, r.expected)
+	}
+	return n, err
+}
+
+// blobPath is the path of the blob with the given digest in the store.
+func (as ArtifactStore) blobPath(blobDigest digest.Digest) string {
+	return filepath.Join(as.storePath, "blobs", blobDigest.Algorithm().String(), blobDigest.Encoded())
+}
@@ -0,0 +1,164 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+)
+
+// checkSignaturePolicy evaluates policy for img, the manifest a pull of its
+// reference copies.  If the policy does not allow the image, the returned
+// error names the first requirement which is not met.  If w is set, the
+// policy section used for the reference and its requirements are written to it.
+//
+// The policy has to be evaluated before the copy because the copy reads the
+// source through the blob transfer wrappers, which only provide the public
+// ImageSource interface and thereby lose all sigstore signatures.
+func checkSignaturePolicy(ctx context.Context, policy *signature.Policy, img types.UnparsedImage, w io.Writer) error {
+	name := transports.ImageName(img.Reference())
+	scope, reqs := policyRequirements(policy, img.Reference())
+	if w != nil {
+		descriptions := make([]string, 0, len(reqs))
+		for _, req := range reqs {
+			descriptions = append(descriptions, describePolicyRequirement(req))
+		}
+		if _, err := fmt.Fprintf(w, "Checking %s against the %s: %s\n", name, scope, strings.Join(descriptions, ", ")); err != nil {
+			return err
+		}
+	}
+
+	allowed, err := isRunningImageAllowed(ctx, policy, img)
+	if allowed && err == nil {
+		return nil
+	}
+	// Evaluate the requirements one by one to find the one which is not met,
+	// all of them have to be met for the image to be allowed.
+	for _, req := range reqs {
+		reqAllowed, reqErr := isRunningImageAllowed(ctx, &signature.Policy{Default: signature.PolicyRequirements{req}}, img)
+		if reqAllowed && reqErr == nil {
+			continue
+		}
+		if reqErr == nil {
+			reqErr = errors.New("the image is not allowed")
+		}
+		return fmt.Errorf("%s: signature policy requirement %s of the %s is not met: %w", name, describePolicyRequirement(req), scope, reqErr)
+	}
+	if err == nil {
+		err = errors.New("the image is not allowed by the signature policy")
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
+
+// isRunningImageAllowed evaluates policy for img with a new policy context.
+func isRunningImageAllowed(ctx context.Context, policy *signature.Policy, img types.UnparsedImage) (bool, error) {
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = policyContext.Destroy()
+	}()
+	return policyContext.IsRunningImageAllowed(ctx, img)
+}
+
+// policyRequirements returns a description of the policy section which
+// applies to ref and its requirements.  The section is looked up the same way
+// as by signature.PolicyContext: the most specific scope of the transport of
+// ref, then the default scope of the transport and finally the global default.
+func policyRequirements(policy *signature.Policy, ref types.ImageReference) (string, signature.PolicyRequirements) {
+	transportName := ref.Transport().Name()
+	if transportScopes, ok := policy.Transports[transportName]; ok {
+		scopes := append([]string{ref.PolicyConfigurationIdentity()}, ref.PolicyConfigurationNamespaces()...)
+		scopes = append(scopes, "")
+		for _, scope := range scopes {
+			if reqs, ok := transportScopes[scope]; ok {
+				return fmt.Sprintf("policy section %q of transport %q", scope, transportName), reqs
+			}
+		}
+	}
+	return "default policy section", policy.Default
+}
+
+// describePolicyRequirement returns the type of req and, if set, the paths of
+// the keys it accepts, e.g. "sigstoreSigned (/etc/pki/key.pub)".
+func describePolicyRequirement(req signature.PolicyRequirement) string {
+	var fields struct {
+		Type     string   `json:"type"`
+		KeyPath  string   `json:"keyPath"`
+		KeyPaths []string `json:"keyPaths"`
+	}
+	data, err := json.Marshal(req)
+	if err == nil {
+		err = json.Unmarshal(data, &fields)
+	}
+	if err != nil || fields.Type == "" {
+		return fmt.Sprintf("%T", req)
+	}
+	keys := fields.KeyPaths
+	if fields.KeyPath != "" {
+		keys = append([]string{fields.KeyPath}, keys...)
+	}
+	if len(keys) == 0 {
+		return fields.Type
+	}
+	return fmt.Sprintf("%s (%s)", fields.Type, strings.Join(keys, ", "))
+}
+
+// pinVerifiedSource returns the reference of the manifest of srcRef with
+// the given digest, which checkSignaturePolicy verified, and writes a policy
+// to a temporary file in dir which only accepts that reference.  The copy
+// uses both, so it cannot read a manifest other than the verified one and does
+// not evaluate the signatures again.  The caller must remove the file.
+func pinVerifiedSource(srcRef types.ImageReference, manifestDigest digest.Digest, dir string) (types.ImageReference, string, error) {
+	named := srcRef.DockerReference()
+	if named == nil {
+		return nil, "", fmt.Errorf("%s is not a registry reference", transports.ImageName(srcRef))
+	}
+	digested, err := reference.WithDigest(reference.TrimNamed(named), manifestDigest)
+	if err != nil {
+		return nil, "", err
+	}
+	pinnedRef, err := docker.NewReference(digested)
+	if err != nil {
+		return nil, "", err
+	}
+
+	policy := signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRReject()},
+		Transports: map[string]signature.PolicyTransportScopes{
+			pinnedRef.Transport().Name(): {
+				pinnedRef.PolicyConfigurationIdentity(): signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+			},
+		},
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.CreateTemp(dir, ".policy-*.json")
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, "", err
+	}
+	return pinnedRef, f.Name(), nil
+}
@@ -0,0 +1,69 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/common/libimage"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/sirupsen/logrus"
+)
+
+// pullAndExtract pulls the artifact and extracts all its blobs to the
+// directory pullOpts.ExtractTo.  With pullOpts.NoStore the artifact is pulled
+// into a temporary store which is removed again, so only the extracted files
+// remain.
+func (as ArtifactStore) pullAndExtract(ctx context.Context, name string, opts libimage.CopyOptions, pullOpts libartTypes.PullOptions) (*libartTypes.PullResult, error) {
+	if pullOpts.ExtractTo == "" {
+		return nil, errors.New("an artifact which is not stored must be extracted")
+	}
+	if isPartialPull(&pullOpts) {
+		return nil, errors.New("cannot extract a partially pulled artifact, all blobs are extracted")
+	}
+
+	pullStore := &as
+	if pullOpts.NoStore {
+		// The temporary store is created next to the store rather than
+		// in the system temporary directory, artifacts can be large and
+		// /tmp is often a tmpfs.
+		tmpDir, err := os.MkdirTemp(as.storePath, ".pull-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logrus.Errorf("Removing temporary artifact pull directory %s: %v", tmpDir, err)
+			}
+		}()
+		pullStore, err = NewArtifactStore(tmpDir, as.SystemContext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := pullStore.pull(ctx, name, opts, pullOpts)
+	if err != nil {
+		return nil, err
+	}
+	arty, err := pullStore.Inspect(ctx, result.ManifestDigest.Encoded())
+	if err != nil {
+		return nil, err
+	}
+	// Pulling to a directory replaces the files of an earlier pull.
+	extractOpts := &libartTypes.ExtractOptions{ExtractAll: true, OnConflict: libartTypes.ExtractConflictOverwrite}
+	filenames, err := blobFileNames(arty.Manifest.Layers, extractOpts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pullStore.Extract(ctx, result.ManifestDigest.Encoded(), pullOpts.ExtractTo, extractOpts); err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		result.ExtractedFiles = append(result.ExtractedFiles, filepath.Join(pullOpts.ExtractTo, filename))
+	}
+	return result, nil
+}
@@ -0,0 +1,206 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/common/libimage/platform"
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// PushIndex pushes the artifacts of entries by digest to the repository of
+// dest and then writes an OCI index referencing each of them for its platform
+// to dest and to pushOpts.AdditionalTags.  Only the index is tagged.  Entries
+// with the same platform are rejected before anything is pushed.  The
+// descriptors of the index carry the index annotations of the artifacts,
+// except for their local names.
+//
+// The index refers to the manifests as they are in the local store, so blobs
+// cannot be compressed or encrypted by the push, which would change their
+// digests.  If writing a tag fails, the result lists the tags written before
+// the error.
+func (as ArtifactStore) PushIndex(ctx context.Context, dest string, entries []libartTypes.IndexEntry, opts libimage.CopyOptions, pushOpts libartTypes.PushOptions) (*libartTypes.PushIndexResult, error) {
+	if len(dest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("an index requires at least one artifact")
+	}
+	switch {
+	case pushOpts.RateLimitBytesPerSec < 0:
+		return nil, errNegativeRateLimit
+	case pushOpts.Timeout < 0:
+		return nil, errNegativeTimeout
+	case pushOpts.DryRun:
+		return nil, errors.New("a dry run cannot be combined with pushing an index")
+	case opts.CompressionFormat != nil:
+		return nil, errors.New("pushing an index cannot be combined with compression, the digests of the compressed artifacts are unknown")
+	case pushOpts.ForceCompression:
+		return nil, errors.New("pushing an index cannot be combined with recompression, the digests of the recompressed artifacts are unknown")
+	case opts.OciEncryptLayers != nil:
+		return nil, errors.New("pushing an index cannot be combined with encryption, the digests of the encrypted artifacts are unknown")
+	}
+	// The timeout applies to the pushes of all artifacts together.
+	ctx, cancel := withTimeout(ctx, pushOpts.Timeout)
+	defer cancel()
+	result, err := as.pushIndex(ctx, dest, entries, opts, pushOpts)
+	if err != nil {
+		return result, timeoutError("pushing the index "+dest, pushOpts.Timeout, err)
+	}
+	return result, nil
+}
+
+// pushIndex is PushIndex within the timeout of pushOpts.
+func (as ArtifactStore) pushIndex(ctx context.Context, dest string, entries []libartTypes.IndexEntry, opts libimage.CopyOptions, pushOpts libartTypes.PushOptions) (*libartTypes.PushIndexResult, error) {
+	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", dest))
+	if err != nil {
+		return nil, err
+	}
+	tagRefs, err := additionalTagReferences(destRef, pushOpts.AdditionalTags)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := as.indexManifests(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	rawIndex, err := json.Marshal(specV1.Index{
+		Versioned: specs.Versioned{SchemaVersion: ManifestSchemaVersion},
+		MediaType: specV1.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &libartTypes.PushIndexResult{
+		IndexDigest: digest.FromBytes(rawIndex),
+		Manifests:   manifests,
+	}
+	repo := reference.TrimNamed(destRef.DockerReference())
+	artifactPushOpts := pushOpts
+	artifactPushOpts.AdditionalTags = nil
+	artifactPushOpts.Timeout = 0
+	for i, entry := range entries {
+		artifactDest, err := reference.WithDigest(repo, manifests[i].Digest)
+		if err != nil {
+			return nil, err
+		}
+		pushed, err := as.Push(ctx, entry.Artifact, artifactDest.String(), opts, artifactPushOpts)
+		if pushed != nil {
+			result.Retries += pushed.Retries
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pushing %s: %w", entry.Artifact, err)
+		}
+	}
+
+	sys := as.registrySystemContext(&opts)
+	for _, ref := range append([]types.ImageReference{destRef}, tagRefs...) {
+		if err := as.putIndex(ctx, sys, ref, rawIndex, &opts, pushOpts.RetryBackoff, result); err != nil {
+			if len(result.Tags) > 0 {
+				return result, err
+			}
+			return nil, err
+		}
+		result.Tags = append(result.Tags, ref.DockerReference().String())
+	}
+	return result, nil
+}
+
+// indexManifests returns the descriptors of the manifests of the artifacts of
+// entries with their platforms.
+func (as ArtifactStore) indexManifests(ctx context.Context, entries []libartTypes.IndexEntry) ([]specV1.Descriptor, error) {
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]specV1.Descriptor, 0, len(entries))
+	// platforms maps the platforms already used to their artifact.
+	platforms := map[string]string{}
+	for _, entry := range entries {
+		if entry.Platform.OS == "" || entry.Platform.Architecture == "" {
+			return nil, fmt.Errorf("the platform of %s requires an OS and an architecture", entry.Artifact)
+		}
+		p := entry.Platform
+		p.OS, p.Architecture, p.Variant = platform.Normalize(p.OS, p.Architecture, p.Variant)
+		key := platform.ToString(p.OS, p.Architecture, p.Variant)
+		if p.OSVersion != "" {
+			key += ":" + p.OSVersion
+		}
+		if other, ok := platforms[key]; ok {
+			return nil, fmt.Errorf("artifacts %s and %s are both for platform %s, the platforms of an index must differ", other, entry.Artifact, key)
+		}
+		platforms[key] = entry.Artifact
+
+		arty, _, err := artifacts.GetByNameOrDigest(entry.Artifact)
+		if err != nil {
+			return nil, err
+		}
+		rawManifest, err := json.Marshal(arty.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		// The name of the artifact in the local store is not
+		// meaningful in the registry.
+		annotations := maps.Clone(arty.IndexAnnotations)
+		delete(annotations, specV1.AnnotationRefName)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		manifests = append(manifests, specV1.Descriptor{
+			MediaType:    specV1.MediaTypeImageManifest,
+			ArtifactType: arty.Manifest.ArtifactType,
+			Digest:       digest.FromBytes(rawManifest),
+			Size:         int64(len(rawManifest)),
+			Platform:     &p,
+			Annotations:  annotations,
+		})
+	}
+	return manifests, nil
+}
+
+// putIndex writes the index to ref, retrying as a push does.
+func (as ArtifactStore) putIndex(ctx context.Context, sys *types.SystemContext, ref types.ImageReference, rawIndex []byte, opts *libimage.CopyOptions, backoff bool, result *libartTypes.PushIndexResult) error {
+	maxRetries := defaultMaxRetries
+	if opts.MaxRetries != nil {
+		maxRetries = int(*opts.MaxRetries)
+	}
+	putOnce := func() error {
+		imageDest, err := ref.NewImageDestination(ctx, sys)
+		if err != nil {
+			return err
+		}
+		defer imageDest.Close()
+		return imageDest.PutManifest(ctx, rawIndex, nil)
+	}
+	for attempt := 0; ; attempt++ {
+		err := putOnce()
+		if err == nil || attempt >= maxRetries || !retry.IsErrorRetryable(err) {
+			return err
+		}
+		delay := backoffDelay(opts.RetryDelay, backoff, attempt)
+		logrus.Warnf("Failed, retrying in %s ... (%d/%d). Error: %v", delay, attempt+1, maxRetries, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		result.Retries++
+	}
+}
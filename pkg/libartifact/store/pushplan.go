@@ -0,0 +1,76 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/podman/v5/pkg/libartifact"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// planPush is the dry run of a push of the artifact to refs, the destination
+// followed by the additional tags.  It asks the destination registry which of
+// the config and layer blobs exist in the repository already and reports the
+// other ones as the blobs a push uploads.  Nothing is uploaded, neither are the
+// blobs missing after a partial pull fetched; their sizes are known from the
+// manifest.
+//
+// Compression and encryption change the digests of the blobs, so they cannot
+// be checked in advance.
+func (as ArtifactStore) planPush(ctx context.Context, arty *libartifact.Artifact, refs []types.ImageReference, opts *libimage.CopyOptions) (*libartTypes.PushResult, error) {
+	if opts.CompressionFormat != nil {
+		return nil, errors.New("a dry run cannot be combined with compression, the digests of the compressed blobs are unknown")
+	}
+	if opts.OciEncryptLayers != nil {
+		return nil, errors.New("a dry run cannot be combined with encryption, the digests of the encrypted blobs are unknown")
+	}
+	manifestDigest, err := arty.StoredDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	imageDest, err := refs[0].NewImageDestination(ctx, as.registrySystemContext(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer imageDest.Close()
+
+	result := &libartTypes.PushResult{ManifestDigest: manifestDigest}
+	for _, ref := range refs {
+		result.Tags = append(result.Tags, pushedName(ref))
+	}
+	var checked []digest.Digest
+	for _, desc := range append([]specV1.Descriptor{arty.Manifest.Config}, arty.Manifest.Layers...) {
+		if slices.Contains(checked, desc.Digest) {
+			continue
+		}
+		checked = append(checked, desc.Digest)
+		// Without a blob info cache and substitution this only checks
+		// the destination repository itself.
+		exists, _, err := imageDest.TryReusingBlob(ctx, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, none.NoCache, false)
+		if err != nil {
+			return nil, err
+		}
+		blob := libartTypes.PushBlob{
+			Title:  desc.Annotations[specV1.AnnotationTitle],
+			Digest: desc.Digest,
+			Size:   desc.Size,
+		}
+		if exists {
+			logrus.Debugf("Blob %s exists in %s", desc.Digest, result.Tags[0])
+			result.SkippedBlobs = append(result.SkippedBlobs, blob)
+		} else {
+			result.UploadBlobs = append(result.UploadBlobs, blob)
+		}
+	}
+	return result, nil
+}
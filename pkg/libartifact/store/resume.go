@@ -0,0 +1,226 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// errRangeUnsupported is returned by getBlobFrom for an image source which
+// cannot read part of a blob.
+var errRangeUnsupported = errors.New("the image source does not support range requests")
+
+// stagingPath is the directory the blobs of a resumable pull are downloaded
+// to.  A blob is removed from it once the pull stored it, so a blob left in it
+// is the part of the blob an interrupted pull downloaded.  The directory is
+// protected by the staging lock of the store.
+func (as ArtifactStore) stagingPath() string {
+	return filepath.Join(as.storePath, "staging")
+}
+
+// stagedBlobPath is the path of the blob with the given digest in the staging
+// directory dir.
+func stagedBlobPath(dir string, blobDigest digest.Digest) string {
+	return filepath.Join(dir, blobDigest.Algorithm().String()+"-"+blobDigest.Encoded())
+}
+
+// getStagedBlob returns the blob described by info, downloading it to its file
+// in the staging directory of the transfer at the same time.  If the file has
+// content already, only the remainder of the blob is downloaded with a range
+// request.  If the source does not support range requests the download of the
+// blob starts over.  A staged blob which does not match its digest is removed,
+// so the next pull downloads it again.
+func (s *blobTransferSource) getStagedBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache) (_ io.ReadCloser, _ int64, retErr error) {
+	dir := s.transfer.options.stagingDir
+	path := stagedBlobPath(dir, info.Digest)
+	if !s.transfer.useStaged(path) {
+		// Another read of the same blob downloads it to the file.
+		reader, size, err := s.ImageSource.GetBlob(ctx, info, cache)
+		if err != nil {
+			return nil, -1, err
+		}
+		return s.transfer.networkReadCloser(ctx, reader), size, nil
+	}
+	defer func() {
+		if retErr != nil {
+			s.transfer.releaseStaged(path)
+		}
+	}()
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, -1, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, -1, err
+	}
+	defer func() {
+		if retErr != nil {
+			file.Close()
+		}
+	}()
+	st, err := file.Stat()
+	if err != nil {
+		return nil, -1, err
+	}
+	offset := st.Size()
+	if info.Size >= 0 && offset > info.Size {
+		offset = 0
+	}
+
+	size := info.Size
+	var network io.ReadCloser
+	switch {
+	case offset > 0 && offset == info.Size:
+		logrus.Debugf("Blob %s was downloaded completely before, verifying it", info.Digest)
+	case offset > 0:
+		network, err = getBlobFrom(ctx, s.ImageSource, info, offset)
+		if err != nil {
+			logrus.Debugf("Cannot resume the download of blob %s after %d bytes, restarting it: %v", info.Digest, offset, err)
+			offset = 0
+		} else {
+			logrus.Debugf("Resuming the download of blob %s after %d bytes", info.Digest, offset)
+		}
+	}
+	if offset == 0 {
+		if err := file.Truncate(0); err != nil {
+			return nil, -1, err
+		}
+		network, size, err = s.ImageSource.GetBlob(ctx, info, cache)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		if network != nil {
+			network.Close()
+		}
+		return nil, -1, err
+	}
+
+	readers := []io.Reader{io.NewSectionReader(file, 0, offset)}
+	if network != nil {
+		network = s.transfer.networkReadCloser(ctx, network)
+		readers = append(readers, io.TeeReader(network, file))
+	}
+	return &stagedBlobReader{
+		reader:   &verifyingReader{reader: io.MultiReader(readers...), expected: info.Digest, verifier: info.Digest.Verifier()},
+		network:  network,
+		file:     file,
+		transfer: s.transfer,
+	}, size, nil
+}
+
+// stagedBlobReader reads the staged part of a blob followed by the remainder
+// downloaded from the source, which is appended to the staged part.
+type stagedBlobReader struct {
+	reader io.Reader
+	// network is nil if the blob was staged completely.
+	network  io.ReadCloser
+	file     *os.File
+	transfer *blobTransfer
+}
+
+func (r *stagedBlobReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if errors.Is(err, libartTypes.ErrBlobDigestMismatch) {
+		if removeErr := os.Remove(r.file.Name()); removeErr != nil {
+			logrus.Errorf("Removing staged blob %s: %v", r.file.Name(), removeErr)
+		}
+	}
+	return n, err
+}
+
+func (r *stagedBlobReader) Close() error {
+	defer r.transfer.releaseStaged(r.file.Name())
+	var err error
+	if r.network != nil {
+		err = r.network.Close()
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// getBlobFrom returns the content of the blob described by info after the
+// first offset bytes.  c/image does not export the interface of image sources
+// which can read parts of a blob, so the method is looked up by reflection.
+// Registries which ignore the range send the whole blob, the source then
+// skips the first offset bytes itself.
+func getBlobFrom(ctx context.Context, src types.ImageSource, info types.BlobInfo, offset int64) (io.ReadCloser, error) {
+	method := reflect.ValueOf(src).MethodByName("GetBlobAt")
+	if !method.IsValid() {
+		return nil, errRangeUnsupported
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 3 || methodType.In(2).Kind() != reflect.Slice || methodType.NumOut() != 3 {
+		return nil, errRangeUnsupported
+	}
+	chunk := reflect.New(methodType.In(2).Elem()).Elem()
+	if chunk.Kind() != reflect.Struct {
+		return nil, errRangeUnsupported
+	}
+	chunkOffset, chunkLength := chunk.FieldByName("Offset"), chunk.FieldByName("Length")
+	if chunkOffset.Kind() != reflect.Uint64 || chunkLength.Kind() != reflect.Uint64 {
+		return nil, errRangeUnsupported
+	}
+	chunkOffset.SetUint(uint64(offset))
+	// The maximum length reads up to the end of the blob.
+	chunkLength.SetUint(math.MaxUint64)
+	chunks := reflect.Append(reflect.MakeSlice(methodType.In(2), 0, 1), chunk)
+
+	results := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(info), chunks})
+	if err, ok := results[2].Interface().(error); ok && err != nil {
+		return nil, err
+	}
+	streams, ok := results[0].Interface().(chan io.ReadCloser)
+	if !ok {
+		return nil, errRangeUnsupported
+	}
+	errs, ok := results[1].Interface().(chan error)
+	if !ok {
+		return nil, errRangeUnsupported
+	}
+	select {
+	case stream, ok := <-streams:
+		if !ok {
+			if err := <-errs; err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("no content after %d bytes of blob %s", offset, info.Digest)
+		}
+		return &rangeReader{ReadCloser: stream, errs: errs}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// rangeReader reads the content of a range request, Close returns the error
+// the source reported after the content was read.
+type rangeReader struct {
+	io.ReadCloser
+	errs chan error
+}
+
+func (r *rangeReader) Close() error {
+	err := r.ReadCloser.Close()
+	for e := range r.errs {
+		if err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// blobSourceTransports are the transports whose content can be added to an
+// artifact by SourceBlobs.
+var blobSourceTransports = []string{"dir", "oci-archive"}
+
+// IsBlobSource returns true if path is prefixed with one of the transports
+// SourceBlobs accepts, e.g. "oci-archive:foo.tar".
+func IsBlobSource(path string) bool {
+	transport, _, ok := strings.Cut(path, ":")
+	return ok && slices.Contains(blobSourceTransports, transport)
+}
+
+// SourceBlobs returns the layers of the image or artifact the transport
+// prefixed source refers to as blobs to add to an artifact.  The blobs keep
+// their media type and are named after their title annotation, or their
+// digest if they have none.  The config of the source is not added.
+//
+// The blobs are read from the source when they are added, the returned
+// function must be called to close the source afterwards.
+func (as ArtifactStore) SourceBlobs(ctx context.Context, source string) ([]libartTypes.ArtifactBlob, func() error, error) {
+	if !IsBlobSource(source) {
+		return nil, nil, fmt.Errorf("%s is not an artifact source, supported transports are: %s", source, strings.Join(blobSourceTransports, ", "))
+	}
+	ref, err := alltransports.ParseImageName(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	imgSrc, err := ref.NewImageSource(ctx, as.SystemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	blobs, err := sourceLayerBlobs(ctx, imgSrc)
+	if err != nil {
+		_ = imgSrc.Close()
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return blobs, imgSrc.Close, nil
+}
+
+// sourceLayerBlobs returns the layers of the manifest of imgSrc as blobs
+// reading from imgSrc.  A layer referenced twice is only returned once.
+func sourceLayerBlobs(ctx context.Context, imgSrc types.ImageSource) ([]libartTypes.ArtifactBlob, error) {
+	rawManifest, mediaType, err := imgSrc.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.MIMETypeIsMultiImage(mediaType) {
+		return nil, errors.New("the source is a manifest list, only a single image or artifact can be added")
+	}
+	mani, err := manifest.FromBlob(rawManifest, manifest.NormalizedMIMEType(mediaType))
+	if err != nil {
+		return nil, err
+	}
+	layers := mani.LayerInfos()
+	if len(layers) == 0 {
+		return nil, errors.New("the source has no blobs")
+	}
+	blobs := make([]libartTypes.ArtifactBlob, 0, len(layers))
+	var added []digest.Digest
+	for _, l := range layers {
+		if slices.Contains(added, l.Digest) {
+			continue
+		}
+		added = append(added, l.Digest)
+		fileName := l.Annotations[specV1.AnnotationTitle]
+		if fileName == "" {
+			fileName = strings.ReplaceAll(l.Digest.String(), ":", "-")
+		}
+		blobs = append(blobs, libartTypes.ArtifactBlob{
+			BlobReader: &sourceBlobReader{ctx: ctx, imgSrc: imgSrc, info: l.BlobInfo},
+			FileName:   fileName,
+			MediaType:  l.MediaType,
+		})
+	}
+	return blobs, nil
+}
+
+// sourceBlobReader reads a blob of an image source, which is only opened by
+// the first read, so not all blobs of the source are open at the same time.
+// The content is verified against the digest of the manifest and the blob is
+// closed at its end.
+type sourceBlobReader struct {
+	ctx    context.Context
+	imgSrc types.ImageSource
+	info   types.BlobInfo
+	blob   io.ReadCloser
+	reader io.Reader
+	// err ends the reading, it is io.EOF after all content was read.
+	err error
+}
+
+func (r *sourceBlobReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.blob == nil {
+		blob, _, err := r.imgSrc.GetBlob(r.ctx, r.info, none.NoCache)
+		if err != nil {
+			return 0, err
+		}
+		r.blob = blob
+		r.reader = &verifyingReader{reader: blob, expected: r.info.Digest, verifier: r.info.Digest.Verifier()}
+	}
+	n, err := r.reader.Read(p)
+	if err != nil {
+		if closeErr := r.blob.Close(); closeErr != nil && errors.Is(err, io.EOF) {
+			err = closeErr
+		}
+		r.err = err
+	}
+	return n, err
+}
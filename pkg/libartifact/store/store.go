@@ -3,6 +3,8 @@
 package store
 
 import (
+	"archive/tar"
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,20 +12,37 @@ import (
 	"io"
 	"io/fs"
 	"maps"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/containers/common/libimage"
+	"github.com/containers/common/libimage/platform"
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/directory"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/image"
 	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/archive"
 	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/containers/image/v5/pkg/shortnames"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports"
 	"github.com/containers/image/v5/transports/alltransports"
 	"github.com/containers/image/v5/types"
 	"github.com/containers/podman/v5/pkg/libartifact"
 	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/podman/v5/version"
 	"github.com/containers/storage/pkg/fileutils"
+	"github.com/containers/storage/pkg/ioutils"
+	"github.com/containers/storage/pkg/lockfile"
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/specs-go"
 	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
@@ -39,6 +58,20 @@ const ManifestSchemaVersion = 2
 type ArtifactStore struct {
 	SystemContext *types.SystemContext
 	storePath     string
+	// lock serializes the changes of the index and the blobs of the store.
+	// Every change holds it exclusively and readers of the index hold it
+	// shared, so listing only waits for changes in progress.  Blobs are
+	// downloaded from registries without holding it, see newDownloadDir.  It is a file
+	// lock serializing all processes using the store, and lockfile adds a
+	// mutex shared by all stores of the same path in a process, so
+	// goroutines are serialized as well.  It is not recursive, functions
+	// documented to require it must not take it again.
+	lock *lockfile.LockFile
+	// stagingLock serializes the resumable pulls, which share the staging
+	// directory.  It is taken before the store lock.
+	stagingLock *lockfile.LockFile
+	// observers are shared by all copies of the store.
+	observers *artifactObservers
 }
 
 // NewArtifactStore is a constructor for artifact stores.  Most artifact dealings depend on this. Store path is
@@ -56,6 +89,7 @@ func NewArtifactStore(storePath string, sc *types.SystemContext) (*ArtifactStore
 	artifactStore := &ArtifactStore{
 		storePath:     storePath,
 		SystemContext: sc,
+		observers:     &artifactObservers{},
 	}
 
 	// if the storage dir does not exist, we need to create it.
@@ -63,20 +97,38 @@ func NewArtifactStore(storePath string, sc *types.SystemContext) (*ArtifactStore
 	if err := os.MkdirAll(baseDir, 0700); err != nil {
 		return nil, err
 	}
+	storeLock, err := lockfile.GetLockFile(filepath.Join(storePath, "index.lock"))
+	if err != nil {
+		return nil, err
+	}
+	artifactStore.lock = storeLock
+	stagingLock, err := lockfile.GetLockFile(filepath.Join(storePath, "staging.lock"))
+	if err != nil {
+		return nil, err
+	}
+	artifactStore.stagingLock = stagingLock
 	// if the index file is not present we need to create an empty one
 	if err := fileutils.Exists(artifactStore.indexPath()); err != nil && errors.Is(err, os.ErrNotExist) {
 		if createErr := artifactStore.createEmptyManifest(); createErr != nil {
 			return nil, createErr
 		}
 	}
+	if err := artifactStore.migrate(); err != nil {
+		return nil, err
+	}
 	return artifactStore, nil
 }
 
-// Remove an artifact from the local artifact store
+// Remove an artifact from the local artifact store.  Blobs shared with other
+// artifacts are kept.
 func (as ArtifactStore) Remove(ctx context.Context, name string) (*digest.Digest, error) {
 	if len(name) == 0 {
 		return nil, ErrEmptyArtifactName
 	}
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
 
 	// validate and see if the input is a digest
 	artifacts, err := as.getArtifacts(ctx, nil)
@@ -84,22 +136,52 @@ func (as ArtifactStore) Remove(ctx context.Context, name string) (*digest.Digest
 		return nil, err
 	}
 
-	arty, nameIsDigest, err := artifacts.GetByNameOrDigest(name)
+	arty, _, err := artifacts.GetByNameOrDigest(name)
 	if err != nil {
 		return nil, err
 	}
-	if nameIsDigest {
-		name = arty.Name
-	}
-	ir, err := layout.NewReference(as.storePath, name)
+	artifactDigest, err := arty.StoredDigest()
 	if err != nil {
 		return nil, err
 	}
-	artifactDigest, err := arty.GetDigest()
+	// Only remove the entry of the artifact found, another name may refer
+	// to the same manifest.
+	removed := false
+	err = as.deleteManifests(ctx, func(desc specV1.Descriptor) bool {
+		if removed || desc.Digest != artifactDigest || desc.Annotations[specV1.AnnotationRefName] != arty.Name {
+			return false
+		}
+		removed = true
+		return true
+	})
 	if err != nil {
-		return nil, err
+		return &artifactDigest, err
+	}
+	changes = append(changes, ArtifactEvent{Operation: ArtifactRemoved, Reference: arty.Name, Digest: artifactDigest})
+	return &artifactDigest, as.removeUnusedMountPoint(ctx, artifactDigest)
+}
+
+// removeUnusedMountPoint removes the mountpoint of a removed artifact unless
+// another artifact with the same manifest is still present.
+func (as ArtifactStore) removeUnusedMountPoint(ctx context.Context, artifactDigest digest.Digest) error {
+	mountPoint := as.mountPointPath(artifactDigest)
+	if err := fileutils.Exists(mountPoint); err != nil {
+		return nil
+	}
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, arty := range artifacts {
+		d, err := arty.StoredDigest()
+		if err != nil {
+			return err
+		}
+		if d == artifactDigest {
+			return nil
+		}
 	}
-	return artifactDigest, ir.DeleteImage(ctx, as.SystemContext)
+	return removeMountPoint(mountPoint)
 }
 
 // Inspect an artifact in a local store
@@ -107,7 +189,7 @@ func (as ArtifactStore) Inspect(ctx context.Context, nameOrDigest string) (*liba
 	if len(nameOrDigest) == 0 {
 		return nil, ErrEmptyArtifactName
 	}
-	artifacts, err := as.getArtifacts(ctx, nil)
+	artifacts, err := as.listArtifacts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -115,409 +197,2241 @@ func (as ArtifactStore) Inspect(ctx context.Context, nameOrDigest string) (*liba
 	return inspectData, err
 }
 
-// List artifacts in the local store
-func (as ArtifactStore) List(ctx context.Context) (libartifact.ArtifactList, error) {
-	return as.getArtifacts(ctx, nil)
-}
-
-// Pull an artifact from an image registry to a local store
-func (as ArtifactStore) Pull(ctx context.Context, name string, opts libimage.CopyOptions) error {
-	if len(name) == 0 {
-		return ErrEmptyArtifactName
+// ManifestBytes returns the manifest of the artifact exactly as it is stored
+// in the local store together with its descriptor.  The bytes are not
+// re-marshaled, the digest of the descriptor is computed over them, so they
+// can be signed out of band, e.g. by cosign.  A manifest whose content no
+// longer matches its digest is an error.
+func (as ArtifactStore) ManifestBytes(ctx context.Context, nameOrDigest string) ([]byte, specV1.Descriptor, error) {
+	if len(nameOrDigest) == 0 {
+		return nil, specV1.Descriptor{}, ErrEmptyArtifactName
 	}
-	srcRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", name))
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	artifacts, err := as.getArtifacts(ctx, nil)
 	if err != nil {
-		return err
+		return nil, specV1.Descriptor{}, err
 	}
-	destRef, err := layout.NewReference(as.storePath, name)
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
 	if err != nil {
-		return err
+		return nil, specV1.Descriptor{}, err
 	}
-	copyer, err := libimage.NewCopier(&opts, as.SystemContext)
+	manifestDigest, err := arty.StoredDigest()
 	if err != nil {
-		return err
+		return nil, specV1.Descriptor{}, err
 	}
-	_, err = copyer.Copy(ctx, srcRef, destRef)
+	rawManifest, err := os.ReadFile(as.blobPath(manifestDigest))
 	if err != nil {
-		return err
+		return nil, specV1.Descriptor{}, err
+	}
+	if actual := manifestDigest.Algorithm().FromBytes(rawManifest); actual != manifestDigest {
+		return nil, specV1.Descriptor{}, fmt.Errorf("the stored manifest of artifact %s has digest %s instead of %s", nameOrDigest, actual, manifestDigest)
+	}
+	mediaType := arty.Manifest.MediaType
+	if mediaType == "" {
+		mediaType = specV1.MediaTypeImageManifest
 	}
-	return copyer.Close()
+	return rawManifest, specV1.Descriptor{
+		MediaType:    mediaType,
+		Digest:       manifestDigest,
+		Size:         int64(len(rawManifest)),
+		ArtifactType: arty.Manifest.ArtifactType,
+	}, nil
 }
 
-// Push an artifact to an image registry
-func (as ArtifactStore) Push(ctx context.Context, src, dest string, opts libimage.CopyOptions) error {
-	if len(dest) == 0 {
-		return ErrEmptyArtifactName
+// InspectRemote returns the artifact the fully-qualified name refers to in its
+// registry, accessed with opts.  Only the manifest is fetched, nothing is
+// stored.
+func (as ArtifactStore) InspectRemote(ctx context.Context, name string, opts libimage.CopyOptions) (*libartifact.Artifact, error) {
+	if len(name) == 0 {
+		return nil, ErrEmptyArtifactName
 	}
-	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", dest))
-	if err != nil {
-		return err
+	if shortnames.IsShortName(name) {
+		return nil, fmt.Errorf("the remote artifact %q must be referenced by a fully-qualified name", name)
 	}
-	srcRef, err := layout.NewReference(as.storePath, src)
+	srcRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", name))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	copyer, err := libimage.NewCopier(&opts, as.SystemContext)
+	imgSrc, err := srcRef.NewImageSource(ctx, as.registrySystemContext(&opts))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = copyer.Copy(ctx, srcRef, destRef)
+	defer imgSrc.Close()
+	mani, err := getManifest(ctx, imgSrc)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return copyer.Close()
+	artifact := &libartifact.Artifact{Manifest: mani}
+	artifact.SetName(srcRef.DockerReference().String())
+	return artifact, nil
 }
 
-// Add takes one or more local files and adds them to the local artifact store.  The empty
-// string input is for possible custom artifact types.
-func (as ArtifactStore) Add(ctx context.Context, dest string, paths []string, options *libartTypes.AddOptions) (*digest.Digest, error) {
-	if len(dest) == 0 {
+// List artifacts in the local store
+func (as ArtifactStore) List(ctx context.Context) (libartifact.ArtifactList, error) {
+	return as.listArtifacts(ctx)
+}
+
+// Pull an artifact from an image registry to a local store.  A short name is
+// resolved like an image name, using the short-name aliases and the
+// unqualified-search registries of registries.conf, and the artifact is
+// stored under the fully-qualified name it was pulled from.
+//
+// If pullOpts.ExtractTo is set, all blobs of the pulled artifact are
+// extracted to that directory as well, see pullAndExtract.
+//
+// A name with a digest pins the pull to that manifest, a name with both a tag
+// and a digest is stored under the tag once its manifest matches the digest.
+//
+// The pull stops when ctx is done or pullOpts.Timeout expires.  The blobs
+// which were stored before a failure are removed unless another artifact uses
+// them, only the staged blobs of a resumable pull are kept.
+func (as ArtifactStore) Pull(ctx context.Context, name string, opts libimage.CopyOptions, pullOpts libartTypes.PullOptions) (*libartTypes.PullResult, error) {
+	if len(name) == 0 {
 		return nil, ErrEmptyArtifactName
 	}
-
-	if options.Append && len(options.ArtifactType) > 0 {
-		return nil, errors.New("append option is not compatible with ArtifactType option")
+	if pullOpts.RateLimitBytesPerSec < 0 {
+		return nil, errNegativeRateLimit
+	}
+	if pullOpts.MaxSizeBytes < 0 {
+		return nil, errNegativeMaxSize
+	}
+	if pullOpts.Timeout < 0 {
+		return nil, errNegativeTimeout
+	}
+	if pullOpts.Resume && pullOpts.NoStore {
+		return nil, errors.New("a pull which is not stored cannot be resumed")
 	}
+	name, err := pinManifestDigest(name, &pullOpts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withTimeout(ctx, pullOpts.Timeout)
+	defer cancel()
+	var result *libartTypes.PullResult
+	if pullOpts.ExtractTo != "" || pullOpts.NoStore {
+		result, err = as.pullAndExtract(ctx, name, opts, pullOpts)
+	} else {
+		result, err = as.pull(ctx, name, opts, pullOpts)
+	}
+	if err != nil {
+		return nil, timeoutError("pulling "+name, pullOpts.Timeout, err)
+	}
+	return result, nil
+}
 
-	// currently we don't allow override of the filename ; if a user requirement emerges,
-	// we could seemingly accommodate but broadens possibilities of something bad happening
-	// for things like `artifact extract`
-	if _, hasTitle := options.Annotations[specV1.AnnotationTitle]; hasTitle {
-		return nil, fmt.Errorf("cannot override filename with %s annotation", specV1.AnnotationTitle)
+// pinManifestDigest returns the name to pull for name and sets
+// pullOpts.ManifestDigest to the manifest digest the pull is pinned to.  The
+// docker transport does not support names with both a tag and a digest, so the
+// digest is removed from such a name: the tag is pulled and its manifest is
+// compared to the digest instead.
+func pinManifestDigest(name string, pullOpts *libartTypes.PullOptions) (string, error) {
+	pullName, nameDigest := name, digest.Digest("")
+	// Invalid names are reported by the pull.
+	if ref, err := reference.Parse(name); err == nil {
+		if digested, ok := ref.(reference.Digested); ok {
+			nameDigest = digested.Digest()
+			if tagged, ok := ref.(reference.NamedTagged); ok {
+				pullName = tagged.Name() + ":" + tagged.Tag()
+			}
+		}
+	}
+	switch {
+	case nameDigest != "" && pullOpts.ManifestDigest != "" && nameDigest != pullOpts.ManifestDigest:
+		return "", fmt.Errorf("%s is pinned to another digest than %s", name, pullOpts.ManifestDigest)
+	case nameDigest != "":
+		pullOpts.ManifestDigest = nameDigest
+	case pullOpts.ManifestDigest != "":
+		if err := pullOpts.ManifestDigest.Validate(); err != nil {
+			return "", fmt.Errorf("invalid manifest digest %q: %w", pullOpts.ManifestDigest, err)
+		}
+	case pullOpts.RequireDigest:
+		return "", fmt.Errorf("%s is not pinned to a manifest digest, use %s@sha256:<digest>", name, name)
 	}
+	return pullName, nil
+}
 
-	// Check if artifact already exists
-	artifacts, err := as.getArtifacts(ctx, nil)
+// pull is Pull without extracting the artifact.
+func (as ArtifactStore) pull(ctx context.Context, name string, opts libimage.CopyOptions, pullOpts libartTypes.PullOptions) (*libartTypes.PullResult, error) {
+	if shortnames.IsShortName(name) {
+		return as.pullShortName(ctx, name, opts, pullOpts)
+	}
+	srcRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", name))
+	if err != nil {
+		return nil, err
+	}
+	destRef, err := as.pullDestination(name, &pullOpts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := as.copyFromRegistry(ctx, srcRef, destRef, opts, pullOpts)
 	if err != nil {
 		return nil, err
 	}
+	result.Reference = srcRef.DockerReference().String()
+	as.notifyObservers(&[]ArtifactEvent{{Operation: ArtifactAdded, Reference: pulledName(name, result, &pullOpts), Digest: result.ManifestDigest}})
+	return result, nil
+}
 
-	var artifactManifest specV1.Manifest
-	var oldDigest *digest.Digest
-	fileNames := map[string]struct{}{}
+// pullDestination returns the reference of the store name is pulled to, which
+// has no name for an anonymous pull.
+func (as ArtifactStore) pullDestination(name string, pullOpts *libartTypes.PullOptions) (types.ImageReference, error) {
+	if pullOpts.Anonymous {
+		name = ""
+	}
+	return layout.NewReference(as.storePath, name)
+}
 
-	if !options.Append {
-		// Check if artifact exists; in GetByName not getting an
-		// error means it exists
-		_, _, err := artifacts.GetByNameOrDigest(dest)
-		if err == nil {
-			return nil, fmt.Errorf("%s: %w", dest, libartTypes.ErrArtifactAlreadyExists)
-		}
-		artifactManifest = specV1.Manifest{
-			Versioned:    specs.Versioned{SchemaVersion: ManifestSchemaVersion},
-			MediaType:    specV1.MediaTypeImageManifest,
-			ArtifactType: options.ArtifactType,
-			// TODO This should probably be configurable once the CLI is capable
-			Config: specV1.DescriptorEmptyJSON,
-			Layers: make([]specV1.Descriptor, 0),
+// pulledName is the name the observers are told the pulled artifact was
+// stored under, the manifest digest for an anonymous pull.
+func pulledName(name string, result *libartTypes.PullResult, pullOpts *libartTypes.PullOptions) string {
+	if pullOpts.Anonymous {
+		return result.ManifestDigest.Encoded()
+	}
+	return name
+}
+
+// pullShortName tries to pull the short name from each of its pull candidates
+// in turn, the same way libimage pulls an image, depending on the short-name
+// mode this may prompt for the registry to use.
+func (as ArtifactStore) pullShortName(ctx context.Context, name string, opts libimage.CopyOptions, pullOpts libartTypes.PullOptions) (*libartTypes.PullResult, error) {
+	resolved, err := shortnames.Resolve(as.registrySystemContext(&opts), name)
+	if err != nil {
+		return nil, err
+	}
+	if desc := resolved.Description(); len(desc) > 0 {
+		logrus.Debug(desc)
+		if opts.Writer != nil {
+			if _, err := fmt.Fprintln(opts.Writer, desc); err != nil {
+				return nil, err
+			}
 		}
-	} else {
-		artifact, _, err := artifacts.GetByNameOrDigest(dest)
+	}
+
+	var pullErrors []error
+	for _, candidate := range resolved.PullCandidates {
+		candidateString := candidate.Value.String()
+		srcRef, err := docker.NewReference(candidate.Value)
 		if err != nil {
 			return nil, err
 		}
-		artifactManifest = artifact.Manifest.Manifest
-		oldDigest, err = artifact.GetDigest()
+		destRef, err := as.pullDestination(candidateString, &pullOpts)
 		if err != nil {
 			return nil, err
 		}
-
-		for _, layer := range artifactManifest.Layers {
-			if value, ok := layer.Annotations[specV1.AnnotationTitle]; ok && value != "" {
-				fileNames[value] = struct{}{}
+		if opts.Writer != nil {
+			if _, err := fmt.Fprintf(opts.Writer, "Trying to pull %s...\n", candidateString); err != nil {
+				return nil, err
 			}
 		}
-	}
-
-	for _, path := range paths {
-		fileName := filepath.Base(path)
-		if _, ok := fileNames[fileName]; ok {
-			return nil, fmt.Errorf("%s: %w", fileName, libartTypes.ErrArtifactFileExists)
+		result, err := as.copyFromRegistry(ctx, srcRef, destRef, opts, pullOpts)
+		if err != nil {
+			logrus.Debugf("Error pulling candidate %s: %v", candidateString, err)
+			pullErrors = append(pullErrors, err)
+			continue
 		}
-		fileNames[fileName] = struct{}{}
+		if err := candidate.Record(); err != nil {
+			// The artifact was pulled, recording the alias is best effort.
+			logrus.Errorf("Error recording short-name alias %q: %v", candidateString, err)
+		}
+		result.Reference = candidateString
+		as.notifyObservers(&[]ArtifactEvent{{Operation: ArtifactAdded, Reference: pulledName(candidateString, result, &pullOpts), Digest: result.ManifestDigest}})
+		return result, nil
 	}
+	return nil, resolved.FormatPullErrors(pullErrors)
+}
 
-	ir, err := layout.NewReference(as.storePath, dest)
+// Copy an artifact from one image registry to another without storing it in
+// the local store.  The blobs are streamed from the source to the destination,
+// each blob is retried on its own as for Pull.
+//
+// The credentials in opts are used for both registries unless copyOpts sets
+// the ones of the source or the destination.  A registry without explicit
+// credentials uses the ones of its host in the auth file.
+func (as ArtifactStore) Copy(ctx context.Context, src, dest string, opts libimage.CopyOptions, copyOpts libartTypes.CopyOptions) (*libartTypes.CopyResult, error) {
+	if len(src) == 0 || len(dest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	srcRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", src))
+	if err != nil {
+		return nil, err
+	}
+	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", dest))
 	if err != nil {
 		return nil, err
 	}
+	sourceAuth := copyCredentials(&opts)
+	if copyOpts.SourceUsername != "" {
+		sourceAuth = &types.DockerAuthConfig{Username: copyOpts.SourceUsername, Password: copyOpts.SourcePassword}
+	}
+	destAuth := copyCredentials(&opts)
+	if copyOpts.DestinationUsername != "" {
+		destAuth = &types.DockerAuthConfig{Username: copyOpts.DestinationUsername, Password: copyOpts.DestinationPassword}
+	}
+	// The copy and the lookup of the source use the credentials of the
+	// source, the destination replaces them with its own.
+	opts = *withoutCredentials(opts)
+	if sourceAuth != nil {
+		opts.Username, opts.Password = sourceAuth.Username, sourceAuth.Password
+		opts.IdentityToken = sourceAuth.IdentityToken
+	}
+	opts.DestinationLookupReferenceFunc = newCredentialsLookup(destAuth, newBlobUploadLookup(blobUploadOptions{maxParallel: DefaultMaxParallelUploads}))
+	result, err := as.copyFromRegistry(ctx, srcRef, destRef, opts, libartTypes.PullOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &libartTypes.CopyResult{
+		ManifestDigest: result.ManifestDigest,
+		Platform:       result.Platform,
+	}, nil
+}
 
-	imageDest, err := ir.NewImageDestination(ctx, as.SystemContext)
+// copyFromRegistry copies the artifact at srcRef in a registry to destRef,
+// reading up to pullOpts.MaxParallelDownloads blobs at the same time.  The
+// platform set in opts selects the manifest of a multi-arch index.  The copy
+// fails if the signature policy does not allow the selected manifest.
+func (as ArtifactStore) copyFromRegistry(ctx context.Context, srcRef, destRef types.ImageReference, opts libimage.CopyOptions, pullOpts libartTypes.PullOptions) (*libartTypes.PullResult, error) {
+	retrier := newPullRetry(&opts)
+	var source *resolvedSource
+	err := retrier.do(ctx, func() error {
+		var err error
+		source, err = as.resolveSource(ctx, srcRef, &opts, &pullOpts)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer imageDest.Close()
 
-	// ImageDestination, in general, requires the caller to write a full image; here we may write only the added layers.
-	// This works for the oci/layout transport we hard-code.
-	for _, path := range paths {
-		mediaType := options.FileType
-		// get the new artifact into the local store
-		newBlobDigest, newBlobSize, err := layout.PutBlobFromLocalFile(ctx, imageDest, path)
+	copyRef := srcRef
+	if source.mirror != nil {
+		if copyRef, err = docker.NewReference(source.mirror.ref); err != nil {
+			return nil, err
+		}
+		opts = source.mirror.copyOptions(opts, srcRef.DockerReference())
+	}
+	pinnedRef, policyPath, err := pinVerifiedSource(copyRef, source.manifestDigest, as.storePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.Remove(policyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("Removing temporary signature policy %s: %v", policyPath, err)
+		}
+	}()
+	opts.SignaturePolicyPath = policyPath
+
+	// Blobs are fetched and retried one by one, so the retry of the whole
+	// copy is disabled.
+	transferOpts := blobTransferOptions{
+		maxParallel: pullOpts.MaxParallelDownloads,
+		retry:       retrier,
+		rateLimit:   pullOpts.RateLimitBytesPerSec,
+		maxBytes:    pullOpts.MaxSizeBytes,
+	}
+	if transferOpts.maxParallel == 0 {
+		transferOpts.maxParallel = DefaultMaxParallelDownloads
+	}
+	if pullOpts.Resume {
+		transferOpts.stagingDir = as.stagingPath()
+		as.stagingLock.Lock()
+		defer as.stagingLock.Unlock()
+	}
+	noRetry := uint(0)
+	opts.MaxRetries = &noRetry
+	transfer := newBlobTransfer(transferOpts)
+	opts.SourceLookupReferenceFunc = transfer.lookupSource
+
+	// A pull downloads the artifact to a download directory without
+	// holding the store lock and only stores it with the lock held.
+	toStore := destRef.Transport().Name() == layout.Transport.Name()
+	copyDest := destRef
+	var downloadDir string
+	if toStore {
+		dir, err := as.newDownloadDir()
 		if err != nil {
 			return nil, err
 		}
+		defer removeDownloadDir(dir)
+		downloadDir = dir
+		if copyDest, err = layout.NewReference(dir, ""); err != nil {
+			return nil, err
+		}
+		lookupDownload := as.newDownloadLookup(dir)
+		opts.DestinationLookupReferenceFunc = lookupDownload
+		if isPartialPull(&pullOpts) {
+			lookupPartial := newPartialPullLookup(&pullOpts)
+			opts.DestinationLookupReferenceFunc = func(ref types.ImageReference) (types.ImageReference, error) {
+				ref, err := lookupDownload(ref)
+				if err != nil {
+					return nil, err
+				}
+				return lookupPartial(ref)
+			}
+		}
+	}
 
-		// If we did not receive an override for the layer's mediatype, use
-		// detection to determine it.
-		if len(mediaType) < 1 {
-			mediaType, err = determineManifestType(path)
-			if err != nil {
+	copyer, err := libimage.NewCopier(&opts, as.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	rawManifest, err := copyer.Copy(ctx, pinnedRef, copyDest)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, err := manifest.Digest(rawManifest)
+	if err != nil {
+		return nil, err
+	}
+	if toStore {
+		as.lock.Lock()
+		defer as.lock.Unlock()
+		if err := as.storeDownload(ctx, downloadDir, destRef, rawManifest); err != nil {
+			return nil, err
+		}
+		if pullOpts.Anonymous {
+			if err := as.removeAnonymousDuplicate(manifestDigest); err != nil {
 				return nil, err
 			}
 		}
+	}
+	transfer.removeStaged()
+	result := &libartTypes.PullResult{
+		ManifestDigest:   manifestDigest,
+		Platform:         source.platform,
+		BlobsFetched:     int(transfer.blobs.Load()),
+		BytesTransferred: transfer.bytes.Load(),
+		PinnedDigest:     pullOpts.ManifestDigest,
+		RateLimitWait:    retrier.rateLimitWaited(),
+	}
+	if toStore {
+		result.BlobsSkipped, result.BytesSkipped = as.skippedBlobs(rawManifest, transfer.fetchedBlobs())
+	}
+	if source.mirror != nil {
+		result.Mirror = source.mirror.location
+	}
+	return result, copyer.Close()
+}
 
-		annotations := maps.Clone(options.Annotations)
-		annotations[specV1.AnnotationTitle] = filepath.Base(path)
-		newLayer := specV1.Descriptor{
-			MediaType:   mediaType,
-			Digest:      newBlobDigest,
-			Size:        newBlobSize,
-			Annotations: annotations,
+// skippedBlobs returns the number and size of the distinct blobs of the pulled
+// manifest which are in the store without having been fetched, they were
+// stored before the pull.  Blobs a partial pull did not select are not in the
+// store and not counted.
+func (as ArtifactStore) skippedBlobs(rawManifest []byte, fetched []digest.Digest) (int, int64) {
+	mani, err := manifest.OCI1FromManifest(rawManifest)
+	if err != nil {
+		// Only OCI manifests are counted, the pull itself succeeded.
+		logrus.Debugf("Not counting the skipped blobs of the pulled manifest: %v", err)
+		return 0, 0
+	}
+	var count int
+	var size int64
+	seen := map[digest.Digest]bool{}
+	for _, blob := range append([]specV1.Descriptor{mani.Config}, mani.Layers...) {
+		if seen[blob.Digest] || slices.Contains(fetched, blob.Digest) {
+			continue
+		}
+		seen[blob.Digest] = true
+		if err := fileutils.Exists(as.blobPath(blob.Digest)); err == nil {
+			count++
+			size += blob.Size
 		}
-		artifactManifest.Layers = append(artifactManifest.Layers, newLayer)
 	}
+	return count, size
+}
 
-	rawData, err := json.Marshal(artifactManifest)
+// resolvedSource describes the manifest a copy of a registry reference reads.
+type resolvedSource struct {
+	// manifestDigest is the digest of the manifest of the reference, which
+	// may be a multi-arch index.
+	manifestDigest digest.Digest
+	// platform of the instance selected from a multi-arch index, nil when
+	// the reference is a single manifest.
+	platform *specV1.Platform
+	// mirror which served the manifest, nil for the registry itself.  The
+	// blobs are copied from the same mirror.
+	mirror *pullMirror
+}
+
+// resolveSource looks up the manifest of srcRef and which instance of a
+// multi-arch index will be pulled for the platform set in opts, the same way
+// the image copy chooses it, and checks the signature policy for it as well as
+// the blob selection of a partial pull.
+func (as ArtifactStore) resolveSource(ctx context.Context, srcRef types.ImageReference, opts *libimage.CopyOptions, pullOpts *libartTypes.PullOptions) (*resolvedSource, error) {
+	sys := as.registrySystemContext(opts)
+	policy, err := signature.DefaultPolicy(sys)
 	if err != nil {
 		return nil, err
 	}
-	if err := imageDest.PutManifest(ctx, rawData, nil); err != nil {
+	imgSrc, mirror, err := openPullSource(ctx, sys, srcRef)
+	if err != nil {
 		return nil, err
 	}
+	defer imgSrc.Close()
 
-	unparsed := newUnparsedArtifactImage(ir, artifactManifest)
-	if err := imageDest.Commit(ctx, unparsed); err != nil {
+	rawManifest, manifestType, err := imgSrc.GetManifest(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
-
-	artifactManifestDigest := digest.FromBytes(rawData)
-
-	// the config is an empty JSON stanza i.e. '{}'; if it does not yet exist, it needs
-	// to be created
-	if err := createEmptyStanza(filepath.Join(as.storePath, specV1.ImageBlobsDir, artifactManifestDigest.Algorithm().String(), artifactManifest.Config.Digest.Encoded())); err != nil {
-		logrus.Errorf("failed to check or write empty stanza file: %v", err)
+	source := &resolvedSource{mirror: mirror}
+	source.manifestDigest, err = manifest.Digest(rawManifest)
+	if err != nil {
+		return nil, err
 	}
-
-	// Clean up after append. Remove previous artifact from store.
-	if oldDigest != nil {
-		lrs, err := layout.List(as.storePath)
+	if pinned := pullOpts.ManifestDigest; pinned != "" && pinned != source.manifestDigest {
+		return nil, fmt.Errorf("%w %s: %s refers to %s", libartTypes.ErrDigestMismatch, pinned, srcRef.DockerReference(), source.manifestDigest)
+	}
+	var instanceDigest *digest.Digest
+	if manifest.MIMETypeIsMultiImage(manifestType) {
+		list, err := manifest.ListFromBlob(rawManifest, manifestType)
 		if err != nil {
 			return nil, err
 		}
-
-		for _, l := range lrs {
-			if oldDigest.String() == l.ManifestDescriptor.Digest.String() {
-				if _, ok := l.ManifestDescriptor.Annotations[specV1.AnnotationRefName]; ok {
-					continue
-				}
-
-				if err := l.Reference.DeleteImage(ctx, as.SystemContext); err != nil {
-					return nil, err
-				}
-				break
-			}
+		chosen, err := list.ChooseInstance(sys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", srcRef.DockerReference(), err)
+		}
+		instance, err := list.Instance(chosen)
+		if err != nil {
+			return nil, err
 		}
+		source.platform = instance.ReadOnly.Platform
+		if source.platform == nil {
+			source.platform = &specV1.Platform{OS: sys.OSChoice, Architecture: sys.ArchitectureChoice, Variant: sys.VariantChoice}
+		}
+		instanceDigest = &chosen
 	}
-	return &artifactManifestDigest, nil
-}
 
-func getArtifactAndImageSource(ctx context.Context, as ArtifactStore, nameOrDigest string, options *libartTypes.FilterBlobOptions) (*libartifact.Artifact, types.ImageSource, error) {
-	if len(options.Digest) > 0 && len(options.Title) > 0 {
-		return nil, nil, errors.New("cannot specify both digest and title")
+	// The copy checks the policy for the selected instance only.
+	if err := checkSignaturePolicy(ctx, policy, image.UnparsedInstance(imgSrc, instanceDigest), pullOpts.PolicyWriter); err != nil {
+		return nil, err
 	}
-	if len(nameOrDigest) == 0 {
-		return nil, nil, ErrEmptyArtifactName
+	if !isPartialPull(pullOpts) && pullOpts.MaxSizeBytes == 0 {
+		return source, nil
 	}
-
-	artifacts, err := as.getArtifacts(ctx, nil)
-	if err != nil {
-		return nil, nil, err
+	if instanceDigest != nil {
+		rawManifest, _, err = imgSrc.GetManifest(ctx, instanceDigest)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	arty, nameIsDigest, err := artifacts.GetByNameOrDigest(nameOrDigest)
-	if err != nil {
-		return nil, nil, err
+	if isPartialPull(pullOpts) {
+		if err := checkBlobSelection(rawManifest, pullOpts); err != nil {
+			return nil, fmt.Errorf("%s: %w", srcRef.DockerReference(), err)
+		}
 	}
-	name := nameOrDigest
-	if nameIsDigest {
-		name = arty.Name
+	if err := checkDeclaredSize(rawManifest, pullOpts); err != nil {
+		return nil, fmt.Errorf("%s: %w", srcRef.DockerReference(), err)
 	}
+	return source, nil
+}
 
-	if len(arty.Manifest.Layers) == 0 {
-		return nil, nil, fmt.Errorf("the artifact has no blobs, nothing to extract")
+// checkDeclaredSize returns an error if the blobs of the artifact manifest
+// rawManifest the pull downloads, the config and the selected layers, declare
+// more than pullOpts.MaxSizeBytes together.
+func checkDeclaredSize(rawManifest []byte, pullOpts *libartTypes.PullOptions) error {
+	if pullOpts.MaxSizeBytes == 0 {
+		return nil
 	}
-
+	mani, err := manifest.OCI1FromManifest(rawManifest)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for i, blob := range append([]specV1.Descriptor{mani.Config}, mani.Layers...) {
+		if i > 0 && isPartialPull(pullOpts) && !isSelectedBlob(pullOpts, types.BlobInfo{Digest: blob.Digest, Annotations: blob.Annotations}) {
+			continue
+		}
+		if blob.Size < 0 || size > math.MaxInt64-blob.Size {
+			return fmt.Errorf("%w: blob %s declares an invalid size of %d bytes", libartTypes.ErrMaxSizeExceeded, blob.Digest, blob.Size)
+		}
+		size += blob.Size
+	}
+	if size > pullOpts.MaxSizeBytes {
+		return fmt.Errorf("%w: the blobs declare %d bytes, the limit is %d bytes", libartTypes.ErrMaxSizeExceeded, size, pullOpts.MaxSizeBytes)
+	}
+	return nil
+}
+
+// registrySystemContext returns a copy of the store's system context with the
+// registry access and platform settings of opts applied, mirroring what
+// libimage.NewCopier does for the copy itself.
+func (as ArtifactStore) registrySystemContext(opts *libimage.CopyOptions) *types.SystemContext {
+	sys := &types.SystemContext{}
+	if as.SystemContext != nil {
+		sysCopy := *as.SystemContext
+		sys = &sysCopy
+	}
+	if opts.InsecureSkipTLSVerify != types.OptionalBoolUndefined {
+		sys.DockerInsecureSkipTLSVerify = opts.InsecureSkipTLSVerify
+	}
+	if opts.AuthFilePath != "" {
+		sys.AuthFilePath = opts.AuthFilePath
+	}
+	if opts.CertDirPath != "" {
+		sys.DockerCertPath = opts.CertDirPath
+	}
+	if auth := copyCredentials(opts); auth != nil {
+		sys.DockerAuthConfig = auth
+	}
+	if opts.SignaturePolicyPath != "" {
+		sys.SignaturePolicyPath = opts.SignaturePolicyPath
+	}
+	sys.OSChoice, sys.ArchitectureChoice, sys.VariantChoice = platform.Normalize(opts.OS, opts.Architecture, opts.Variant)
+	return sys
+}
+
+// Push an artifact to an image registry.  If opts.CompressionFormat is set, blobs
+// which are not compressed yet are compressed with it before being pushed, and
+// with pushOpts.ForceCompression the compressed ones are recompressed.
+//
+// A dest prefixed with the oci, oci-archive or dir transport pushes the
+// artifact to that local destination instead, with the same options.  The
+// registry credentials do not apply to it, additional tags and mounting blobs
+// are not supported.
+//
+// Up to pushOpts.MaxParallelUploads blobs are uploaded at the same time and the
+// first failed upload cancels the others.  A retry of the push does not upload
+// the blobs again which were uploaded before the failure.
+//
+// The manifest is also written to each of pushOpts.AdditionalTags of the
+// destination repository.  The blobs are uploaded only once as they already
+// exist in the repository.  If writing a tag fails, the result lists the tags
+// written before the error.
+//
+// Blobs missing in the destination repository are mounted from the
+// repositories of pushOpts.MountFrom on the same registry if the registry
+// allows it, otherwise they are uploaded.  The repositories are recorded as
+// locations of the blobs in the blob info cache of the copy, which only
+// offers locations of blobs whose compression it knows, i.e. blobs pushed or
+// pulled on this host before.  The result counts the mounted and the uploaded
+// blobs.
+//
+// The push, including its retries, stops when ctx is done or
+// pushOpts.Timeout expires.
+func (as ArtifactStore) Push(ctx context.Context, src, dest string, opts libimage.CopyOptions, pushOpts libartTypes.PushOptions) (*libartTypes.PushResult, error) {
+	if len(dest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	if pushOpts.RateLimitBytesPerSec < 0 {
+		return nil, errNegativeRateLimit
+	}
+	if pushOpts.Timeout < 0 {
+		return nil, errNegativeTimeout
+	}
+	if pushOpts.ForceCompression && opts.CompressionFormat == nil {
+		return nil, errors.New("forcing the compression requires a compression format")
+	}
+	ctx, cancel := withTimeout(ctx, pushOpts.Timeout)
+	defer cancel()
+	result, err := as.push(ctx, src, dest, opts, pushOpts)
+	if err != nil {
+		return result, timeoutError("pushing "+src, pushOpts.Timeout, err)
+	}
+	return result, nil
+}
+
+// push is Push within the timeout of pushOpts.
+func (as ArtifactStore) push(ctx context.Context, src, dest string, opts libimage.CopyOptions, pushOpts libartTypes.PushOptions) (*libartTypes.PushResult, error) {
+	destRef, err := pushDestination(dest)
+	if err != nil {
+		return nil, err
+	}
+	if destRef.Transport().Name() != docker.Transport.Name() {
+		if len(pushOpts.AdditionalTags) > 0 {
+			return nil, fmt.Errorf("additional tags require a registry destination, not %s", transports.ImageName(destRef))
+		}
+		if len(pushOpts.MountFrom) > 0 {
+			return nil, fmt.Errorf("mounting blobs requires a registry destination, not %s", transports.ImageName(destRef))
+		}
+	}
+	tagRefs, err := additionalTagReferences(destRef, pushOpts.AdditionalTags)
+	if err != nil {
+		return nil, err
+	}
+	// The blobs a partial pull skipped are fetched from the registry the
+	// artifact was pulled from first.  The credentials in opts are the ones
+	// of the destination, that registry uses its own from the auth file.
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(src)
+	if err != nil {
+		return nil, err
+	}
+	if pushOpts.DryRun {
+		return as.planPush(ctx, arty, append([]types.ImageReference{destRef}, tagRefs...), &opts)
+	}
+	if err := as.fetchMissingBlobsWithContext(ctx, as.registrySystemContext(withoutCredentials(opts)), arty, arty.Manifest.Layers); err != nil {
+		return nil, err
+	}
+	maxParallel := pushOpts.MaxParallelUploads
+	if maxParallel == 0 {
+		maxParallel = DefaultMaxParallelUploads
+	}
+	var mountFrom []reference.Named
+	if len(pushOpts.MountFrom) > 0 {
+		if mountFrom, err = mountSources(destRef.DockerReference(), pushOpts.MountFrom); err != nil {
+			return nil, err
+		}
+	}
+	counts := &blobUploadCounts{}
+	opts.DestinationLookupReferenceFunc = newBlobUploadLookup(blobUploadOptions{
+		maxParallel: maxParallel,
+		rateLimit:   pushOpts.RateLimitBytesPerSec,
+		mountFrom:   mountFrom,
+		counts:      counts,
+	})
+
+	// The push is retried here rather than by the copy, so the delay can
+	// back off and the retries are counted.
+	maxRetries := defaultMaxRetries
+	if opts.MaxRetries != nil {
+		maxRetries = int(*opts.MaxRetries)
+	}
+	retryDelay := opts.RetryDelay
+	noRetry := uint(0)
+	opts.MaxRetries = &noRetry
+
+	result := &libartTypes.PushResult{}
+	pushOnce := func(srcRef types.ImageReference) error {
+		copyer, err := libimage.NewCopier(&opts, as.SystemContext)
+		if err != nil {
+			return err
+		}
+		result.Tags = nil
+		for _, ref := range append([]types.ImageReference{destRef}, tagRefs...) {
+			rawManifest, err := copyer.Copy(ctx, srcRef, ref)
+			if err == nil {
+				result.ManifestDigest, err = manifest.Digest(rawManifest)
+			}
+			if err != nil {
+				_ = copyer.Close()
+				return err
+			}
+			result.Tags = append(result.Tags, pushedName(ref))
+		}
+		return copyer.Close()
+	}
+	push := func(srcRef types.ImageReference) error {
+		for attempt := 0; ; attempt++ {
+			err := pushOnce(srcRef)
+			if err == nil || attempt >= maxRetries || !retry.IsErrorRetryable(err) {
+				return err
+			}
+			delay := backoffDelay(retryDelay, pushOpts.RetryBackoff, attempt)
+			logrus.Warnf("Failed, retrying in %s ... (%d/%d). Error: %v", delay, attempt+1, maxRetries, err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return err
+			}
+			result.Retries++
+		}
+	}
+	if opts.CompressionFormat != nil {
+		algorithm := *opts.CompressionFormat
+		level := opts.CompressionLevel
+		// The blobs are compressed already, the copy must not touch them.
+		opts.CompressionFormat = nil
+		opts.CompressionLevel = nil
+		result.RecompressedBlobs, err = as.pushCompressed(ctx, src, algorithm, level, pushOpts.ForceCompression, push)
+	} else {
+		var srcRef types.ImageReference
+		srcRef, err = layout.NewReference(as.storePath, src)
+		if err != nil {
+			return nil, err
+		}
+		err = push(srcRef)
+	}
+	result.MountedBlobs = int(counts.mounted.Load())
+	result.UploadedBlobs = int(counts.uploaded.Load())
+	if err != nil {
+		if len(result.Tags) > 0 {
+			return result, err
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// pushDestination returns the reference of dest.  A name prefixed with the oci,
+// oci-archive or dir transport is a local destination, e.g.
+// oci-archive:/tmp/artifact.tar, any other name is a registry reference.
+func pushDestination(dest string) (types.ImageReference, error) {
+	if ref, err := alltransports.ParseImageName(dest); err == nil {
+		switch ref.Transport().Name() {
+		case docker.Transport.Name(), layout.Transport.Name(), archive.Transport.Name(), directory.Transport.Name():
+			return ref, nil
+		}
+		return nil, fmt.Errorf("pushing artifacts with the %s transport is not supported", ref.Transport().Name())
+	}
+	return alltransports.ParseImageName(fmt.Sprintf("docker://%s", dest))
+}
+
+// pushedName is the name a push reports for ref, the registry reference or
+// the transport-prefixed name of a local destination.
+func pushedName(ref types.ImageReference) string {
+	if named := ref.DockerReference(); named != nil && ref.Transport().Name() == docker.Transport.Name() {
+		return named.String()
+	}
+	return transports.ImageName(ref)
+}
+
+// additionalTagReferences returns the references of tags in the repository of destRef.
+func additionalTagReferences(destRef types.ImageReference, tags []string) ([]types.ImageReference, error) {
+	refs := make([]types.ImageReference, 0, len(tags))
+	for _, tag := range tags {
+		named, err := reference.WithTag(reference.TrimNamed(destRef.DockerReference()), tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid additional tag %q: %w", tag, err)
+		}
+		ref, err := docker.NewReference(named)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Add takes one or more artifact blobs, either local files or streams, and adds them to the
+// local artifact store.  The empty string input is for possible custom artifact types.
+//
+// When appending, a blob whose digest is already part of the artifact is not added again
+// unless options.AllowDuplicate is set, see libartTypes.AddedBlob.Deduplicated.  With
+// options.Replace an existing artifact of the same name is replaced as a whole.
+//
+// Two blobs of the artifact with the same title are an error naming both of them,
+// unless options.AllowDuplicateTitles is set.
+func (as ArtifactStore) Add(ctx context.Context, dest string, artifactBlobs []libartTypes.ArtifactBlob, options *libartTypes.AddOptions) (*libartTypes.AddResult, error) {
+	if len(dest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+
+	if options.Append && options.Replace {
+		return nil, errors.New("append option is not compatible with Replace option")
+	}
+	if options.Append && options.Subject != nil {
+		return nil, errors.New("append option is not compatible with Subject option")
+	}
+	if options.Append && (options.ConfigFile != "" || options.ConfigType != "") {
+		return nil, errors.New("append option is not compatible with the config options")
+	}
+	if options.ConfigFile != "" && options.ConfigType == "" {
+		return nil, errors.New("a config file requires a config type")
+	}
+
+	// currently we don't allow override of the filename ; if a user requirement emerges,
+	// we could seemingly accommodate but broadens possibilities of something bad happening
+	// for things like `artifact extract`
+	if _, hasTitle := options.Annotations[specV1.AnnotationTitle]; hasTitle {
+		return nil, fmt.Errorf("cannot override filename with %s annotation", specV1.AnnotationTitle)
+	}
+	if _, hasRefName := options.IndexAnnotations[specV1.AnnotationRefName]; hasRefName {
+		return nil, fmt.Errorf("cannot set the %s index annotation, it holds the name of the artifact", specV1.AnnotationRefName)
+	}
+
+	// The blobs are written before the manifest referencing them, a
+	// concurrent removal must not delete them in between.
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	// Check if artifact already exists
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifactManifest specV1.Manifest
+	var oldDigest *digest.Digest
+	var replacedDigest digest.Digest
+	var indexAnnotations map[string]string
+	// fileNames maps the title of all existing blobs to their digest.
+	fileNames := map[string]digest.Digest{}
+	deduplicate := options.Append && !options.AllowDuplicate
+
+	if !options.Append {
+		// Check if artifact exists; in GetByName not getting an
+		// error means it exists
+		existing, _, err := artifacts.GetByNameOrDigest(dest)
+		if err == nil {
+			if !options.Replace {
+				return nil, fmt.Errorf("%s: %w", dest, libartTypes.ErrArtifactAlreadyExists)
+			}
+			// The store is locked until the new manifest took over
+			// the name, so readers never see a partial replacement.
+			if replacedDigest, err = existing.StoredDigest(); err != nil {
+				return nil, err
+			}
+			oldDigest = &replacedDigest
+		}
+		artifactManifest = specV1.Manifest{
+			Versioned:    specs.Versioned{SchemaVersion: ManifestSchemaVersion},
+			MediaType:    specV1.MediaTypeImageManifest,
+			ArtifactType: options.ArtifactType,
+			Config:       specV1.DescriptorEmptyJSON,
+			Layers:       make([]specV1.Descriptor, 0),
+			Subject:      options.Subject,
+			// Appending keeps the creation time of the artifact.
+			Annotations: map[string]string{
+				specV1.AnnotationCreated: time.Now().UTC().Format(time.RFC3339Nano),
+			},
+		}
+	} else {
+		artifact, _, err := artifacts.GetByNameOrDigest(dest)
+		if err != nil {
+			return nil, err
+		}
+		artifactManifest = artifact.Manifest.Manifest
+		if err := checkAppendedArtifactType(dest, artifactManifest.ArtifactType, options.ArtifactType); err != nil {
+			return nil, err
+		}
+		appendedDigest, err := artifact.StoredDigest()
+		if err != nil {
+			return nil, err
+		}
+		oldDigest = &appendedDigest
+		indexAnnotations = maps.Clone(artifact.IndexAnnotations)
+
+		for _, layer := range artifactManifest.Layers {
+			if value, ok := layer.Annotations[specV1.AnnotationTitle]; ok && value != "" {
+				fileNames[value] = layer.Digest
+			}
+		}
+	}
+
+	if len(options.ManifestAnnotations) > 0 {
+		artifactManifest.Annotations = maps.Clone(artifactManifest.Annotations)
+		if artifactManifest.Annotations == nil {
+			artifactManifest.Annotations = make(map[string]string)
+		}
+		maps.Copy(artifactManifest.Annotations, options.ManifestAnnotations)
+	}
+	if len(options.IndexAnnotations) > 0 {
+		if indexAnnotations == nil {
+			indexAnnotations = make(map[string]string)
+		}
+		maps.Copy(indexAnnotations, options.IndexAnnotations)
+	}
+
+	var checkMediaType func(string) error
+	if options.StrictType {
+		if artifactManifest.ArtifactType == "" {
+			return nil, errors.New("strict type checking requires an artifact type")
+		}
+		checkMediaType = func(mediaType string) error {
+			return checkBlobMediaType(artifactManifest.ArtifactType, mediaType)
+		}
+	}
+
+	if !options.AllowDuplicateTitles {
+		if err := checkBlobTitles(dest, artifactBlobs, fileNames, deduplicate); err != nil {
+			return nil, err
+		}
+	}
+
+	var autoAnnotations map[string]string
+	if options.AutoAnnotate {
+		autoAnnotations, err = addAnnotations()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// layerIndexes maps the digest of all blobs to their index in the layers.
+	layerIndexes := map[digest.Digest]int{}
+	if deduplicate {
+		for i, layer := range artifactManifest.Layers {
+			if _, ok := layerIndexes[layer.Digest]; !ok {
+				layerIndexes[layer.Digest] = i
+			}
+		}
+	}
+
+	ir, err := layout.NewReference(as.storePath, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	imageDest, err := ir.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	defer imageDest.Close()
+
+	if options.ConfigFile != "" {
+		configDigest, configSize, err := putBlobFromFile(ctx, imageDest, options.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("adding config: %w", err)
+		}
+		artifactManifest.Config = specV1.Descriptor{
+			MediaType: options.ConfigType,
+			Digest:    configDigest,
+			Size:      configSize,
+		}
+	} else if options.ConfigType != "" {
+		artifactManifest.Config.MediaType = options.ConfigType
+	}
+
+	// ImageDestination, in general, requires the caller to write a full image; here we may write only the added layers.
+	// This works for the oci/layout transport we hard-code.
+	addedBlobs := make([]libartTypes.AddedBlob, 0, len(artifactBlobs))
+	for _, blob := range artifactBlobs {
+		var (
+			newBlobDigest digest.Digest
+			newBlobSize   int64
+			mediaType     string
+		)
+		if blob.StoredBlob != nil {
+			// The blob is already in the store, the manifest only
+			// references it.
+			newBlobDigest, newBlobSize, mediaType, err = as.checkStoredBlob(blob, options.FileType, checkMediaType)
+		} else {
+			// get the new artifact into the local store
+			newBlobDigest, newBlobSize, mediaType, err = putArtifactBlob(ctx, imageDest, blob, options.FileType, checkMediaType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		addedBlob := libartTypes.AddedBlob{
+			FileName:  blob.FileName,
+			Digest:    newBlobDigest,
+			MediaType: mediaType,
+		}
+
+		if i, ok := layerIndexes[newBlobDigest]; ok {
+			existing := &artifactManifest.Layers[i]
+			if len(options.Annotations) > 0 {
+				existing.Annotations = maps.Clone(existing.Annotations)
+				if existing.Annotations == nil {
+					existing.Annotations = make(map[string]string)
+				}
+				maps.Copy(existing.Annotations, options.Annotations)
+			}
+			addedBlob.Deduplicated = true
+			addedBlob.MediaType = existing.MediaType
+			addedBlobs = append(addedBlobs, addedBlob)
+			continue
+		}
+
+		annotations := make(map[string]string)
+		if blob.StoredBlob != nil {
+			maps.Copy(annotations, blob.StoredBlob.Annotations)
+		}
+		maps.Copy(annotations, autoAnnotations)
+		maps.Copy(annotations, options.Annotations)
+		annotations[specV1.AnnotationTitle] = blob.FileName
+		if options.RecordMode && blob.BlobFilePath != "" {
+			if err := recordFileMode(blob.BlobFilePath, annotations); err != nil {
+				return nil, err
+			}
+		}
+		newLayer := specV1.Descriptor{
+			MediaType:   mediaType,
+			Digest:      newBlobDigest,
+			Size:        newBlobSize,
+			Annotations: annotations,
+		}
+		if deduplicate {
+			layerIndexes[newBlobDigest] = len(artifactManifest.Layers)
+		}
+		artifactManifest.Layers = append(artifactManifest.Layers, newLayer)
+		addedBlob.Shared = blob.StoredBlob != nil
+		addedBlobs = append(addedBlobs, addedBlob)
+	}
+
+	rawData, err := json.Marshal(artifactManifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := imageDest.PutManifest(ctx, rawData, nil); err != nil {
+		return nil, err
+	}
+
+	unparsed := newUnparsedArtifactImage(ir, artifactManifest)
+	if err := imageDest.Commit(ctx, unparsed); err != nil {
+		return nil, err
+	}
+
+	artifactManifestDigest := digest.FromBytes(rawData)
+	// Writing the manifest replaced the entry of the name in the index.
+	if err := as.setIndexAnnotations(artifactManifestDigest, dest, indexAnnotations); err != nil {
+		return nil, err
+	}
+
+	// the config is an empty JSON stanza i.e. '{}' unless a config file was given;
+	// if it does not yet exist, it needs to be created
+	if artifactManifest.Config.Digest == specV1.DescriptorEmptyJSON.Digest {
+		if err := createEmptyStanza(filepath.Join(as.storePath, specV1.ImageBlobsDir, artifactManifestDigest.Algorithm().String(), artifactManifest.Config.Digest.Encoded())); err != nil {
+			logrus.Errorf("failed to check or write empty stanza file: %v", err)
+		}
+	}
+	changes = append(changes, ArtifactEvent{Operation: ArtifactAdded, Reference: dest, Digest: artifactManifestDigest})
+
+	// Clean up after append or replace. Remove previous artifact from store.
+	if oldDigest != nil {
+		// A mount of the previous artifact would not show the appended blob.
+		if err := as.removeReplacedManifest(ctx, *oldDigest); err != nil {
+			return nil, err
+		}
+	}
+	return &libartTypes.AddResult{
+		ManifestDigest: artifactManifestDigest,
+		Blobs:          addedBlobs,
+		ReplacedDigest: replacedDigest,
+	}, nil
+}
+
+// checkAppendedArtifactType returns an error if the artifact type given when
+// appending to the artifact dest of type existing differs from it.  An empty
+// type keeps the existing one.
+func checkAppendedArtifactType(dest, existing, artifactType string) error {
+	if artifactType == "" || artifactType == existing {
+		return nil
+	}
+	if existing == "" {
+		return fmt.Errorf("%w: appending to %s with the type %q, it has no type", libartTypes.ErrArtifactTypeConflict, dest, artifactType)
+	}
+	return fmt.Errorf("%w: appending to %s with the type %q, it has the type %q", libartTypes.ErrArtifactTypeConflict, dest, artifactType, existing)
+}
+
+// addAnnotations returns the annotations AddOptions.AutoAnnotate adds to the
+// blobs.
+func addAnnotations() (map[string]string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("getting the hostname to annotate the blobs with: %w", err)
+	}
+	return map[string]string{
+		specV1.AnnotationCreated:            time.Now().UTC().Format(time.RFC3339Nano),
+		libartTypes.AnnotationHostname:      hostname,
+		libartTypes.AnnotationPodmanVersion: version.Version.String(),
+	}, nil
+}
+
+// setIndexAnnotations adds annotations to the entry of the manifest with the
+// given digest and name in the index of the store.  The caller must hold the
+// store lock.
+func (as ArtifactStore) setIndexAnnotations(manifestDigest digest.Digest, name string, annotations map[string]string) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	index, err := as.readIndex()
+	if err != nil {
+		return err
+	}
+	for i, desc := range index.Manifests {
+		if desc.Digest != manifestDigest || desc.Annotations[specV1.AnnotationRefName] != name {
+			continue
+		}
+		updated := maps.Clone(desc.Annotations)
+		maps.Copy(updated, annotations)
+		updated[specV1.AnnotationRefName] = name
+		index.Manifests[i].Annotations = updated
+		return as.writeIndex(index)
+	}
+	return fmt.Errorf("%s: %w", name, libartTypes.ErrArtifactNotExist)
+}
+
+// checkBlobTitles returns an error if two of artifactBlobs or one of them and
+// a blob of the artifact dest, whose titles map to their digests in fileNames,
+// have the same title.  With deduplicate, a file with the title and content of
+// an existing blob is not a conflict, it is not added again.
+func checkBlobTitles(dest string, artifactBlobs []libartTypes.ArtifactBlob, fileNames map[string]digest.Digest, deduplicate bool) error {
+	// newFileNames maps the titles of the added blobs to their source.
+	newFileNames := map[string]string{}
+	for i := range artifactBlobs {
+		blob := &artifactBlobs[i]
+		source := blobSource(blob)
+		if other, ok := newFileNames[blob.FileName]; ok {
+			return duplicateTitleError(blob.FileName, other, source)
+		}
+		newFileNames[blob.FileName] = source
+		existingDigest, ok := fileNames[blob.FileName]
+		if !ok {
+			continue
+		}
+		existing := fmt.Sprintf("blob %s of %s", existingDigest, dest)
+		// Re-adding an unchanged file is deduplicated, only a file with the
+		// same name and different content is a conflict.
+		if deduplicate && blob.StoredBlob != nil {
+			if blob.StoredBlob.Digest != existingDigest {
+				return duplicateTitleError(blob.FileName, existing, source)
+			}
+			continue
+		}
+		if !deduplicate || blob.BlobFilePath == "" {
+			return duplicateTitleError(blob.FileName, existing, source)
+		}
+		if same, err := fileHasDigest(blob.BlobFilePath, existingDigest); err != nil {
+			return err
+		} else if !same {
+			return duplicateTitleError(blob.FileName, existing, source)
+		}
+	}
+	return nil
+}
+
+// duplicateTitleError is the error for a blob whose title is already used by
+// another blob of the artifact, which would make extracting it by title
+// ambiguous.  first and second describe where the two blobs come from.
+func duplicateTitleError(title, first, second string) error {
+	return fmt.Errorf("%s: %w: %s and %s have the same title", title, libartTypes.ErrArtifactFileExists, first, second)
+}
+
+// blobSource describes where the content of blob is read from.
+func blobSource(blob *libartTypes.ArtifactBlob) string {
+	switch {
+	case blob.BlobFilePath != "":
+		return blob.BlobFilePath
+	case blob.StoredBlob != nil:
+		return fmt.Sprintf("stored blob %s", blob.StoredBlob.Digest)
+	default:
+		return "the input stream"
+	}
+}
+
+// removeAnonymousDuplicate removes the entry without a name an anonymous pull
+// added to the index of the store for the manifest with the given digest if
+// the manifest is also stored under a name, so the artifact is not listed
+// twice.  The caller must hold the store lock.
+func (as ArtifactStore) removeAnonymousDuplicate(manifestDigest digest.Digest) error {
+	index, err := as.readIndex()
+	if err != nil {
+		return err
+	}
+	named := slices.ContainsFunc(index.Manifests, func(desc specV1.Descriptor) bool {
+		return desc.Digest == manifestDigest && desc.Annotations[specV1.AnnotationRefName] != ""
+	})
+	if !named {
+		return nil
+	}
+	index.Manifests = slices.DeleteFunc(index.Manifests, func(desc specV1.Descriptor) bool {
+		return desc.Digest == manifestDigest && desc.Annotations[specV1.AnnotationRefName] == ""
+	})
+	return as.writeIndex(index)
+}
+
+// removeReplacedManifest removes the manifest with the given digest after its
+// name was moved to a new manifest, unless it is still used by another name,
+// together with its mountpoint.
+func (as ArtifactStore) removeReplacedManifest(ctx context.Context, oldDigest digest.Digest) error {
+	err := as.deleteManifests(ctx, func(desc specV1.Descriptor) bool {
+		_, named := desc.Annotations[specV1.AnnotationRefName]
+		return !named && desc.Digest == oldDigest
+	})
+	if err != nil {
+		return err
+	}
+	return as.removeUnusedMountPoint(ctx, oldDigest)
+}
+
+// fileHasDigest returns whether the content of the file at path has the given digest.
+func fileHasDigest(path string, expected digest.Digest) (bool, error) {
+	if err := expected.Validate(); err != nil {
+		return false, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	actual, err := expected.Algorithm().FromReader(f)
+	if err != nil {
+		return false, err
+	}
+	return actual == expected, nil
+}
+
+// putArtifactBlob writes a single blob to imageDest and returns its digest, size and
+// media type.  If fileType is empty, the media type is detected from the blob content.
+// If checkMediaType is set, it is called with the media type before the blob is
+// written, so a rejected blob is not stored.
+func putArtifactBlob(ctx context.Context, imageDest types.ImageDestination, blob libartTypes.ArtifactBlob, fileType string, checkMediaType func(string) error) (digest.Digest, int64, string, error) {
+	var err error
+	mediaType := fileType
+	if len(blob.MediaType) > 0 {
+		mediaType = blob.MediaType
+	}
+	if checkMediaType == nil {
+		checkMediaType = func(string) error { return nil }
+	}
+
+	if blob.BlobReader == nil {
+		// If we did not receive an override for the layer's mediatype, use
+		// detection to determine it.
+		if len(mediaType) < 1 {
+			mediaType, err = determineManifestType(blob.BlobFilePath, blob.FileName)
+			if err != nil {
+				return "", -1, "", err
+			}
+		}
+		if err := checkMediaType(mediaType); err != nil {
+			return "", -1, "", fmt.Errorf("%s: %w", blob.FileName, err)
+		}
+		newBlobDigest, newBlobSize, err := putBlobFromFile(ctx, imageDest, blob.BlobFilePath)
+		if err != nil {
+			return "", -1, "", err
+		}
+		return newBlobDigest, newBlobSize, mediaType, nil
+	}
+
+	// A stream can only be read once, so peek at the beginning of it for the
+	// media type detection before handing it over to the destination.
+	reader := bufio.NewReader(blob.BlobReader)
+	header, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", -1, "", err
+	}
+	if len(header) == 0 {
+		return "", -1, "", fmt.Errorf("%s: no data read from input stream, refusing to add an empty blob", blob.FileName)
+	}
+	if len(mediaType) < 1 {
+		mediaType = detectMediaType(blob.FileName, header)
+	}
+	if err := checkMediaType(mediaType); err != nil {
+		return "", -1, "", fmt.Errorf("%s: %w", blob.FileName, err)
+	}
+	blobInfo, err := imageDest.PutBlob(ctx, reader, types.BlobInfo{Size: -1}, none.NoCache, false)
+	if err != nil {
+		return "", -1, "", err
+	}
+	return blobInfo.Digest, blobInfo.Size, mediaType, nil
+}
+
+// putBlobFromFile writes the content of the file at path to imageDest as a
+// blob and returns its digest and size.  The content is streamed and hashed
+// while it is written, so the file is read once and never held in memory as a
+// whole, however large it is.  A regular file whose size changes while it is
+// read is an error, other files like pipes are read until their end.
+func putBlobFromFile(ctx context.Context, imageDest types.ImageDestination, path string) (digest.Digest, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", -1, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return "", -1, err
+	}
+	size := int64(-1)
+	if st.Mode().IsRegular() {
+		size = st.Size()
+	}
+	blobInfo, err := imageDest.PutBlob(ctx, f, types.BlobInfo{Size: size}, none.NoCache, false)
+	if err != nil {
+		return "", -1, fmt.Errorf("%s: %w", path, err)
+	}
+	return blobInfo.Digest, blobInfo.Size, nil
+}
+
+func getArtifactAndImageSource(ctx context.Context, as ArtifactStore, nameOrDigest string, options *libartTypes.FilterBlobOptions) (*libartifact.Artifact, types.ImageSource, error) {
+	if len(options.Digest) > 0 && len(options.Title) > 0 {
+		return nil, nil, errors.New("cannot specify both digest and title")
+	}
+	if options.Index != nil && (len(options.Digest) > 0 || len(options.Title) > 0) {
+		return nil, nil, errors.New("cannot specify index together with digest or title")
+	}
+	if len(nameOrDigest) == 0 {
+		return nil, nil, ErrEmptyArtifactName
+	}
+	as.lock.RLock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	arty, nameIsDigest, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := nameOrDigest
+	if nameIsDigest {
+		name = arty.Name
+	}
+
+	if len(arty.Manifest.Layers) == 0 {
+		return nil, nil, fmt.Errorf("the artifact has no blobs, nothing to extract")
+	}
+
 	ir, err := layout.NewReference(as.storePath, name)
 	if err != nil {
 		return nil, nil, err
 	}
-	imgSrc, err := ir.NewImageSource(ctx, as.SystemContext)
-	return arty, imgSrc, err
+	imgSrc, err := ir.NewImageSource(ctx, as.SystemContext)
+	return arty, imgSrc, err
+}
+
+// BlobMountPaths allows the caller to access the file names from the store and how they should be mounted.
+func (as ArtifactStore) BlobMountPaths(ctx context.Context, nameOrDigest string, options *libartTypes.BlobMountPathOptions) ([]libartTypes.BlobMountPath, error) {
+	arty, imgSrc, err := getArtifactAndImageSource(ctx, as, nameOrDigest, &options.FilterBlobOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer imgSrc.Close()
+
+	if isBlobFilterSet(&options.FilterBlobOptions) {
+		digest, err := findDigest(arty, &options.FilterBlobOptions)
+		if err != nil {
+			return nil, err
+		}
+		// In case the digest is set we always use it as target name
+		// so we do not have to get the actual title annotation form the blob.
+		filename, err := generateArtifactBlobName(filteredBlobTitle(arty, &options.FilterBlobOptions), digest)
+		if err != nil {
+			return nil, err
+		}
+		if err := as.fetchMissingBlobs(ctx, arty, layersWithDigest(arty, digest)); err != nil {
+			return nil, err
+		}
+		path, err := layout.GetLocalBlobPath(ctx, imgSrc, digest)
+		if err != nil {
+			return nil, err
+		}
+		return []libartTypes.BlobMountPath{{
+			SourcePath: path,
+			Name:       filename,
+		}}, nil
+	}
+
+	if err := as.fetchMissingBlobs(ctx, arty, arty.Manifest.Layers); err != nil {
+		return nil, err
+	}
+	mountPaths := make([]libartTypes.BlobMountPath, 0, len(arty.Manifest.Layers))
+	for _, l := range arty.Manifest.Layers {
+		title := l.Annotations[specV1.AnnotationTitle]
+		filename, err := generateArtifactBlobName(title, l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		path, err := layout.GetLocalBlobPath(ctx, imgSrc, l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		mountPaths = append(mountPaths, libartTypes.BlobMountPath{
+			SourcePath: path,
+			Name:       filename,
+		})
+	}
+	return mountPaths, nil
+}
+
+// Extract an artifact to local file or directory.  Files which exist already
+// at the destination of a blob are handled according to options.OnConflict,
+// the result lists the ones which were skipped.
+func (as ArtifactStore) Extract(ctx context.Context, nameOrDigest string, target string, options *libartTypes.ExtractOptions) (*libartTypes.ExtractResult, error) {
+	arty, imgSrc, err := getArtifactAndImageSource(ctx, as, nameOrDigest, &options.FilterBlobOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer imgSrc.Close()
+	if options.StripComponents < 0 {
+		return nil, fmt.Errorf("invalid number of path elements to strip: %d", options.StripComponents)
+	}
+	switch options.OnConflict {
+	case "", libartTypes.ExtractConflictFail, libartTypes.ExtractConflictSkip, libartTypes.ExtractConflictOverwrite:
+	default:
+		return nil, fmt.Errorf("invalid conflict policy %q, must be %s, %s or %s", options.OnConflict, libartTypes.ExtractConflictSkip, libartTypes.ExtractConflictOverwrite, libartTypes.ExtractConflictFail)
+	}
+	extractor := blobExtractor{as: as, arty: arty, imgSrc: imgSrc, verify: options.Verify, decompress: options.Decompress, preserveMode: options.PreserveMode, preserveOwner: options.PreserveOwner}
+
+	// With blob filters any number of blobs can be selected, filtered is
+	// nil without them.
+	var filtered []specV1.Descriptor
+	if len(options.BlobFilters) > 0 {
+		if options.Index != nil {
+			return nil, errors.New("cannot specify index together with blob filters")
+		}
+		filtered = filterLayers(arty, options)
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("%w of the artifact matches the filters", libartTypes.ErrBlobNotExist)
+		}
+	}
+
+	if options.Writer != nil {
+		if len(target) > 0 {
+			return nil, errors.New("cannot extract to both a target path and a writer")
+		}
+		if options.ExtractAll {
+			return nil, errors.New("cannot extract all blobs to a stream")
+		}
+		if filtered != nil {
+			if len(filtered) > 1 {
+				return nil, fmt.Errorf("%d blobs match the filters, only a single blob can be streamed", len(filtered))
+			}
+			return &libartTypes.ExtractResult{}, extractor.toWriter(ctx, filtered[0].Digest, options.Writer)
+		}
+		digest := arty.Manifest.Layers[0].Digest
+		if len(arty.Manifest.Layers) > 1 || options.Index != nil {
+			if !isBlobFilterSet(&options.FilterBlobOptions) {
+				return nil, errors.New("the artifact consists of several blobs and neither digest, title or index was specified to only stream a single blob")
+			}
+			digest, err = findDigest(arty, &options.FilterBlobOptions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &libartTypes.ExtractResult{}, extractor.toWriter(ctx, digest, options.Writer)
+	}
+
+	if options.TarOutput != nil {
+		if len(target) > 0 || options.Writer != nil {
+			return nil, errors.New("cannot extract to a tar stream together with a target path or a writer")
+		}
+		if options.Decompress {
+			return nil, errors.New("cannot decompress blobs extracted to a tar stream")
+		}
+		if options.ExtractAll && isBlobFilterSet(&options.FilterBlobOptions) {
+			return nil, errors.New("cannot extract all blobs when a digest, title or index is specified")
+		}
+		layers, filenames, err := extractedLayers(arty, options, filtered)
+		if err != nil {
+			return nil, err
+		}
+		return &libartTypes.ExtractResult{}, extractor.toTar(ctx, layers, filenames, options.TarOutput)
+	}
+
+	// check if dest is a dir to know if we can copy more than one blob
+	destIsFile := true
+	stat, err := os.Stat(target)
+	if err == nil {
+		destIsFile = !stat.IsDir()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if options.ExtractAll {
+		if isBlobFilterSet(&options.FilterBlobOptions) {
+			return nil, errors.New("cannot extract all blobs when a digest, title or index is specified")
+		}
+		if destIsFile {
+			if stat != nil {
+				return nil, fmt.Errorf("the target %q must be a directory to extract all blobs", target)
+			}
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return nil, err
+			}
+			destIsFile = false
+		}
+	}
+
+	if destIsFile && filtered != nil {
+		if len(filtered) > 1 {
+			return nil, fmt.Errorf("%d blobs match the filters and the target %q is not a directory", len(filtered), target)
+		}
+		return extractor.toNewFile(ctx, filtered[0], target, options.OnConflict)
+	}
+
+	if destIsFile {
+		layer := arty.Manifest.Layers[0]
+		if len(arty.Manifest.Layers) > 1 || options.Index != nil {
+			if !isBlobFilterSet(&options.FilterBlobOptions) {
+				return nil, fmt.Errorf("the artifact consists of several blobs and the target %q is not a directory and neither digest, title or index was specified to only copy a single blob", target)
+			}
+			i, err := findLayerIndex(arty, &options.FilterBlobOptions)
+			if err != nil {
+				return nil, err
+			}
+			layer = arty.Manifest.Layers[i]
+		}
+
+		return extractor.toNewFile(ctx, layer, target, options.OnConflict)
+	}
+
+	// The names are computed first so we do not write anything when two
+	// blobs would end up with the same file name.
+	layers, filenames, err := extractedLayers(arty, options, filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]string, 0, len(filenames))
+	for i, filename := range filenames {
+		if err := checkExtractPath(target, filename); err != nil {
+			return nil, fmt.Errorf("blob %s: %w", layers[i].Digest, err)
+		}
+		targets = append(targets, filepath.Join(target, filename))
+	}
+	skip, err := conflictingTargets(targets, options.OnConflict)
+	if err != nil {
+		return nil, err
+	}
+	result := &libartTypes.ExtractResult{}
+	for i, l := range layers {
+		if skip[targets[i]] {
+			result.Skipped = append(result.Skipped, targets[i])
+			continue
+		}
+		err = extractor.toDir(ctx, l, target, filenames[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// OpenBlob returns a stream of the content of a single blob of the artifact
+// together with its descriptor, which carries the size, media type and
+// annotations from the manifest.  The blob is selected like a single blob to
+// extract.  The content is returned as stored and not verified against the
+// digest.  A blob skipped by a partial pull is fetched first.  The caller must
+// close the stream.
+func (as ArtifactStore) OpenBlob(ctx context.Context, nameOrDigest string, options *libartTypes.OpenBlobOptions) (io.ReadCloser, specV1.Descriptor, error) {
+	imgSrc, layer, err := as.openedBlob(ctx, nameOrDigest, options)
+	if err != nil {
+		return nil, specV1.Descriptor{}, err
+	}
+	src, _, err := imgSrc.GetBlob(ctx, types.BlobInfo{Digest: layer.Digest}, nil)
+	if err != nil {
+		imgSrc.Close()
+		return nil, specV1.Descriptor{}, fmt.Errorf("failed to get artifact file: %w", err)
+	}
+	return &blobReadCloser{ReadCloser: src, imgSrc: imgSrc}, layer, nil
+}
+
+// OpenBlobSeeker returns the content of a single blob of the artifact for
+// random access, selected and fetched like by OpenBlob, together with its
+// descriptor.  It is the file of the blob in the store opened read-only, so
+// seeking is cheap and the blob is not copied.  The content is not verified
+// against the digest.  The caller must close it.
+//
+// Blobs are never modified in the store, so any number of readers, in this
+// or other processes, may read the same blob concurrently, but a single
+// returned reader must not be used by several goroutines at once as it has
+// one offset.  If the artifact is removed while the blob is open, the reader
+// keeps reading the removed content until it is closed.
+func (as ArtifactStore) OpenBlobSeeker(ctx context.Context, nameOrDigest string, options *libartTypes.OpenBlobOptions) (io.ReadSeekCloser, specV1.Descriptor, error) {
+	imgSrc, layer, err := as.openedBlob(ctx, nameOrDigest, options)
+	if err != nil {
+		return nil, specV1.Descriptor{}, err
+	}
+	imgSrc.Close()
+	f, err := os.Open(as.blobPath(layer.Digest))
+	if err != nil {
+		return nil, specV1.Descriptor{}, fmt.Errorf("failed to open artifact file: %w", err)
+	}
+	return f, layer, nil
+}
+
+// openedBlob returns the image source of the artifact and the layer OpenBlob
+// and OpenBlobSeeker open, after fetching it if a partial pull skipped it.
+// The caller must close the image source.
+func (as ArtifactStore) openedBlob(ctx context.Context, nameOrDigest string, options *libartTypes.OpenBlobOptions) (types.ImageSource, specV1.Descriptor, error) {
+	arty, imgSrc, err := getArtifactAndImageSource(ctx, as, nameOrDigest, &options.FilterBlobOptions)
+	if err != nil {
+		return nil, specV1.Descriptor{}, err
+	}
+	layer := arty.Manifest.Layers[0]
+	if isBlobFilterSet(&options.FilterBlobOptions) {
+		i, err := findLayerIndex(arty, &options.FilterBlobOptions)
+		if err != nil {
+			imgSrc.Close()
+			return nil, specV1.Descriptor{}, err
+		}
+		layer = arty.Manifest.Layers[i]
+	} else if len(arty.Manifest.Layers) > 1 {
+		imgSrc.Close()
+		return nil, specV1.Descriptor{}, errors.New("the artifact consists of several blobs and neither digest, title or index was specified to only open a single blob")
+	}
+	if err := as.fetchMissingBlobs(ctx, arty, layersWithDigest(arty, layer.Digest)); err != nil {
+		imgSrc.Close()
+		return nil, specV1.Descriptor{}, err
+	}
+	return imgSrc, layer, nil
+}
+
+// blobReadCloser is the stream of a blob returned by OpenBlob, closing it
+// closes the image source it was read from as well.
+type blobReadCloser struct {
+	io.ReadCloser
+	imgSrc types.ImageSource
+}
+
+func (r *blobReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	if closeErr := r.imgSrc.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// extractedLayers returns the layers extracted into a directory or a tar
+// stream and the names they are extracted to, in the same order.  These are
+// the filtered layers if blob filters are set, the single layer selected by
+// the digest, title or index, or all layers otherwise.
+func extractedLayers(arty *libartifact.Artifact, options *libartTypes.ExtractOptions, filtered []specV1.Descriptor) ([]specV1.Descriptor, []string, error) {
+	if filtered == nil && isBlobFilterSet(&options.FilterBlobOptions) {
+		i, err := findLayerIndex(arty, &options.FilterBlobOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		// In case the digest is set we always use it as target name
+		// so we do not have to get the actual title annotation form the blob.
+		filename, err := generateArtifactBlobName(filteredBlobTitle(arty, &options.FilterBlobOptions), arty.Manifest.Layers[i].Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if filename, err = rewriteBlobFileName(filename, options); err != nil {
+			return nil, nil, err
+		}
+		return arty.Manifest.Layers[i : i+1], []string{filename}, nil
+	}
+	layers := arty.Manifest.Layers
+	if filtered != nil {
+		layers = filtered
+	}
+	filenames, err := blobFileNames(layers, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return layers, filenames, nil
+}
+
+// blobExtractor copies blobs of an artifact out of the store, optionally
+// verifying their content against the manifest, decompressing them and
+// restoring the recorded file modes.  Blobs missing after a partial pull are
+// fetched first.
+type blobExtractor struct {
+	as            ArtifactStore
+	arty          *libartifact.Artifact
+	imgSrc        types.ImageSource
+	verify        bool
+	decompress    bool
+	preserveMode  bool
+	preserveOwner bool
+}
+
+// recordedMode returns the parts of the mode and ownership recorded in the
+// annotations of layer which are to be restored, nil if none are.
+func (e blobExtractor) recordedMode(layer specV1.Descriptor) (*recordedMode, error) {
+	if !e.preserveMode && !e.preserveOwner {
+		return nil, nil
+	}
+	mode, err := parseRecordedMode(layer.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("blob %q: %w", e.blobTitle(layer.Digest), err)
+	}
+	if !e.preserveMode {
+		mode.perm = nil
+	}
+	if !e.preserveOwner {
+		mode.uid, mode.gid = -1, -1
+	}
+	return mode, nil
+}
+
+// toFile copies the blob of layer to the file target, restoring its recorded
+// mode if requested.  When verifying, a file which does not match the digest
+// is removed again.
+func (e blobExtractor) toFile(ctx context.Context, layer specV1.Descriptor, target string) error {
+	mode, err := e.recordedMode(layer)
+	if err != nil {
+		return err
+	}
+	if err := e.writeFile(ctx, layer.Digest, target); err != nil {
+		return err
+	}
+	if mode != nil {
+		return mode.restore(target)
+	}
+	return nil
+}
+
+// writeFile copies the blob with the given digest to the file target.
+func (e blobExtractor) writeFile(ctx context.Context, blobDigest digest.Digest, target string) error {
+	if err := e.as.fetchMissingBlobs(ctx, e.arty, layersWithDigest(e.arty, blobDigest)); err != nil {
+		return err
+	}
+	if !e.verify && e.decompressor(blobDigest) == nil {
+		return copyTrustedImageBlobToFile(ctx, e.imgSrc, blobDigest, target)
+	}
+	dest, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create target file: %w", err)
+	}
+	err = e.copyContent(ctx, blobDigest, dest)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if rmErr := os.Remove(target); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+			logrus.Errorf("Removing partially extracted file %s: %v", target, rmErr)
+		}
+	}
+	return err
+}
+
+// toNewFile copies the blob of layer to the file target like toFile, unless
+// the file exists already and policy does not allow to overwrite it.
+func (e blobExtractor) toNewFile(ctx context.Context, layer specV1.Descriptor, target string, policy libartTypes.ExtractConflictPolicy) (*libartTypes.ExtractResult, error) {
+	skip, err := conflictingTargets([]string{target}, policy)
+	if err != nil {
+		return nil, err
+	}
+	if skip[target] {
+		return &libartTypes.ExtractResult{Skipped: []string{target}}, nil
+	}
+	return &libartTypes.ExtractResult{}, e.toFile(ctx, layer, target)
+}
+
+// conflictingTargets checks which of the files targets exist already before
+// anything is extracted, so that blobs sharing a name do not conflict with
+// each other.  With the fail policy, the default, an existing file is an
+// error, with the skip policy the existing files are returned to be skipped.
+// Files are overwritten without checking them with the overwrite policy.
+func conflictingTargets(targets []string, policy libartTypes.ExtractConflictPolicy) (map[string]bool, error) {
+	if policy == libartTypes.ExtractConflictOverwrite {
+		return nil, nil
+	}
+	existing := map[string]bool{}
+	for _, target := range targets {
+		_, err := os.Lstat(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if policy != libartTypes.ExtractConflictSkip {
+			return nil, fmt.Errorf("%w %s", libartTypes.ErrExtractTargetExists, target)
+		}
+		existing[target] = true
+	}
+	return existing, nil
+}
+
+// toDir copies the blob of layer to filename in the directory dir, creating
+// the parent directories of blobs named by a relative path.  Symlinks below
+// dir are never followed, see checkExtractPath.
+func (e blobExtractor) toDir(ctx context.Context, layer specV1.Descriptor, dir, filename string) error {
+	if err := checkExtractPath(dir, filename); err != nil {
+		return fmt.Errorf("blob %s: %w", layer.Digest, err)
+	}
+	target := filepath.Join(dir, filename)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return e.toFile(ctx, layer, target)
+}
+
+// toWriter streams the blob to w.  When verifying, the mismatch can only be
+// detected after all content was written, so w must not trust the content
+// if an error is returned.
+func (e blobExtractor) toWriter(ctx context.Context, blobDigest digest.Digest, w io.Writer) error {
+	if err := e.as.fetchMissingBlobs(ctx, e.arty, layersWithDigest(e.arty, blobDigest)); err != nil {
+		return err
+	}
+	if !e.verify && e.decompressor(blobDigest) == nil {
+		return copyTrustedImageBlobToWriter(ctx, e.imgSrc, blobDigest, w)
+	}
+	return e.copyContent(ctx, blobDigest, w)
+}
+
+// toTar writes the blobs described by layers to w as a tar stream, each one a
+// regular file named by the name in filenames at the same index.  The
+// entries carry the creation time of the artifact and, if requested, the
+// recorded mode and ownership of the blob.  As with toWriter, w must not
+// trust the stream if an error is returned.
+func (e blobExtractor) toTar(ctx context.Context, layers []specV1.Descriptor, filenames []string, w io.Writer) error {
+	var modTime time.Time
+	if created, ok := e.arty.Manifest.Annotations[specV1.AnnotationCreated]; ok {
+		// A creation time which cannot be parsed is not worth failing for.
+		modTime, _ = time.Parse(time.RFC3339Nano, created)
+	}
+	tw := tar.NewWriter(w)
+	for i, l := range layers {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filenames[i],
+			Size:     l.Size,
+			Mode:     0o644,
+			ModTime:  modTime,
+			Format:   tar.FormatPAX,
+		}
+		mode, err := e.recordedMode(l)
+		if err != nil {
+			return err
+		}
+		if mode != nil {
+			mode.setTarHeader(hdr)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if err := e.toWriter(ctx, l.Digest, tw); err != nil {
+			return err
+		}
+	}
+	// Close fails if a blob is shorter than the manifest declares, a longer
+	// one already failed to be written.
+	return tw.Close()
+}
+
+// decompressor returns the decompressor for the blob with the given digest if
+// decompression is enabled and the media type of the blob declares it as
+// compressed, nil otherwise.
+func (e blobExtractor) decompressor(blobDigest digest.Digest) compression.DecompressorFunc {
+	if !e.decompress {
+		return nil
+	}
+	layers := layersWithDigest(e.arty, blobDigest)
+	if len(layers) == 0 {
+		return nil
+	}
+	return mediaTypeDecompressor(layers[0].MediaType)
 }
 
-// BlobMountPaths allows the caller to access the file names from the store and how they should be mounted.
-func (as ArtifactStore) BlobMountPaths(ctx context.Context, nameOrDigest string, options *libartTypes.BlobMountPathOptions) ([]libartTypes.BlobMountPath, error) {
-	arty, imgSrc, err := getArtifactAndImageSource(ctx, as, nameOrDigest, &options.FilterBlobOptions)
+// copyContent streams the blob to w, decompressing it if needed.  When
+// verifying, the digest is computed over the content as stored, and an error
+// naming the blob is returned if it does not match.
+func (e blobExtractor) copyContent(ctx context.Context, expected digest.Digest, w io.Writer) error {
+	if err := expected.Validate(); err != nil {
+		return err
+	}
+	src, _, err := e.imgSrc.GetBlob(ctx, types.BlobInfo{Digest: expected}, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get artifact file: %w", err)
 	}
-	defer imgSrc.Close()
+	defer src.Close()
 
-	if len(options.Digest) > 0 || len(options.Title) > 0 {
-		digest, err := findDigest(arty, &options.FilterBlobOptions)
+	var reader io.Reader = src
+	var verifier digest.Digester
+	if e.verify {
+		verifier = expected.Algorithm().Digester()
+		reader = io.TeeReader(src, verifier.Hash())
+	}
+	stored := reader
+	if decompressor := e.decompressor(expected); decompressor != nil {
+		decompressed, err := decompressor(reader)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("blob %q: %w", e.blobTitle(expected), err)
 		}
-		// In case the digest is set we always use it as target name
-		// so we do not have to get the actual title annotation form the blob.
-		// Passing options.Title is enough because we know it is empty when digest
-		// is set as we only allow either one.
-		filename, err := generateArtifactBlobName(options.Title, digest)
-		if err != nil {
-			return nil, err
+		defer decompressed.Close()
+		reader = decompressed
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("blob %q: %w", e.blobTitle(expected), err)
+	}
+
+	if verifier != nil {
+		// The decompressor can stop before the end of the stored content,
+		// the remainder must be hashed too.
+		if _, err := io.Copy(io.Discard, stored); err != nil {
+			return err
 		}
-		path, err := layout.GetLocalBlobPath(ctx, imgSrc, digest)
-		if err != nil {
-			return nil, err
+		if actual := verifier.Digest(); actual != expected {
+			return fmt.Errorf("blob %q: %w: expected %s, got %s", e.blobTitle(expected), libartTypes.ErrBlobDigestMismatch, expected, actual)
+		}
+	}
+	return nil
+}
+
+// blobTitle returns the title of the blob with the given digest, or the
+// digest itself for blobs without a title.
+func (e blobExtractor) blobTitle(blobDigest digest.Digest) string {
+	for _, l := range e.arty.Manifest.Layers {
+		if l.Digest == blobDigest {
+			if title := l.Annotations[specV1.AnnotationTitle]; title != "" {
+				return title
+			}
+			break
 		}
-		return []libartTypes.BlobMountPath{{
-			SourcePath: path,
-			Name:       filename,
-		}}, nil
 	}
+	return blobDigest.String()
+}
 
-	mountPaths := make([]libartTypes.BlobMountPath, 0, len(arty.Manifest.Layers))
-	for _, l := range arty.Manifest.Layers {
+// blobFileNames returns the names of the files the blobs described by layers
+// are extracted to in a directory, in the same order, rewritten as requested
+// by options.  Two blobs with the same name are an error unless
+// options.Overwrite is set.
+func blobFileNames(layers []specV1.Descriptor, options *libartTypes.ExtractOptions) ([]string, error) {
+	filenames := make([]string, 0, len(layers))
+	seen := make(map[string]struct{}, len(layers))
+	for _, l := range layers {
 		title := l.Annotations[specV1.AnnotationTitle]
 		filename, err := generateArtifactBlobName(title, l.Digest)
 		if err != nil {
 			return nil, err
 		}
-		path, err := layout.GetLocalBlobPath(ctx, imgSrc, l.Digest)
-		if err != nil {
+		if filename, err = rewriteBlobFileName(filename, options); err != nil {
 			return nil, err
 		}
-		mountPaths = append(mountPaths, libartTypes.BlobMountPath{
-			SourcePath: path,
-			Name:       filename,
-		})
+		if _, ok := seen[filename]; ok && !options.Overwrite {
+			return nil, fmt.Errorf("%w %q, refusing to overwrite it", libartTypes.ErrDuplicateBlobName, filename)
+		}
+		seen[filename] = struct{}{}
+		filenames = append(filenames, filename)
 	}
-	return mountPaths, nil
+	return filenames, nil
 }
 
-// Extract an artifact to local file or directory
-func (as ArtifactStore) Extract(ctx context.Context, nameOrDigest string, target string, options *libartTypes.ExtractOptions) error {
-	arty, imgSrc, err := getArtifactAndImageSource(ctx, as, nameOrDigest, &options.FilterBlobOptions)
-	if err != nil {
-		return err
-	}
-	defer imgSrc.Close()
-
-	// check if dest is a dir to know if we can copy more than one blob
-	destIsFile := true
-	stat, err := os.Stat(target)
-	if err == nil {
-		destIsFile = !stat.IsDir()
-	} else if !errors.Is(err, fs.ErrNotExist) {
-		return err
+func generateArtifactBlobName(title string, digest digest.Digest) (string, error) {
+	filename := title
+	if len(filename) == 0 {
+		// No filename given, use the digest. But because ":" is not a valid path char
+		// on all platforms replace it with "-".
+		filename = strings.ReplaceAll(digest.String(), ":", "-")
 	}
 
-	if destIsFile {
-		var digest digest.Digest
-		if len(arty.Manifest.Layers) > 1 {
-			if len(options.Digest) == 0 && len(options.Title) == 0 {
-				return fmt.Errorf("the artifact consists of several blobs and the target %q is not a directory and neither digest or title was specified to only copy a single blob", target)
+	// Important: A potentially malicious artifact could contain a title name with "/"
+	// and could try via relative paths such as "../" try to overwrite files on the host
+	// the user did not intend. Titles of blobs added from a directory are relative
+	// paths using "/", so only such paths made of plain names are accepted rather than
+	// trying to "make it safe" via securejoin or others.
+	components := strings.Split(filename, "/")
+	for _, component := range components {
+		if component == "" || component == "." || component == ".." {
+			return "", fmt.Errorf("blob %s: invalid name: %q must be a relative path without empty, \".\" or \"..\" elements", digest, filename)
+		}
+		// We must use os.IsPathSeparator() as on Windows it checks "\\" as well.
+		for i := 0; i < len(component); i++ {
+			if os.IsPathSeparator(component[i]) && component[i] != '/' {
+				return "", fmt.Errorf("blob %s: invalid name: %q cannot contain %c", digest, filename, component[i])
 			}
-			digest, err = findDigest(arty, &options.FilterBlobOptions)
-			if err != nil {
-				return err
+			if component[i] == 0 {
+				return "", fmt.Errorf("blob %s: invalid name: %q cannot contain a NUL byte", digest, filename)
 			}
-		} else {
-			digest = arty.Manifest.Layers[0].Digest
 		}
-
-		return copyTrustedImageBlobToFile(ctx, imgSrc, digest, target)
 	}
+	// On Windows this also rejects volume names and reserved names like NUL.
+	name := filepath.Join(components...)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("blob %s: invalid name: %q is not a local path", digest, filename)
+	}
+	return name, nil
+}
 
-	if len(options.Digest) > 0 || len(options.Title) > 0 {
-		digest, err := findDigest(arty, &options.FilterBlobOptions)
+// checkExtractPath returns an error if an existing element of the path
+// filename below dir is a symlink.  Following it, a blob could be written
+// outside of dir, e.g. if dir has a symlink named like a directory of the
+// title pointing elsewhere, or replace the target of the symlink.  The
+// elements which do not exist yet are created as directories, or as the
+// file of the blob, by the extraction.
+func checkExtractPath(dir, filename string) error {
+	path := dir
+	for _, element := range strings.Split(filename, string(filepath.Separator)) {
+		path = filepath.Join(path, element)
+		st, err := os.Lstat(path)
 		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
 			return err
 		}
-		// In case the digest is set we always use it as target name
-		// so we do not have to get the actual title annotation form the blob.
-		// Passing options.Title is enough because we know it is empty when digest
-		// is set as we only allow either one.
-		filename, err := generateArtifactBlobName(options.Title, digest)
-		if err != nil {
-			return err
+		if st.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to extract %q, %s is a symlink", filepath.ToSlash(filename), path)
+		}
+	}
+	return nil
+}
+
+// rewriteBlobFileName removes options.PathPrefix and then
+// options.StripComponents leading elements from filename, a name returned by
+// generateArtifactBlobName.  Only whole elements of the already validated name
+// are removed, so the result stays a relative path below the target.
+func rewriteBlobFileName(filename string, options *libartTypes.ExtractOptions) (string, error) {
+	if options.PathPrefix == "" && options.StripComponents == 0 {
+		return filename, nil
+	}
+	elements := strings.Split(filename, string(filepath.Separator))
+	if options.PathPrefix != "" {
+		prefix := filepath.Clean(filepath.FromSlash(options.PathPrefix))
+		if !filepath.IsLocal(prefix) {
+			return "", fmt.Errorf("invalid path prefix %q: it must be a relative path without \"..\" elements", options.PathPrefix)
+		}
+		if prefix != "." {
+			prefixElements := strings.Split(prefix, string(filepath.Separator))
+			if len(elements) <= len(prefixElements) || !slices.Equal(elements[:len(prefixElements)], prefixElements) {
+				return "", fmt.Errorf("blob %q is not below the path prefix %q", filepath.ToSlash(filename), options.PathPrefix)
+			}
+			elements = elements[len(prefixElements):]
 		}
-		return copyTrustedImageBlobToFile(ctx, imgSrc, digest, filepath.Join(target, filename))
 	}
+	if len(elements) <= options.StripComponents {
+		return "", fmt.Errorf("blob %q has no path elements left after stripping %d of them", filepath.ToSlash(filename), options.StripComponents)
+	}
+	return filepath.Join(elements[options.StripComponents:]...), nil
+}
 
+// filterLayers returns the layers of the artifact which match all blob filters
+// of options and, if set, its title or digest, in manifest order.
+func filterLayers(arty *libartifact.Artifact, options *libartTypes.ExtractOptions) []specV1.Descriptor {
+	var layers []specV1.Descriptor
 	for _, l := range arty.Manifest.Layers {
-		title := l.Annotations[specV1.AnnotationTitle]
-		filename, err := generateArtifactBlobName(title, l.Digest)
-		if err != nil {
-			return err
+		if len(options.Title) > 0 && l.Annotations[specV1.AnnotationTitle] != options.Title {
+			continue
 		}
-		err = copyTrustedImageBlobToFile(ctx, imgSrc, l.Digest, filepath.Join(target, filename))
-		if err != nil {
-			return err
+		if len(options.Digest) > 0 && l.Digest.String() != options.Digest {
+			continue
 		}
+		if slices.ContainsFunc(options.BlobFilters, func(filter libartTypes.BlobFilter) bool { return !filter(l) }) {
+			continue
+		}
+		layers = append(layers, l)
 	}
+	return layers
+}
 
-	return nil
+// isBlobFilterSet returns true if the options select a single blob.
+func isBlobFilterSet(options *libartTypes.FilterBlobOptions) bool {
+	return len(options.Digest) > 0 || len(options.Title) > 0 || options.Index != nil
 }
 
-func generateArtifactBlobName(title string, digest digest.Digest) (string, error) {
-	filename := title
-	if len(filename) == 0 {
-		// No filename given, use the digest. But because ":" is not a valid path char
-		// on all platforms replace it with "-".
-		filename = strings.ReplaceAll(digest.String(), ":", "-")
+// filteredBlobTitle returns the title that should be used as name for the blob
+// selected by the filter options.  When filtering by digest the title is empty
+// so the digest gets used as name.
+func filteredBlobTitle(arty *libartifact.Artifact, options *libartTypes.FilterBlobOptions) string {
+	if options.Index != nil {
+		return arty.Manifest.Layers[*options.Index].Annotations[specV1.AnnotationTitle]
 	}
+	return options.Title
+}
 
-	// Important: A potentially malicious artifact could contain a title name with "/"
-	// and could try via relative paths such as "../" try to overwrite files on the host
-	// the user did not intend. As there is no use for directories in this path we
-	// disallow all of them and not try to "make it safe" via securejoin or others.
-	// We must use os.IsPathSeparator() as on Windows it checks both "\\" and "/".
-	for i := 0; i < len(filename); i++ {
-		if os.IsPathSeparator(filename[i]) {
-			return "", fmt.Errorf("invalid name: %q cannot contain %c", filename, filename[i])
-		}
+func findDigest(arty *libartifact.Artifact, options *libartTypes.FilterBlobOptions) (digest.Digest, error) {
+	i, err := findLayerIndex(arty, options)
+	if err != nil {
+		return "", err
 	}
-	return filename, nil
+	return arty.Manifest.Layers[i].Digest, nil
 }
 
-func findDigest(arty *libartifact.Artifact, options *libartTypes.FilterBlobOptions) (digest.Digest, error) {
-	var digest digest.Digest
-	for _, l := range arty.Manifest.Layers {
+// findLayerIndex returns the index of the single layer of the artifact
+// selected by the filter options.
+func findLayerIndex(arty *libartifact.Artifact, options *libartTypes.FilterBlobOptions) (int, error) {
+	if options.Index != nil {
+		numLayers := len(arty.Manifest.Layers)
+		if *options.Index < 0 || *options.Index >= numLayers {
+			return -1, fmt.Errorf("blob index %d is out of range, valid range is 0 to %d", *options.Index, numLayers-1)
+		}
+		return *options.Index, nil
+	}
+	index := -1
+	for i, l := range arty.Manifest.Layers {
 		if options.Digest == l.Digest.String() {
-			if len(digest.String()) > 0 {
-				return digest, fmt.Errorf("more than one match for the digest %q", options.Digest)
+			if index >= 0 {
+				return -1, fmt.Errorf("%w for the digest %q", libartTypes.ErrBlobAmbiguous, options.Digest)
 			}
-			digest = l.Digest
+			index = i
 		}
 		if len(options.Title) > 0 {
 			if val, ok := l.Annotations[specV1.AnnotationTitle]; ok &&
 				val == options.Title {
-				if len(digest.String()) > 0 {
-					return digest, fmt.Errorf("more than one match for the title %q", options.Title)
+				if index >= 0 {
+					return -1, fmt.Errorf("%w for the title %q", libartTypes.ErrBlobAmbiguous, options.Title)
 				}
-				digest = l.Digest
+				index = i
 			}
 		}
 	}
-	if len(digest.String()) == 0 {
+	if index < 0 {
 		if len(options.Title) > 0 {
-			return digest, fmt.Errorf("no blob with the title %q", options.Title)
+			return -1, fmt.Errorf("%w with the title %q", libartTypes.ErrBlobNotExist, options.Title)
+		}
+		return -1, fmt.Errorf("%w with the digest %q", libartTypes.ErrBlobNotExist, options.Digest)
+	}
+	return index, nil
+}
+
+// VerifyBlobs re-hashes every blob of the artifact in the local store and
+// compares it to the digest recorded in the manifest.  All blobs are checked
+// and an error listing every mismatching or unreadable blob is returned.
+func (as ArtifactStore) VerifyBlobs(ctx context.Context, nameOrDigest string, options *libartTypes.VerifyOptions) ([]libartTypes.BlobDigest, error) {
+	if len(nameOrDigest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	if options.DigestAlgorithm != "" && !options.DigestAlgorithm.Available() {
+		return nil, fmt.Errorf("digest algorithm %q is not available", options.DigestAlgorithm)
+	}
+	as.lock.RLock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	arty, nameIsDigest, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	name := nameOrDigest
+	if nameIsDigest {
+		name = arty.Name
+	}
+	ir, err := layout.NewReference(as.storePath, name)
+	if err != nil {
+		return nil, err
+	}
+	imgSrc, err := ir.NewImageSource(ctx, as.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	defer imgSrc.Close()
+
+	var errs []error
+	blobs := make([]libartTypes.BlobDigest, 0, len(arty.Manifest.Layers))
+	for _, l := range arty.Manifest.Layers {
+		title := l.Annotations[specV1.AnnotationTitle]
+		blob := libartTypes.BlobDigest{
+			Title:  title,
+			Digest: l.Digest,
+		}
+		alternate, err := verifyLocalBlob(ctx, imgSrc, l.Digest, options.DigestAlgorithm)
+		if err != nil {
+			if title == "" {
+				title = l.Digest.String()
+			}
+			errs = append(errs, fmt.Errorf("blob %q: %w", title, err))
+			continue
 		}
-		return digest, fmt.Errorf("no blob with the digest %q", options.Digest)
+		blob.AlternateDigest = alternate
+		blobs = append(blobs, blob)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return blobs, nil
+}
+
+// verifyLocalBlob hashes the blob of the local store with the algorithm of
+// expected and compares the result.  If algorithm is set and differs from the
+// one of expected, the digest computed with it is returned as well.
+func verifyLocalBlob(ctx context.Context, imgSrc types.ImageSource, expected digest.Digest, algorithm digest.Algorithm) (digest.Digest, error) {
+	if err := expected.Validate(); err != nil {
+		return "", err
+	}
+	path, err := layout.GetLocalBlobPath(ctx, imgSrc, expected)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	verifier := expected.Algorithm().Digester()
+	writers := []io.Writer{verifier.Hash()}
+	var alternate digest.Digester
+	if algorithm != "" && algorithm != expected.Algorithm() {
+		alternate = algorithm.Digester()
+		writers = append(writers, alternate.Hash())
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return "", err
+	}
+	if actual := verifier.Digest(); actual != expected {
+		return "", fmt.Errorf("%w: expected %s, got %s", libartTypes.ErrBlobDigestMismatch, expected, actual)
 	}
-	return digest, nil
+	if alternate == nil {
+		return "", nil
+	}
+	return alternate.Digest(), nil
 }
 
 // copyTrustedImageBlobToFile copies blob identified by digest in imgSrc to file target.
@@ -546,9 +2460,21 @@ func copyTrustedImageBlobToFile(ctx context.Context, imgSrc types.ImageSource, d
 	return err
 }
 
-// readIndex is currently unused but I want to keep this around until
-// the artifact code is more mature.
-func (as ArtifactStore) readIndex() (*specV1.Index, error) { //nolint:unused
+// copyTrustedImageBlobToWriter streams the blob with the given digest to w.
+//
+// WARNING: Like copyTrustedImageBlobToFile, this does not validate the contents.
+func copyTrustedImageBlobToWriter(ctx context.Context, imgSrc types.ImageSource, digest digest.Digest, w io.Writer) error {
+	src, _, err := imgSrc.GetBlob(ctx, types.BlobInfo{Digest: digest}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get artifact file: %w", err)
+	}
+	defer src.Close()
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// readIndex returns the index of the store.
+func (as ArtifactStore) readIndex() (*specV1.Index, error) {
 	index := specV1.Index{}
 	rawData, err := os.ReadFile(as.indexPath())
 	if err != nil {
@@ -558,17 +2484,34 @@ func (as ArtifactStore) readIndex() (*specV1.Index, error) { //nolint:unused
 	return &index, err
 }
 
-func (as ArtifactStore) createEmptyManifest() error {
-	index := specV1.Index{
-		MediaType: specV1.MediaTypeImageIndex,
-		Versioned: specs.Versioned{SchemaVersion: ManifestSchemaVersion},
-	}
-	rawData, err := json.Marshal(&index)
+// writeIndex replaces the index of the store with index.  The caller must
+// hold the store lock.
+func (as ArtifactStore) writeIndex(index *specV1.Index) error {
+	rawData, err := json.Marshal(index)
 	if err != nil {
 		return err
 	}
+	return ioutils.AtomicWriteFile(as.indexPath(), rawData, 0o644)
+}
 
-	return os.WriteFile(as.indexPath(), rawData, 0o644)
+// createEmptyManifest writes an empty index unless another process created
+// the index, and possibly added artifacts to it, since it was found missing.
+func (as ArtifactStore) createEmptyManifest() error {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	if err := fileutils.Exists(as.indexPath()); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := as.writeOCILayout(); err != nil {
+		return err
+	}
+	index := &specV1.Index{
+		MediaType: specV1.MediaTypeImageIndex,
+		Versioned: specs.Versioned{SchemaVersion: ManifestSchemaVersion},
+		Manifests: []specV1.Descriptor{},
+	}
+	setIndexStoreVersion(index)
+	return as.writeIndex(index)
 }
 
 func (as ArtifactStore) indexPath() string {
@@ -578,34 +2521,106 @@ func (as ArtifactStore) indexPath() string {
 // getArtifacts returns an ArtifactList based on the artifact's store.  The return error and
 // unused opts is meant for future growth like filters, etc so the API does not change.
 func (as ArtifactStore) getArtifacts(ctx context.Context, _ *libartTypes.GetArtifactOptions) (libartifact.ArtifactList, error) {
-	var (
-		al libartifact.ArtifactList
-	)
+	var al libartifact.ArtifactList
+	err := as.walkArtifacts(ctx, func(artifact *libartifact.Artifact) error {
+		al = append(al, artifact)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return al, nil
+}
 
+// ForEach calls fn for each artifact in the store, in the order of the index,
+// without reading all of them first.  The index is read once when ForEach
+// starts, each manifest is read with the store locked for reading when its
+// artifact is visited.  The lock is not held while fn runs, so fn may use the
+// store, even remove artifacts.  Artifacts whose manifest was removed after
+// ForEach started are skipped, the ones added are not visited.  An error
+// returned by fn stops the walk and is returned.
+func (as ArtifactStore) ForEach(ctx context.Context, fn func(*libartifact.Artifact) error) error {
+	as.lock.RLock()
 	lrs, err := layout.List(as.storePath)
+	as.lock.Unlock()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	for _, l := range lrs {
-		imgSrc, err := l.Reference.NewImageSource(ctx, as.SystemContext)
-		if err != nil {
-			return nil, err
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		as.lock.RLock()
+		artifact, err := as.readListedArtifact(ctx, l)
+		removed := err != nil && errors.Is(fileutils.Exists(as.blobPath(l.ManifestDescriptor.Digest)), fs.ErrNotExist)
+		as.lock.Unlock()
+		if removed {
+			continue
 		}
-		manifest, err := getManifest(ctx, imgSrc)
-		imgSrc.Close()
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(artifact); err != nil {
+			return err
 		}
-		artifact := libartifact.Artifact{
-			Manifest: manifest,
+	}
+	return nil
+}
+
+// walkArtifacts calls fn for each artifact in the store.  The caller must
+// hold the store lock.
+func (as ArtifactStore) walkArtifacts(ctx context.Context, fn func(*libartifact.Artifact) error) error {
+	lrs, err := layout.List(as.storePath)
+	if err != nil {
+		return err
+	}
+	for _, l := range lrs {
+		artifact, err := as.readListedArtifact(ctx, l)
+		if err != nil {
+			return err
 		}
-		if val, ok := l.ManifestDescriptor.Annotations[specV1.AnnotationRefName]; ok {
-			artifact.SetName(val)
+		if err := fn(artifact); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		al = append(al, &artifact)
+// readListedArtifact reads the artifact of an entry of the index.  The caller
+// must hold the store lock.
+func (as ArtifactStore) readListedArtifact(ctx context.Context, l layout.ListResult) (*libartifact.Artifact, error) {
+	imgSrc, err := l.Reference.NewImageSource(ctx, as.SystemContext)
+	if err != nil {
+		return nil, err
 	}
-	return al, nil
+	manifest, err := getManifest(ctx, imgSrc)
+	imgSrc.Close()
+	if err != nil {
+		return nil, err
+	}
+	artifact := libartifact.Artifact{
+		Manifest: manifest,
+	}
+	if val, ok := l.ManifestDescriptor.Annotations[specV1.AnnotationRefName]; ok {
+		artifact.SetName(val)
+	}
+	artifact.IndexAnnotations = l.ManifestDescriptor.Annotations
+	artifact.SetStoredDigest(l.ManifestDescriptor.Digest)
+	// Several names may share the manifest, its file is written
+	// whenever one of them is stored.
+	if st, err := os.Stat(as.blobPath(l.ManifestDescriptor.Digest)); err == nil {
+		artifact.SetStoredTime(st.ModTime())
+	}
+	return &artifact, nil
+}
+
+// listArtifacts returns the artifacts of the store like getArtifacts, but
+// holds the store lock for reading so that the index is not read while it is
+// being written.  Callers which hold the store lock use getArtifacts.
+func (as ArtifactStore) listArtifacts(ctx context.Context) (libartifact.ArtifactList, error) {
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	return as.getArtifacts(ctx, nil)
 }
 
 // getManifest takes an imgSrc and returns the manifest for the imgSrc.
@@ -636,7 +2651,7 @@ func createEmptyStanza(path string) error {
 	return os.WriteFile(path, specV1.DescriptorEmptyJSON.Data, 0644)
 }
 
-func determineManifestType(path string) (string, error) {
+func determineManifestType(path, fileName string) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return "", err
@@ -650,5 +2665,70 @@ func determineManifestType(path string) (string, error) {
 	if err != nil && !errors.Is(err, io.EOF) {
 		return "", err
 	}
-	return http.DetectContentType(b[:n]), nil
+	return detectMediaType(fileName, b[:n]), nil
+}
+
+// mediaTypesByExtension refines the media type of blobs detected as plain
+// text.  A fixed table is used rather than the mime package, as its result
+// depends on the mime.types files installed on the host.
+var mediaTypesByExtension = map[string]string{
+	".json": "application/json",
+	".md":   "text/markdown; charset=utf-8",
+	".toml": "application/toml",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+}
+
+// blobMediaTypesByArtifactType lists the media types of the blobs of well-known
+// artifact types whose blob media types do not extend the artifact type.
+var blobMediaTypesByArtifactType = map[string][]string{
+	"application/vnd.cncf.helm.config.v1+json": {
+		"application/vnd.cncf.helm.chart.content.v1.tar+gzip",
+		"application/vnd.cncf.helm.chart.provenance.v1.prov",
+	},
+}
+
+// checkBlobMediaType returns an error if mediaType is not consistent with the
+// artifact type.  A media type is consistent if it is listed for the artifact
+// type in blobMediaTypesByArtifactType, or if, without parameters and
+// structured syntax suffix, it equals the artifact type or extends it by
+// further dot-separated parts.  For example application/vnd.example.model.gguf
+// is consistent with application/vnd.example.model.
+func checkBlobMediaType(artifactType, mediaType string) error {
+	if mediaType == artifactType || slices.Contains(blobMediaTypesByArtifactType[artifactType], mediaType) {
+		return nil
+	}
+	family := mediaTypeBase(artifactType)
+	base := mediaTypeBase(mediaType)
+	if base == family || strings.HasPrefix(base, family+".") {
+		return nil
+	}
+	return fmt.Errorf("media type %q of the blob is not consistent with the artifact type %q", mediaType, artifactType)
+}
+
+// mediaTypeBase returns the media type without parameters and structured
+// syntax suffix, e.g. application/vnd.example for
+// application/vnd.example+json; charset=utf-8.
+func mediaTypeBase(mediaType string) string {
+	base, _, _ := strings.Cut(mediaType, ";")
+	base = strings.TrimSpace(base)
+	if i := strings.LastIndex(base, "+"); i > strings.LastIndex(base, "/") {
+		base = base[:i]
+	}
+	return strings.ToLower(base)
+}
+
+// detectMediaType returns the media type of a blob named fileName starting
+// with header.  The type is detected from the content, and if that is plain
+// text, refined by the file name extension.  Content which is not recognized
+// is application/octet-stream.  The result only depends on the arguments.
+func detectMediaType(fileName string, header []byte) string {
+	mediaType := http.DetectContentType(header)
+	if mediaType != "text/plain; charset=utf-8" {
+		return mediaType
+	}
+	if byExtension, ok := mediaTypesByExtension[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return byExtension
+	}
+	return mediaType
 }
@@ -0,0 +1,453 @@
+//go:build !remote
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/podman/v5/pkg/libartifact"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlob is the name and content of a blob added by addTestArtifact.
+type testBlob struct {
+	name    string
+	content string
+}
+
+func newTestStore(t *testing.T) *ArtifactStore {
+	t.Helper()
+	as, err := NewArtifactStore(t.TempDir(), nil)
+	require.NoError(t, err)
+	return as
+}
+
+func addTestArtifact(t *testing.T, as *ArtifactStore, name string, blobs ...testBlob) digest.Digest {
+	t.Helper()
+	artifactBlobs := make([]libartTypes.ArtifactBlob, 0, len(blobs))
+	for _, b := range blobs {
+		artifactBlobs = append(artifactBlobs, libartTypes.ArtifactBlob{
+			BlobReader: strings.NewReader(b.content),
+			FileName:   b.name,
+		})
+	}
+	result, err := as.Add(context.Background(), name, artifactBlobs, &libartTypes.AddOptions{})
+	require.NoError(t, err)
+	return result.ManifestDigest
+}
+
+// readTestBlob returns the content of the blob with the title of the artifact.
+func readTestBlob(t *testing.T, as *ArtifactStore, nameOrDigest, title string) string {
+	t.Helper()
+	blob, _, err := as.OpenBlob(context.Background(), nameOrDigest, &libartTypes.OpenBlobOptions{
+		FilterBlobOptions: libartTypes.FilterBlobOptions{Title: title},
+	})
+	require.NoError(t, err)
+	defer blob.Close()
+	content, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// openFiles returns the number of open file descriptors of the process.
+func openFiles(t *testing.T) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("cannot count open files: %v", err)
+	}
+	return len(fds)
+}
+
+func TestOpenBlob(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	addTestArtifact(t, as, "localhost/test/open",
+		testBlob{name: "first.txt", content: "first blob"},
+		testBlob{name: "second.txt", content: "the second blob"})
+	art, err := as.Inspect(ctx, "localhost/test/open")
+	require.NoError(t, err)
+
+	for i, layer := range art.Manifest.Layers {
+		before := openFiles(t)
+		blob, desc, err := as.OpenBlob(ctx, "localhost/test/open", &libartTypes.OpenBlobOptions{
+			FilterBlobOptions: libartTypes.FilterBlobOptions{Digest: layer.Digest.String()},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, layer, desc)
+		content, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		assert.Equal(t, desc.Digest, digest.FromBytes(content), "layer %d", i)
+		assert.Equal(t, desc.Size, int64(len(content)), "layer %d", i)
+		assert.Equal(t, []string{"first.txt", "second.txt"}[i], desc.Annotations[specV1.AnnotationTitle])
+
+		// The store is not locked while the blob is open and closing
+		// it releases the files it opened.
+		require.NoError(t, as.lock.TryLock())
+		as.lock.Unlock()
+		require.NoError(t, blob.Close())
+		assert.Equal(t, before, openFiles(t), "layer %d", i)
+	}
+
+	_, _, err = as.OpenBlob(ctx, "localhost/test/open", &libartTypes.OpenBlobOptions{
+		FilterBlobOptions: libartTypes.FilterBlobOptions{Digest: digest.FromString("unknown").String()},
+	})
+	assert.ErrorIs(t, err, libartTypes.ErrBlobNotExist)
+	_, _, err = as.OpenBlob(ctx, "localhost/test/open", &libartTypes.OpenBlobOptions{
+		FilterBlobOptions: libartTypes.FilterBlobOptions{Title: "unknown.txt"},
+	})
+	assert.ErrorIs(t, err, libartTypes.ErrBlobNotExist)
+	_, _, err = as.OpenBlob(ctx, "localhost/test/unknown", &libartTypes.OpenBlobOptions{})
+	assert.ErrorIs(t, err, libartTypes.ErrArtifactNotExist)
+}
+
+func TestForEach(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	names := []string{"localhost/test/first", "localhost/test/second", "localhost/test/third"}
+	for _, name := range names {
+		addTestArtifact(t, as, name, testBlob{name: "blob", content: name})
+	}
+
+	var visited []string
+	require.NoError(t, as.ForEach(ctx, func(a *libartifact.Artifact) error {
+		visited = append(visited, a.Name)
+		return nil
+	}))
+	assert.Equal(t, names, visited)
+
+	// An error of fn stops the walk and is returned.
+	errStop := errors.New("stop")
+	visited = nil
+	err := as.ForEach(ctx, func(a *libartifact.Artifact) error {
+		visited = append(visited, a.Name)
+		if len(visited) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, names[:2], visited)
+
+	// Artifacts removed during the walk, by fn itself as the store is not
+	// locked while it runs, are not visited anymore.
+	visited = nil
+	require.NoError(t, as.ForEach(ctx, func(a *libartifact.Artifact) error {
+		visited = append(visited, a.Name)
+		if a.Name == names[0] {
+			if _, err := as.Remove(ctx, a.Name); err != nil {
+				return err
+			}
+			if _, err := as.Remove(ctx, names[2]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	assert.Equal(t, names[:2], visited)
+	artifacts, err := as.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, names[1], artifacts[0].Name)
+}
+
+func TestManifestBytes(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	artifactDigest := addTestArtifact(t, as, "localhost/test/manifest", testBlob{name: "blob", content: "manifest bytes"})
+	art, err := as.Inspect(ctx, "localhost/test/manifest")
+	require.NoError(t, err)
+
+	for _, nameOrDigest := range []string{"localhost/test/manifest", artifactDigest.Encoded()} {
+		rawManifest, desc, err := as.ManifestBytes(ctx, nameOrDigest)
+		require.NoError(t, err)
+		assert.Equal(t, artifactDigest, digest.FromBytes(rawManifest))
+		assert.Equal(t, artifactDigest, desc.Digest)
+		assert.Equal(t, int64(len(rawManifest)), desc.Size)
+		assert.Equal(t, specV1.MediaTypeImageManifest, desc.MediaType)
+		assert.Equal(t, art.Manifest.ArtifactType, desc.ArtifactType)
+	}
+
+	// A manifest changed on disk no longer matches its digest.
+	require.NoError(t, os.WriteFile(as.blobPath(artifactDigest), []byte("{}"), 0o644))
+	_, _, err = as.ManifestBytes(ctx, "localhost/test/manifest")
+	assert.ErrorContains(t, err, "instead of "+artifactDigest.String())
+}
+
+func TestOpenBlobSeeker(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	content := strings.Repeat("0123456789abcdef", 4096)
+	addTestArtifact(t, as, "localhost/test/seek", testBlob{name: "data.bin", content: content})
+	options := &libartTypes.OpenBlobOptions{FilterBlobOptions: libartTypes.FilterBlobOptions{Title: "data.bin"}}
+
+	blob, desc, err := as.OpenBlobSeeker(ctx, "localhost/test/seek", options)
+	require.NoError(t, err)
+	defer blob.Close()
+	assert.Equal(t, int64(len(content)), desc.Size)
+	assert.Equal(t, digest.FromString(content), desc.Digest)
+
+	end, err := blob.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, desc.Size, end)
+	for _, offset := range []int64{0, 1, 4095, 30000, desc.Size - 10} {
+		pos, err := blob.Seek(offset, io.SeekStart)
+		require.NoError(t, err)
+		require.Equal(t, offset, pos)
+		buf := make([]byte, 10)
+		_, err = io.ReadFull(blob, buf)
+		require.NoError(t, err)
+		assert.Equal(t, content[offset:offset+10], string(buf), "offset %d", offset)
+	}
+	_, err = blob.Seek(-5, io.SeekEnd)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, content[len(content)-5:], string(rest))
+
+	readerAt, ok := blob.(io.ReaderAt)
+	require.True(t, ok, "the blob does not implement io.ReaderAt")
+	buf := make([]byte, 16)
+	_, err = readerAt.ReadAt(buf, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, content[1000:1016], string(buf))
+
+	// Each reader has its own offset, so several of them can read the
+	// same blob at the same time.
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, _, err := as.OpenBlobSeeker(ctx, "localhost/test/seek", options)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer r.Close()
+			offset := int64(i * 1024)
+			if _, err := r.Seek(offset, io.SeekStart); err != nil {
+				errs[i] = err
+				return
+			}
+			data, err := io.ReadAll(r)
+			errs[i] = err
+			results[i] = string(data)
+		}()
+	}
+	wg.Wait()
+	for i, data := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, content[i*1024:], data, "reader %d", i)
+	}
+}
+
+func TestUpdateKeepsSharedBlobs(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	shared := testBlob{name: "shared", content: "shared content"}
+	oldDigest := addTestArtifact(t, as, "localhost/test/updated", shared)
+	addTestArtifact(t, as, "localhost/test/other", shared, testBlob{name: "other", content: "other content"})
+
+	result, err := as.Update(ctx, "localhost/test/updated", &libartTypes.UpdateOptions{
+		SetAnnotations: map[string]string{"com.example.updated": "true"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, oldDigest, result.OldDigest)
+	assert.NotEqual(t, result.OldDigest, result.NewDigest)
+
+	// The old manifest is gone, the blob it shared with the new manifest
+	// and the other artifact is still there.
+	assert.NoFileExists(t, as.blobPath(oldDigest))
+	assert.FileExists(t, as.blobPath(digest.FromString(shared.content)))
+	updated, err := as.Inspect(ctx, "localhost/test/updated")
+	require.NoError(t, err)
+	assert.Equal(t, "true", updated.Manifest.Annotations["com.example.updated"])
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/updated", "shared"))
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/other", "shared"))
+	assert.Equal(t, "other content", readTestBlob(t, as, "localhost/test/other", "other"))
+}
+
+func TestNonCanonicalManifestSharedByTwoNames(t *testing.T) {
+	ctx := context.Background()
+	_, _, stream := exportTestArtifact(t)
+
+	// Store the manifest indented, so its digest differs from the digest
+	// of the manifest as marshaled again.
+	entries := readTarEntries(t, stream)
+	require.Equal(t, tarStreamManifest, entries[0].name)
+	var indented bytes.Buffer
+	require.NoError(t, json.Indent(&indented, []byte(entries[0].content), "", "  "))
+	entries[0].content = indented.String()
+
+	as := newTestStore(t)
+	storedDigest, err := as.ImportTar(ctx, bytes.NewReader(writeTarEntries(t, entries)), "")
+	require.NoError(t, err)
+	assert.Equal(t, digest.FromString(indented.String()), storedDigest)
+	arty, err := as.Inspect(ctx, "localhost/test/tar")
+	require.NoError(t, err)
+	canonicalDigest, err := arty.GetDigest()
+	require.NoError(t, err)
+	require.NotEqual(t, storedDigest, *canonicalDigest)
+
+	result, err := as.Tag(ctx, "localhost/test/tar", "localhost/test/tagged", &libartTypes.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, storedDigest, result.Digest)
+	withBlob, err := as.ArtifactsWithBlob(ctx, storedDigest)
+	require.NoError(t, err)
+	assert.Len(t, withBlob, 2)
+
+	removed, err := as.Remove(ctx, "localhost/test/tar")
+	require.NoError(t, err)
+	assert.Equal(t, storedDigest, *removed)
+	_, err = as.Inspect(ctx, "localhost/test/tar")
+	assert.ErrorIs(t, err, libartTypes.ErrArtifactNotExist)
+
+	// The manifest and blobs are kept for the other name.
+	assert.FileExists(t, as.blobPath(storedDigest))
+	assert.Equal(t, "first blob", readTestBlob(t, as, "localhost/test/tagged", "first.txt"))
+	assert.Equal(t, "second blob", readTestBlob(t, as, "localhost/test/tagged", "second.txt"))
+}
+
+func TestTagForceKeepsSharedBlobs(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	shared := testBlob{name: "shared", content: "shared content"}
+	taggedDigest := addTestArtifact(t, as, "localhost/test/tagged", shared)
+	replacedDigest := addTestArtifact(t, as, "localhost/test/replaced", shared, testBlob{name: "own", content: "own content"})
+
+	_, err := as.Tag(ctx, "localhost/test/tagged", "localhost/test/replaced", &libartTypes.TagOptions{})
+	require.ErrorIs(t, err, libartTypes.ErrArtifactAlreadyExists)
+
+	result, err := as.Tag(ctx, "localhost/test/tagged", "localhost/test/replaced", &libartTypes.TagOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, taggedDigest, result.Digest)
+
+	// The replaced artifact is gone together with the blobs only it used,
+	// the blob it shared with the tagged artifact is kept.
+	replaced, err := as.Inspect(ctx, "localhost/test/replaced")
+	require.NoError(t, err)
+	replacedNow, err := replaced.StoredDigest()
+	require.NoError(t, err)
+	assert.Equal(t, taggedDigest, replacedNow)
+	assert.NoFileExists(t, as.blobPath(replacedDigest))
+	assert.NoFileExists(t, as.blobPath(digest.FromString("own content")))
+	assert.FileExists(t, as.blobPath(digest.FromString(shared.content)))
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/tagged", "shared"))
+	assert.Equal(t, shared.content, readTestBlob(t, as, "localhost/test/replaced", "shared"))
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	// A second store of the same path, as used by another process.
+	other, err := NewArtifactStore(as.storePath, nil)
+	require.NoError(t, err)
+
+	const goroutines = 8
+	const iterations = 10
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		store := as
+		if g%2 == 1 {
+			store = other
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				name := fmt.Sprintf("localhost/test/concurrent-%d", g)
+				blobs := []libartTypes.ArtifactBlob{
+					{BlobReader: strings.NewReader("shared content"), FileName: "shared"},
+					{BlobReader: strings.NewReader(fmt.Sprintf("content %d-%d", g, i)), FileName: "own"},
+				}
+				_, err := store.Add(ctx, name, blobs, &libartTypes.AddOptions{Replace: true})
+				if !assert.NoError(t, err) {
+					return
+				}
+				// All goroutines move the same name around.
+				_, err = store.Tag(ctx, name, "localhost/test/common", &libartTypes.TagOptions{Force: true})
+				assert.NoError(t, err)
+				if i%2 == 0 {
+					_, err = store.Remove(ctx, name)
+					assert.NoError(t, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	index, err := as.readIndex()
+	require.NoError(t, err)
+	require.NotEmpty(t, index.Manifests)
+	for _, desc := range index.Manifests {
+		blobs, err := as.manifestBlobs(desc.Digest)
+		require.NoError(t, err)
+		for _, d := range blobs {
+			assert.FileExists(t, as.blobPath(d), "blob %s of %s", d, desc.Annotations[specV1.AnnotationRefName])
+		}
+	}
+	result, err := as.Check(ctx, libimage.CopyOptions{}, libartTypes.CheckOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Damaged)
+	assert.Equal(t, "shared content", readTestBlob(t, as, "localhost/test/common", "shared"))
+}
+
+func TestCopyBlobSharesBlob(t *testing.T) {
+	ctx := context.Background()
+	as := newTestStore(t)
+	addTestArtifact(t, as, "localhost/test/src", testBlob{name: "copied", content: "copied content"}, testBlob{name: "other", content: "other content"})
+	copiedDigest := digest.FromString("copied content")
+	before := storeFiles(t, as)
+	copiedInfo, err := os.Stat(as.blobPath(copiedDigest))
+	require.NoError(t, err)
+
+	result, err := as.CopyBlob(ctx, "localhost/test/src", copiedDigest, "localhost/test/dest", &libartTypes.AddOptions{})
+	require.NoError(t, err)
+	dest, err := as.Inspect(ctx, "localhost/test/dest")
+	require.NoError(t, err)
+	require.Len(t, dest.Manifest.Layers, 1)
+	assert.Equal(t, copiedDigest, dest.Manifest.Layers[0].Digest)
+	assert.Equal(t, "copied", dest.Manifest.Layers[0].Annotations[specV1.AnnotationTitle])
+	assert.Equal(t, "copied content", readTestBlob(t, as, "localhost/test/dest", "copied"))
+
+	// The only new blob file is the manifest of dest, the copied blob is
+	// the same file.
+	var added []string
+	for path := range storeFiles(t, as) {
+		if _, ok := before[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	assert.Equal(t, []string{as.blobPath(result.ManifestDigest)}, added)
+	info, err := os.Stat(as.blobPath(copiedDigest))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(copiedInfo, info))
+	assert.Equal(t, copiedInfo.ModTime(), info.ModTime())
+}
+
+func TestPushIndexRejectsRecompression(t *testing.T) {
+	as := newTestStore(t)
+	addTestArtifact(t, as, "localhost/test/amd64", testBlob{name: "blob", content: "amd64 content"})
+	entries := []libartTypes.IndexEntry{{Artifact: "localhost/test/amd64", Platform: specV1.Platform{OS: "linux", Architecture: "amd64"}}}
+
+	// The check is done before the registry is contacted.
+	_, err := as.PushIndex(context.Background(), "localhost:1/test/index", entries, libimage.CopyOptions{}, libartTypes.PushOptions{ForceCompression: true})
+	assert.EqualError(t, err, "pushing an index cannot be combined with recompression, the digests of the recompressed artifacts are unknown")
+}
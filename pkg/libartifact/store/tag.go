@@ -0,0 +1,109 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/containers/image/v5/oci/layout"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Tag adds newName to the index of the store, referring to the manifest of the
+// artifact with the given name or digest.  No blob is copied, both names share
+// the manifest.  If options.RemoveOld is set the old name is replaced, so the
+// artifact is renamed.  Another artifact already named newName is only
+// replaced if options.Force is set.
+func (as ArtifactStore) Tag(ctx context.Context, nameOrDigest, newName string, options *libartTypes.TagOptions) (*libartTypes.TagResult, error) {
+	if len(nameOrDigest) == 0 || len(newName) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	// The name must be valid for the OCI layout of the store.
+	if _, err := layout.NewReference(as.storePath, newName); err != nil {
+		return nil, err
+	}
+
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	artifactDigest, err := arty.StoredDigest()
+	if err != nil {
+		return nil, err
+	}
+	result := &libartTypes.TagResult{
+		Digest:  artifactDigest,
+		OldName: arty.Name,
+		NewName: newName,
+	}
+	if arty.Name == newName {
+		return result, nil
+	}
+
+	for _, other := range artifacts {
+		if other.Name != newName {
+			continue
+		}
+		if !options.Force {
+			return nil, fmt.Errorf("%s: %w", newName, libartTypes.ErrArtifactAlreadyExists)
+		}
+		otherDigest, err := other.StoredDigest()
+		if err != nil {
+			return nil, err
+		}
+		// The blobs shared with the tagged artifact are kept, its entry
+		// still references them.
+		err = as.deleteManifests(ctx, func(desc specV1.Descriptor) bool {
+			return desc.Annotations[specV1.AnnotationRefName] == newName
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := as.removeUnusedMountPoint(ctx, otherDigest); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	index, err := as.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	for i, desc := range index.Manifests {
+		if desc.Digest != artifactDigest || desc.Annotations[specV1.AnnotationRefName] != arty.Name {
+			continue
+		}
+		tagged := desc
+		tagged.Annotations = maps.Clone(desc.Annotations)
+		if tagged.Annotations == nil {
+			tagged.Annotations = map[string]string{}
+		}
+		tagged.Annotations[specV1.AnnotationRefName] = newName
+		if options.RemoveOld {
+			index.Manifests[i] = tagged
+		} else {
+			index.Manifests = append(index.Manifests, tagged)
+		}
+		if err := as.writeIndex(index); err != nil {
+			return nil, err
+		}
+		if options.RemoveOld {
+			changes = append(changes, ArtifactEvent{Operation: ArtifactRemoved, Reference: arty.Name, Digest: artifactDigest})
+		}
+		changes = append(changes, ArtifactEvent{Operation: ArtifactAdded, Reference: newName, Digest: artifactDigest})
+		return result, nil
+	}
+	return nil, fmt.Errorf("%s: %w", nameOrDigest, libartTypes.ErrArtifactNotExist)
+}
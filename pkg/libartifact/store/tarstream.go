@@ -0,0 +1,349 @@
+//go:build !remote
+
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// tarStreamManifest is the entry of a tar stream holding the manifest.
+	tarStreamManifest = "manifest.json"
+	// tarStreamName is the entry of a tar stream holding the artifact name.
+	tarStreamName = "name"
+	// tarStreamBlobsDir is the directory of a tar stream holding the blobs.
+	tarStreamBlobsDir = "blobs"
+	// maxTarStreamManifestSize is the largest manifest ImportTar reads.
+	maxTarStreamManifestSize = 4 * 1024 * 1024
+	// maxTarStreamNameSize is the largest artifact name ImportTar reads.
+	maxTarStreamNameSize = 4096
+)
+
+// ExportTar writes the artifact as a single tar stream to w.  The stream can
+// be added to a store again with ImportTar.  Blobs of a partially pulled
+// artifact which are not in the store are fetched first.
+//
+// The stream contains regular files only, in this order:
+//
+//	manifest.json                 the OCI image manifest of the artifact, byte
+//	                              for byte as stored, so its digest is kept
+//	name                          the name of the artifact followed by a
+//	                              newline, missing for an artifact without one
+//	blobs/<algorithm>/<encoded>   the config and then all layers in the order
+//	                              of the manifest, each blob only once
+//
+// The blob paths are the ones of an OCI image layout.  A consumer can read the
+// stream sequentially as the manifest comes first.  The format is described in
+// docs/artifact_tar_format.md.
+func (as ArtifactStore) ExportTar(ctx context.Context, nameOrDigest string, w io.Writer) (digest.Digest, error) {
+	if len(nameOrDigest) == 0 {
+		return "", ErrEmptyArtifactName
+	}
+	artifacts, err := as.listArtifacts(ctx)
+	if err != nil {
+		return "", err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	if err := as.fetchMissingBlobs(ctx, arty, arty.Manifest.Layers); err != nil {
+		return "", err
+	}
+
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	artifacts, err = as.getArtifacts(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	arty, _, err = artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return "", err
+	}
+	artifactDigest, err := arty.StoredDigest()
+	if err != nil {
+		return "", err
+	}
+	rawManifest, err := os.ReadFile(as.blobPath(artifactDigest))
+	if err != nil {
+		return "", err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarEntry(tw, tarStreamManifest, int64(len(rawManifest)), bytes.NewReader(rawManifest)); err != nil {
+		return "", err
+	}
+	if arty.Name != "" {
+		name := arty.Name + "\n"
+		if err := writeTarEntry(tw, tarStreamName, int64(len(name)), strings.NewReader(name)); err != nil {
+			return "", err
+		}
+	}
+	written := map[digest.Digest]bool{}
+	for _, desc := range append([]specV1.Descriptor{arty.Manifest.Config}, arty.Manifest.Layers...) {
+		if written[desc.Digest] {
+			continue
+		}
+		written[desc.Digest] = true
+		if err := as.writeTarBlob(ctx, tw, desc); err != nil {
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return artifactDigest, nil
+}
+
+// writeTarBlob writes the blob described by desc from the store to tw.  An
+// empty config which was never written to the store is written as well.
+func (as ArtifactStore) writeTarBlob(ctx context.Context, tw *tar.Writer, desc specV1.Descriptor) error {
+	name := path.Join(tarStreamBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+	f, err := os.Open(as.blobPath(desc.Digest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && desc.Digest == specV1.DescriptorEmptyJSON.Digest {
+			data := string(specV1.DescriptorEmptyJSON.Data)
+			return writeTarEntry(tw, name, int64(len(data)), strings.NewReader(data))
+		}
+		return err
+	}
+	defer f.Close()
+	verified := &verifyingReader{reader: f, expected: desc.Digest, verifier: desc.Digest.Verifier()}
+	if err := writeTarEntry(tw, name, desc.Size, &contextReader{ctx: ctx, reader: verified}); err != nil {
+		return fmt.Errorf("writing blob %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// writeTarEntry writes a regular file with size bytes of content to tw.
+func writeTarEntry(tw *tar.Writer, name string, size int64, content io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return err
+	}
+	n, err := io.Copy(tw, content)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("%s has %d bytes, expected %d", name, n, size)
+	}
+	return nil
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// ImportTar adds the artifact of a tar stream in the format written by
+// ExportTar to the store under the name dest, or the name recorded in the
+// stream if dest is empty.  The manifest must be the first entry, the name and
+// the blobs may follow in any order.  The blobs are verified against the
+// manifest while they are read, the artifact is only added once all of them
+// were read.  The config may be missing from the stream if it is the empty
+// JSON object.
+func (as ArtifactStore) ImportTar(ctx context.Context, r io.Reader, dest string) (digest.Digest, error) {
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return "", fmt.Errorf("reading the tar stream: %w", err)
+	}
+	if tarEntryName(hdr) != tarStreamManifest || hdr.Typeflag != tar.TypeReg {
+		return "", fmt.Errorf("the tar stream must start with %s, found %q", tarStreamManifest, hdr.Name)
+	}
+	if hdr.Size > maxTarStreamManifestSize {
+		return "", fmt.Errorf("the manifest of the tar stream has %d bytes, more than the maximum of %d", hdr.Size, maxTarStreamManifestSize)
+	}
+	rawManifest, err := io.ReadAll(tr)
+	if err != nil {
+		return "", err
+	}
+	var mani specV1.Manifest
+	if err := json.Unmarshal(rawManifest, &mani); err != nil {
+		return "", fmt.Errorf("parsing the manifest of the tar stream: %w", err)
+	}
+	if mani.MediaType != specV1.MediaTypeImageManifest {
+		return "", fmt.Errorf("the manifest of the tar stream has media type %q, expected %s", mani.MediaType, specV1.MediaTypeImageManifest)
+	}
+	if mani.Config.MediaType == specV1.MediaTypeImageConfig {
+		return "", errors.New("the manifest of the tar stream is a container image, not an artifact")
+	}
+
+	// missing are the blobs of the manifest not read yet.
+	blobs := append([]specV1.Descriptor{mani.Config}, mani.Layers...)
+	missing := map[digest.Digest]specV1.Descriptor{}
+	for _, desc := range blobs {
+		if err := desc.Digest.Validate(); err != nil {
+			return "", fmt.Errorf("invalid blob digest %q in the manifest of the tar stream: %w", desc.Digest, err)
+		}
+		missing[desc.Digest] = desc
+	}
+
+	if dest != "" {
+		if err := as.checkTarImportName(ctx, dest); err != nil {
+			return "", err
+		}
+	}
+	// The name may only follow the blobs, so they are written without one
+	// and the manifest is added under the name once the stream was read.
+	blobRef, err := layout.NewReference(as.storePath, "")
+	if err != nil {
+		return "", err
+	}
+	blobDest, err := blobRef.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return "", err
+	}
+	defer blobDest.Close()
+
+	var recordedName *string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading the tar stream: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		name := tarEntryName(hdr)
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			continue
+		case hdr.Typeflag != tar.TypeReg:
+			return "", fmt.Errorf("unexpected entry %q of type %q in the tar stream", hdr.Name, hdr.Typeflag)
+		case name == tarStreamName:
+			if recordedName != nil {
+				return "", errors.New("the tar stream records the artifact name more than once")
+			}
+			if hdr.Size > maxTarStreamNameSize {
+				return "", fmt.Errorf("the artifact name in the tar stream has %d bytes, more than the maximum of %d", hdr.Size, maxTarStreamNameSize)
+			}
+			name, err := io.ReadAll(tr)
+			if err != nil {
+				return "", err
+			}
+			recorded := strings.TrimSuffix(string(name), "\n")
+			recordedName = &recorded
+			continue
+		}
+
+		blobPath, ok := strings.CutPrefix(name, tarStreamBlobsDir+"/")
+		algorithm, encoded, hasAlgorithm := strings.Cut(blobPath, "/")
+		if !ok || !hasAlgorithm {
+			return "", fmt.Errorf("unexpected entry %q in the tar stream", hdr.Name)
+		}
+		blobDigest := digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded)
+		desc, ok := missing[blobDigest]
+		if !ok {
+			return "", fmt.Errorf("blob %q of the tar stream is not referenced by the manifest or was read before", hdr.Name)
+		}
+		if hdr.Size != desc.Size {
+			return "", fmt.Errorf("blob %s of the tar stream has %d bytes, the manifest expects %d", blobDigest, hdr.Size, desc.Size)
+		}
+		verified := &verifyingReader{reader: tr, expected: blobDigest, verifier: blobDigest.Verifier()}
+		if _, err := blobDest.PutBlob(ctx, verified, types.BlobInfo{Digest: blobDigest, Size: desc.Size}, none.NoCache, blobDigest == mani.Config.Digest); err != nil {
+			return "", fmt.Errorf("blob %s of the tar stream: %w", blobDigest, err)
+		}
+		delete(missing, blobDigest)
+	}
+
+	emptyConfig := mani.Config.Digest == specV1.DescriptorEmptyJSON.Digest
+	if emptyConfig {
+		delete(missing, mani.Config.Digest)
+	}
+	if i := slices.IndexFunc(blobs, func(desc specV1.Descriptor) bool { _, ok := missing[desc.Digest]; return ok }); i >= 0 {
+		return "", fmt.Errorf("blob %s of the manifest is missing from the tar stream", blobs[i].Digest)
+	}
+	name := dest
+	if name == "" && recordedName != nil {
+		name = *recordedName
+	}
+	if name == "" {
+		return "", fmt.Errorf("the tar stream does not record a name and none was given: %w", ErrEmptyArtifactName)
+	}
+	if err := as.checkTarImportName(ctx, name); err != nil {
+		return "", err
+	}
+	destRef, err := layout.NewReference(as.storePath, name)
+	if err != nil {
+		return "", err
+	}
+	imageDest, err := destRef.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return "", err
+	}
+	defer imageDest.Close()
+	if err := imageDest.PutManifest(ctx, rawManifest, nil); err != nil {
+		return "", err
+	}
+	if err := imageDest.Commit(ctx, newUnparsedArtifactImage(destRef, mani)); err != nil {
+		return "", err
+	}
+	if emptyConfig {
+		if err := createEmptyStanza(as.blobPath(mani.Config.Digest)); err != nil {
+			return "", err
+		}
+	}
+	manifestDigest := digest.FromBytes(rawManifest)
+	changes = append(changes, ArtifactEvent{Operation: ArtifactAdded, Reference: name, Digest: manifestDigest})
+	return manifestDigest, nil
+}
+
+// tarEntryName returns the name of the tar entry without a leading "./", as
+// written by tar for the content of a directory.
+func tarEntryName(hdr *tar.Header) string {
+	return path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+}
+
+// checkTarImportName returns an error if ImportTar cannot add an artifact
+// named name because one exists already.  The store lock must be held.
+func (as ArtifactStore) checkTarImportName(ctx context.Context, name string) error {
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, _, err := artifacts.GetByNameOrDigest(name); err == nil {
+		return fmt.Errorf("%s: %w", name, libartTypes.ErrArtifactAlreadyExists)
+	}
+	return nil
+}
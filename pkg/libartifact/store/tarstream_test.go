@@ -0,0 +1,196 @@
+//go:build !remote
+
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"testing"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarEntry is a regular file of a tar stream.
+type tarEntry struct {
+	name    string
+	content string
+	// size is written to the header instead of the length of the content
+	// if set.
+	size int64
+}
+
+func readTarEntries(t *testing.T, stream []byte) []tarEntry {
+	t.Helper()
+	var entries []tarEntry
+	tr := tar.NewReader(bytes.NewReader(stream))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, tarEntry{name: hdr.Name, content: string(content)})
+	}
+}
+
+func writeTarEntries(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		size := e.size
+		if size == 0 {
+			size = int64(len(e.content))
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e.name, Mode: 0o644, Size: size}))
+		// A short write of a wrong size is caught by the reader.
+		_, _ = tw.Write([]byte(e.content))
+	}
+	_ = tw.Close()
+	return buf.Bytes()
+}
+
+func blobEntryName(d digest.Digest) string {
+	return path.Join(tarStreamBlobsDir, d.Algorithm().String(), d.Encoded())
+}
+
+func exportTestArtifact(t *testing.T) (*ArtifactStore, digest.Digest, []byte) {
+	t.Helper()
+	as := newTestStore(t)
+	artifactDigest := addTestArtifact(t, as, "localhost/test/tar",
+		testBlob{name: "first.txt", content: "first blob"},
+		testBlob{name: "second.txt", content: "second blob"},
+		testBlob{name: "copy.txt", content: "first blob"})
+	var buf bytes.Buffer
+	exported, err := as.ExportTar(context.Background(), "localhost/test/tar", &buf)
+	require.NoError(t, err)
+	require.Equal(t, artifactDigest, exported)
+	return as, artifactDigest, buf.Bytes()
+}
+
+func TestTarStreamRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, artifactDigest, stream := exportTestArtifact(t)
+
+	entries := readTarEntries(t, stream)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, tarStreamManifest, entries[0].name)
+	assert.Equal(t, tarStreamName, entries[1].name)
+	assert.Equal(t, "localhost/test/tar\n", entries[1].content)
+	srcManifest, _, err := src.ManifestBytes(ctx, artifactDigest.Encoded())
+	require.NoError(t, err)
+	assert.Equal(t, string(srcManifest), entries[0].content)
+	// The config and the two distinct layers, the duplicate once.
+	assert.Len(t, entries, 5)
+
+	for _, test := range []struct {
+		dest, name string
+	}{
+		{dest: "", name: "localhost/test/tar"},
+		{dest: "localhost/test/renamed", name: "localhost/test/renamed"},
+	} {
+		dst := newTestStore(t)
+		imported, err := dst.ImportTar(ctx, bytes.NewReader(stream), test.dest)
+		require.NoError(t, err)
+		assert.Equal(t, artifactDigest, imported)
+
+		dstManifest, _, err := dst.ManifestBytes(ctx, test.name)
+		require.NoError(t, err)
+		assert.Equal(t, srcManifest, dstManifest)
+		assert.Equal(t, "first blob", readTestBlob(t, dst, test.name, "first.txt"))
+		assert.Equal(t, "second blob", readTestBlob(t, dst, test.name, "second.txt"))
+		assert.Equal(t, "first blob", readTestBlob(t, dst, test.name, "copy.txt"))
+	}
+
+	// The name and the blobs may come in any order after the manifest.
+	reordered := append([]tarEntry{entries[0]}, entries[2:]...)
+	reordered = append(reordered, entries[1])
+	dst := newTestStore(t)
+	imported, err := dst.ImportTar(ctx, bytes.NewReader(writeTarEntries(t, reordered)), "")
+	require.NoError(t, err)
+	assert.Equal(t, artifactDigest, imported)
+	assert.Equal(t, "second blob", readTestBlob(t, dst, "localhost/test/tar", "second.txt"))
+
+	// The name cannot be added twice.
+	_, err = dst.ImportTar(ctx, bytes.NewReader(stream), "")
+	assert.ErrorIs(t, err, libartTypes.ErrArtifactAlreadyExists)
+}
+
+func TestImportTarRejects(t *testing.T) {
+	ctx := context.Background()
+	src, _, stream := exportTestArtifact(t)
+	entries := readTarEntries(t, stream)
+	art, err := src.Inspect(ctx, "localhost/test/tar")
+	require.NoError(t, err)
+	first := blobEntryName(art.Manifest.Layers[0].Digest)
+
+	// modified returns the entries with the blob of the first layer
+	// changed by fn.
+	modified := func(fn func(e *tarEntry)) []tarEntry {
+		changed := make([]tarEntry, 0, len(entries))
+		for _, e := range entries {
+			if e.name == first {
+				fn(&e)
+			}
+			changed = append(changed, e)
+		}
+		return changed
+	}
+
+	for _, test := range []struct {
+		name    string
+		entries []tarEntry
+		err     string
+	}{
+		{
+			name:    "wrong digest",
+			entries: modified(func(e *tarEntry) { e.content = strings.ToUpper(e.content) }),
+			err:     "blob " + art.Manifest.Layers[0].Digest.String() + " of the tar stream",
+		},
+		{
+			name:    "wrong size",
+			entries: modified(func(e *tarEntry) { e.content += "!" }),
+			err:     "the manifest expects 10",
+		},
+		{
+			name:    "unknown entry",
+			entries: append(append([]tarEntry{}, entries...), tarEntry{name: "README", content: "hello"}),
+			err:     `unexpected entry "README" in the tar stream`,
+		},
+		{
+			name:    "unreferenced blob",
+			entries: append(append([]tarEntry{}, entries...), tarEntry{name: blobEntryName(digest.FromString("other")), content: "other"}),
+			err:     "is not referenced by the manifest",
+		},
+		{
+			name:    "missing blob",
+			entries: entries[:len(entries)-1],
+			err:     "is missing from the tar stream",
+		},
+		{
+			name:    "manifest not first",
+			entries: append([]tarEntry{entries[1]}, append(entries[:1:1], entries[2:]...)...),
+			err:     "the tar stream must start with manifest.json",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dst := newTestStore(t)
+			_, err := dst.ImportTar(ctx, bytes.NewReader(writeTarEntries(t, test.entries)), "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+			artifacts, err := dst.List(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, artifacts)
+		})
+	}
+}
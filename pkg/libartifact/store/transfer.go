@@ -0,0 +1,650 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+)
+
+// DefaultMaxParallelDownloads is the number of blobs downloaded concurrently
+// when pulling an artifact if no other value is given.
+const DefaultMaxParallelDownloads = 3
+
+// DefaultMaxParallelUploads is the number of blobs uploaded concurrently
+// when pushing an artifact if no other value is given.
+const DefaultMaxParallelUploads = 3
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = time.Second
+	// maxRetryBackoff is the longest delay an exponential backoff of the
+	// push and pull retries waits for.
+	maxRetryBackoff = 5 * time.Minute
+	// maxRateLimitBurst is the largest number of bytes a rate limited
+	// transfer reads at once, the size of the copy buffers.
+	maxRateLimitBurst = 32 * 1024
+)
+
+// errNegativeRateLimit is returned for a negative transfer rate limit.
+var errNegativeRateLimit = errors.New("the rate limit must not be negative")
+
+// errNegativeMaxSize is returned for a negative maximum pull size.
+var errNegativeMaxSize = errors.New("the maximum size must not be negative")
+
+// errNegativeTimeout is returned for a negative transfer timeout.
+var errNegativeTimeout = errors.New("the timeout must not be negative")
+
+// withTimeout returns ctx with a deadline after timeout, or ctx itself if the
+// timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError returns err of the operation, wrapped in ErrTimeout if it is
+// caused by the deadline set by withTimeout.  Other errors, even ones returned
+// after the deadline, are returned unchanged.
+func timeoutError(operation string, timeout time.Duration, err error) error {
+	if timeout == 0 || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s: %w after %s: %w", operation, libartTypes.ErrTimeout, timeout, err)
+}
+
+// blobTransferOptions control how blobs are read from an image source wrapped
+// by newBlobTransferLookup.
+type blobTransferOptions struct {
+	// maxParallel is the number of blobs that can be read at the same
+	// time.  Zero means no limit besides the one of the image copy.
+	maxParallel uint
+	// retry, if set, retries fetching each blob on its own.
+	retry *pullRetry
+	// rateLimit is the number of bytes per second all blobs together
+	// are read with.  Zero means no limit.
+	rateLimit int64
+	// maxBytes is the number of bytes all blobs together may have, the
+	// transfer fails once more were read.  Zero means no limit.
+	maxBytes int64
+	// stagingDir, if set, is the directory the blobs are downloaded to
+	// at the same time as they are read, so an interrupted transfer can
+	// be resumed by the next one.
+	stagingDir string
+}
+
+// pullRetry retries the requests of a pull, the manifest and each blob on its
+// own, up to maxRetries times after a delay.  Requests the registry rate
+// limited, with HTTP 429 Too Many Requests or 503 Service Unavailable, are
+// retried as well and the delay doubles with each of their attempts: the
+// registry client already waited as long as the Retry-After header of each
+// 429 response asked for before it gave up, so retrying sooner would only be
+// rate limited again.
+type pullRetry struct {
+	maxRetries int
+	delay      time.Duration
+	// rateLimitWait is the time, in nanoseconds, spent on rate limited
+	// requests, including the waits of the registry client, and waiting
+	// before their retries.
+	rateLimitWait atomic.Int64
+}
+
+// newPullRetry returns the retries of a pull with the retry options of opts,
+// using the same defaults as libimage.
+func newPullRetry(opts *libimage.CopyOptions) *pullRetry {
+	r := &pullRetry{maxRetries: defaultMaxRetries, delay: defaultRetryDelay}
+	if opts.MaxRetries != nil {
+		r.maxRetries = int(*opts.MaxRetries)
+	}
+	if opts.RetryDelay != nil {
+		r.delay = *opts.RetryDelay
+	}
+	return r
+}
+
+// do runs operation until it succeeds, fails with an error which is not worth
+// retrying or was retried maxRetries times, and returns its last error.
+//
+// The registry client waits as long as the Retry-After header of a 429
+// response asks for, at most a minute, and sends the request again a few
+// times before it fails.  The error no longer carries the response, so the
+// retries here cannot follow the header and back off exponentially instead.
+func (r *pullRetry) do(ctx context.Context, operation func() error) error {
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		err := operation()
+		rateLimited := isRateLimitError(err)
+		if rateLimited {
+			r.rateLimitWait.Add(int64(time.Since(start)))
+		}
+		if err == nil || attempt >= r.maxRetries || !(rateLimited || retry.IsErrorRetryable(err)) {
+			return err
+		}
+		delay := r.delay
+		if rateLimited {
+			delay = backoffDelay(&r.delay, true, attempt)
+		}
+		logrus.Warnf("Failed, retrying in %s ... (%d/%d). Error: %v", delay, attempt+1, r.maxRetries, err)
+		start = time.Now()
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		if rateLimited {
+			r.rateLimitWait.Add(int64(time.Since(start)))
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// backoffDelay returns the delay before the retry of a push or pull after the
+// given failed attempt, counted from zero.  Without a delay it starts at one
+// second and doubles with each attempt, as for the retries of libimage.  A
+// given delay is used for all retries unless backoff doubles it as well.
+// Doubling stops at maxRetryBackoff.
+func backoffDelay(delay *time.Duration, backoff bool, attempt int) time.Duration {
+	if delay != nil && !backoff {
+		return *delay
+	}
+	d := time.Second
+	if delay != nil {
+		d = *delay
+	}
+	for i := 0; i < attempt && d < maxRetryBackoff; i++ {
+		d = min(2*d, maxRetryBackoff)
+	}
+	return d
+}
+
+// rateLimitWaited returns the time spent on rate limited requests so far.
+func (r *pullRetry) rateLimitWaited() time.Duration {
+	return time.Duration(r.rateLimitWait.Load())
+}
+
+// isRateLimitError returns true for the errors of a registry which rate
+// limits the requests or is unavailable for now.
+func isRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, docker.ErrTooManyRequests) {
+		return true
+	}
+	var statusErr docker.UnexpectedHTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable
+	}
+	var codeErr errcode.Error
+	if errors.As(err, &codeErr) {
+		return codeErr.Code == errcode.ErrorCodeTooManyRequests || codeErr.Code == errcode.ErrorCodeUnavailable
+	}
+	return false
+}
+
+// blobTransfer is the state shared by all blob transfers of a single pull or
+// push.  The first error of any blob is recorded so that all other transfers
+// are aborted.
+type blobTransfer struct {
+	options blobTransferOptions
+	sem     *semaphore.Weighted
+	// limiter is shared by all blobs, nil without a rate limit.
+	limiter *rate.Limiter
+	// aborted is canceled once an error was recorded.
+	aborted context.Context
+	abort   context.CancelFunc
+
+	// blobs and bytes count the blobs read from the source and their size.
+	blobs atomic.Int64
+	bytes atomic.Int64
+	// read counts the bytes of all blobs read by the copy, including the
+	// ones of staged blobs which were not transferred again.
+	read atomic.Int64
+
+	lock sync.Mutex
+	err  error
+	// staged are the paths of the blobs staged by the transfer, true
+	// while one is read.
+	staged map[string]bool
+	// fetched are the digests of the blobs read from the source.
+	fetched []digest.Digest
+}
+
+func newBlobTransfer(options blobTransferOptions) *blobTransfer {
+	t := &blobTransfer{options: options}
+	if options.maxParallel > 0 {
+		t.sem = semaphore.NewWeighted(int64(options.maxParallel))
+	}
+	t.limiter = newRateLimiter(options.rateLimit)
+	t.aborted, t.abort = context.WithCancel(context.Background())
+	return t
+}
+
+// fail records err unless another error was recorded before and returns the
+// first error, i.e. the failure which caused the cancellation of the other
+// transfers rather than the cancellation itself.
+func (t *blobTransfer) fail(err error) error {
+	t.setError(err)
+	return t.firstError()
+}
+
+// acquire waits for a free transfer slot and returns the function releasing it.
+func (t *blobTransfer) acquire(ctx context.Context) (func(), error) {
+	if t.sem == nil {
+		return func() {}, nil
+	}
+	if err := t.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { t.sem.Release(1) }) }, nil
+}
+
+// networkReadCloser counts the bytes read from rc as transferred and limits
+// them to the rate of the transfer.
+func (t *blobTransfer) networkReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{newRateLimitedReader(ctx, &countingReader{reader: rc, count: &t.bytes}, t.limiter), rc}
+}
+
+// useStaged records the path of a staged blob being read and returns false if
+// the blob is read already, e.g. for a blob referenced twice.
+func (t *blobTransfer) useStaged(path string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.staged[path] {
+		return false
+	}
+	if t.staged == nil {
+		t.staged = map[string]bool{}
+	}
+	t.staged[path] = true
+	return true
+}
+
+// releaseStaged records that the staged blob at path is no longer read.
+func (t *blobTransfer) releaseStaged(path string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.staged[path] = false
+}
+
+// removeStaged removes the blobs staged by the transfer, once they are stored.
+func (t *blobTransfer) removeStaged() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for path := range t.staged {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("Removing staged blob %s: %v", path, err)
+		}
+	}
+	t.staged = nil
+}
+
+// fetchedBlobs returns the digests of the blobs read from the source.
+func (t *blobTransfer) fetchedBlobs() []digest.Digest {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return slices.Clone(t.fetched)
+}
+
+func (t *blobTransfer) firstError() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.err
+}
+
+func (t *blobTransfer) setError(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.err == nil {
+		t.err = err
+		t.abort()
+	}
+}
+
+// blobTransferReference is an ImageReference whose image sources read blobs
+// according to the blobTransfer they share.
+type blobTransferReference struct {
+	types.ImageReference
+	transfer *blobTransfer
+}
+
+// lookupSource is suitable for libimage.CopyOptions.SourceLookupReferenceFunc
+// and wraps the source reference to apply the transfer options to all blob reads.
+func (t *blobTransfer) lookupSource(ref types.ImageReference) (types.ImageReference, error) {
+	return &blobTransferReference{ImageReference: ref, transfer: t}, nil
+}
+
+func (r *blobTransferReference) NewImageSource(ctx context.Context, sys *types.SystemContext) (types.ImageSource, error) {
+	src, err := r.ImageReference.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	return &blobTransferSource{ImageSource: src, transfer: r.transfer}, nil
+}
+
+type blobTransferSource struct {
+	types.ImageSource
+	transfer *blobTransfer
+}
+
+func (s *blobTransferSource) retry(ctx context.Context, operation func() error) error {
+	if s.transfer.options.retry == nil {
+		return operation()
+	}
+	return s.transfer.options.retry.do(ctx, operation)
+}
+
+// GetManifest is retried the same way as the blobs since the retry of the
+// whole copy is not used.
+func (s *blobTransferSource) GetManifest(ctx context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	var (
+		rawManifest  []byte
+		manifestType string
+	)
+	err := s.retry(ctx, func() error {
+		var err error
+		rawManifest, manifestType, err = s.ImageSource.GetManifest(ctx, instanceDigest)
+		return err
+	})
+	return rawManifest, manifestType, err
+}
+
+func (s *blobTransferSource) GetBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache) (io.ReadCloser, int64, error) {
+	if err := s.transfer.firstError(); err != nil {
+		return nil, -1, err
+	}
+
+	release, err := s.transfer.acquire(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	var (
+		reader io.ReadCloser
+		size   int64
+	)
+	getBlob := func() error {
+		var err error
+		if s.transfer.options.stagingDir != "" {
+			reader, size, err = s.getStagedBlob(ctx, info, cache)
+			return err
+		}
+		reader, size, err = s.ImageSource.GetBlob(ctx, info, cache)
+		if err == nil {
+			reader = s.transfer.networkReadCloser(ctx, reader)
+		}
+		return err
+	}
+	if err := s.retry(ctx, getBlob); err != nil {
+		release()
+		s.transfer.setError(err)
+		return nil, -1, err
+	}
+	s.transfer.blobs.Add(1)
+	s.transfer.lock.Lock()
+	s.transfer.fetched = append(s.transfer.fetched, info.Digest)
+	s.transfer.lock.Unlock()
+	return &blobTransferReader{ReadCloser: reader, transfer: s.transfer, release: release}, size, nil
+}
+
+// blobTransferReader releases the transfer slot of the blob once it is closed
+// and aborts the read as soon as any other blob of the transfer failed.
+type blobTransferReader struct {
+	io.ReadCloser
+	transfer *blobTransfer
+	release  func()
+}
+
+func (r *blobTransferReader) Read(p []byte) (int, error) {
+	if err := r.transfer.firstError(); err != nil {
+		return 0, err
+	}
+	n, err := r.ReadCloser.Read(p)
+	if maxBytes := r.transfer.options.maxBytes; maxBytes > 0 && r.transfer.read.Add(int64(n)) > maxBytes {
+		err = fmt.Errorf("%w: more than the limit of %d bytes were downloaded", libartTypes.ErrMaxSizeExceeded, maxBytes)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.transfer.setError(err)
+	}
+	return n, err
+}
+
+func (r *blobTransferReader) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
+}
+
+// blobUploadOptions control how blobs are written to an image destination
+// wrapped by newBlobUploadLookup.
+type blobUploadOptions struct {
+	// maxParallel is the number of blobs uploaded at the same time.
+	maxParallel uint
+	// rateLimit is the number of bytes per second all blobs together are
+	// uploaded with.  Zero means no limit.
+	rateLimit int64
+	// mountFrom are repositories of the destination registry blobs
+	// missing in the destination are mounted from before they are
+	// uploaded.
+	mountFrom []reference.Named
+	// counts, if set, counts the blobs mounted and uploaded.
+	counts *blobUploadCounts
+}
+
+// blobUploadCounts are the numbers of blobs a push mounted from other
+// repositories and uploaded, over all attempts and tags.  Blobs present in
+// the destination already are not counted.
+type blobUploadCounts struct {
+	mounted  atomic.Int64
+	uploaded atomic.Int64
+}
+
+// blobUploadReference is an ImageReference whose image destinations upload
+// blobs according to options.
+type blobUploadReference struct {
+	types.ImageReference
+	options blobUploadOptions
+}
+
+// newBlobUploadLookup returns a function suitable for
+// libimage.CopyOptions.DestinationLookupReferenceFunc which wraps the
+// destination reference to upload at most options.maxParallel blobs at the
+// same time and, unless options.rateLimit is zero, all of them together with
+// at most rateLimit bytes per second.  The first failed upload cancels all
+// others.  Each attempt of the copy uses a new destination, so a retry of the
+// copy starts without the earlier error.
+func newBlobUploadLookup(options blobUploadOptions) func(types.ImageReference) (types.ImageReference, error) {
+	return func(ref types.ImageReference) (types.ImageReference, error) {
+		return &blobUploadReference{ImageReference: ref, options: options}, nil
+	}
+}
+
+func (r *blobUploadReference) NewImageDestination(ctx context.Context, sys *types.SystemContext) (types.ImageDestination, error) {
+	dest, err := r.ImageReference.NewImageDestination(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	transfer := newBlobTransfer(blobTransferOptions{maxParallel: r.options.maxParallel, rateLimit: r.options.rateLimit})
+	return &blobUploadDestination{ImageDestination: dest, transfer: transfer, mountFrom: r.options.mountFrom, counts: r.options.counts}, nil
+}
+
+type blobUploadDestination struct {
+	types.ImageDestination
+	transfer  *blobTransfer
+	mountFrom []reference.Named
+	counts    *blobUploadCounts
+}
+
+// mountSources returns the repositories of sources, which must be on the
+// registry of dest.  The repository of dest itself is left out.
+func mountSources(dest reference.Named, sources []string) ([]reference.Named, error) {
+	repos := make([]reference.Named, 0, len(sources))
+	for _, source := range sources {
+		named, err := reference.ParseNormalizedNamed(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository %q to mount blobs from: %w", source, err)
+		}
+		if reference.Domain(named) != reference.Domain(dest) {
+			return nil, fmt.Errorf("cannot mount blobs from %s, it is not on the registry %s of the destination", named.Name(), reference.Domain(dest))
+		}
+		if named.Name() == dest.Name() {
+			continue
+		}
+		repos = append(repos, reference.TrimNamed(named))
+	}
+	return repos, nil
+}
+
+// TryReusingBlob reuses a blob present in the destination or mounts it from
+// another repository the blob info cache knows it from.  The blob is recorded
+// in the cache as known in each of the repositories of mountFrom first, so the
+// destination tries to mount it from them as well.  Mounted blobs are counted.
+func (d *blobUploadDestination) TryReusingBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache, canSubstitute bool) (bool, types.BlobInfo, error) {
+	// Without a cache only the destination repository itself is checked.
+	present, presentInfo, err := d.ImageDestination.TryReusingBlob(ctx, info, none.NoCache, false)
+	if err != nil || present {
+		return present, presentInfo, err
+	}
+	// The cache prefers the locations recorded last, so the repositories
+	// are recorded in reverse to be tried in the order given.
+	for _, repo := range slices.Backward(d.mountFrom) {
+		cache.RecordKnownLocation(d.Reference().Transport(), types.BICTransportScope{Opaque: reference.Domain(repo)}, info.Digest, types.BICLocationReference{Opaque: repo.Name()})
+	}
+	reused, reusedInfo, err := d.ImageDestination.TryReusingBlob(ctx, info, cache, canSubstitute)
+	if err != nil {
+		return false, types.BlobInfo{}, err
+	}
+	if reused && d.counts != nil {
+		d.counts.mounted.Add(1)
+	}
+	return reused, reusedInfo, nil
+}
+
+func (d *blobUploadDestination) PutBlob(ctx context.Context, stream io.Reader, inputInfo types.BlobInfo, cache types.BlobInfoCache, isConfig bool) (types.BlobInfo, error) {
+	putCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(d.transfer.aborted, cancel)
+	defer stop()
+
+	release, err := d.transfer.acquire(putCtx)
+	if err != nil {
+		return types.BlobInfo{}, d.transfer.fail(err)
+	}
+	defer release()
+	if err := d.transfer.firstError(); err != nil {
+		return types.BlobInfo{}, err
+	}
+	// The copy only tries to reuse layers, the config is mounted here.
+	if isConfig && len(d.mountFrom) > 0 && inputInfo.Digest != "" {
+		reused, reusedInfo, err := d.TryReusingBlob(putCtx, inputInfo, cache, false)
+		if err != nil {
+			return types.BlobInfo{}, d.transfer.fail(err)
+		}
+		if reused {
+			return reusedInfo, nil
+		}
+	}
+	// The destination does not read the stream of a blob it has already.
+	upload := &readTracker{reader: newRateLimitedReader(putCtx, stream, d.transfer.limiter)}
+	info, err := d.ImageDestination.PutBlob(putCtx, upload, inputInfo, cache, isConfig)
+	if err != nil {
+		return types.BlobInfo{}, d.transfer.fail(err)
+	}
+	if upload.read && d.counts != nil {
+		d.counts.uploaded.Add(1)
+	}
+	return info, nil
+}
+
+// readTracker records whether reader was read from.
+type readTracker struct {
+	reader io.Reader
+	read   bool
+}
+
+func (r *readTracker) Read(p []byte) (int, error) {
+	r.read = true
+	return r.reader.Read(p)
+}
+
+// countingReader adds the number of bytes read to count.
+type countingReader struct {
+	reader io.Reader
+	count  *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+// newRateLimiter returns a limiter for bytesPerSec, nil for no limit.
+func newRateLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), int(min(bytesPerSec, maxRateLimitBurst)))
+}
+
+// rateLimitedReader waits for the limiter after each read.  The limiter can be
+// shared by several readers, which then stay within the limit together.
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// newRateLimitedReader returns reader limited by limiter, reader itself if
+// limiter is nil.
+func newRateLimitedReader(ctx context.Context, reader io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return reader
+	}
+	return &rateLimitedReader{ctx: ctx, reader: reader, limiter: limiter}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// A single wait cannot exceed the burst of the limiter.
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			// The limiter fails at once if the wait would
+			// exceed the deadline, before the context expires.
+			if _, ok := r.ctx.Deadline(); ok && r.ctx.Err() == nil {
+				waitErr = fmt.Errorf("%w: %w", waitErr, context.DeadlineExceeded)
+			}
+			err = waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,139 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/containers/image/v5/oci/layout"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Update changes the annotations of the manifest, or of a single blob if the
+// blob filter options are set, of the artifact.  Only a new manifest is
+// written, the blobs are left untouched.  As the annotations are part of the
+// manifest, the updated artifact has a new digest.
+func (as ArtifactStore) Update(ctx context.Context, nameOrDigest string, options *libartTypes.UpdateOptions) (*libartTypes.UpdateResult, error) {
+	if len(nameOrDigest) == 0 {
+		return nil, ErrEmptyArtifactName
+	}
+	if len(options.Digest) > 0 && len(options.Title) > 0 {
+		return nil, errors.New("cannot specify both digest and title")
+	}
+	if options.Index != nil && (len(options.Digest) > 0 || len(options.Title) > 0) {
+		return nil, errors.New("cannot specify index together with digest or title")
+	}
+	if len(options.SetAnnotations) == 0 && len(options.RemoveAnnotations) == 0 {
+		return nil, errors.New("no annotations to set or remove")
+	}
+	for _, key := range options.RemoveAnnotations {
+		if _, ok := options.SetAnnotations[key]; ok {
+			return nil, fmt.Errorf("annotation %q cannot be both set and removed", key)
+		}
+	}
+
+	var changes []ArtifactEvent
+	defer as.notifyObservers(&changes)
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	artifacts, err := as.getArtifacts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	arty, _, err := artifacts.GetByNameOrDigest(nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	// The new manifest replaces the old one under the same name, an
+	// unnamed artifact could only be duplicated.
+	if arty.Name == "" {
+		return nil, fmt.Errorf("%s: %w", nameOrDigest, libartTypes.ErrArtifactUnamed)
+	}
+	oldDigest, err := arty.StoredDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	artifactManifest := arty.Manifest.Manifest
+	if isBlobFilterSet(&options.FilterBlobOptions) {
+		i, err := findLayerIndex(arty, &options.FilterBlobOptions)
+		if err != nil {
+			return nil, err
+		}
+		if title, ok := options.SetAnnotations[specV1.AnnotationTitle]; ok {
+			for j, layer := range artifactManifest.Layers {
+				if j != i && layer.Annotations[specV1.AnnotationTitle] == title {
+					return nil, duplicateTitleError(title, fmt.Sprintf("blob %s", layer.Digest), fmt.Sprintf("blob %s", artifactManifest.Layers[i].Digest))
+				}
+			}
+		}
+		// Copy the layers so the manifest of the store is not modified
+		// through the shared slice.
+		artifactManifest.Layers = append([]specV1.Descriptor(nil), artifactManifest.Layers...)
+		artifactManifest.Layers[i].Annotations = updateAnnotations(artifactManifest.Layers[i].Annotations, options)
+	} else {
+		artifactManifest.Annotations = updateAnnotations(artifactManifest.Annotations, options)
+	}
+
+	rawData, err := json.Marshal(artifactManifest)
+	if err != nil {
+		return nil, err
+	}
+	newDigest := digest.FromBytes(rawData)
+	result := &libartTypes.UpdateResult{
+		OldDigest: oldDigest,
+		NewDigest: newDigest,
+	}
+	if newDigest == oldDigest {
+		return result, nil
+	}
+
+	ir, err := layout.NewReference(as.storePath, arty.Name)
+	if err != nil {
+		return nil, err
+	}
+	imageDest, err := ir.NewImageDestination(ctx, as.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	defer imageDest.Close()
+	if err := imageDest.PutManifest(ctx, rawData, nil); err != nil {
+		return nil, err
+	}
+	if err := imageDest.Commit(ctx, newUnparsedArtifactImage(ir, artifactManifest)); err != nil {
+		return nil, err
+	}
+	if err := as.setIndexAnnotations(newDigest, arty.Name, arty.IndexAnnotations); err != nil {
+		return nil, err
+	}
+	changes = append(changes, ArtifactEvent{Operation: ArtifactAdded, Reference: arty.Name, Digest: newDigest})
+	if err := as.removeReplacedManifest(ctx, oldDigest); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateAnnotations returns a copy of annotations with the changes of options
+// applied.  The result is nil if no annotation is left, so that the field is
+// omitted from the manifest.
+func updateAnnotations(annotations map[string]string, options *libartTypes.UpdateOptions) map[string]string {
+	updated := maps.Clone(annotations)
+	if updated == nil {
+		updated = make(map[string]string, len(options.SetAnnotations))
+	}
+	maps.Copy(updated, options.SetAnnotations)
+	for _, key := range options.RemoveAnnotations {
+		delete(updated, key)
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	return updated
+}
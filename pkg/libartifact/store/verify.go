@@ -0,0 +1,197 @@
+//go:build !remote
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containers/common/libimage"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// sigstoreSignatureAnnotation is the annotation of the layers of a sigstore
+// attachment holding the signature of the payload.
+const sigstoreSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// VerifySignatures checks the signatures of the stored manifest of an artifact
+// against the signature policy, or against the keys of verifyOpts if set.
+//
+// The store does not keep signatures, so they are read from the registry of
+// verifyOpts.Reference or the name of the artifact, accessed with opts, for
+// the digest of the stored manifest.  Only the manifest and the signatures are
+// fetched, none of the blobs.  The requirements are evaluated one by one so
+// that the result reports each of them; an artifact which does not meet them
+// is not an error, but a result which is not Allowed.
+func (as ArtifactStore) VerifySignatures(ctx context.Context, nameOrDigest string, opts libimage.CopyOptions, verifyOpts libartTypes.SignatureVerifyOptions) (*libartTypes.SignatureVerifyResult, error) {
+	arty, err := as.Inspect(ctx, nameOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, err := arty.StoredDigest()
+	if err != nil {
+		return nil, err
+	}
+	name := verifyOpts.Reference
+	if name == "" {
+		if name, err = arty.GetName(); err != nil {
+			return nil, fmt.Errorf("%s: the registry reference to read the signatures from is required: %w", nameOrDigest, err)
+		}
+	}
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return nil, err
+	}
+	digested, err := reference.WithDigest(reference.TrimNamed(named), manifestDigest)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := docker.NewReference(digested)
+	if err != nil {
+		return nil, err
+	}
+
+	sys := as.registrySystemContext(&opts)
+	scope, reqs, err := verifyRequirements(sys, ref, &verifyOpts)
+	if err != nil {
+		return nil, err
+	}
+	imgSrc, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	defer imgSrc.Close()
+	img := image.UnparsedInstance(imgSrc, nil)
+	// A registry which does not have the manifest fails the verification
+	// with its own error rather than with each requirement.
+	if _, _, err := img.Manifest(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &libartTypes.SignatureVerifyResult{
+		Reference: digested.String(),
+		Scope:     scope,
+		Allowed:   true,
+	}
+	if result.Signatures, err = foundSignatures(ctx, sys, imgSrc, digested); err != nil {
+		return nil, err
+	}
+	for _, req := range reqs {
+		verified := libartTypes.VerifiedRequirement{Requirement: describePolicyRequirement(req)}
+		allowed, err := isRunningImageAllowed(ctx, &signature.Policy{Default: signature.PolicyRequirements{req}}, img)
+		switch {
+		case err != nil:
+			verified.Error = err.Error()
+		case !allowed:
+			verified.Error = "the artifact is not allowed"
+		default:
+			verified.Satisfied = true
+		}
+		result.Allowed = result.Allowed && verified.Satisfied
+		result.Requirements = append(result.Requirements, verified)
+	}
+	return result, nil
+}
+
+// verifyRequirements returns the requirements of verifyOpts for ref and a
+// description of them, or those of the signature policy if it sets no keys.
+func verifyRequirements(sys *types.SystemContext, ref types.ImageReference, verifyOpts *libartTypes.SignatureVerifyOptions) (string, signature.PolicyRequirements, error) {
+	if len(verifyOpts.SigstoreKeys) == 0 && len(verifyOpts.GPGKeys) == 0 {
+		policy, err := signature.DefaultPolicy(sys)
+		if err != nil {
+			return "", nil, err
+		}
+		scope, reqs := policyRequirements(policy, ref)
+		return scope, reqs, nil
+	}
+	var reqs signature.PolicyRequirements
+	if len(verifyOpts.SigstoreKeys) > 0 {
+		req, err := signature.NewPRSigstoreSigned(
+			signature.PRSigstoreSignedWithKeyPaths(verifyOpts.SigstoreKeys),
+			signature.PRSigstoreSignedWithSignedIdentity(signature.NewPRMMatchRepoDigestOrExact()),
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	if len(verifyOpts.GPGKeys) > 0 {
+		req, err := signature.NewPRSignedByKeyPaths(signature.SBKeyTypeGPGKeys, verifyOpts.GPGKeys, signature.NewPRMMatchRepoDigestOrExact())
+		if err != nil {
+			return "", nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return "given keys", reqs, nil
+}
+
+// foundSignatures returns the simple signatures imgSrc provides for its
+// manifest and the sigstore signatures of the attachment of the manifest
+// digested refers to in its repository.
+func foundSignatures(ctx context.Context, sys *types.SystemContext, imgSrc types.ImageSource, digested reference.Canonical) ([]libartTypes.FoundSignature, error) {
+	var found []libartTypes.FoundSignature
+	simple, err := imgSrc.GetSignatures(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading the signatures of %s: %w", digested, err)
+	}
+	for _, sig := range simple {
+		info, err := signature.GetUntrustedSignatureInformationWithoutVerifying(sig)
+		if err != nil {
+			logrus.Debugf("Ignoring unreadable simple signature of %s: %v", digested, err)
+			continue
+		}
+		found = append(found, libartTypes.FoundSignature{Format: "simple-signing", KeyID: info.UntrustedShortKeyIdentifier})
+	}
+
+	layers, err := sigstoreAttachmentLayers(ctx, sys, digested)
+	if err != nil {
+		// The registry served the manifest with the same settings, so
+		// this is almost always an attachment which does not exist.
+		logrus.Debugf("No sigstore attachment for %s: %v", digested, err)
+		return found, nil
+	}
+	for _, layer := range layers {
+		if _, ok := layer.Annotations[sigstoreSignatureAnnotation]; ok {
+			found = append(found, libartTypes.FoundSignature{Format: "sigstore", Digest: layer.Digest})
+		}
+	}
+	return found, nil
+}
+
+// sigstoreAttachmentLayers returns the layers of the sigstore attachment of
+// the manifest digested refers to, which is tagged after its digest in the
+// same repository.
+func sigstoreAttachmentLayers(ctx context.Context, sys *types.SystemContext, digested reference.Canonical) ([]specV1.Descriptor, error) {
+	tag := strings.Replace(digested.Digest().String(), ":", "-", 1) + ".sig"
+	tagged, err := reference.WithTag(reference.TrimNamed(digested), tag)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := docker.NewReference(tagged)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	rawManifest, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	mani, err := manifest.OCI1FromManifest(rawManifest)
+	if err != nil {
+		return nil, err
+	}
+	return mani.Layers, nil
+}
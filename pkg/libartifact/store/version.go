@@ -0,0 +1,181 @@
+//go:build !remote
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	libartTypes "github.com/containers/podman/v5/pkg/libartifact/types"
+	"github.com/containers/storage/pkg/ioutils"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// StoreVersion is the version of the on-disk format of the store written by
+// this package.  Stores of an older version are migrated when they are
+// opened, stores of a newer version are refused.
+const StoreVersion = 2
+
+// storeVersionAnnotation is the annotation of the index of the store holding
+// its version.  Keeping it in the index means the version changes together
+// with the index a migration rewrites.  Stores without it have version 1.
+const storeVersionAnnotation = "io.podman.artifact.store.version"
+
+// storeMigration upgrades the store from the version before version.
+type storeMigration struct {
+	version     int
+	description string
+	// migrate changes the index, which is written once all migrations
+	// succeeded, and may write files of the store which are not used by
+	// the older version, so a failed migration leaves a store the older
+	// version can still use.
+	migrate func(as ArtifactStore, index *specV1.Index) error
+}
+
+// storeMigrations are the migrations to StoreVersion in order.
+var storeMigrations = []storeMigration{
+	{
+		version:     2,
+		description: "make the store a valid OCI image layout",
+		migrate:     migrateToOCILayout,
+	},
+}
+
+// migrateToOCILayout writes the oci-layout file and an empty list of
+// manifests, which version 1 stores only had once an artifact was added.
+// Without them other tools do not accept the store as an OCI layout.
+func migrateToOCILayout(as ArtifactStore, index *specV1.Index) error {
+	if index.Manifests == nil {
+		index.Manifests = []specV1.Descriptor{}
+	}
+	if index.MediaType == "" {
+		index.MediaType = specV1.MediaTypeImageIndex
+	}
+	return as.writeOCILayout()
+}
+
+// writeOCILayout writes the oci-layout file of the store.
+func (as ArtifactStore) writeOCILayout() error {
+	layout, err := json.Marshal(specV1.ImageLayout{Version: specV1.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(filepath.Join(as.storePath, specV1.ImageLayoutFile), layout, 0o644)
+}
+
+// indexStoreVersion returns the store version recorded in index.
+func indexStoreVersion(index *specV1.Index) (int, error) {
+	value, ok := index.Annotations[storeVersionAnnotation]
+	if !ok {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid artifact store version %q", value)
+	}
+	return version, nil
+}
+
+// setIndexStoreVersion records StoreVersion in index.
+func setIndexStoreVersion(index *specV1.Index) {
+	if index.Annotations == nil {
+		index.Annotations = map[string]string{}
+	}
+	index.Annotations[storeVersionAnnotation] = strconv.Itoa(StoreVersion)
+}
+
+// migrate upgrades the store to StoreVersion.  The index is backed up before
+// and written once with the changes of all migrations and the new version, so
+// the store is either migrated completely or still has its old version.  The
+// version is checked under the shared lock, the exclusive one is only taken
+// if the store needs to be migrated.
+func (as ArtifactStore) migrate() error {
+	needed, err := as.needsMigration()
+	if err != nil || !needed {
+		return err
+	}
+
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	// Another process may have migrated the store in the meantime.
+	index, err := as.readIndex()
+	if err != nil {
+		return err
+	}
+	version, needed, err := as.checkVersion(index)
+	if err != nil || !needed {
+		return err
+	}
+
+	rawIndex, err := os.ReadFile(as.indexPath())
+	if err != nil {
+		return err
+	}
+	backup := as.indexBackupPath(version)
+	if err := ioutils.AtomicWriteFile(backup, rawIndex, 0o644); err != nil {
+		return fmt.Errorf("backing up the index of the artifact store: %w", err)
+	}
+	for _, m := range storeMigrations {
+		if m.version <= version {
+			continue
+		}
+		logrus.Infof("Migrating artifact store %s to version %d: %s", as.storePath, m.version, m.description)
+		if err := m.migrate(as, index); err != nil {
+			return fmt.Errorf("migrating artifact store %s to version %d, the index is backed up to %s: %w", as.storePath, m.version, backup, err)
+		}
+	}
+	setIndexStoreVersion(index)
+	return as.writeIndex(index)
+}
+
+// needsMigration returns true if the store has a version older than
+// StoreVersion, reading its index under the shared lock.
+func (as ArtifactStore) needsMigration() (bool, error) {
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	index, err := as.readIndex()
+	if err != nil {
+		return false, err
+	}
+	_, needed, err := as.checkVersion(index)
+	return needed, err
+}
+
+// checkVersion returns the store version recorded in index and true if it is
+// older than StoreVersion, and an error if it is newer.
+func (as ArtifactStore) checkVersion(index *specV1.Index) (int, bool, error) {
+	version, err := indexStoreVersion(index)
+	if err != nil {
+		return 0, false, err
+	}
+	if version > StoreVersion {
+		return 0, false, fmt.Errorf("%w: %s has version %d, this version of podman supports up to version %d", libartTypes.ErrStoreVersionUnsupported, as.storePath, version, StoreVersion)
+	}
+	return version, version < StoreVersion, nil
+}
+
+// indexBackupPath is the file the index of a store of the given version is
+// backed up to before migrating it.
+func (as ArtifactStore) indexBackupPath(version int) string {
+	return filepath.Join(as.storePath, fmt.Sprintf("%s.v%d.bak", specV1.ImageIndexFile, version))
+}
+
+// Version returns the version of the store on disk and the version written by
+// this package.
+func (as ArtifactStore) Version() (*libartTypes.StoreVersionInfo, error) {
+	as.lock.RLock()
+	defer as.lock.Unlock()
+	index, err := as.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	version, err := indexStoreVersion(index)
+	if err != nil {
+		return nil, err
+	}
+	return &libartTypes.StoreVersionInfo{Current: StoreVersion, OnDisk: version}, nil
+}
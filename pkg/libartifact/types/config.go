@@ -1,18 +1,152 @@
 package types
 
+import (
+	"io"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Annotations of a blob recording the file it was added from, see
+// AddOptions.RecordMode, ExtractOptions.PreserveMode and
+// ExtractOptions.PreserveOwner.
+const (
+	// AnnotationFileMode is the permission bits of the file in octal,
+	// including the setuid, setgid and sticky bits, e.g. "0755".
+	AnnotationFileMode = "org.podman.file.mode"
+	// AnnotationFileUID and AnnotationFileGID are the numeric owner and
+	// group of the file.
+	AnnotationFileUID = "org.podman.file.uid"
+	AnnotationFileGID = "org.podman.file.gid"
+)
+
+// Annotations of a blob recording where it was added, see
+// AddOptions.AutoAnnotate.  The time is recorded in the
+// org.opencontainers.image.created annotation.
+const (
+	// AnnotationHostname is the name of the host the blob was added on.
+	AnnotationHostname = "org.podman.hostname"
+	// AnnotationPodmanVersion is the version of Podman which added the blob.
+	AnnotationPodmanVersion = "org.podman.version"
+)
+
 // GetArtifactOptions is a struct containing options that for obtaining artifacts.
 // It is meant for future growth or changes required without wacking the API
 type GetArtifactOptions struct{}
 
 // AddOptions are additional descriptors of an artifact file
 type AddOptions struct {
-	Annotations  map[string]string `json:"annotations,omitempty"`
-	ArtifactType string            `json:",omitempty"`
-	// append option is not compatible with ArtifactType option
+	// Annotations are set on each added blob.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ManifestAnnotations are set on the manifest of the artifact.  When
+	// appending they are added to the existing annotations.
+	ManifestAnnotations map[string]string `json:",omitempty"`
+	// IndexAnnotations are set on the descriptor of the artifact in the
+	// index of the store, and in the index written by PushIndex.  When
+	// appending they are added to the existing annotations.  The
+	// org.opencontainers.image.ref.name annotation is the name of the
+	// artifact and cannot be set.
+	IndexAnnotations map[string]string `json:",omitempty"`
+	// ArtifactType is the type of the new artifact.  When appending, it
+	// must be empty or the type of the existing artifact, which cannot be
+	// changed, otherwise the add fails with ErrArtifactTypeConflict.
+	ArtifactType string `json:",omitempty"`
+	// Append adds the blobs to the existing artifact of the name, keeping
+	// its type, subject, config and blobs.
 	Append bool `json:",omitempty"`
+	// Replace replaces an existing artifact of the same name with the new
+	// one instead of failing.  Nothing of the existing artifact is kept,
+	// its blobs are removed unless another artifact uses them.  Not
+	// compatible with Append.
+	Replace bool `json:",omitempty"`
 	// FileType describes the media type for the layer.  It is an override
-	// for the standard detection
+	// for the standard detection, which looks at the content and the file
+	// name extension of each blob, see AddedBlob.MediaType.  It applies to
+	// every blob of the add, but not to the blobs an appended artifact
+	// has already, which keep their media type.
 	FileType string `json:",omitempty"`
+	// AllowDuplicate adds a blob when appending even if a blob with the same
+	// digest is already part of the artifact.  By default such a blob is not
+	// added again and only the annotations of the existing blob are updated.
+	AllowDuplicate bool `json:",omitempty"`
+	// AllowDuplicateTitles adds blobs whose title, the file name, is
+	// already used by another added blob or, when appending, a blob of the
+	// artifact.  By default that is an error, because such a blob cannot be
+	// told apart from the other one by its title, e.g. when extracting it.
+	AllowDuplicateTitles bool `json:",omitempty"`
+	// Subject is set as the subject of the new artifact manifest, which
+	// makes the artifact a referrer of that manifest.  The subject of an
+	// existing artifact is kept when appending.
+	Subject *specV1.Descriptor `json:",omitempty"`
+	// StrictType rejects blobs whose media type is not consistent with the
+	// artifact type, which is then required.  When appending, the type of
+	// the existing artifact is used.
+	StrictType bool `json:",omitempty"`
+	// ConfigFile is the path of the file stored as the config blob of the
+	// new artifact, which requires ConfigType.  Without it the config is
+	// the empty JSON object.
+	ConfigFile string `json:",omitempty"`
+	// ConfigType is the media type of the config blob.  It defaults to the
+	// OCI empty media type for the empty config.  Both config options are
+	// not compatible with Append, which keeps the existing config.
+	ConfigType string `json:",omitempty"`
+	// RecordMode records the mode and ownership of each blob added from a
+	// file in the AnnotationFileMode, AnnotationFileUID and
+	// AnnotationFileGID annotations.  Blobs read from a stream have none.
+	RecordMode bool `json:",omitempty"`
+	// AutoAnnotate records the time, the hostname and the Podman version
+	// of the add in the org.opencontainers.image.created,
+	// AnnotationHostname and AnnotationPodmanVersion annotations of each
+	// added blob.  Annotations with the same keys take precedence.
+	AutoAnnotate bool `json:",omitempty"`
+}
+
+// AddResult describes the outcome of adding blobs to an artifact.
+type AddResult struct {
+	// ManifestDigest is the digest of the resulting artifact manifest.
+	ManifestDigest digest.Digest
+	// Blobs are the added blobs, in the order they were given.
+	Blobs []AddedBlob
+	// ReplacedDigest is the digest of the manifest of the artifact
+	// replaced with AddOptions.Replace, empty if there was none.
+	ReplacedDigest digest.Digest
+}
+
+// AddedBlob describes a single blob given to add.
+type AddedBlob struct {
+	FileName string
+	Digest   digest.Digest
+	// MediaType of the blob in the manifest, either AddOptions.FileType
+	// or the detected type.
+	MediaType string
+	// Deduplicated is true when the blob was already part of the artifact
+	// and was not added a second time.
+	Deduplicated bool
+	// Shared is true when the blob of another artifact in the store was
+	// added by reference, see ArtifactBlob.StoredBlob.  No content was
+	// copied, both artifacts use the same blob of the store.
+	Shared bool
+}
+
+// ArtifactBlob is a single blob to be added to an artifact.  Exactly one of
+// BlobFilePath, BlobReader or StoredBlob must be set.
+type ArtifactBlob struct {
+	// BlobFilePath is the path of a local file holding the blob content.
+	BlobFilePath string
+	// BlobReader is a stream holding the blob content, i.e. stdin.
+	BlobReader io.Reader
+	// FileName is used as the title annotation of the blob.
+	FileName string
+	// MediaType of the blob, e.g. the one of a blob of an image source.
+	// It takes precedence over AddOptions.FileType and the detection.
+	MediaType string
+	// StoredBlob is the descriptor of a blob of another artifact in the
+	// store.  The blob is added by referencing it from the new manifest
+	// instead of reading and writing its content again.  Its media type,
+	// unless MediaType or AddOptions.FileType is set, and its annotations
+	// other than the title are kept.
+	StoredBlob *specV1.Descriptor
 }
 
 // FilterBlobOptions options used to filter for a single blob in an artifact
@@ -23,16 +157,158 @@ type FilterBlobOptions struct {
 	// Digest of the blob to extract.
 	// Optional. Conflicts with Title.
 	Digest string
+	// Index of the blob to extract in manifest layer order, starting at 0.
+	// Optional. Conflicts with Title and Digest.
+	Index *int
 }
 
+// BlobFilter is a function to determine whether a blob of an artifact is
+// selected.  A true return selects the blob described by the descriptor.
+type BlobFilter func(specV1.Descriptor) bool
+
 type ExtractOptions struct {
 	FilterBlobOptions
+	// BlobFilters select all blobs which match every filter rather than a
+	// single blob.  The title and digest of FilterBlobOptions further
+	// narrow the selection, the index conflicts with them.  Several
+	// selected blobs require a directory as target.
+	BlobFilters []BlobFilter
+	// ExtractAll extracts all blobs into the target directory, which is
+	// created if needed.  Conflicts with the title, digest and index, together
+	// with BlobFilters only the matching blobs are extracted.
+	ExtractAll bool
+	// Overwrite allows a blob to overwrite a previously extracted blob
+	// with the same name.  By default this is an error.
+	Overwrite bool
+	// Verify computes the digest of each blob while extracting it and fails
+	// if it does not match the manifest, removing the partially written
+	// file.  Opt-in for now, it is planned to become the default.
+	Verify bool
+	// Decompress decompresses blobs whose media type declares gzip or zstd
+	// compression while extracting them.  Blobs of other media types are
+	// extracted unchanged.  Verify still checks the compressed content.
+	Decompress bool
+	// PreserveMode sets the permission bits of each extracted file to the
+	// ones recorded in the annotations of its blob, see
+	// AddOptions.RecordMode.  The setuid, setgid and sticky bits are never
+	// set.
+	PreserveMode bool
+	// PreserveOwner sets the owner and group of each extracted file to the
+	// ones recorded in the annotations of its blob.  Ownership which cannot
+	// be changed, e.g. without privileges, is left as it is.
+	PreserveOwner bool
+	// Writer receives the content of a single blob instead of a file at
+	// the target path, which must then be empty.  The blob is streamed, so
+	// it does not need to fit in memory.  Conflicts with ExtractAll.
+	Writer io.Writer
+	// TarOutput receives all selected blobs as a single tar stream instead
+	// of files at the target path, which must then be empty.  Each blob is
+	// an entry named by its title, or by its digest if it has none, just
+	// like the files extracted to a directory.  Conflicts with Writer and
+	// Decompress.
+	TarOutput io.Writer
+	// PathPrefix is a leading directory removed from the names of the
+	// blobs extracted to a directory or a tar stream, e.g. "models/v2"
+	// extracts the blob titled "models/v2/weights.bin" as "weights.bin".
+	// The name of every extracted blob must be below it.
+	PathPrefix string
+	// StripComponents removes as many leading elements from the names of
+	// the blobs extracted to a directory or a tar stream, after
+	// PathPrefix, like the --strip-components option of tar.  A name
+	// without more elements than that is an error.
+	StripComponents int
+	// OnConflict is what happens to a file which exists already at the
+	// destination of a blob extracted to a file or a directory.  The
+	// files are checked before anything is extracted.  Empty is
+	// ExtractConflictFail.
+	OnConflict ExtractConflictPolicy
+}
+
+// ExtractConflictPolicy is what Extract does with a file which exists already
+// where a blob is extracted to.
+type ExtractConflictPolicy string
+
+const (
+	// ExtractConflictFail fails the extraction before any blob is written.
+	ExtractConflictFail ExtractConflictPolicy = "fail"
+	// ExtractConflictSkip leaves the existing file unchanged and does not
+	// extract the blob.
+	ExtractConflictSkip ExtractConflictPolicy = "skip"
+	// ExtractConflictOverwrite replaces the existing file with the blob.
+	ExtractConflictOverwrite ExtractConflictPolicy = "overwrite"
+)
+
+// ExtractResult describes the outcome of an extraction.
+type ExtractResult struct {
+	// Skipped are the paths of the existing files which were not
+	// overwritten with the ExtractConflictSkip policy.
+	Skipped []string
+}
+
+// ExportOptions are options for exporting an artifact to an OCI image layout.
+type ExportOptions struct {
+	// Archive writes a tar archive of the layout instead of a directory.
+	Archive bool
+}
+
+// ImportOptions are options for importing an artifact from an OCI image layout.
+type ImportOptions struct {
+	// Digest selects the manifest to import if the layout contains
+	// more than one.
+	Digest digest.Digest
+}
+
+// UpdateOptions are options for changing the annotations of an artifact.
+type UpdateOptions struct {
+	// FilterBlobOptions select the blob whose annotations are changed.
+	// Without any filter the annotations of the manifest are changed.
+	FilterBlobOptions
+	// SetAnnotations are added to the annotations, replacing existing
+	// values of the same keys.
+	SetAnnotations map[string]string
+	// RemoveAnnotations are the keys of annotations to remove.  Keys
+	// which are not set are ignored.
+	RemoveAnnotations []string
+}
+
+// UpdateResult describes the outcome of an artifact update.
+type UpdateResult struct {
+	// OldDigest is the digest of the manifest before the update.
+	OldDigest digest.Digest
+	// NewDigest is the digest of the updated manifest.  It equals
+	// OldDigest if the update did not change any annotation.
+	NewDigest digest.Digest
+}
+
+// TagOptions are options for adding a name to an artifact.
+type TagOptions struct {
+	// Force replaces another artifact which already has the new name.
+	Force bool
+	// RemoveOld removes the old name, so the artifact is renamed.
+	RemoveOld bool
+}
+
+// TagResult describes the outcome of tagging an artifact.
+type TagResult struct {
+	// Digest is the digest of the manifest, both names refer to it.
+	Digest digest.Digest
+	// OldName is the name the artifact was looked up by.  It is empty if
+	// the artifact was looked up by its digest and has no name.
+	OldName string
+	// NewName is the added name.
+	NewName string
 }
 
 type BlobMountPathOptions struct {
 	FilterBlobOptions
 }
 
+// OpenBlobOptions select the blob opened by OpenBlob.  Without a title,
+// digest or index the artifact must consist of a single blob.
+type OpenBlobOptions struct {
+	FilterBlobOptions
+}
+
 // BlobMountPath contains the info on how the artifact must be mounted
 type BlobMountPath struct {
 	// Source path of the blob, i.e. full path in the blob dir.
@@ -40,3 +316,444 @@ type BlobMountPath struct {
 	// Name of the file in the container.
 	Name string
 }
+
+// PullResult describes the outcome of an artifact pull.
+type PullResult struct {
+	// Reference is the fully-qualified reference the artifact was pulled
+	// from, with short names resolved.
+	Reference string
+	// ManifestDigest is the digest of the pulled manifest.
+	ManifestDigest digest.Digest
+	// Platform of the manifest selected from a multi-arch index.  It is nil
+	// when the pulled reference is a single manifest.
+	Platform *specV1.Platform
+	// BlobsFetched is the number of blobs downloaded from the registry,
+	// including the config.  Blobs already in the store are not fetched.
+	BlobsFetched int
+	// BlobsSkipped is the number of distinct blobs of the manifest,
+	// including the config, which were already in the store, e.g. from an
+	// earlier version of the artifact, and BytesSkipped their size.
+	BlobsSkipped int
+	BytesSkipped int64
+	// BytesTransferred is the number of bytes downloaded for all fetched blobs.
+	BytesTransferred int64
+	// Mirror is the location of the mirror configured in registries.conf
+	// the artifact was pulled from, empty if it was pulled from the
+	// registry itself.
+	Mirror string
+	// ExtractedFiles are the paths of the files written to the directory
+	// of PullOptions.ExtractTo, in manifest order.
+	ExtractedFiles []string
+	// PinnedDigest is the manifest digest the pull was pinned to, by the
+	// pulled name or PullOptions.ManifestDigest, and which the manifest of
+	// the registry was verified to have.  It differs from ManifestDigest
+	// if it pins a multi-arch index.  Empty if the pull was not pinned.
+	PinnedDigest digest.Digest
+	// RateLimitWait is the time spent on requests the registry rate
+	// limited, with HTTP 429 or 503, and waiting before their retries.
+	RateLimitWait time.Duration
+}
+
+// CopyOptions are artifact specific options for copying an artifact between
+// registries.
+type CopyOptions struct {
+	// SourceUsername and SourcePassword authenticate at the source registry
+	// instead of the credentials of the copy options.
+	SourceUsername string
+	SourcePassword string
+	// DestinationUsername and DestinationPassword authenticate at the
+	// destination registry instead of the credentials of the copy options.
+	DestinationUsername string
+	DestinationPassword string
+}
+
+// CopyResult describes the outcome of copying an artifact between registries.
+type CopyResult struct {
+	// ManifestDigest is the digest of the manifest written to the destination.
+	ManifestDigest digest.Digest
+	// Platform of the manifest selected from a multi-arch index.  It is nil
+	// when the source reference is a single manifest.
+	Platform *specV1.Platform
+}
+
+// PullOptions are artifact specific options for pulling an artifact.
+type PullOptions struct {
+	// MaxParallelDownloads is the maximum number of blobs downloaded at the
+	// same time.  Zero means the store default.
+	MaxParallelDownloads uint
+	// RateLimitBytesPerSec is the maximum number of bytes per second all
+	// blobs are downloaded with together.  Zero means no limit.
+	RateLimitBytesPerSec int64
+	// MaxSizeBytes is the largest total size of the blobs the pull
+	// downloads, zero means no limit.  The pull fails with
+	// ErrMaxSizeExceeded before downloading any blob if the sizes the
+	// manifest declares exceed it, and as soon as more bytes were
+	// downloaded in case the declared sizes are wrong.
+	MaxSizeBytes int64
+	// PolicyWriter, if set, receives the signature policy requirements the
+	// pulled manifest is checked against.
+	PolicyWriter io.Writer
+	// Titles and Digests select the blobs to pull.  When either is set,
+	// only the manifest, the config and the blobs with one of the titles or
+	// digests are stored.  The other blobs are fetched on demand when they
+	// are extracted or mounted.
+	Titles  []string
+	Digests []digest.Digest
+	// ExtractTo is a directory all blobs of the pulled artifact are
+	// extracted to, named by their title annotations.  The directory is
+	// created if needed.  Conflicts with Titles and Digests.
+	ExtractTo string
+	// NoStore only extracts the artifact to ExtractTo, it is not kept in
+	// the store.  Requires ExtractTo.
+	NoStore bool
+	// Resume downloads the blobs to a staging directory of the store, so
+	// a pull which was interrupted continues with the blobs downloaded
+	// partially before, using range requests.  Conflicts with NoStore.
+	Resume bool
+	// Timeout is the longest time the pull may take, zero means no limit.
+	// When it expires the pull fails with ErrTimeout and the blobs it
+	// stored are removed again, unless another artifact uses them.  With
+	// Resume the partially downloaded blobs stay staged for the next pull.
+	Timeout time.Duration
+	// ManifestDigest pins the pull to the manifest with the digest, the
+	// same as a name with a digest, e.g. quay.io/artifact:v1@sha256:...
+	// A name with a tag is pulled by the tag and stored under it, and the
+	// pull fails with ErrDigestMismatch before any blob is downloaded if
+	// the tag refers to another manifest.
+	ManifestDigest digest.Digest
+	// RequireDigest refuses to pull a name which is not pinned to a
+	// manifest digest by the name or ManifestDigest.
+	RequireDigest bool
+	// Anonymous stores the artifact without its name, it is only found by
+	// its manifest digest and listed as dangling, so prune removes it.  An
+	// artifact of the same digest which is already stored under a name is
+	// not changed.  Combined with NoStore nothing is kept after extracting.
+	Anonymous bool
+}
+
+// PushOptions are artifact specific options for pushing an artifact.
+type PushOptions struct {
+	// MaxParallelUploads is the maximum number of blobs uploaded at the
+	// same time.  Zero means the store default.
+	MaxParallelUploads uint
+	// RateLimitBytesPerSec is the maximum number of bytes per second all
+	// blobs are uploaded with together.  Zero means no limit.
+	RateLimitBytesPerSec int64
+	// AdditionalTags are tags of the destination repository the manifest
+	// is written to in addition to the destination itself.
+	AdditionalTags []string
+	// RetryBackoff doubles the retry delay of the copy options after each
+	// failed attempt.  Without a retry delay the delay always starts at one
+	// second and doubles.
+	RetryBackoff bool
+	// DryRun only checks which blobs already exist in the destination
+	// repository.  No blob is uploaded and no manifest is written.
+	DryRun bool
+	// MountFrom are repositories on the registry of the destination the
+	// blobs missing in the destination repository are mounted from
+	// rather than uploaded.  A blob the registry does not mount from any
+	// of them is uploaded.  A dry run does not check them.
+	MountFrom []string
+	// Timeout is the longest time the push may take including its
+	// retries, zero means no limit.  When it expires the push fails with
+	// ErrTimeout.
+	Timeout time.Duration
+	// ForceCompression recompresses the blobs which are compressed
+	// already with the compression format of the copy options, which is
+	// required.  Blobs compressed with the same algorithm are only
+	// recompressed if a compression level is set.  Without it, compressed
+	// blobs are pushed unchanged.
+	ForceCompression bool
+}
+
+// PushResult describes the outcome of an artifact push.
+type PushResult struct {
+	// ManifestDigest is the digest of the manifest written to the registry.
+	ManifestDigest digest.Digest
+	// Tags are the references the manifest was written to, starting with
+	// the destination followed by the additional tags.
+	Tags []string
+	// Retries is the number of times the push was retried after a failure.
+	Retries int
+	// MountedBlobs is the number of blobs mounted from the repositories
+	// of PushOptions.MountFrom and UploadedBlobs the number of blobs
+	// uploaded.  Blobs present in the destination already are neither.
+	MountedBlobs  int
+	UploadedBlobs int
+	// RecompressedBlobs is the number of compressed blobs which were
+	// recompressed due to PushOptions.ForceCompression.
+	RecompressedBlobs int
+	// UploadBlobs are the blobs a dry run found missing in the destination
+	// repository, which a push uploads, and SkippedBlobs the ones already
+	// present there.  Only set by a dry run.
+	UploadBlobs  []PushBlob
+	SkippedBlobs []PushBlob
+}
+
+// PushBlob is a blob of an artifact checked by a dry run of a push.
+type PushBlob struct {
+	// Title annotation of the blob, if any.
+	Title string `json:",omitempty"`
+	// Digest and Size of the blob as recorded in the manifest.
+	Digest digest.Digest
+	Size   int64
+}
+
+// IndexEntry is a local artifact referenced by a pushed index.
+type IndexEntry struct {
+	// Artifact is the name of the artifact in the local store.
+	Artifact string
+	// Platform the artifact is for, which requires an OS and an
+	// architecture.  The platforms of the entries of an index must differ.
+	Platform specV1.Platform
+}
+
+// PushIndexResult describes the outcome of pushing an index of artifacts.
+type PushIndexResult struct {
+	// IndexDigest is the digest of the index written to the registry.
+	IndexDigest digest.Digest
+	// Manifests are the descriptors of the artifact manifests in the
+	// index, in the order of the entries.
+	Manifests []specV1.Descriptor
+	// Tags are the references the index was written to, starting with the
+	// destination followed by the additional tags.
+	Tags []string
+	// Retries is the number of times the pushes were retried after a
+	// failure.
+	Retries int
+}
+
+// VerifyOptions are options for verifying the blobs of an artifact.
+type VerifyOptions struct {
+	// DigestAlgorithm is used to additionally compute the digest of each
+	// blob when it differs from the algorithm used by the manifest.
+	// Optional, defaults to the algorithm of the manifest.
+	DigestAlgorithm digest.Algorithm
+}
+
+// BlobDigest is the result of verifying a single blob against the manifest.
+type BlobDigest struct {
+	// Title annotation of the blob, if any.
+	Title string `json:",omitempty"`
+	// Digest of the blob as recorded in the manifest and verified on disk.
+	Digest digest.Digest
+	// AlternateDigest is the digest of the blob computed with
+	// VerifyOptions.DigestAlgorithm when it differs from the one of Digest.
+	AlternateDigest digest.Digest `json:",omitempty"`
+}
+
+// CheckOptions are options for checking the blobs of all artifacts in the
+// store.
+type CheckOptions struct {
+	// Repair fetches the corrupt and missing blobs again from the registry
+	// of the artifacts using them.  A blob is only replaced by content
+	// which matches its digest, nothing is removed from the store.
+	Repair bool
+}
+
+// CheckResult summarizes the check of the store.  Each blob is counted once,
+// even if several artifacts use it.
+type CheckResult struct {
+	// Healthy is the number of blobs whose content matches their digest.
+	Healthy int
+	// Corrupt is the number of blobs whose content does not match their
+	// digest, including the repaired ones.
+	Corrupt int
+	// Missing is the number of blobs which are not in the store,
+	// including the repaired ones.
+	Missing int
+	// Repaired is the number of corrupt or missing blobs fetched again.
+	Repaired int
+	// Damaged are the corrupt and missing blobs.
+	Damaged []CheckedBlob
+}
+
+// SignatureVerifyOptions are options for checking the signatures of an
+// artifact of the store.
+type SignatureVerifyOptions struct {
+	// Reference is the registry repository the signatures are read from.
+	// It defaults to the name of the artifact and has to be set for an
+	// unnamed artifact.
+	Reference string
+	// SigstoreKeys are paths of public keys.  If they or GPGKeys are set,
+	// the artifact has to be signed by one of each instead of meeting the
+	// requirements of the signature policy.
+	SigstoreKeys []string
+	// GPGKeys are paths of GPG keyrings a simple signature has to be made
+	// with.
+	GPGKeys []string
+}
+
+// SignatureVerifyResult describes the signatures of an artifact and whether
+// they meet the requirements it was checked against.
+type SignatureVerifyResult struct {
+	// Reference is the registry reference of the stored manifest, by
+	// digest, the signatures were read for.
+	Reference string
+	// Scope describes where the requirements come from, e.g. the policy
+	// section which applies to Reference.
+	Scope string
+	// Signatures are the signatures found for the manifest.  They are not
+	// verified, see Requirements.
+	Signatures []FoundSignature
+	// Requirements are the requirements the artifact was checked against.
+	Requirements []VerifiedRequirement
+	// Allowed is true if all requirements are met.
+	Allowed bool
+}
+
+// FoundSignature describes a signature of a manifest.
+type FoundSignature struct {
+	// Format is "simple-signing" or "sigstore".
+	Format string
+	// KeyID is the identifier of the key a simple signature claims to be
+	// made with.
+	KeyID string `json:",omitempty"`
+	// Digest is the digest of the payload of a sigstore signature.
+	Digest digest.Digest `json:",omitempty"`
+}
+
+// VerifiedRequirement is the result of checking a signature policy
+// requirement.
+type VerifiedRequirement struct {
+	// Requirement is the type of the requirement and the keys it accepts.
+	Requirement string
+	// Satisfied is true if the artifact meets the requirement.
+	Satisfied bool
+	// Error describes why the requirement is not met.
+	Error string `json:",omitempty"`
+}
+
+// StoreVersionInfo describes the format version of a store.
+type StoreVersionInfo struct {
+	// Current is the version written by this version of the store.
+	Current int
+	// OnDisk is the version of the store on disk.  Opening a store
+	// migrates an older version, so it only differs from Current for a
+	// store changed since it was opened.
+	OnDisk int
+}
+
+// CheckedBlob describes a blob found corrupt or missing by a check.
+type CheckedBlob struct {
+	// Digest of the blob as recorded in the manifest or index.
+	Digest digest.Digest
+	// Artifacts are the names of the artifacts using the blob, unnamed
+	// artifacts by the digest of their manifest.
+	Artifacts []string
+	// Missing is true if the blob is not in the store, otherwise its
+	// content does not match its digest.
+	Missing bool
+	// Repaired is true if the blob was fetched again.
+	Repaired bool `json:",omitempty"`
+	// RepairError describes why the blob could not be fetched again.
+	RepairError string `json:",omitempty"`
+}
+
+// DiffKind is how an entry of a diff differs between the two artifacts.
+type DiffKind string
+
+const (
+	// DiffAdded entries are only in the second artifact.
+	DiffAdded DiffKind = "added"
+	// DiffRemoved entries are only in the first artifact.
+	DiffRemoved DiffKind = "removed"
+	// DiffChanged entries are in both artifacts with different values.
+	DiffChanged DiffKind = "changed"
+)
+
+// DiffResult describes the differences between two artifacts.  It is empty
+// apart from the digests if both have the same manifest.
+type DiffResult struct {
+	// OldDigest and NewDigest are the digests of the manifests of the
+	// first and the second artifact.
+	OldDigest digest.Digest
+	NewDigest digest.Digest
+	// Fields are the differences of the artifact type and config of the
+	// manifests, named "artifactType", "config.mediaType" and
+	// "config.digest".
+	Fields []ValueDiff `json:",omitempty"`
+	// Annotations are the differences of the manifest annotations.
+	Annotations []ValueDiff `json:",omitempty"`
+	// Blobs are the blobs which were added, removed or changed, in the
+	// order of the second artifact followed by the removed ones.
+	Blobs []BlobDiff `json:",omitempty"`
+}
+
+// ValueDiff is a field or annotation whose value differs.
+type ValueDiff struct {
+	Kind DiffKind
+	Key  string
+	// OldValue is empty for an added value, NewValue for a removed one.
+	OldValue string `json:",omitempty"`
+	NewValue string `json:",omitempty"`
+}
+
+// BlobDiff is a blob which differs between two artifacts.  Blobs are matched
+// by their title, blobs without a title by their digest.  The content of a
+// changed blob differs if its digests differ, otherwise only its media type
+// or annotations do.
+type BlobDiff struct {
+	Kind  DiffKind
+	Title string `json:",omitempty"`
+	// OldDigest is empty for an added blob, NewDigest for a removed one.
+	OldDigest digest.Digest `json:",omitempty"`
+	NewDigest digest.Digest `json:",omitempty"`
+	// MediaType is the difference of the media types of a changed blob.
+	MediaType *ValueDiff `json:",omitempty"`
+	// Annotations are the differences of the annotations of a changed
+	// blob, apart from the title.
+	Annotations []ValueDiff `json:",omitempty"`
+}
+
+// GCOptions are options for removing the blobs in the store which no artifact
+// references.
+type GCOptions struct {
+	// DryRun only reports the unreferenced blobs.
+	DryRun bool
+}
+
+// GCResult describes the blobs removed by a garbage collection.
+type GCResult struct {
+	// Blobs are the removed blobs, or the ones a dry run would remove,
+	// sorted by digest.
+	Blobs []StoredBlob
+	// ReclaimedSize is the sum of the sizes of the blobs.
+	ReclaimedSize int64
+}
+
+// StoredBlob is a blob file in the store.
+type StoredBlob struct {
+	Digest digest.Digest
+	// Size of the file in bytes.
+	Size int64
+}
+
+// DiskUsage describes the space used by the artifacts in the store.
+type DiskUsage struct {
+	// TotalSize is the size of all manifests and blobs referenced by
+	// artifacts, blobs used by several artifacts are only counted once.
+	TotalSize int64
+	// SharedSize is the size of the blobs used by more than one artifact,
+	// each counted once.
+	SharedSize int64
+	// Artifacts holds the usage of every artifact in the store.
+	Artifacts []ArtifactDiskUsage
+}
+
+// ArtifactDiskUsage describes the space used by a single artifact.
+type ArtifactDiskUsage struct {
+	// Name of the artifact, empty if it has none.
+	Name string
+	// Digest of the artifact manifest.
+	Digest digest.Digest
+	// Size is the size of the manifest, config and all blobs of the
+	// artifact, regardless of whether other artifacts use them as well.
+	Size int64
+	// UniqueSize is the size of the blobs only used by this artifact,
+	// which is the space freed by removing it.
+	UniqueSize int64
+	// SharedSize is the size of the blobs this artifact shares with
+	// other artifacts.
+	SharedSize int64
+}
@@ -4,9 +4,38 @@ import (
 	"errors"
 )
 
+// Errors returned by the artifact store wrap these, so callers can test for
+// them with errors.Is.  Errors of registries, e.g. the
+// docker.ErrUnauthorizedForCredentials of an authentication failure, are
+// wrapped unchanged and can be tested with errors.As.
 var (
 	ErrArtifactUnamed        = errors.New("artifact is unnamed")
 	ErrArtifactNotExist      = errors.New("artifact does not exist")
 	ErrArtifactAlreadyExists = errors.New("artifact already exists")
 	ErrArtifactFileExists    = errors.New("file already exists in artifact")
+	ErrBlobDigestMismatch    = errors.New("blob digest does not match the manifest")
+	// ErrArtifactTypeConflict is wrapped by the error of appending to an
+	// artifact with another AddOptions.ArtifactType than its own.
+	ErrArtifactTypeConflict = errors.New("artifact type conflicts with the existing artifact")
+	// ErrTimeout is wrapped by the error of a pull or push which did not
+	// complete within its timeout, together with the error of the
+	// interrupted transfer.
+	ErrTimeout = errors.New("timed out")
+	// ErrMaxSizeExceeded is wrapped by the error of a pull of an artifact
+	// larger than PullOptions.MaxSizeBytes.
+	ErrMaxSizeExceeded = errors.New("artifact exceeds the maximum size")
+	// ErrDigestMismatch is wrapped by the error of a pull pinned to a
+	// manifest digest when the registry serves another manifest.
+	ErrDigestMismatch = errors.New("manifest does not match the pinned digest")
+	// ErrStoreVersionUnsupported is wrapped by the error of opening a
+	// store written by a newer version, which cannot be migrated.
+	ErrStoreVersionUnsupported = errors.New("unsupported artifact store version")
+	// The blob errors are the beginning of the messages they are wrapped
+	// in, e.g. "no blob with the title ...", so messages read the same.
+	ErrBlobNotExist      = errors.New("no blob")
+	ErrBlobAmbiguous     = errors.New("more than one match")
+	ErrDuplicateBlobName = errors.New("more than one blob with the name")
+	// ErrExtractTargetExists is the beginning of the error of extracting a
+	// blob to an existing file with the default conflict policy.
+	ErrExtractTargetExists = errors.New("refusing to overwrite the existing file")
 )
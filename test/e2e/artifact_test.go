@@ -3,14 +3,33 @@
 package integration
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/containers/podman/v5/pkg/domain/entities"
 	. "github.com/containers/podman/v5/test/utils"
+	"github.com/containers/podman/v5/version"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	specV1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 const (
@@ -62,6 +81,21 @@ var _ = Describe("Podman artifact", func() {
 		truncOutput := noTruncSession.OutputToStringArray()[0]
 		Expect(truncOutput).To(HaveLen(len(add1.OutputToString())))
 
+		// The digest is not truncated to fit a narrow terminal
+		narrowSession := podmanTest.PodmanExitCleanlyWithOptions(PodmanExecOptions{Env: append(os.Environ(), "COLUMNS=20")}, "artifact", "ls", "--no-trunc", "--format", "{{.Digest}}")
+		Expect(narrowSession.OutputToStringArray()).To(ContainElement(add1.OutputToString()))
+
+		// The total size in bytes should be available
+		sizeSession := podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}} {{.TotalSize}}")
+		Expect(sizeSession.OutputToStringArray()).To(ContainElements(artifact1Name+" 4192", artifact2Name+" 10240"))
+
+		// The digest algorithm and the artifact type are available
+		typedName := "localhost/test/typed"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.example", typedName, artifact1File)
+		typeSession := podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}},{{.DigestAlgorithm}},{{.ArtifactType}}")
+		Expect(typeSession.OutputToStringArray()).To(ContainElements(artifact1Name+",sha256,", typedName+",sha256,application/vnd.example"))
+		podmanTest.PodmanExitCleanly("artifact", "rm", typedName)
+
 		// check with --noheading and verify the header is not present through a line count AND substring match
 		noHeaderSession := podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
 		noHeaderOutput := noHeaderSession.OutputToStringArray()
@@ -70,157 +104,2611 @@ var _ = Describe("Podman artifact", func() {
 
 	})
 
-	It("podman artifact simple add", func() {
+	It("podman artifact ls --format aliases and validation", func() {
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := "localhost/test/format:v1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "table")
+		Expect(session.OutputToString()).To(Equal(podmanTest.PodmanExitCleanly("artifact", "ls").OutputToString()))
+		Expect(session.OutputToStringArray()[0]).To(ContainSubstring("REPOSITORY"))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading", "--format", "table")
+		Expect(session.OutputToStringArray()).To(HaveLen(1))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "json")
+		var listed []map[string]any
+		Expect(json.Unmarshal(session.Out.Contents(), &listed)).To(Succeed())
+		Expect(listed).To(HaveLen(1))
+		Expect(listed[0]).To(HaveKeyWithValue("Repository", "localhost/test/format"))
+		Expect(listed[0]).To(HaveKeyWithValue("Tag", "v1"))
+		Expect(listed[0]).To(HaveKeyWithValue("TotalSize", BeNumerically("==", 1024)))
+
+		// An unknown field fails before anything is printed, also in a
+		// branch which is not run for the artifact.
+		session = podmanTest.Podman([]string{"artifact", "ls", "--format", "{{.Repository}} {{.Tags}}"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `"Tags" is not a valid field for the format. Choose from: ArtifactType, Created,`))
+		Expect(session.OutputToString()).To(BeEmpty())
+		session = podmanTest.Podman([]string{"artifact", "ls", "--format", "{{range .}}{{if .Description}}{{.Descr}}{{end}}{{end}}"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `"Descr" is not a valid field for the format`))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{with .}}{{.Tag}}{{end}}")
+		Expect(session.OutputToString()).To(Equal("v1"))
+	})
+
+	It("podman artifact ls --sort", func() {
+		names := []string{"localhost/test/artifact1", "localhost/test/artifact3", "localhost/test/artifact2"}
+		for i, name := range names {
+			artifactFile, err := createArtifactFile(int64(1024 * (3 - i)))
+			Expect(err).ToNot(HaveOccurred())
+			podmanTest.PodmanExitCleanly("artifact", "add", name, artifactFile)
+		}
+
+		// The creation time is recorded when adding and kept when appending
+		a := podmanTest.InspectArtifact(names[0])
+		created, ok := a.CreatedTime()
+		Expect(ok).To(BeTrue())
+		Expect(created).To(BeTemporally("~", time.Now(), time.Minute))
+		appendFile, err := createArtifactFile(16)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", names[0], appendFile)
+		a = podmanTest.InspectArtifact(names[0])
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue(specV1.AnnotationCreated, created.Format(time.RFC3339Nano)))
+
+		// The newest artifact is listed first
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "created", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{names[2], names[1], names[0]}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "repository", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{names[0], names[2], names[1]}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "size", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{names[2], names[1], names[0]}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "created", "--format", "{{.Created}}")
+		Expect(session.OutputToStringArray()[0]).To(HaveSuffix(" ago"))
+
+		session = podmanTest.Podman([]string{"artifact", "ls", "--sort", "bogus"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: "bogus" is not a valid field for sorting. Choose from: created, digest, repository, size`))
+	})
+
+	It("podman artifact ls --filter", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		add1 := podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.test.one", "--annotation", "color=blue", artifact1Name, artifact1File)
+
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.test.two", "--annotation", "color=red", artifact2Name, artifact2File)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "type=application/vnd.test.one", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact1Name}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "annotation=color=red", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact2Name}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "annotation=color", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(HaveLen(2))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "type=application/vnd.test.one", "--filter", "annotation=color=red", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(BeEmpty())
+
+		// An empty value only matches "key=", all annotation filters and
+		// the type filter must match.
+		emptyName := "localhost/test/empty"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.test.one", "--annotation", "color=", "--annotation", "size=big", emptyName, artifact1File)
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "annotation=color=", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{emptyName}))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "repository", "--filter", "annotation=color", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact1Name, artifact2Name, emptyName}))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "annotation=color", "--filter", "annotation=size=big", "--filter", "type=application/vnd.test.one", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{emptyName}))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "annotation=color=blue", "--filter", "annotation=size", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(BeEmpty())
+		session = podmanTest.Podman([]string{"artifact", "ls", "--filter", "annotation==red"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `"=red" is not a valid value for the "annotation" filter - must be key or key=value`))
+		podmanTest.PodmanExitCleanly("artifact", "rm", emptyName)
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "-q", "--no-trunc", "--filter", "type=application/vnd.test.one")
+		Expect(session.OutputToStringArray()).To(Equal([]string{add1.OutputToString()}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "-q")
+		Expect(session.OutputToStringArray()).To(HaveLen(2))
+		Expect(session.OutputToStringArray()).To(ContainElement(add1.OutputToString()[:12]))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "dangling=true", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(BeEmpty())
+
+		session = podmanTest.Podman([]string{"artifact", "ls", "--filter", "foo=bar"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: "foo" is an invalid artifact filter, supported filters are: annotation, dangling, kind, type`))
+	})
+
+	It("podman artifact ls --filter kind", func() {
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		chartName := "localhost/test/chart"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.cncf.helm.config.v1+json", chartName, artifactFile)
+		sbomName := "localhost/test/sbom"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/spdx+json; charset=utf-8", sbomName, artifactFile)
+		genericName := "localhost/test/generic"
+		podmanTest.PodmanExitCleanly("artifact", "add", genericName, artifactFile)
+		unknownName := "localhost/test/unknown"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.example", unknownName, artifactFile)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "repository", "--format", "{{.Repository}} {{.Kind}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{
+			chartName + " helm-chart",
+			genericName + " generic",
+			sbomName + " sbom",
+			unknownName + " unknown",
+		}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "kind=helm-chart", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{chartName}))
+
+		// Repeated kind filters match any of the kinds
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "repository", "--filter", "kind=sbom", "--filter", "kind=generic", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{genericName, sbomName}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--filter", "kind=model", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(BeEmpty())
+
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "-f", "--filter", "kind=unknown")
+		Expect(session.OutputToStringArray()).To(HaveLen(2))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--sort", "repository", "--format", "{{.Repository}}", "--noheading")
+		Expect(session.OutputToStringArray()).To(Equal([]string{chartName, genericName, sbomName}))
+	})
+
+	It("podman artifact simple add", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		Expect(a.Name).To(Equal(artifact1Name))
+
+		// Adding an artifact with an existing name should fail
+		addAgain := podmanTest.Podman([]string{"artifact", "add", artifact1Name, artifact1File})
+		addAgain.WaitWithDefaultTimeout()
+		Expect(addAgain).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact already exists", artifact1Name)))
+	})
+
+	It("podman artifact add with options", func() {
+		yamlType := "text/yaml"
+		artifact1Name := "localhost/test/artifact1"
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactType := "octet/foobar"
+		annotation1 := "color=blue"
+		annotation2 := "flavor=lemon"
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", yamlType, "--type", artifactType, "--annotation", annotation1, "--annotation", annotation2, artifact1Name, artifact1File)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Name).To(Equal(artifact1Name))
+		Expect(a.Manifest.ArtifactType).To(Equal(artifactType))
+		Expect(a.Manifest.Layers[0].Annotations["color"]).To(Equal("blue"))
+		Expect(a.Manifest.Layers[0].Annotations["flavor"]).To(Equal("lemon"))
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal(yamlType))
+
+		failSession := podmanTest.Podman([]string{"artifact", "add", "--annotation", "org.opencontainers.image.title=foobar", "foobar", artifact1File})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, "Error: cannot override filename with org.opencontainers.image.title annotation"))
+	})
+
+	It("podman artifact add --auto-annotate", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		hostname, err := os.Hostname()
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := "localhost/test/autoannotate"
+		before := time.Now()
+		podmanTest.PodmanExitCleanly("artifact", "add", "--auto-annotate", "--annotation", "flavor=lemon", artifactName, artifact1File)
+		a := podmanTest.InspectArtifact(artifactName)
+		annotations := a.Manifest.Layers[0].Annotations
+		Expect(annotations).To(HaveKeyWithValue("org.podman.hostname", hostname))
+		Expect(annotations).To(HaveKeyWithValue("org.podman.version", version.Version.String()))
+		Expect(annotations).To(HaveKeyWithValue("flavor", "lemon"))
+		created, err := time.Parse(time.RFC3339Nano, annotations[specV1.AnnotationCreated])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTemporally(">=", before.Add(-time.Second)))
+
+		// Given annotations take precedence
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--auto-annotate", "--annotation", "org.podman.hostname=builder", artifactName, artifact2File)
+		a = podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		Expect(a.Manifest.Layers[0].Annotations).To(HaveKeyWithValue("org.podman.hostname", hostname))
+		Expect(a.Manifest.Layers[1].Annotations).To(HaveKeyWithValue("org.podman.hostname", "builder"))
+		Expect(a.Manifest.Layers[1].Annotations).To(HaveKey(specV1.AnnotationCreated))
+
+		// Without the option no annotations are added
+		podmanTest.PodmanExitCleanly("artifact", "add", "localhost/test/noautoannotate", artifact1File)
+		a = podmanTest.InspectArtifact("localhost/test/noautoannotate")
+		Expect(a.Manifest.Layers[0].Annotations).ToNot(HaveKey("org.podman.hostname"))
+		Expect(a.Manifest.Layers[0].Annotations).ToNot(HaveKey(specV1.AnnotationCreated))
+	})
+
+	It("podman artifact add --manifest-annotation and --index-annotation", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := "localhost/test/levels"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "level=blob", "--manifest-annotation", "level=manifest", "--index-annotation", "level=index", artifactName, artifact1File)
+		a := podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.Layers[0].Annotations).To(HaveKeyWithValue("level", "blob"))
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue("level", "manifest"))
+		Expect(a.Manifest.Annotations).To(HaveKey(specV1.AnnotationCreated))
+		Expect(a.IndexAnnotations).To(Equal(map[string]string{"level": "index", specV1.AnnotationRefName: artifactName}))
+
+		// Appending adds to the annotations of both levels
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--manifest-annotation", "appended=manifest", "--index-annotation", "appended=index", artifactName, artifact2File)
+		a = podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.Layers[1].Annotations).ToNot(HaveKey("level"))
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue("level", "manifest"))
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue("appended", "manifest"))
+		Expect(a.IndexAnnotations).To(HaveKeyWithValue("level", "index"))
+		Expect(a.IndexAnnotations).To(HaveKeyWithValue("appended", "index"))
+
+		// The index annotations are kept by a new name and an update
+		taggedName := "localhost/test/levels:tagged"
+		podmanTest.PodmanExitCleanly("artifact", "tag", artifactName, taggedName)
+		podmanTest.PodmanExitCleanly("artifact", "update", "--annotation", "updated=yes", taggedName)
+		a = podmanTest.InspectArtifact(taggedName)
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue("updated", "yes"))
+		Expect(a.IndexAnnotations).To(Equal(map[string]string{"level": "index", "appended": "index", specV1.AnnotationRefName: taggedName}))
+
+		failSession := podmanTest.Podman([]string{"artifact", "add", "--index-annotation", "org.opencontainers.image.ref.name=other", "localhost/test/refname", artifact1File})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, "Error: cannot set the org.opencontainers.image.ref.name index annotation, it holds the name of the artifact"))
+	})
+
+	It("podman artifact add --description", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := "localhost/test/described"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--description", "First version", "--manifest-annotation", "org.opencontainers.image.description=ignored", artifactName, artifact1File)
+		a := podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.Annotations).To(HaveKeyWithValue(specV1.AnnotationDescription, "First version"))
+		Expect(a.Description()).To(Equal("First version"))
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}} {{.Description}}")
+		Expect(session.OutputToString()).To(Equal(artifactName + " First version"))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "table {{.Description}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{"DESCRIPTION", "First version"}))
+
+		// Appending replaces the description.
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--description", "Second version", artifactName, artifact2File)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Description}}", artifactName)
+		Expect(session.OutputToString()).To(Equal("Second version"))
+
+		// Without a description the field is empty.
+		podmanTest.PodmanExitCleanly("artifact", "add", "localhost/test/undescribed", artifact1File)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Description}}", "localhost/test/undescribed")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		failSession := podmanTest.Podman([]string{"artifact", "add", "--description", "two\nlines", "localhost/test/multiline", artifact1File})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, "Error: the artifact description must be a single line"))
+	})
+
+	It("podman artifact add --replace", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := "localhost/test/replaced"
+		session := podmanTest.PodmanExitCleanly("artifact", "add", "--index-annotation", "channel=stable", artifactName, artifact1File)
+		oldDigest := session.OutputToString()
+		oldBlob := podmanTest.InspectArtifact(artifactName).Manifest.Layers[0].Digest
+
+		// Without a previous artifact, replace just adds.
+		session = podmanTest.PodmanExitCleanly("artifact", "add", "--replace", "localhost/test/new", artifact2File)
+		Expect(session.ErrorToString()).To(BeEmpty())
+
+		session = podmanTest.PodmanExitCleanly("artifact", "add", "--replace", "--type", "application/vnd.example", artifactName, artifact2File)
+		newDigest := session.OutputToString()
+		Expect(newDigest).ToNot(Equal(oldDigest))
+		Expect(session.ErrorToString()).To(Equal("Replaced artifact " + oldDigest))
+
+		a := podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.ArtifactType).To(Equal("application/vnd.example"))
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Annotations).To(HaveKeyWithValue(specV1.AnnotationTitle, filepath.Base(artifact2File)))
+		Expect(a.IndexAnnotations).ToNot(HaveKey("channel"))
+
+		// The replaced manifest and its blob are gone.
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--no-trunc", "--format", "{{.Digest}}")
+		Expect(session.OutputToStringArray()).ToNot(ContainElement(oldDigest))
+		Expect(filepath.Join(podmanTest.Root, "artifacts", "blobs", "sha256", oldBlob.Encoded())).ToNot(BeAnExistingFile())
+
+		// A blob which another artifact uses is kept.
+		podmanTest.PodmanExitCleanly("artifact", "add", "localhost/test/other", artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--replace", artifactName, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--replace", artifactName, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "extract", "localhost/test/other", filepath.Join(podmanTest.TempDir, "other"))
+		Expect(readFileToString(filepath.Join(podmanTest.TempDir, "other"))).To(Equal(readFileToString(artifact1File)))
+
+		failSession := podmanTest.Podman([]string{"artifact", "add", "--replace", "--append", artifactName, artifact1File})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, "Error: append option is not compatible with Replace option"))
+	})
+
+	It("podman artifact add with a custom config", func() {
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		configType := "application/vnd.example.config.v1+json"
+
+		// Without options the config is the empty JSON object.
+		podmanTest.PodmanExitCleanly("artifact", "add", "localhost/test/default", artifactFile)
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Config.MediaType}} {{.Config.Digest}} {{.Config.Size}}", "localhost/test/default")
+		Expect(session.OutputToString()).To(Equal(fmt.Sprintf("%s %s 2", specV1.MediaTypeEmptyJSON, specV1.DescriptorEmptyJSON.Digest)))
+
+		// The empty config with a custom media type.
+		podmanTest.PodmanExitCleanly("artifact", "add", "--config-type", configType, "localhost/test/typed", artifactFile)
+		a := podmanTest.InspectArtifact("localhost/test/typed")
+		Expect(a.Manifest.Config.MediaType).To(Equal(configType))
+		Expect(a.Manifest.Config.Digest).To(Equal(specV1.DescriptorEmptyJSON.Digest))
+
+		configFile := filepath.Join(podmanTest.TempDir, "config.json")
+		configContent := `{"kind":"example"}`
+		Expect(os.WriteFile(configFile, []byte(configContent), 0o644)).To(Succeed())
+		configDigest := digest.FromString(configContent)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--config-type", configType, "--config-file", configFile, "localhost/test/custom", artifactFile)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Config.MediaType}} {{.Config.Digest}} {{.Config.Size}}", "localhost/test/custom")
+		Expect(session.OutputToString()).To(Equal(fmt.Sprintf("%s %s %d", configType, configDigest, len(configContent))))
+		stored, err := os.ReadFile(filepath.Join(podmanTest.Root, "artifacts", "blobs", "sha256", configDigest.Encoded()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(stored)).To(Equal(configContent))
+
+		// Removing the artifact removes its config blob.
+		podmanTest.PodmanExitCleanly("artifact", "rm", "localhost/test/custom")
+		Expect(filepath.Join(podmanTest.Root, "artifacts", "blobs", "sha256", configDigest.Encoded())).ToNot(BeAnExistingFile())
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--config-file", configFile, "localhost/test/untyped", artifactFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: a config file requires a config type"))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--append", "--config-type", configType, "localhost/test/typed", artifactFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: append option is not compatible with the config options"))
+	})
+
+	It("podman artifact add multiple", func() {
+		artifact1File1, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1File2, err := createArtifactFile(8192)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost/test/artifact1"
+
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File1, artifact1File2)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Name).To(Equal(artifact1Name))
+
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+	})
+
+	It("podman artifact add detects the media type", func() {
+		artifactDir := filepath.Join(podmanTest.TempDir, "artifacts")
+		err := os.MkdirAll(artifactDir, 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		jsonFile := filepath.Join(artifactDir, "data.json")
+		err = os.WriteFile(jsonFile, []byte(`{"color": "blue"}`), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		textFile := filepath.Join(artifactDir, "notes")
+		err = os.WriteFile(textFile, []byte("hello world\n"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		binaryFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, jsonFile, textFile, binaryFile)
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(3))
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal("application/json"))
+		Expect(a.Manifest.Layers[1].MediaType).To(Equal("text/plain; charset=utf-8"))
+		Expect(a.Manifest.Layers[2].MediaType).To(Equal("application/octet-stream"))
+
+		// An explicit file type disables the detection
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "text/plain", artifact2Name, jsonFile)
+		a = podmanTest.InspectArtifact(artifact2Name)
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal("text/plain"))
+	})
+
+	It("podman artifact add --recursive", func() {
+		modelDir := filepath.Join(podmanTest.TempDir, "modeldir")
+		err := os.MkdirAll(filepath.Join(modelDir, "shards"), 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.MkdirAll(filepath.Join(modelDir, "empty"), 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		for name, content := range map[string]string{
+			"config.json":         `{"layers": 2}`,
+			"shards/model-1.bin":  "shard one",
+			"shards/model-2.bin":  "shard two",
+			"shards/download.tmp": "partial",
+		} {
+			err = os.WriteFile(filepath.Join(modelDir, name), []byte(content), 0o644)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		err = os.Symlink("config.json", filepath.Join(modelDir, "link.json"))
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost/test/artifact1"
+		session := podmanTest.Podman([]string{"artifact", "add", artifact1Name, modelDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s is a directory, use --recursive to add the files it contains", modelDir)))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--recursive", "--exclude", "*.tmp", artifact1Name, modelDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		Expect(session.ErrorToString()).To(ContainSubstring(fmt.Sprintf("Skipping %s: the directory is empty", filepath.Join(modelDir, "empty"))))
+		Expect(session.ErrorToString()).To(ContainSubstring(fmt.Sprintf("Skipping %s: symlinks are only added with --follow-symlinks", filepath.Join(modelDir, "link.json"))))
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		titles := make([]string, 0, len(a.Manifest.Layers))
+		for _, l := range a.Manifest.Layers {
+			titles = append(titles, l.Annotations["org.opencontainers.image.title"])
+		}
+		Expect(titles).To(Equal([]string{"config.json", "shards/model-1.bin", "shards/model-2.bin"}))
+
+		// Extracting recreates the directories
+		extractDir := filepath.Join(podmanTest.TempDir, "extracted")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", artifact1Name, extractDir)
+		Expect(readFileToString(filepath.Join(extractDir, "shards", "model-2.bin"))).To(Equal("shard two"))
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", "--title", "shards/model-1.bin", artifact1Name, extractDir)
+		Expect(readFileToString(filepath.Join(extractDir, "shards", "model-1.bin"))).To(Equal("shard one"))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "mount", artifact1Name)
+		mountPoint := session.OutputToString()
+		Expect(readFileToString(filepath.Join(mountPoint, "shards", "model-1.bin"))).To(Equal("shard one"))
+		podmanTest.PodmanExitCleanly("artifact", "unmount", artifact1Name)
+		Expect(mountPoint).ToNot(BeADirectory())
+
+		artifact2Name := "localhost/test/artifact2"
+		session = podmanTest.Podman([]string{"artifact", "add", "--recursive", "--follow-symlinks", "--exclude", "shards", artifact2Name, modelDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		a = podmanTest.InspectArtifact(artifact2Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		Expect(a.Manifest.Layers[1].Annotations["org.opencontainers.image.title"]).To(Equal("link.json"))
+		Expect(a.Manifest.Layers[1].Digest).To(Equal(a.Manifest.Layers[0].Digest))
+	})
+
+	It("podman artifact extract --path-prefix and --strip-components", func() {
+		modelDir := filepath.Join(podmanTest.TempDir, "modeldir")
+		err := os.MkdirAll(filepath.Join(modelDir, "models", "v2", "shards"), 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		for name, content := range map[string]string{
+			"models/v2/config.json":        `{"layers": 2}`,
+			"models/v2/shards/model-1.bin": "shard one",
+		} {
+			err = os.WriteFile(filepath.Join(modelDir, name), []byte(content), 0o644)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		artifactName := "localhost/test/prefixed"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--recursive", artifactName, modelDir)
+
+		prefixDir := filepath.Join(podmanTest.TempDir, "prefix")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", "--path-prefix", "models/v2", artifactName, prefixDir)
+		Expect(readFileToString(filepath.Join(prefixDir, "config.json"))).To(Equal(`{"layers": 2}`))
+		Expect(readFileToString(filepath.Join(prefixDir, "shards", "model-1.bin"))).To(Equal("shard one"))
+
+		stripDir := filepath.Join(podmanTest.TempDir, "strip")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", "--path-prefix", "models", "--strip-components", "1", artifactName, stripDir)
+		Expect(readFileToString(filepath.Join(stripDir, "config.json"))).To(Equal(`{"layers": 2}`))
+		Expect(readFileToString(filepath.Join(stripDir, "shards", "model-1.bin"))).To(Equal("shard one"))
+		session := podmanTest.PodmanExitCleanly("artifact", "extract", "--tar", "--title", "models/v2/config.json", "--strip-components", "2", artifactName)
+		tr := tar.NewReader(bytes.NewReader(session.Out.Contents()))
+		hdr, err := tr.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hdr.Name).To(Equal("config.json"))
+
+		// The names must keep at least one element below the prefix.
+		failSession := podmanTest.Podman([]string{"artifact", "extract", "--all", "--strip-components", "3", artifactName, filepath.Join(podmanTest.TempDir, "fail1")})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, `Error: blob "models/v2/config.json" has no path elements left after stripping 3 of them`))
+		failSession = podmanTest.Podman([]string{"artifact", "extract", "--all", "--path-prefix", "models/v1", artifactName, filepath.Join(podmanTest.TempDir, "fail2")})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, `Error: blob "models/v2/config.json" is not below the path prefix "models/v1"`))
+		failSession = podmanTest.Podman([]string{"artifact", "extract", "--all", "--path-prefix", "../models", artifactName, filepath.Join(podmanTest.TempDir, "fail3")})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, `Error: invalid path prefix "../models": it must be a relative path without ".." elements`))
+		failSession = podmanTest.Podman([]string{"artifact", "extract", "--all", "--strip-components", "-1", artifactName, filepath.Join(podmanTest.TempDir, "fail4")})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, "Error: invalid number of path elements to strip: -1"))
+
+		// Names which collide once stripped are an error.
+		otherDir := filepath.Join(podmanTest.TempDir, "otherdir")
+		err = os.MkdirAll(filepath.Join(otherDir, "other", "v2"), 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(filepath.Join(otherDir, "other", "v2", "config.json"), []byte(`{"layers": 3}`), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--recursive", artifactName, otherDir)
+		failSession = podmanTest.Podman([]string{"artifact", "extract", "--all", "--strip-components", "1", artifactName, filepath.Join(podmanTest.TempDir, "fail5")})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, `Error: more than one blob with the name "v2/config.json", refusing to overwrite it`))
+	})
+
+	It("podman artifact add from stdin", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost/test/artifact1"
+		stdinOpts := PodmanExecOptions{
+			Wrapper: []string{"sh", "-c", fmt.Sprintf(`exec "$@" < %s`, artifact1File), "sh"},
+		}
+		podmanTest.PodmanExitCleanlyWithOptions(stdinOpts, "artifact", "add", "--file-name", "data.bin", artifact1Name, "-", artifact2File)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		Expect(a.Manifest.Layers[0].Annotations["org.opencontainers.image.title"]).To(Equal("data.bin"))
+		Expect(a.Manifest.Layers[0].Size).To(Equal(int64(1024)))
+		Expect(a.Manifest.Layers[1].Annotations["org.opencontainers.image.title"]).To(Equal(filepath.Base(artifact2File)))
+
+		// Appending from stdin works too
+		artifact3File, err := createArtifactFile(4192)
+		Expect(err).ToNot(HaveOccurred())
+		stdinOpts.Wrapper = []string{"sh", "-c", fmt.Sprintf(`exec "$@" < %s`, artifact3File), "sh"}
+		podmanTest.PodmanExitCleanlyWithOptions(stdinOpts, "artifact", "add", "--append", "--file-name", "more.bin", artifact1Name, "-")
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(3))
+
+		// A name is required
+		session := podmanTest.Podman([]string{"artifact", "add", "localhost/test/artifact2", "-"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: a file name must be provided when reading a blob from stdin"))
+
+		// Empty stdin is an error
+		stdinOpts.Wrapper = []string{"sh", "-c", `exec "$@" < /dev/null`, "sh"}
+		session = podmanTest.PodmanWithOptions(stdinOpts, "artifact", "add", "--file-name", "empty", "localhost/test/artifact2", "-")
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: empty: no data read from input stream, refusing to add an empty blob"))
+	})
+
+	It("podman artifact add streams large blobs", func() {
+		const size = 1024 * 1024 * 1024
+		largeFile := filepath.Join(podmanTest.TempDir, "large.bin")
+		f, err := os.Create(largeFile)
+		Expect(err).ToNot(HaveOccurred())
+		err = f.Truncate(size)
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+
+		artifactName := "localhost/test/large"
+		session := podmanTest.PodmanExitCleanly("artifact", "add", artifactName, largeFile)
+		// The blob is hashed while it is written, not read into memory
+		rusage, ok := session.Command.ProcessState.SysUsage().(*syscall.Rusage)
+		Expect(ok).To(BeTrue())
+		Expect(rusage.Maxrss * 1024).To(BeNumerically("<", size/4))
+
+		a := podmanTest.InspectArtifact(artifactName)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Size).To(Equal(int64(size)))
+		zeros, err := os.Open("/dev/zero")
+		Expect(err).ToNot(HaveOccurred())
+		defer zeros.Close()
+		expected, err := digest.FromReader(io.LimitReader(zeros, size))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(a.Manifest.Layers[0].Digest).To(Equal(expected))
+	})
+
+	It("podman artifact push and pull", func() {
+		// Before starting a registry, try to pull a bogus image from a bogus registry
+		// using retry-delay
+		retrySession := podmanTest.Podman([]string{"artifact", "pull", "--retry", "1", "--retry-delay", "100ms", "127.0.0.1/mybadimagename"})
+		retrySession.WaitWithDefaultTimeout()
+		Expect(retrySession).Should(ExitWithError(125, "connect: connection refused"))
+		Expect(retrySession.ErrorToString()).To(ContainSubstring("retrying in 100ms ..."))
+
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The delay between push retries can back off
+		badName := "127.0.0.1/mybadimagename"
+		podmanTest.PodmanExitCleanly("artifact", "add", badName, artifact1File)
+		retrySession = podmanTest.Podman([]string{"artifact", "push", "--retry", "2", "--retry-delay", "100ms", "--retry-backoff", badName})
+		retrySession.WaitWithDefaultTimeout()
+		Expect(retrySession).Should(ExitWithError(125, "connect: connection refused"))
+		Expect(retrySession.ErrorToString()).To(ContainSubstring("retrying in 100ms ... (1/2)"))
+		Expect(retrySession.ErrorToString()).To(ContainSubstring("retrying in 200ms ... (2/2)"))
+		retrySession = podmanTest.Podman([]string{"artifact", "push", "--retry", "1", "--retry-delay", "bogus", badName})
+		retrySession.WaitWithDefaultTimeout()
+		Expect(retrySession).Should(ExitWithError(125, `Error: invalid retry delay "bogus"`))
+		podmanTest.PodmanExitCleanly("artifact", "rm", badName)
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := fmt.Sprintf("localhost:%s/test/artifact1", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", artifact1Name)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		Expect(a.Name).To(Equal(artifact1Name))
+
+		// The manifest is written to all additional tags
+		session := podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--additional-tag", "bad:tag", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `invalid additional tag "bad:tag"`))
+
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--additional-tag", "v1", "--additional-tag", "v2", artifact1Name)
+		for _, tag := range []string{"v1", "v2"} {
+			taggedName := artifact1Name + ":" + tag
+			podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", taggedName)
+			tagged := podmanTest.InspectArtifact(taggedName)
+			Expect(tagged.Manifest.Layers).To(Equal(a.Manifest.Layers))
+		}
+
+		// Downloading the blobs one at a time gives the same artifact
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2Name := fmt.Sprintf("localhost:%s/test/artifact2", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact1File, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact2Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact2Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", "--max-parallel-downloads", "1", artifact2Name)
+
+		a = podmanTest.InspectArtifact(artifact2Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+
+		// Uploading the blobs one at a time writes the same manifest
+		digestFile := filepath.Join(podmanTest.TempDir, "digestfile")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--max-parallel-uploads", "1", "--digestfile", digestFile, artifact2Name)
+		pushedDigest, err := os.ReadFile(digestFile)
+		Expect(err).ToNot(HaveOccurred())
+		inspect := podmanTest.PodmanExitCleanly("artifact", "inspect", artifact2Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(inspect.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(string(pushedDigest)).To(Equal(report.Digest))
+	})
+
+	It("podman artifact push and pull --authfile", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := fmt.Sprintf("localhost:%s/test/authfile", port)
+		authFile := filepath.Join(podmanTest.TempDir, "auth.json")
+		err = os.WriteFile(authFile, []byte(`{"auths":{}}`), 0o600)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The same authentication file works for a pull and a push.
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--authfile", authFile, artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--authfile", authFile, artifactName)
+
+		missing := filepath.Join(podmanTest.TempDir, "missing.json")
+		for _, cmd := range []string{"pull", "push"} {
+			session := podmanTest.Podman([]string{"artifact", cmd, "--tls-verify=false", "--authfile", missing, artifactName})
+			session.WaitWithDefaultTimeout()
+			Expect(session).Should(ExitWithError(125, fmt.Sprintf("credential file is not accessible: faccessat %s: no such file or directory", missing)))
+		}
+	})
+
+	It("podman artifact push, pull and copy --creds", func() {
+		lock, port, err := setupAuthRegistry("podmantest", "test")
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := fmt.Sprintf("localhost:%s/test/creds", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+
+		// The registry refuses anonymous pushes and wrong credentials.
+		for _, creds := range [][]string{nil, {"--creds", "podmantest:wrongpasswd"}} {
+			session := podmanTest.Podman(append([]string{"artifact", "push", "-q", "--tls-verify=false", artifactName}, creds...))
+			session.WaitWithDefaultTimeout()
+			Expect(session).Should(ExitWithError(125, "/test/creds: authentication required"))
+		}
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--creds", "podmantest:test", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		session := podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--creds", "podmantest:wrongpasswd", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "authentication required"))
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--creds", "podmantest:test", artifactName)
+
+		// Each registry of a copy uses its own credentials, both ends are
+		// the same registry here.
+		copyName := fmt.Sprintf("localhost:%s/test/creds-copy", port)
+		for _, creds := range [][]string{
+			{"--src-creds", "podmantest:wrongpasswd", "--dest-creds", "podmantest:test"},
+			{"--src-creds", "podmantest:test", "--dest-creds", "podmantest:wrongpasswd"},
+		} {
+			session := podmanTest.Podman(append([]string{"artifact", "copy", "-q", "--tls-verify=false", artifactName, copyName}, creds...))
+			session.WaitWithDefaultTimeout()
+			Expect(session).Should(ExitWithError(125, "authentication required"))
+		}
+		podmanTest.PodmanExitCleanly("artifact", "copy", "-q", "--tls-verify=false", "--src-creds", "podmantest:test", "--dest-creds", "podmantest:test", artifactName, copyName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--creds", "podmantest:test", copyName)
+		Expect(podmanTest.InspectArtifact(copyName).Manifest).To(Equal(podmanTest.InspectArtifact(artifactName).Manifest))
+	})
+
+	It("podman artifact push and pull --rate-limit", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := fmt.Sprintf("localhost:%s/test/ratelimit", port)
+		addArgs := []string{"artifact", "add", artifactName}
+		for range 3 {
+			artifactFile, err := createArtifactFile(64 * 1024)
+			Expect(err).ToNot(HaveOccurred())
+			addArgs = append(addArgs, artifactFile)
+		}
+		podmanTest.PodmanExitCleanly(addArgs...)
+		a := podmanTest.InspectArtifact(artifactName)
+
+		// The limit applies to the three blobs together, so the 192KiB
+		// take more than two seconds even though they are parallel.
+		start := time.Now()
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--rate-limit", "64k", artifactName)
+		Expect(time.Since(start)).To(BeNumerically(">", 2*time.Second))
+
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		start = time.Now()
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--rate-limit", "64k", artifactName)
+		Expect(time.Since(start)).To(BeNumerically(">", 2*time.Second))
+		pulled := podmanTest.InspectArtifact(artifactName)
+		Expect(pulled.Manifest.Layers).To(Equal(a.Manifest.Layers))
+
+		session := podmanTest.Podman([]string{"artifact", "push", "--tls-verify=false", "--rate-limit", "fast", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `invalid rate limit "fast"`))
+	})
+
+	It("podman artifact push and pull --timeout", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := fmt.Sprintf("localhost:%s/test/timeout", port)
+		addArgs := []string{"artifact", "add", artifactName}
+		for range 3 {
+			artifactFile, err := createArtifactFile(64 * 1024)
+			Expect(err).ToNot(HaveOccurred())
+			addArgs = append(addArgs, artifactFile)
+		}
+		podmanTest.PodmanExitCleanly(addArgs...)
+
+		// Rate limited to 64k the 192KiB take more than two seconds
+		session := podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--rate-limit", "64k", "--timeout", "1s", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: pushing %s: timed out after 1s: ", artifactName)))
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--timeout", "1m", artifactName)
+
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--rate-limit", "64k", "--timeout", "1s", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: pulling %s: timed out after 1s: ", artifactName)))
+		// The blobs the pull stored before timing out are removed
+		session = podmanTest.PodmanExitCleanly("artifact", "gc", "--dry-run")
+		Expect(session.OutputToStringArray()).To(Equal([]string{"Total reclaimable space: 0B"}))
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--timeout", "1m", artifactName)
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--tls-verify=false", "--timeout", "-1s", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: the timeout must not be negative"))
+	})
+
+	It("podman artifact pull --max-size", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := fmt.Sprintf("localhost:%s/test/maxsize", port)
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifact1File, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		// The two blobs and the empty config are 2050 bytes together.
+		session := podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--max-size", "2k", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "artifact exceeds the maximum size: the blobs declare 2050 bytes, the limit is 2048 bytes"))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		// Only the selected blobs of a partial pull count.
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--max-size", "2k", "--title", filepath.Base(artifact1File), artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--max-size", "3k", artifactName)
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--tls-verify=false", "--max-size", "huge", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `invalid maximum size "huge"`))
+	})
+
+	It("podman artifact pull pinned to a digest", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := fmt.Sprintf("localhost:%s/test/pinned:v1", port)
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		artifactDigest := podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", artifactName).OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		// A tag and a digest store the artifact under the tag.
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", "--require-digest", artifactName+"@"+artifactDigest)
+		Expect(session.ErrorToString()).To(ContainSubstring("Verified pinned digest " + artifactDigest))
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", artifactName)
+		Expect(session.OutputToString()).To(Equal(artifactDigest))
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--tls-verify=false", "--require-digest", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, artifactName+" is not pinned to a manifest digest"))
+
+		// Moving the tag to another manifest fails the pinned pull.
+		artifact2File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		movedDigest := podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", artifactName).OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", artifactName + "@" + artifactDigest})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("manifest does not match the pinned digest %s: %s refers to %s", artifactDigest, artifactName, movedDigest)))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		// The old manifest can still be pulled by its digest alone.
+		repository := strings.TrimSuffix(artifactName, ":v1")
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--require-digest", repository+"@"+artifactDigest)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", repository+"@"+artifactDigest)
+		Expect(session.OutputToString()).To(Equal(artifactDigest))
+	})
+
+	It("podman artifact pull skips blobs already in the store", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		repository := fmt.Sprintf("localhost:%s/test/incremental", port)
+		sharedFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", repository+":v1", sharedFile, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", repository+":v1")
+		podmanTest.PodmanExitCleanly("artifact", "add", repository+":v2", sharedFile, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", repository+":v2")
+		podmanTest.PodmanExitCleanly("artifact", "rm", "--all")
+
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", repository+":v1")
+		Expect(session.ErrorToString()).ToNot(ContainSubstring("skipped"))
+
+		// Only the blob v1 does not have is downloaded.
+		session = podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", repository+":v2")
+		Expect(session.ErrorToString()).To(ContainSubstring("skipped 1 (1.024kB) already in the local store"))
+		a := podmanTest.InspectArtifact(repository + ":v2")
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		podmanTest.PodmanExitCleanly("artifact", "check")
+
+		session = podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", repository+":v2")
+		Expect(session.ErrorToString()).To(BeEmpty())
+	})
+
+	It("podman artifact pull backs off from a rate limiting registry", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := fmt.Sprintf("localhost:%s/test/ratelimit:v1", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		// The proxy to the registry rejects the first blob requests with
+		// 429 Too Many Requests, more than the registry client retries
+		// on its own.
+		registryURL, err := url.Parse("http://localhost:" + port)
+		Expect(err).ToNot(HaveOccurred())
+		proxy := httputil.NewSingleHostReverseProxy(registryURL)
+		var limited atomic.Int32
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/blobs/") && limited.Add(1) <= 6 {
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte(`{"errors":[{"code":"TOOMANYREQUESTS","message":"rate limited"}]}`))
+					return
+				}
+				proxy.ServeHTTP(w, r)
+			}),
+			ReadHeaderTimeout: time.Second,
+		}
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer lis.Close()
+		defer srv.Close()
+		go func() {
+			defer GinkgoRecover()
+			Expect(srv.Serve(lis)).To(MatchError(http.ErrServerClosed))
+		}()
+
+		proxiedName := lis.Addr().String() + "/test/ratelimit:v1"
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", "--retry", "2", "--retry-delay", "100ms", proxiedName)
+		Expect(session.ErrorToString()).To(ContainSubstring("retrying in 100ms ... (1/2)"))
+		Expect(session.ErrorToString()).To(ContainSubstring("on registry rate limits"))
+		a := podmanTest.InspectArtifact(proxiedName)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+	})
+
+	It("podman artifact pull --anonymous", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactName := fmt.Sprintf("localhost:%s/test/anonymous:v1", port)
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		artifactDigest := podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", artifactName).OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		// The artifact is only found by the digest the pull prints.
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--anonymous", artifactName)
+		encoded := strings.TrimPrefix(artifactDigest, "sha256:")
+		Expect(session.OutputToString()).To(Equal(encoded))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}} {{.Tag}}")
+		Expect(session.OutputToString()).To(Equal("<none> <none>"))
+		session = podmanTest.Podman([]string{"artifact", "inspect", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "artifact does not exist"))
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", encoded[:12])
+		Expect(session.OutputToString()).To(Equal(artifactDigest))
+
+		// Pulling it by name afterwards names the same entry.
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", artifactName)
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToStringArray()).To(HaveLen(1))
+
+		// An anonymous pull of a named artifact does not add another entry.
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--anonymous", artifactName)
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}")
+		Expect(session.OutputToString()).To(Equal(strings.TrimSuffix(artifactName, ":v1")))
+
+		// Prune removes anonymous artifacts.
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--anonymous", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "prune", "-f")
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToString()).To(BeEmpty())
+	})
+
+	It("podman artifact pull --resume", func() {
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifactFile, err := createArtifactFile(64 * 1024)
+		Expect(err).ToNot(HaveOccurred())
+		content, err := os.ReadFile(artifactFile)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := fmt.Sprintf("localhost:%s/test/resume", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifactName)
+		a := podmanTest.InspectArtifact(artifactName)
+		layerDigest := a.Manifest.Layers[0].Digest
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+
+		// The first half of the blob left behind by an interrupted pull
+		stagingDir := filepath.Join(podmanTest.Root, "artifacts", "staging")
+		err = os.MkdirAll(stagingDir, 0o700)
+		Expect(err).ToNot(HaveOccurred())
+		stagedBlob := filepath.Join(stagingDir, layerDigest.Algorithm().String()+"-"+layerDigest.Encoded())
+		err = os.WriteFile(stagedBlob, content[:len(content)/2], 0o600)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--resume", artifactName)
+		Expect(stagedBlob).ToNot(BeAnExistingFile())
+
+		extracted := filepath.Join(podmanTest.TempDir, "extracted")
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifactName, extracted)
+		extractedContent, err := os.ReadFile(extracted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extractedContent).To(Equal(content))
+
+		// A staged part which does not match the blob is removed
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactName)
+		err = os.WriteFile(stagedBlob, make([]byte, len(content)/2), 0o600)
+		Expect(err).ToNot(HaveOccurred())
+		session := podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--resume", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("the fetched content does not match %s", layerDigest)))
+		Expect(stagedBlob).ToNot(BeAnExistingFile())
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--resume", artifactName)
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--tls-verify=false", "--resume", "--no-store", "--extract-to", podmanTest.TempDir, artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "--resume and --no-store cannot be used together"))
+	})
+
+	It("podman artifact push to a local destination", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		add := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		artifactDigest := "sha256:" + add.OutputToString()
+
+		archivePath := filepath.Join(podmanTest.TempDir, "artifact.tar")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", artifact1Name, "oci-archive:"+archivePath)
+		Expect(archivePath).To(BeAnExistingFile())
+
+		layoutPath := filepath.Join(podmanTest.TempDir, "layout")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--digestfile", filepath.Join(podmanTest.TempDir, "digest"), artifact1Name, "oci:"+layoutPath+":v1")
+		pushedDigest, err := os.ReadFile(filepath.Join(podmanTest.TempDir, "digest"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(pushedDigest)).To(Equal(artifactDigest))
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "import", layoutPath, artifact1Name)
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Annotations[specV1.AnnotationTitle]).To(Equal(filepath.Base(artifact1File)))
+
+		dirPath := filepath.Join(podmanTest.TempDir, "dir")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", artifact1Name, "dir:"+dirPath)
+		Expect(filepath.Join(dirPath, "manifest.json")).To(BeAnExistingFile())
+
+		session := podmanTest.Podman([]string{"artifact", "push", "--additional-tag", "v2", artifact1Name, "oci:" + layoutPath})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "additional tags require a registry destination"))
+
+		session = podmanTest.Podman([]string{"artifact", "push", artifact1Name, "docker-archive:" + archivePath})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "pushing artifacts with the docker-archive transport is not supported"))
+	})
+
+	It("podman artifact push --dry-run", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := fmt.Sprintf("localhost:%s/test/artifact1", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		pushed := podmanTest.InspectArtifact(artifact1Name)
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", artifact1Name, artifact2File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "push", "--dry-run", "--tls-verify=false", artifact1Name)
+		lines := session.OutputToStringArray()
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[0]).To(HavePrefix(fmt.Sprintf("Upload %s ", a.Manifest.Layers[1].Digest)))
+		Expect(lines[0]).To(HaveSuffix(filepath.Base(artifact2File)))
+		Expect(lines[1]).To(HavePrefix(fmt.Sprintf("Skip   %s ", a.Manifest.Config.Digest)))
+		Expect(lines[2]).To(HavePrefix(fmt.Sprintf("Skip   %s ", a.Manifest.Layers[0].Digest)))
+		Expect(lines[3]).To(Equal("1 blob to upload (2.048kB), 2 blobs already present (1.026kB)"))
+
+		// Nothing was pushed
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", artifact1Name)
+		pulled := podmanTest.InspectArtifact(artifact1Name)
+		Expect(pulled.Manifest.Layers).To(Equal(pushed.Manifest.Layers))
+
+		session = podmanTest.Podman([]string{"artifact", "push", "--dry-run", "--tls-verify=false", "--compression-format", "gzip", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "a dry run cannot be combined with compression"))
+	})
+
+	It("podman artifact push --mount-from", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		baseName := fmt.Sprintf("localhost:%s/test/base", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", baseName, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", baseName)
+
+		// The config and the first blob are mounted, a repository
+		// without the blobs is skipped and the second blob is uploaded.
+		teamName := fmt.Sprintf("localhost:%s/test/team", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", teamName, artifact1File, artifact2File)
+		session := podmanTest.PodmanExitCleanly("artifact", "push", "--tls-verify=false",
+			"--mount-from", fmt.Sprintf("localhost:%s/test/missing", port), "--mount-from", baseName, teamName)
+		Expect(session.OutputToString()).To(Equal("2 blobs mounted, 1 blob uploaded"))
+		pushed := podmanTest.InspectArtifact(teamName)
+
+		// All blobs are present now
+		session = podmanTest.PodmanExitCleanly("artifact", "push", "--tls-verify=false", "--mount-from", baseName, teamName)
+		Expect(session.OutputToString()).To(Equal("0 blobs mounted, 0 blobs uploaded"))
+
+		podmanTest.PodmanExitCleanly("artifact", "rm", teamName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", teamName)
+		pulled := podmanTest.InspectArtifact(teamName)
+		Expect(pulled.Manifest.Layers).To(Equal(pushed.Manifest.Layers))
+
+		session = podmanTest.Podman([]string{"artifact", "push", "--tls-verify=false", "--mount-from", "quay.io/test/base", teamName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: cannot mount blobs from quay.io/test/base, it is not on the registry localhost:%s of the destination", port)))
+	})
+
+	It("podman artifact push --platform-all", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		amd64Name := "localhost/test/artifact:amd64"
+		arm64Name := "localhost/test/artifact:arm64"
+		podmanTest.PodmanExitCleanly("artifact", "add", amd64Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", arm64Name, artifact2File)
+		amd64Artifact := podmanTest.InspectArtifact(amd64Name)
+		amd64Digest, err := amd64Artifact.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+		arm64Artifact := podmanTest.InspectArtifact(arm64Name)
+		arm64Digest, err := arm64Artifact.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+
+		// Duplicate platforms are rejected before anything is pushed.
+		indexName := fmt.Sprintf("localhost:%s/test/index:latest", port)
+		session := podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--platform-all", indexName, "linux/amd64=" + amd64Name, "amd64=" + arm64Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: artifacts %s and %s are both for platform linux/amd64, the platforms of an index must differ", amd64Name, arm64Name)))
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", indexName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "manifest unknown"))
+
+		digestFile := filepath.Join(podmanTest.TempDir, "digestfile")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--digestfile", digestFile, "--platform-all", indexName, "linux/amd64="+amd64Name, "linux/arm64="+arm64Name)
+		indexDigest, err := os.ReadFile(digestFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(indexDigest)).To(HavePrefix("sha256:"))
+
+		session = podmanTest.PodmanExitCleanly("manifest", "inspect", "--tls-verify=false", indexName)
+		var index specV1.Index
+		Expect(json.Unmarshal(session.Out.Contents(), &index)).To(Succeed())
+		Expect(index.Manifests).To(HaveLen(2))
+		Expect(index.Manifests[0].Digest).To(Equal(*amd64Digest))
+		Expect(index.Manifests[0].Platform.Architecture).To(Equal("amd64"))
+		Expect(index.Manifests[1].Digest).To(Equal(*arm64Digest))
+		Expect(index.Manifests[1].Platform.Architecture).To(Equal("arm64"))
+
+		// Pulling the index selects the artifact of the platform.
+		session = podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", "--os", "linux", "--arch", "arm64", indexName)
+		Expect(session.ErrorToString()).To(ContainSubstring("Selected platform linux/arm64"))
+		pulled := podmanTest.InspectArtifact(indexName)
+		Expect(pulled.Manifest.Layers).To(Equal(arm64Artifact.Manifest.Layers))
+
+		session = podmanTest.Podman([]string{"artifact", "push", "--tls-verify=false", "--platform-all", indexName, "linux/amd64"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: invalid argument "linux/amd64", must be PLATFORM=ARTIFACT`))
+	})
+
+	It("podman artifact pull short name", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		server := "localhost:" + port
+		artifact1Name := server + "/test/artifact1:latest"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+
+		registriesConf := filepath.Join(podmanTest.TempDir, "registries.conf")
+		err = os.WriteFile(registriesConf, []byte(fmt.Sprintf(`unqualified-search-registries = ["%s"]
+short-name-mode = "enforcing"
+
+[[registry]]
+location = "%s"
+insecure = true
+`, server, server)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		// Environment is per-process, tests are not run in parallel within a process.
+		oldRCP, hasRCP := os.LookupEnv("CONTAINERS_REGISTRIES_CONF")
+		defer func() {
+			if hasRCP {
+				os.Setenv("CONTAINERS_REGISTRIES_CONF", oldRCP)
+			} else {
+				os.Unsetenv("CONTAINERS_REGISTRIES_CONF")
+			}
+		}()
+		os.Setenv("CONTAINERS_REGISTRIES_CONF", registriesConf)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "test/artifact1")
+		Expect(session.OutputToString()).To(ContainSubstring(fmt.Sprintf(`Resolving "test/artifact1" using unqualified-search registries (%s)`, registriesConf)))
+		Expect(session.OutputToString()).To(ContainSubstring(fmt.Sprintf("Trying to pull %s...", artifact1Name)))
+
+		// The artifact is stored under the resolved name
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Name).To(Equal(artifact1Name))
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "test/missing"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("initializing source docker://%s/test/missing:latest", server)))
+	})
+
+	It("podman artifact push and pull access insecure registries the same way", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		server := "localhost:" + port
+		artifact1Name := server + "/test/insecure:latest"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		registriesConf := filepath.Join(podmanTest.TempDir, "registries.conf")
+		err = os.WriteFile(registriesConf, []byte(fmt.Sprintf(`[[registry]]
+location = "%s"
+insecure = true
+`, server)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		// Environment is per-process, tests are not run in parallel within a process.
+		oldRCP, hasRCP := os.LookupEnv("CONTAINERS_REGISTRIES_CONF")
+		defer func() {
+			if hasRCP {
+				os.Setenv("CONTAINERS_REGISTRIES_CONF", oldRCP)
+			} else {
+				os.Unsetenv("CONTAINERS_REGISTRIES_CONF")
+			}
+		}()
+		os.Setenv("CONTAINERS_REGISTRIES_CONF", registriesConf)
+
+		// The insecure setting of registries.conf applies to both
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", artifact1Name)
+
+		// --tls-verify overrides it for both
+		for _, command := range []string{"push", "pull"} {
+			session := podmanTest.Podman([]string{"artifact", command, "-q", "--retry", "0", "--tls-verify=true", artifact1Name})
+			session.WaitWithDefaultTimeout()
+			Expect(session).Should(ExitWithError(125, fmt.Sprintf("pinging container registry %s", server)))
+		}
+
+		// A missing certificate directory is an error for both
+		certDir := filepath.Join(podmanTest.TempDir, "missing-certs")
+		for _, command := range []string{"push", "pull"} {
+			session := podmanTest.Podman([]string{"artifact", command, "-q", "--cert-dir", certDir, artifact1Name})
+			session.WaitWithDefaultTimeout()
+			Expect(session).Should(ExitWithError(125, fmt.Sprintf("certificate directory: stat %s: no such file or directory", certDir)))
+		}
+	})
+
+	It("podman artifact pull from a mirror", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		mirror := "localhost:" + port
+		podmanTest.PodmanExitCleanly("artifact", "add", mirror+"/test/mirrored:latest", artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", mirror+"/test/mirrored:latest")
+		podmanTest.PodmanExitCleanly("artifact", "rm", mirror+"/test/mirrored:latest")
+
+		// Neither the registry nor the first mirror are reachable.
+		registriesConf := filepath.Join(podmanTest.TempDir, "registries.conf")
+		err = os.WriteFile(registriesConf, []byte(fmt.Sprintf(`[[registry]]
+location = "localhost:1"
+
+[[registry.mirror]]
+location = "localhost:1/unreachable"
+
+[[registry.mirror]]
+location = "%s"
+insecure = true
+`, mirror)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		// Environment is per-process, tests are not run in parallel within a process.
+		oldRCP, hasRCP := os.LookupEnv("CONTAINERS_REGISTRIES_CONF")
+		defer func() {
+			if hasRCP {
+				os.Setenv("CONTAINERS_REGISTRIES_CONF", oldRCP)
+			} else {
+				os.Unsetenv("CONTAINERS_REGISTRIES_CONF")
+			}
+		}()
+		os.Setenv("CONTAINERS_REGISTRIES_CONF", registriesConf)
+
+		artifactName := "localhost:1/test/mirrored:latest"
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "--retry", "0", artifactName)
+		Expect(session.ErrorToString()).To(ContainSubstring("Pulled from mirror " + mirror))
+		a := podmanTest.InspectArtifact(artifactName)
+		Expect(a.Name).To(Equal(artifactName))
+		path := filepath.Join(podmanTest.TempDir, "mirrored")
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifactName, path)
+		Expect(readFileToString(path)).To(Equal(readFileToString(artifact1File)))
+
+		// An artifact no mirror has fails with the error of the registry.
+		session = podmanTest.Podman([]string{"artifact", "pull", "--retry", "0", "localhost:1/test/missing:latest"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "pinging container registry localhost:1"))
+	})
+
+	It("podman artifact pull enforces the signature policy", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		server := "localhost:" + port
+		signedName := server + "/signed/artifact1:latest"
+		unsignedName := server + "/unsigned/artifact1:latest"
+		for _, name := range []string{signedName, unsignedName} {
+			podmanTest.PodmanExitCleanly("artifact", "add", name, artifact1File)
+			podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", name)
+			podmanTest.PodmanExitCleanly("artifact", "rm", name)
+		}
+
+		keyPath := filepath.Join(podmanTest.TempDir, "key.pub")
+		err = os.WriteFile(keyPath, []byte("not a key"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		policyPath := filepath.Join(podmanTest.TempDir, "policy.json")
+		err = os.WriteFile(policyPath, []byte(fmt.Sprintf(`{
+  "default": [{"type": "insecureAcceptAnything"}],
+  "transports": {"docker": {"%s/signed": [{"type": "sigstoreSigned", "keyPath": "%s"}]}}
+}`, server, keyPath)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The artifact is not signed
+		session := podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--signature-policy", policyPath, signedName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf(`docker://%s: signature policy requirement sigstoreSigned (%s) of the policy section "%s/signed" of transport "docker" is not met: A signature was required, but no signature exists`, signedName, keyPath, server)))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}")
+		Expect(session.OutputToString()).ToNot(ContainSubstring(server))
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--signature-policy", policyPath, "--log-policy", unsignedName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		Expect(session.ErrorToString()).To(Equal(fmt.Sprintf("Checking docker://%s against the default policy section: insecureAcceptAnything", unsignedName)))
+		podmanTest.InspectArtifact(unsignedName)
+	})
+
+	It("podman artifact verify", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		server := "localhost:" + port
+		artifact1Name := server + "/signed/artifact1:latest"
+		add := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		unsignedName := server + "/unsigned/artifact1:latest"
+		podmanTest.PodmanExitCleanly("artifact", "tag", artifact1Name, unsignedName)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", unsignedName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", unsignedName)
+		digested := fmt.Sprintf("%s/signed/artifact1@sha256:%s", server, add.OutputToString())
+
+		keyPath := filepath.Join(podmanTest.TempDir, "key.pub")
+		err = os.WriteFile(keyPath, []byte("not a key"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		policyPath := filepath.Join(podmanTest.TempDir, "policy.json")
+		err = os.WriteFile(policyPath, []byte(fmt.Sprintf(`{
+  "default": [{"type": "insecureAcceptAnything"}],
+  "transports": {"docker": {"%s/signed": [{"type": "sigstoreSigned", "keyPath": "%s"}]}}
+}`, server, keyPath)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The artifact is not signed
+		session := podmanTest.Podman([]string{"artifact", "verify", "--tls-verify=false", "--signature-policy", policyPath, artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf(`%s: signature policy requirement sigstoreSigned (%s) of the policy section "%s/signed" of transport "docker" is not met: A signature was required, but no signature exists`, digested, keyPath, server)))
+		Expect(session.OutputToStringArray()).To(Equal([]string{
+			fmt.Sprintf(`Verifying %s against the policy section "%s/signed" of transport "docker"`, digested, server),
+			"No signatures found",
+			fmt.Sprintf("Requirement sigstoreSigned (%s): not met: A signature was required, but no signature exists", keyPath),
+		}))
+
+		session = podmanTest.Podman([]string{"artifact", "verify", "--tls-verify=false", "--sigstore-key", keyPath, artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("signature policy requirement sigstoreSigned (%s) of the given keys is not met", keyPath)))
+
+		// Another repository is accepted by the default section.
+		session = podmanTest.PodmanExitCleanly("artifact", "verify", "--tls-verify=false", "--signature-policy", policyPath, "--reference", server+"/unsigned/artifact1", artifact1Name)
+		Expect(session.OutputToString()).To(ContainSubstring("Requirement insecureAcceptAnything: met"))
+
+		// Only the stored manifest can be verified.
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		session = podmanTest.Podman([]string{"artifact", "verify", "--tls-verify=false", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "artifact does not exist"))
+	})
+
+	It("podman artifact pull --extract-to", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost:" + port + "/test/artifact1:latest"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+
+		// The artifact is only extracted
+		extractDir := filepath.Join(podmanTest.TempDir, "extract")
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--extract-to", extractDir, "--no-store", artifact1Name)
+		extracted1 := filepath.Join(extractDir, filepath.Base(artifact1File))
+		extracted2 := filepath.Join(extractDir, filepath.Base(artifact2File))
+		Expect(session.OutputToStringArray()).To(Equal([]string{extracted1, extracted2}))
+		Expect(extracted1).To(BeARegularFile())
+		Expect(extracted2).To(BeARegularFile())
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "-q")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		// The artifact is stored and extracted
+		extractDir = filepath.Join(podmanTest.TempDir, "extract-stored")
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--extract-to", extractDir, artifact1Name)
+		Expect(filepath.Join(extractDir, filepath.Base(artifact1File))).To(BeARegularFile())
+		podmanTest.InspectArtifact(artifact1Name)
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--no-store", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: --no-store requires --extract-to"))
+	})
+
+	It("podman artifact diff", func() {
+		writeBlob := func(dir, name, content string) string {
+			path := filepath.Join(podmanTest.TempDir, dir, name)
+			Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+			return path
+		}
+		v1Name := "localhost/test/diff:v1"
+		v2Name := "localhost/test/diff:v2"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "role=draft", v1Name, writeBlob("v1", "model.bin", "old weights"), writeBlob("v1", "notes.txt", "notes"))
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "role=weights", v2Name, writeBlob("v2", "model.bin", "new weights"), writeBlob("v2", "tokenizer.json", "{}"))
+		v1 := podmanTest.InspectArtifact(v1Name)
+		v2 := podmanTest.InspectArtifact(v2Name)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "diff", "--format", "json", v1Name, v2Name)
+		var report entities.ArtifactDiffReport
+		Expect(json.Unmarshal(session.Out.Contents(), &report)).To(Succeed())
+		v1Digest, err := v1.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+		v2Digest, err := v2.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.OldDigest).To(Equal(*v1Digest))
+		Expect(report.NewDigest).To(Equal(*v2Digest))
+		Expect(report.Fields).To(BeEmpty())
+		Expect(report.Blobs).To(HaveLen(3))
+		Expect(report.Blobs[0].Kind).To(BeEquivalentTo("changed"))
+		Expect(report.Blobs[0].Title).To(Equal("model.bin"))
+		Expect(report.Blobs[0].OldDigest).To(Equal(v1.Manifest.Layers[0].Digest))
+		Expect(report.Blobs[0].NewDigest).To(Equal(v2.Manifest.Layers[0].Digest))
+		Expect(report.Blobs[0].Annotations).To(HaveLen(1))
+		Expect(report.Blobs[0].Annotations[0].Key).To(Equal("role"))
+		Expect(report.Blobs[0].Annotations[0].OldValue).To(Equal("draft"))
+		Expect(report.Blobs[0].Annotations[0].NewValue).To(Equal("weights"))
+		Expect(report.Blobs[1].Kind).To(BeEquivalentTo("added"))
+		Expect(report.Blobs[1].Title).To(Equal("tokenizer.json"))
+		Expect(report.Blobs[2].Kind).To(BeEquivalentTo("removed"))
+		Expect(report.Blobs[2].Title).To(Equal("notes.txt"))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "diff", v1Name, v2Name)
+		Expect(session.OutputToStringArray()).To(ContainElements(
+			fmt.Sprintf("changed blob model.bin %s -> %s", v1.Manifest.Layers[0].Digest, v2.Manifest.Layers[0].Digest),
+			"  changed annotation role: draft -> weights",
+			fmt.Sprintf("added blob tokenizer.json %s", v2.Manifest.Layers[1].Digest),
+			fmt.Sprintf("removed blob notes.txt %s", v1.Manifest.Layers[1].Digest),
+		))
+
+		// An artifact does not differ from itself
+		session = podmanTest.PodmanExitCleanly("artifact", "diff", v1Name, v1Name)
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		// Compare against the registry after pushing the first version
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+		remoteName := fmt.Sprintf("localhost:%s/test/diff:latest", port)
+		podmanTest.PodmanExitCleanly("artifact", "tag", v1Name, remoteName)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", remoteName)
+		session = podmanTest.PodmanExitCleanly("artifact", "diff", "--remote", "--tls-verify=false", v1Name, remoteName)
+		Expect(session.OutputToString()).To(BeEmpty())
+		session = podmanTest.PodmanExitCleanly("artifact", "diff", "--remote", "--tls-verify=false", "--format", "{{len .Blobs}}", v2Name, remoteName)
+		Expect(session.OutputToString()).To(Equal("3"))
+
+		session = podmanTest.Podman([]string{"artifact", "diff", "--remote", v1Name, "diff"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: the remote artifact "diff" must be referenced by a fully-qualified name`))
+	})
+
+	It("podman artifact check", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost:" + port + "/test/artifact1:latest"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		session := podmanTest.PodmanExitCleanly("artifact", "check")
+		Expect(session.OutputToString()).To(Equal("3 healthy, 0 corrupt, 0 missing blobs"))
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		layerDigest := a.Manifest.Layers[0].Digest
+		blobPath := filepath.Join(podmanTest.Root, "artifacts", "blobs", layerDigest.Algorithm().String(), layerDigest.Encoded())
+		err = os.WriteFile(blobPath, []byte("corrupt"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		session = podmanTest.Podman([]string{"artifact", "check"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: damage detected in the artifact store"))
+		Expect(session.OutputToStringArray()).To(Equal([]string{
+			fmt.Sprintf("Corrupt blob %s of %s", layerDigest, artifact1Name),
+			"2 healthy, 1 corrupt, 0 missing blobs",
+		}))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "check", "--repair", "--tls-verify=false")
+		Expect(session.OutputToString()).To(ContainSubstring("Repaired"))
+		Expect(session.OutputToString()).To(HaveSuffix("2 healthy, 1 corrupt, 0 missing blobs, 1 repaired"))
+		podmanTest.PodmanExitCleanly("artifact", "check")
+	})
+
+	It("podman artifact store migration", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		storeDir := filepath.Join(podmanTest.Root, "artifacts")
+		session := podmanTest.PodmanExitCleanly("info", "--format", "{{.Store.ArtifactStore.Version}} {{.Store.ArtifactStore.SupportedVersion}}")
+		Expect(session.OutputToString()).To(Equal("2 2"))
+
+		// Turn the store into a version 1 store, which had no version
+		// annotation and no oci-layout file.
+		indexPath := filepath.Join(storeDir, "index.json")
+		indexData, err := os.ReadFile(indexPath)
+		Expect(err).ToNot(HaveOccurred())
+		var index specV1.Index
+		Expect(json.Unmarshal(indexData, &index)).To(Succeed())
+		Expect(index.Annotations).To(HaveKeyWithValue("io.podman.artifact.store.version", "2"))
+		delete(index.Annotations, "io.podman.artifact.store.version")
+		v1Index, err := json.Marshal(index)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(indexPath, v1Index, 0o644)).To(Succeed())
+		Expect(os.Remove(filepath.Join(storeDir, "oci-layout"))).To(Succeed())
+
+		// Opening the store migrates it and keeps the artifact intact.
+		session = podmanTest.PodmanExitCleanly("artifact", "check")
+		Expect(session.OutputToString()).To(Equal("3 healthy, 0 corrupt, 0 missing blobs"))
+		Expect(filepath.Join(storeDir, "oci-layout")).To(BeARegularFile())
+		backup, err := os.ReadFile(filepath.Join(storeDir, "index.json.v1.bak"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backup).To(Equal(v1Index))
+		indexData, err = os.ReadFile(indexPath)
+		Expect(err).ToNot(HaveOccurred())
+		index = specV1.Index{}
+		Expect(json.Unmarshal(indexData, &index)).To(Succeed())
+		Expect(index.Annotations).To(HaveKeyWithValue("io.podman.artifact.store.version", "2"))
+		Expect(index.Manifests).To(HaveLen(1))
+		session = podmanTest.PodmanExitCleanly("info", "--format", "{{.Store.ArtifactStore.Version}}")
+		Expect(session.OutputToString()).To(Equal("2"))
+
+		extractDir := filepath.Join(podmanTest.TempDir, "extract")
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifact1Name, extractDir)
+		Expect(readFileToString(extractDir)).To(Equal(readFileToString(artifact1File)))
+
+		// A store of a newer version is refused.
+		index.Annotations["io.podman.artifact.store.version"] = "3"
+		newerIndex, err := json.Marshal(index)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(indexPath, newerIndex, 0o644)).To(Succeed())
+		session = podmanTest.Podman([]string{"artifact", "ls"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("unsupported artifact store version: %s has version 3, this version of podman supports up to version 2", storeDir)))
+	})
+
+	It("podman artifact gc", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "gc")
+		Expect(session.OutputToStringArray()).To(Equal([]string{"Total reclaimed space: 0B"}))
+
+		// A blob no manifest references, as left by an interrupted add
+		orphan := []byte("orphaned blob")
+		orphanDigest := digest.FromBytes(orphan)
+		blobsDir := filepath.Join(podmanTest.Root, "artifacts", "blobs", "sha256")
+		orphanPath := filepath.Join(blobsDir, orphanDigest.Encoded())
+		Expect(os.WriteFile(orphanPath, orphan, 0o644)).To(Succeed())
+		// Files which are not named by a digest are not blobs
+		otherPath := filepath.Join(blobsDir, "not-a-blob")
+		Expect(os.WriteFile(otherPath, orphan, 0o644)).To(Succeed())
+
+		session = podmanTest.PodmanExitCleanly("artifact", "gc", "--dry-run")
+		Expect(session.OutputToStringArray()).To(Equal([]string{orphanDigest.String(), "Total reclaimable space: 13B"}))
+		Expect(orphanPath).To(BeARegularFile())
+
+		session = podmanTest.PodmanExitCleanly("artifact", "gc")
+		Expect(session.OutputToStringArray()).To(Equal([]string{orphanDigest.String(), "Total reclaimed space: 13B"}))
+		Expect(orphanPath).ToNot(BeAnExistingFile())
+		Expect(otherPath).To(BeARegularFile())
+		session = podmanTest.PodmanExitCleanly("artifact", "check")
+		Expect(session.OutputToString()).To(Equal("3 healthy, 0 corrupt, 0 missing blobs"))
+
+		// Nothing is removed while the blobs of an artifact are unknown
+		Expect(os.WriteFile(orphanPath, orphan, 0o644)).To(Succeed())
+		a := podmanTest.InspectArtifact(artifact1Name)
+		manifestDigest, err := a.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(blobsDir, manifestDigest.Encoded()), []byte("corrupt"), 0o644)).To(Succeed())
+		session = podmanTest.Podman([]string{"artifact", "gc"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: reading the manifest of artifact %s, the blobs it references are unknown", artifact1Name)))
+		Expect(orphanPath).To(BeARegularFile())
+	})
+
+	It("podman artifact pull selected blobs", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		server := "localhost:" + port
+		artifact1Name := server + "/test/artifact1:latest"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--title", filepath.Base(artifact1File), artifact1Name)
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.Partial).To(BeTrue())
+		missingDigest := report.Manifest.Layers[1].Digest
+		Expect(report.MissingBlobs).To(Equal([]digest.Digest{missingDigest}))
+		Expect(filepath.Join(podmanTest.Root, "artifacts", "blobs", missingDigest.Algorithm().String(), missingDigest.Encoded())).ToNot(BeAnExistingFile())
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "-q", "--tls-verify=false", "--title", "missing", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf(`%s: no blob with title "missing" in the artifact`, artifact1Name)))
+
+		// Extracting fetches the missing blob from the registry
+		registriesConf := filepath.Join(podmanTest.TempDir, "registries.conf")
+		err = os.WriteFile(registriesConf, []byte(fmt.Sprintf("[[registry]]\nlocation = \"%s\"\ninsecure = true\n", server)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		// Environment is per-process, tests are not run in parallel within a process.
+		oldRCP, hasRCP := os.LookupEnv("CONTAINERS_REGISTRIES_CONF")
+		defer func() {
+			if hasRCP {
+				os.Setenv("CONTAINERS_REGISTRIES_CONF", oldRCP)
+			} else {
+				os.Unsetenv("CONTAINERS_REGISTRIES_CONF")
+			}
+		}()
+		os.Setenv("CONTAINERS_REGISTRIES_CONF", registriesConf)
+
+		extractDir := filepath.Join(podmanTest.TempDir, "extract")
+		err = os.Mkdir(extractDir, 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifact1Name, extractDir)
+		Expect(readFileToString(filepath.Join(extractDir, filepath.Base(artifact2File)))).To(Equal(readFileToString(artifact2File)))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name)
+		reports = []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report = reports[0]
+		Expect(report.Partial).To(BeFalse())
+		Expect(report.MissingBlobs).To(BeEmpty())
+
+		// Pushing fetches the missing blob from the registry first
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", "--title", filepath.Base(artifact1File), artifact1Name)
+		// The fetch uses --tls-verify of the push rather than registries.conf
+		os.Unsetenv("CONTAINERS_REGISTRIES_CONF")
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		Expect(filepath.Join(podmanTest.Root, "artifacts", "blobs", missingDigest.Algorithm().String(), missingDigest.Encoded())).To(BeARegularFile())
+	})
+
+	It("podman artifact pull from multi-arch index", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		indexName := fmt.Sprintf("localhost:%s/test/index", port)
+		podmanTest.PodmanExitCleanly("manifest", "create", indexName)
+		podmanTest.PodmanExitCleanly("manifest", "add", "--artifact", "--os", "linux", "--arch", "arm64", indexName, artifact1File)
+		podmanTest.PodmanExitCleanly("manifest", "add", "--artifact", "--os", "linux", "--arch", "amd64", indexName, artifact2File)
+		podmanTest.PodmanExitCleanly("manifest", "push", "-q", "--all", "--tls-verify=false", indexName)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", "--os", "linux", "--arch", "arm64", indexName)
+		Expect(session.ErrorToString()).To(ContainSubstring("Selected platform linux/arm64"))
+		a := podmanTest.InspectArtifact(indexName)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Annotations["org.opencontainers.image.title"]).To(Equal(filepath.Base(artifact1File)))
+
+		session = podmanTest.Podman([]string{"artifact", "pull", "--tls-verify=false", "--arch", "s390x", indexName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "no image found in"))
+
+		// Copying between registries selects the platform the same way and
+		// does not add the artifact to the local store
+		podmanTest.PodmanExitCleanly("artifact", "rm", indexName)
+		mirrorName := fmt.Sprintf("localhost:%s/mirror/artifact", port)
+		session = podmanTest.PodmanExitCleanly("artifact", "copy", "--tls-verify=false", "--os", "linux", "--arch", "amd64", indexName, mirrorName)
+		Expect(session.ErrorToString()).To(ContainSubstring("Selected platform linux/amd64"))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(BeEmpty())
+
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", mirrorName)
+		a = podmanTest.InspectArtifact(mirrorName)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Annotations["org.opencontainers.image.title"]).To(Equal(filepath.Base(artifact2File)))
+	})
+
+	It("podman artifact push with compression", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := fmt.Sprintf("localhost:%s/test/artifact1", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "text/plain", artifact1Name, artifact1File)
+
+		session := podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--compression-format", "zstd:chunked", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `unsupported compression format "zstd:chunked" for artifacts, must be gzip, zstd or none`))
+
+		session = podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--compression-level", "3", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "compression level requires a compression format"))
+
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--compression-format", "gzip", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", artifact1Name)
+
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal("text/plain+gzip"))
+		Expect(a.Manifest.Layers[0].Annotations["org.opencontainers.image.title"]).To(Equal(filepath.Base(artifact1File)))
+		gzipDigest := a.Manifest.Layers[0].Digest
+
+		// Blobs which are compressed already are pushed unchanged
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", "--compression-format", "zstd", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", artifact1Name)
+
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal("text/plain+gzip"))
+		Expect(a.Manifest.Layers[0].Digest).To(Equal(gzipDigest))
+
+		session = podmanTest.Podman([]string{"artifact", "push", "-q", "--tls-verify=false", "--compress-force", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "forcing the compression requires a compression format"))
+
+		// Forcing the compression recompresses them
+		session = podmanTest.PodmanExitCleanly("artifact", "push", "--tls-verify=false", "--compression-format", "zstd", "--compress-force", artifact1Name)
+		Expect(session.OutputToString()).To(Equal("1 blob recompressed"))
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", artifact1Name)
+
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers[0].MediaType).To(Equal("text/plain+zstd"))
+		Expect(a.Manifest.Layers[0].Digest).ToNot(Equal(gzipDigest))
+		extracted := filepath.Join(podmanTest.TempDir, "recompressed")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--decompress", artifact1Name, extracted)
+		Expect(readFileToString(extracted)).To(Equal(readFileToString(artifact1File)))
+	})
+
+	It("podman artifact remove", func() {
+		// Trying to remove an image that does not exist should fail
+		rmFail := podmanTest.Podman([]string{"artifact", "rm", "foobar"})
+		rmFail.WaitWithDefaultTimeout()
+		Expect(rmFail).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", "foobar")))
+
+		// Add an artifact to remove later
+		artifact1File, err := createArtifactFile(4192)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		addArtifact1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		// Removing that artifact should work
+		rmWorks := podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		// The digests printed by removal should be the same as the digest that was added
+		Expect(rmWorks.OutputToString()).To(ContainSubstring(addArtifact1.OutputToString()))
+
+		// Inspecting that the removed artifact should fail
+		inspectArtifact := podmanTest.Podman([]string{"artifact", "inspect", artifact1Name})
+		inspectArtifact.WaitWithDefaultTimeout()
+		Expect(inspectArtifact).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", artifact1Name)))
+
+		// Add some artifacts back in
+		artifact2File, err := createArtifactFile(8096)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		// Using -a and an arg should trigger an error
+		failArgs := podmanTest.Podman([]string{"artifact", "rm", "-a", artifact1Name})
+		failArgs.WaitWithDefaultTimeout()
+		Expect(failArgs).Should(ExitWithError(125, "Error: when using the --all switch, you may not pass any artifact names or digests"))
+
+		// No args is an error
+		failNoArgs := podmanTest.Podman([]string{"artifact", "rm"})
+		failNoArgs.WaitWithDefaultTimeout()
+		Expect(failNoArgs).Should(ExitWithError(125, "Error: at least one artifact name or digest must be specified"))
+
+		// A missing artifact among several fails without --ignore
+		multipleArgs := podmanTest.Podman([]string{"artifact", "rm", artifact1Name, "foobar"})
+		multipleArgs.WaitWithDefaultTimeout()
+		Expect(multipleArgs).Should(ExitWithError(125, "Error: foobar: artifact does not exist"))
+		podmanTest.InspectArtifact(artifact1Name)
+
+		// A dry run lists the artifacts without removing them
+		dryRun := podmanTest.PodmanExitCleanly("artifact", "rm", "-a", "--dry-run")
+		Expect(dryRun.OutputToStringArray()).To(HaveLen(2))
+		lsAll := podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(lsAll.OutputToStringArray()).To(HaveLen(2))
+
+		// Remove all
+		removeAll := podmanTest.PodmanExitCleanly("artifact", "rm", "-a")
+		Expect(removeAll.OutputToStringArray()).To(Equal(dryRun.OutputToStringArray()))
+
+		// There should be no artifacts in the store
+		rmAll := podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(rmAll.OutputToString()).To(BeEmpty())
+	})
+
+	It("podman artifact rm --ignore", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		add1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		add2 := podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+
+		// Only the removed artifacts are listed
+		dryRun := podmanTest.PodmanExitCleanly("artifact", "rm", "--ignore", "--dry-run", artifact1Name, "foobar", "localhost/nomatch/*", artifact2Name)
+		Expect(dryRun.OutputToStringArray()).To(Equal([]string{add1.OutputToString(), add2.OutputToString()}))
+		session := podmanTest.PodmanExitCleanly("artifact", "rm", "-i", artifact1Name, "foobar", "localhost/nomatch/*", artifact2Name)
+		Expect(session.OutputToStringArray()).To(Equal(dryRun.OutputToStringArray()))
+
+		// Running it again succeeds without removing anything
+		session = podmanTest.PodmanExitCleanly("artifact", "rm", "--ignore", artifact1Name, artifact2Name)
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		session = podmanTest.Podman([]string{"artifact", "rm", "localhost/nomatch/*"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: no artifacts matched "localhost/nomatch/*": artifact does not exist`))
+	})
+
+	It("podman artifact inspect and rm --latest", func() {
+		session := podmanTest.Podman([]string{"artifact", "inspect", "--latest"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: no latest artifact, the local store is empty: artifact does not exist"))
+		session = podmanTest.Podman([]string{"artifact", "rm", "-l"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: no latest artifact, the local store is empty: artifact does not exist"))
+		podmanTest.PodmanExitCleanly("artifact", "rm", "--latest", "--ignore")
+
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		add2 := podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "-l", "--format", "{{.Name}}")
+		Expect(session.OutputToString()).To(Equal(artifact2Name))
+		session = podmanTest.PodmanExitCleanly("artifact", "rm", "--latest")
+		Expect(session.OutputToString()).To(Equal(add2.OutputToString()))
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--latest", "--format", "{{.Name}}")
+		Expect(session.OutputToString()).To(Equal(artifact1Name))
+
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--latest", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: --latest and artifact names or digests cannot be used together"))
+		session = podmanTest.Podman([]string{"artifact", "rm", "--latest", "--all"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: --all and --latest cannot be used together"))
+	})
+
+	It("podman artifact rm keeps shared blobs", func() {
+		sharedFile, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		ownFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, sharedFile)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, sharedFile, ownFile)
+
+		a := podmanTest.InspectArtifact(artifact2Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		blobPath := func(d digest.Digest) string {
+			return filepath.Join(podmanTest.Root, "artifacts", "blobs", d.Algorithm().String(), d.Encoded())
+		}
+		sharedBlob := blobPath(a.Manifest.Layers[0].Digest)
+		ownBlob := blobPath(a.Manifest.Layers[1].Digest)
+
+		// The blob used by the remaining artifact is kept
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		Expect(sharedBlob).To(BeARegularFile())
+		extractDir := filepath.Join(podmanTest.TempDir, "extract")
+		Expect(os.Mkdir(extractDir, 0o755)).To(Succeed())
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifact2Name, extractDir)
+		extracted, err := os.ReadFile(filepath.Join(extractDir, filepath.Base(sharedFile)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extracted).To(HaveLen(2048))
+
+		// Removing the last artifact using it deletes it
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact2Name)
+		Expect(sharedBlob).ToNot(BeAnExistingFile())
+		Expect(ownBlob).ToNot(BeAnExistingFile())
+	})
+
+	It("podman artifact add and rm concurrently", func() {
+		sharedFile, err := createArtifactFile(4096)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Removals must not delete the shared blob while it is being added
+		// to another artifact.
+		var wg sync.WaitGroup
+		for i := range 4 {
+			wg.Add(2)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				name := fmt.Sprintf("localhost/test/removed%d", i)
+				podmanTest.PodmanExitCleanly("artifact", "add", name, sharedFile)
+				podmanTest.PodmanExitCleanly("artifact", "rm", name)
+			}()
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				podmanTest.PodmanExitCleanly("artifact", "add", fmt.Sprintf("localhost/test/kept%d", i), sharedFile)
+			}()
+		}
+		wg.Wait()
+
+		for i := range 4 {
+			extractFile := filepath.Join(podmanTest.TempDir, fmt.Sprintf("extract%d", i))
+			podmanTest.PodmanExitCleanly("artifact", "extract", fmt.Sprintf("localhost/test/kept%d", i), extractFile)
+			extracted, err := os.ReadFile(extractFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extracted).To(HaveLen(4096))
+		}
+	})
+
+	It("podman artifact store stays consistent under concurrent changes", func() {
+		sharedFile, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The store does not exist yet, the first commands create it
+		// concurrently.
+		const workers = 6
+		var wg sync.WaitGroup
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				ownFile, err := createArtifactFile(int64(1024 + i))
+				Expect(err).ToNot(HaveOccurred())
+				name := fmt.Sprintf("localhost/test/worker%d", i)
+				for j := range 3 {
+					podmanTest.PodmanExitCleanly("artifact", "add", name, sharedFile, ownFile)
+					podmanTest.PodmanExitCleanly("artifact", "tag", name, fmt.Sprintf("%s:v%d", name, j))
+					podmanTest.PodmanExitCleanly("artifact", "ls", "-q")
+					podmanTest.PodmanExitCleanly("artifact", "rm", name)
+					podmanTest.PodmanExitCleanly("artifact", "prune", "-f")
+				}
+				podmanTest.PodmanExitCleanly("artifact", "add", name, sharedFile, ownFile)
+			}()
+		}
+		wg.Wait()
+
+		// Every artifact which was not removed is in the index with all
+		// of its blobs.
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}:{{.Tag}}", "--noheading")
+		Expect(session.OutputToStringArray()).To(HaveLen(workers * 4))
+		for i := range workers {
+			Expect(session.OutputToStringArray()).To(ContainElement(fmt.Sprintf("localhost/test/worker%d:v2", i)))
+		}
+		session = podmanTest.PodmanExitCleanly("artifact", "check")
+		Expect(session.OutputToString()).To(ContainSubstring(" healthy, 0 corrupt, 0 missing blobs"))
+	})
+
+	It("podman artifact rm by name pattern", func() {
+		names := []string{"quay.io/models/llama-1", "quay.io/models/llama-2:v2", "localhost/models/mistral"}
+		for _, name := range names {
+			artifactFile, err := createArtifactFile(1024)
+			Expect(err).ToNot(HaveOccurred())
+			podmanTest.PodmanExitCleanly("artifact", "add", name, artifactFile)
+		}
+
+		session := podmanTest.PodmanExitCleanly("artifact", "rm", "--dry-run", "models/llama-*")
+		Expect(session.OutputToStringArray()).To(HaveLen(2))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "rm", "models/llama-*")
+		Expect(session.OutputToStringArray()).To(HaveLen(2))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}", "--noheading")
+		Expect(session.OutputToStringArray()).To(Equal([]string{"localhost/models/mistral"}))
+
+		session = podmanTest.Podman([]string{"artifact", "rm", "models/llama-*"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: no artifacts matched "models/llama-*": artifact does not exist`))
+
+		session = podmanTest.Podman([]string{"artifact", "rm", "models/[llama"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: invalid artifact name pattern "models/[llama": syntax error in pattern`))
+	})
+
+	It("podman artifact inspect with full or partial digest", func() {
+		artifact1File, err := createArtifactFile(4192)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		addArtifact1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		artifactDigest := addArtifact1.OutputToString()
+
+		podmanTest.PodmanExitCleanly("artifact", "inspect", artifactDigest)
+		podmanTest.PodmanExitCleanly("artifact", "inspect", artifactDigest[:12])
+
+		// The full manifest is reported, including the config descriptor
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.Manifest).ToNot(BeNil())
+		Expect(report.Manifest.MediaType).To(Equal(specV1.MediaTypeImageManifest))
+		Expect(report.Manifest.Config.MediaType).To(Equal(specV1.MediaTypeEmptyJSON))
+		Expect(report.Manifest.Layers).To(HaveLen(1))
+		Expect(report.Manifest.Layers[0].Annotations).To(HaveKeyWithValue(specV1.AnnotationTitle, filepath.Base(artifact1File)))
+	})
+
+	It("podman artifact inspect --format", func() {
 		artifact1File, err := createArtifactFile(1024)
 		Expect(err).ToNot(HaveOccurred())
-
 		artifact1Name := "localhost/test/artifact1"
-		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		addArtifact1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		artifactDigest := addArtifact1.OutputToString()
 
-		a := podmanTest.InspectArtifact(artifact1Name)
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Digest}}", artifact1Name)
+		Expect(session.OutputToString()).To(Equal("sha256:" + artifactDigest))
 
-		Expect(a.Name).To(Equal(artifact1Name))
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Name}} {{(index .Manifest.Layers 0).Size}}", artifact1Name)
+		Expect(session.OutputToString()).To(Equal(artifact1Name + " 1024"))
 
-		// Adding an artifact with an existing name should fail
-		addAgain := podmanTest.Podman([]string{"artifact", "add", artifact1Name, artifact1File})
-		addAgain.WaitWithDefaultTimeout()
-		Expect(addAgain).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact already exists", artifact1Name)))
-	})
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{json .Manifest.Config}}", artifact1Name)
+		Expect(session.OutputToString()).To(ContainSubstring(specV1.MediaTypeEmptyJSON))
 
-	It("podman artifact add with options", func() {
-		yamlType := "text/yaml"
-		artifact1Name := "localhost/test/artifact1"
-		artifact1File, err := createArtifactFile(1024)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "json", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
 		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.Digest).To(Equal("sha256:" + artifactDigest))
+		Expect(report.BlobCount).To(Equal(1))
+		Expect(report.MediaTypes).To(Equal([]string{"application/octet-stream"}))
+
+		// The media types of several blobs are reported once each
+		textFile := filepath.Join(podmanTest.TempDir, "notes.txt")
+		err = os.WriteFile(textFile, []byte("some notes\n"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", artifact1Name, textFile, artifact2File)
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.BlobCount}} {{json .MediaTypes}}", artifact1Name)
+		Expect(session.OutputToString()).To(Equal(`3 ["application/octet-stream","text/plain; charset=utf-8"]`))
 
-		artifactType := "octet/foobar"
-		annotation1 := "color=blue"
-		annotation2 := "flavor=lemon"
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--format", "{{.Bogus}}", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `can't evaluate field Bogus`))
+	})
 
-		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", yamlType, "--type", artifactType, "--annotation", annotation1, "--annotation", annotation2, artifact1Name, artifact1File)
+	It("podman artifact inspect fingerprint", func() {
+		content := []byte("fingerprinted content")
+		artifactFile := filepath.Join(podmanTest.TempDir, "data.txt")
+		Expect(os.WriteFile(artifactFile, content, 0o644)).To(Succeed())
 
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		artifact3Name := "localhost/test/artifact3"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "color=blue", "--annotation", "shape=round", artifact1Name, artifactFile)
+		// The annotations in another order and another creation time
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "shape=round", "--annotation", "color=blue", artifact2Name, artifactFile)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "color=red", "--annotation", "shape=round", artifact3Name, artifactFile)
+
+		format := "{{.Digest}} {{.Fingerprint}}"
+		artifact1 := strings.Fields(podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", format, artifact1Name).OutputToString())
+		artifact2 := strings.Fields(podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", format, artifact2Name).OutputToString())
+		artifact3 := strings.Fields(podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", format, artifact3Name).OutputToString())
+		Expect(artifact1[0]).ToNot(Equal(artifact2[0]))
+		Expect(artifact1[1]).To(Equal(artifact2[1]))
+		Expect(artifact1[1]).ToNot(Equal(artifact3[1]))
+
+		// The canonical form is documented and must not change.
 		a := podmanTest.InspectArtifact(artifact1Name)
-		Expect(a.Name).To(Equal(artifact1Name))
-		Expect(a.Manifest.ArtifactType).To(Equal(artifactType))
-		Expect(a.Manifest.Layers[0].Annotations["color"]).To(Equal("blue"))
-		Expect(a.Manifest.Layers[0].Annotations["flavor"]).To(Equal("lemon"))
-		Expect(a.Manifest.Layers[0].MediaType).To(Equal(yamlType))
-
-		failSession := podmanTest.Podman([]string{"artifact", "add", "--annotation", "org.opencontainers.image.title=foobar", "foobar", artifact1File})
-		failSession.WaitWithDefaultTimeout()
-		Expect(failSession).Should(ExitWithError(125, "Error: cannot override filename with org.opencontainers.image.title annotation"))
+		canonical := fmt.Sprintf(`{"fingerprintVersion":1,"config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[{"mediaType":"%s","digest":"%s","size":%d,"annotations":{"color":"blue","org.opencontainers.image.title":"data.txt","shape":"round"}}]}`,
+			a.Manifest.Layers[0].MediaType, digest.FromBytes(content), len(content))
+		Expect(artifact1[1]).To(Equal(digest.FromString(canonical).String()))
+
+		// The cached fingerprint is removed with the manifest.
+		cached := filepath.Join(podmanTest.Root, "artifacts", "fingerprints", "v1", "sha256", strings.TrimPrefix(artifact3[0], "sha256:"))
+		Expect(cached).To(BeAnExistingFile())
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact3Name)
+		podmanTest.PodmanExitCleanly("artifact", "gc")
+		Expect(cached).ToNot(BeAnExistingFile())
 	})
 
-	It("podman artifact add multiple", func() {
-		artifact1File1, err := createArtifactFile(1024)
+	It("podman artifact inspect multiple artifacts", func() {
+		artifact1File, err := createArtifactFile(1024)
 		Expect(err).ToNot(HaveOccurred())
-		artifact1File2, err := createArtifactFile(8192)
+		artifact2File, err := createArtifactFile(2048)
 		Expect(err).ToNot(HaveOccurred())
-
 		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
 
-		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File1, artifact1File2)
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name, artifact2Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(2))
+		Expect(reports[0].Name).To(Equal(artifact1Name))
+		Expect(reports[1].Name).To(Equal(artifact2Name))
 
-		a := podmanTest.InspectArtifact(artifact1Name)
-		Expect(a.Name).To(Equal(artifact1Name))
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--format", "{{.Name}}", artifact2Name, artifact1Name)
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact2Name, artifact1Name}))
 
-		Expect(a.Manifest.Layers).To(HaveLen(2))
+		// A missing artifact fails the command, the others are still reported
+		missingName := "localhost/test/missing"
+		session = podmanTest.Podman([]string{"artifact", "inspect", artifact1Name, missingName, artifact2Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", missingName)))
+		reports = []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(2))
+
+		// With --ignore it is only reported on stderr
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--ignore", "--format", "{{.Name}}", artifact1Name, missingName, artifact2Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact1Name, artifact2Name}))
+		Expect(session.ErrorToString()).To(ContainSubstring(fmt.Sprintf("%s: artifact does not exist", missingName)))
+
+		session = podmanTest.Podman([]string{"artifact", "inspect", missingName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", missingName)))
+		Expect(session.OutputToString()).To(Equal("[]"))
 	})
 
-	It("podman artifact push and pull", func() {
-		// Before starting a registry, try to pull a bogus image from a bogus registry
-		// using retry-delay
-		retrySession := podmanTest.Podman([]string{"artifact", "pull", "--retry", "1", "--retry-delay", "100ms", "127.0.0.1/mybadimagename"})
-		retrySession.WaitWithDefaultTimeout()
-		Expect(retrySession).Should(ExitWithError(125, "connect: connection refused"))
-		Expect(retrySession.ErrorToString()).To(ContainSubstring("retrying in 100ms ..."))
+	It("podman artifact inspect --verify", func() {
+		artifact1File, err := createArtifactFile(4192)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
 
-		artifact1File, err := createArtifactFile(1024)
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", "--verify", "--digest-algorithm", "sha512", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
 		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.AlternateDigest).To(HavePrefix("sha512:"))
+		Expect(report.Blobs).To(HaveLen(1))
+		blobDigest := report.Manifest.Layers[0].Digest
+		Expect(report.Blobs[0].Digest).To(Equal(blobDigest))
+		Expect(report.Blobs[0].AlternateDigest.Algorithm().String()).To(Equal("sha512"))
+
+		// Without another algorithm there is no alternate digest
+		session = podmanTest.PodmanExitCleanly("artifact", "inspect", "--verify", "--digest-algorithm", "sha256", artifact1Name)
+		reports = []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report = reports[0]
+		Expect(report.AlternateDigest).To(BeEmpty())
+		Expect(report.Blobs[0].AlternateDigest).To(BeEmpty())
+
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--digest-algorithm", "md5", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: unsupported digest algorithm "md5", must be sha256 or sha512`))
+
+		// Corrupt the blob in the store
+		blobPath := filepath.Join(podmanTest.Root, "artifacts", "blobs", blobDigest.Algorithm().String(), blobDigest.Encoded())
+		err = os.Remove(blobPath)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(blobPath, []byte("corrupted"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		// A plain inspect does not read the blobs
+		podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name)
+
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--verify", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf(`Error: blob %q: blob digest does not match the manifest: expected %s, got`, filepath.Base(artifact1File), blobDigest)))
+	})
 
+	It("podman artifact inspect --remote", func() {
 		lock, port, err := setupRegistry(nil)
 		if err == nil {
 			defer lock.Unlock()
 		}
 		Expect(err).ToNot(HaveOccurred())
 
-		artifact1Name := fmt.Sprintf("localhost:%s/test/artifact1", port)
-		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
-
+		artifact1File, err := createArtifactFile(4192)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := fmt.Sprintf("localhost:%s/test/artifact1:v1", port)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "color=blue", artifact1Name, artifact1File)
 		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
-
+		local := podmanTest.InspectArtifact(artifact1Name)
+		localDigest, err := local.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
 		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
 
-		podmanTest.PodmanExitCleanly("artifact", "pull", "--tls-verify=false", artifact1Name)
+		// Only the manifest is fetched, nothing is stored.
+		session := podmanTest.PodmanExitCleanly("artifact", "inspect", "--remote", "--tls-verify=false", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(session.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.Remote).To(BeTrue())
+		Expect(report.Name).To(Equal(artifact1Name))
+		Expect(report.Digest).To(Equal(localDigest.String()))
+		Expect(report.BlobCount).To(Equal(1))
+		Expect(report.Manifest.Layers[0].Size).To(Equal(int64(4192)))
+		Expect(report.Manifest.Layers[0].Annotations).To(HaveKeyWithValue("color", "blue"))
+		Expect(report.IndexAnnotations).To(BeEmpty())
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--tls-verify=false", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "--tls-verify requires --remote"))
+		session = podmanTest.Podman([]string{"artifact", "inspect", "--remote", "--verify", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "--remote and --verify cannot be used together"))
+	})
 
+	It("podman artifact extract --verify", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
 		a := podmanTest.InspectArtifact(artifact1Name)
+		blobDigest := a.Manifest.Layers[0].Digest
 
-		Expect(a.Name).To(Equal(artifact1Name))
+		target := filepath.Join(podmanTest.TempDir, "extracted")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--verify", artifact1Name, target)
+		Expect(readFileToString(target)).To(Equal(readFileToString(artifact1File)))
+		err = os.Remove(target)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Corrupt the blob in the store
+		blobPath := filepath.Join(podmanTest.Root, "artifacts", "blobs", blobDigest.Algorithm().String(), blobDigest.Encoded())
+		err = os.Remove(blobPath)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(blobPath, []byte("corrupted"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		expectedErr := fmt.Sprintf(`Error: blob %q: blob digest does not match the manifest: expected %s, got`, filepath.Base(artifact1File), blobDigest)
+		session := podmanTest.Podman([]string{"artifact", "extract", "--verify", artifact1Name, target})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, expectedErr))
+		Expect(target).ToNot(BeAnExistingFile())
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--verify", artifact1Name, "-"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, expectedErr))
+
+		// Without --verify the content on disk is extracted
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifact1Name, target)
+		Expect(readFileToString(target)).To(Equal("corrupted"))
 	})
 
-	It("podman artifact remove", func() {
-		// Trying to remove an image that does not exist should fail
-		rmFail := podmanTest.Podman([]string{"artifact", "rm", "foobar"})
-		rmFail.WaitWithDefaultTimeout()
-		Expect(rmFail).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", "foobar")))
+	It("podman artifact extract --preserve-mode", func() {
+		dir := filepath.Join(podmanTest.TempDir, "modes")
+		Expect(os.Mkdir(dir, 0o755)).To(Succeed())
+		tool := filepath.Join(dir, "tool.sh")
+		Expect(os.WriteFile(tool, []byte("#!/bin/sh\n"), 0o644)).To(Succeed())
+		Expect(os.Chmod(tool, 0o750)).To(Succeed())
+		secret := filepath.Join(dir, "secret.txt")
+		Expect(os.WriteFile(secret, []byte("secret"), 0o644)).To(Succeed())
+		Expect(os.Chmod(secret, 0o600)).To(Succeed())
+
+		artifactName := "localhost/test/modes"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--record-mode", "--recursive", artifactName, dir)
+		a := podmanTest.InspectArtifact(artifactName)
+		for _, l := range a.Manifest.Layers {
+			Expect(l.Annotations).To(HaveKeyWithValue("org.podman.file.uid", fmt.Sprint(os.Getuid())))
+			Expect(l.Annotations).To(HaveKeyWithValue("org.podman.file.gid", fmt.Sprint(os.Getgid())))
+			switch l.Annotations[specV1.AnnotationTitle] {
+			case "tool.sh":
+				Expect(l.Annotations).To(HaveKeyWithValue("org.podman.file.mode", "0750"))
+			case "secret.txt":
+				Expect(l.Annotations).To(HaveKeyWithValue("org.podman.file.mode", "0600"))
+			}
+		}
 
-		// Add an artifact to remove later
-		artifact1File, err := createArtifactFile(4192)
+		// Without --preserve-mode the default mode is used.
+		plain := filepath.Join(podmanTest.TempDir, "plain")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", artifactName, plain)
+		st, err := os.Stat(filepath.Join(plain, "tool.sh"))
 		Expect(err).ToNot(HaveOccurred())
-		artifact1Name := "localhost/test/artifact1"
-		addArtifact1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		Expect(st.Mode().Perm() & 0o111).To(BeZero())
 
-		// Removing that artifact should work
-		rmWorks := podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
-		// The digests printed by removal should be the same as the digest that was added
-		Expect(rmWorks.OutputToString()).To(ContainSubstring(addArtifact1.OutputToString()))
+		preserved := filepath.Join(podmanTest.TempDir, "preserved")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", "--preserve-mode", artifactName, preserved)
+		st, err = os.Stat(filepath.Join(preserved, "tool.sh"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode().Perm()).To(Equal(os.FileMode(0o750)))
+		st, err = os.Stat(filepath.Join(preserved, "secret.txt"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		// The setuid, setgid and sticky bits are never restored and the
+		// ownership only with --preserve-owner.
+		other := "localhost/test/other-owner"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "org.podman.file.mode=7700", "--annotation", "org.podman.file.uid=4242", "--annotation", "org.podman.file.gid=4242", other, tool)
+		target := filepath.Join(podmanTest.TempDir, "other")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--preserve-mode", other, target)
+		st, err = os.Stat(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)).To(Equal(os.FileMode(0o700)))
+		sys := st.Sys().(*syscall.Stat_t)
+		Expect(sys.Uid).To(Equal(uint32(os.Getuid())))
+		Expect(sys.Gid).To(Equal(uint32(os.Getgid())))
+
+		// An owner which cannot be set rootless is skipped, the mode is
+		// still applied.
+		owned := filepath.Join(podmanTest.TempDir, "owned")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--preserve-mode", "--preserve-owner", other, owned)
+		st, err = os.Stat(owned)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)).To(Equal(os.FileMode(0o700)))
+		if !isRootless() {
+			sys := st.Sys().(*syscall.Stat_t)
+			Expect(sys.Uid).To(Equal(uint32(4242)))
+			Expect(sys.Gid).To(Equal(uint32(4242)))
+		}
 
-		// Inspecting that the removed artifact should fail
-		inspectArtifact := podmanTest.Podman([]string{"artifact", "inspect", artifact1Name})
-		inspectArtifact.WaitWithDefaultTimeout()
-		Expect(inspectArtifact).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", artifact1Name)))
+		bad := "localhost/test/bad-mode"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "org.podman.file.mode=rwx", bad, tool)
+		badTarget := filepath.Join(podmanTest.TempDir, "bad")
+		session := podmanTest.Podman([]string{"artifact", "extract", "--preserve-mode", bad, badTarget})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: blob "tool.sh": invalid file mode "rwx" in the org.podman.file.mode annotation`))
+		Expect(badTarget).ToNot(BeAnExistingFile())
+	})
 
-		// Add some artifacts back in
-		artifact2File, err := createArtifactFile(8096)
+	It("podman artifact extract --decompress", func() {
+		content := "decompressed content\n"
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, err := gz.Write([]byte(content))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+		gzFile := filepath.Join(podmanTest.TempDir, "data.txt.gz")
+		err = os.WriteFile(gzFile, compressed.Bytes(), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		plainFile, err := createArtifactFile(1024)
 		Expect(err).ToNot(HaveOccurred())
-		artifact2Name := "localhost/test/artifact2"
-		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
-		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
 
-		// Using -a and an arg should trigger an error
-		failArgs := podmanTest.Podman([]string{"artifact", "rm", "-a", artifact1Name})
-		failArgs.WaitWithDefaultTimeout()
-		Expect(failArgs).Should(ExitWithError(125, "Error: when using the --all switch, you may not pass any artifact names or digests"))
+		artifact1Name := "localhost/test/compressed"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "application/x-foo+gzip", artifact1Name, gzFile)
+		artifact2Name := "localhost/test/plain"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, plainFile)
 
-		// No args is an error
-		failNoArgs := podmanTest.Podman([]string{"artifact", "rm"})
-		failNoArgs.WaitWithDefaultTimeout()
-		Expect(failNoArgs).Should(ExitWithError(125, "Error: a single artifact name or digest must be specified"))
+		target := filepath.Join(podmanTest.TempDir, "extracted")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--decompress", "--verify", artifact1Name, target)
+		Expect(readFileToString(target)).To(Equal(content))
 
-		// Multiple args is an error
-		multipleArgs := podmanTest.Podman([]string{"artifact", "rm", artifact1Name, artifact2File})
-		multipleArgs.WaitWithDefaultTimeout()
-		Expect(multipleArgs).Should(ExitWithError(125, "Error: too many arguments: only accepts one artifact name or digest"))
+		session := podmanTest.PodmanExitCleanly("artifact", "extract", "--decompress", artifact1Name, "-")
+		Expect(session.OutputToString()).To(Equal(strings.TrimSpace(content)))
 
-		// Remove all
-		podmanTest.PodmanExitCleanly("artifact", "rm", "-a")
+		// Without --decompress the blob is extracted as stored
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", artifact1Name, target)
+		Expect(readFileToString(target)).To(Equal(compressed.String()))
 
-		// There should be no artifacts in the store
-		rmAll := podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
-		Expect(rmAll.OutputToString()).To(BeEmpty())
+		// Blobs whose media type is not compressed are extracted unchanged
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", "--decompress", artifact2Name, target)
+		Expect(readFileToString(target)).To(Equal(readFileToString(plainFile)))
 	})
 
-	It("podman artifact inspect with full or partial digest", func() {
-		artifact1File, err := createArtifactFile(4192)
+	It("podman artifact extract --filter", func() {
+		artifactName := "localhost/test/model"
+		var files []string
+		for i, role := range []string{"weights", "weights", "config"} {
+			f, err := createArtifactFile(int64(1024 * (i + 1)))
+			Expect(err).ToNot(HaveOccurred())
+			files = append(files, f)
+			args := []string{"artifact", "add", "--annotation", "role=" + role}
+			if i > 0 {
+				args = append(args, "--append")
+			}
+			podmanTest.PodmanExitCleanly(append(args, artifactName, f)...)
+		}
+
+		dir := filepath.Join(podmanTest.TempDir, "weights")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", "--filter", "annotation=role=weights", artifactName, dir)
+		entries, err := os.ReadDir(dir)
 		Expect(err).ToNot(HaveOccurred())
-		artifact1Name := "localhost/test/artifact1"
-		addArtifact1 := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		Expect(entries).To(HaveLen(2))
+		for _, f := range files[:2] {
+			Expect(readFileToString(filepath.Join(dir, filepath.Base(f)))).To(Equal(readFileToString(f)))
+		}
 
-		artifactDigest := addArtifact1.OutputToString()
+		// A single matching blob can be written to a file
+		target := filepath.Join(podmanTest.TempDir, "config")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--filter", "annotation=role=config", artifactName, target)
+		Expect(readFileToString(target)).To(Equal(readFileToString(files[2])))
 
-		podmanTest.PodmanExitCleanly("artifact", "inspect", artifactDigest)
-		podmanTest.PodmanExitCleanly("artifact", "inspect", artifactDigest[:12])
+		// Title narrows the selection
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", "--filter", "annotation=role", "--title", filepath.Base(files[1]), artifactName, target)
+		Expect(readFileToString(target)).To(Equal(readFileToString(files[1])))
+
+		session := podmanTest.Podman([]string{"artifact", "extract", "--filter", "annotation=role=weights", artifactName, filepath.Join(podmanTest.TempDir, "file")})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "2 blobs match the filters and the target"))
 
+		session = podmanTest.Podman([]string{"artifact", "extract", "--filter", "annotation=role=weights", artifactName})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "2 blobs match the filters, only a single blob can be streamed"))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--filter", "annotation=role=other", artifactName, dir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "no blob of the artifact matches the filters"))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--filter", "type=foo", artifactName, dir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `"type" is an invalid artifact blob filter, supported filters are: annotation`))
 	})
 
 	It("podman artifact extract single", func() {
@@ -237,12 +2725,15 @@ var _ = Describe("Podman artifact", func() {
 		podmanTest.PodmanExitCleanly("artifact", "extract", ARTIFACT_SINGLE, path)
 		Expect(readFileToString(path)).To(Equal(artifactContent))
 
-		// Extract to existing file will overwrite file
+		// Extract to existing file fails unless it may be overwritten
 		path = filepath.Join(podmanTest.TempDir, "abcd")
 		f, err := os.Create(path)
 		Expect(err).ToNot(HaveOccurred())
 		f.Close()
-		podmanTest.PodmanExitCleanly("artifact", "extract", ARTIFACT_SINGLE, path)
+		session := podmanTest.Podman([]string{"artifact", "extract", ARTIFACT_SINGLE, path})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, "refusing to overwrite the existing file "+path))
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", ARTIFACT_SINGLE, path)
 		Expect(readFileToString(path)).To(Equal(artifactContent))
 
 		tests := []struct {
@@ -276,7 +2767,7 @@ var _ = Describe("Podman artifact", func() {
 		}
 
 		// invalid digest
-		session := podmanTest.Podman([]string{"artifact", "extract", "--digest", "blah", ARTIFACT_SINGLE, podmanTest.TempDir})
+		session = podmanTest.Podman([]string{"artifact", "extract", "--digest", "blah", ARTIFACT_SINGLE, podmanTest.TempDir})
 		session.WaitWithDefaultTimeout()
 		Expect(session).To(ExitWithError(125, `no blob with the digest "blah"`))
 
@@ -341,6 +2832,22 @@ var _ = Describe("Podman artifact", func() {
 					{filename: digestToFilename(artifactDigest2), content: artifactContent2},
 				},
 			},
+			{
+				name:      "extract multi blob to dir with --index",
+				image:     ARTIFACT_MULTI,
+				extraArgs: []string{"--index", "1"},
+				expect: []expect{
+					{filename: artifactTitle2, content: artifactContent2},
+				},
+			},
+			{
+				name:      "extract multi blob to dir without title with --index",
+				image:     ARTIFACT_MULTI_NO_TITLE,
+				extraArgs: []string{"--index", "0"},
+				expect: []expect{
+					{filename: digestToFilename(artifactDigest1), content: artifactContent1},
+				},
+			},
 		}
 
 		for _, tt := range tests {
@@ -356,6 +2863,115 @@ var _ = Describe("Podman artifact", func() {
 				Expect(readFileToString(filepath.Join(dir, expect.filename))).To(Equal(expect.content))
 			}
 		}
+
+		// extract by index to a file
+		path := filepath.Join(podmanTest.TempDir, "indexfile")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--index", "0", ARTIFACT_MULTI, path)
+		Expect(readFileToString(path)).To(Equal(artifactContent1))
+
+		// out of range index
+		session := podmanTest.Podman([]string{"artifact", "extract", "--index", "2", ARTIFACT_MULTI, podmanTest.TempDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, "blob index 2 is out of range, valid range is 0 to 1"))
+
+		// --all creates the target directory
+		dir := filepath.Join(podmanTest.TempDir, "newdir")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", ARTIFACT_MULTI, dir)
+		Expect(readFileToString(filepath.Join(dir, artifactTitle1))).To(Equal(artifactContent1))
+		Expect(readFileToString(filepath.Join(dir, artifactTitle2))).To(Equal(artifactContent2))
+
+		// --all requires the target to be a directory
+		session = podmanTest.Podman([]string{"artifact", "extract", "--all", ARTIFACT_MULTI, path})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, fmt.Sprintf("the target %q must be a directory to extract all blobs", path)))
+
+		// --all conflicts with --title
+		session = podmanTest.Podman([]string{"artifact", "extract", "--all", "--title", artifactTitle1, ARTIFACT_MULTI, dir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, "cannot extract all blobs when a digest, title or index is specified"))
+
+		// index conflicts with title
+		session = podmanTest.Podman([]string{"artifact", "extract", "--index", "0", "--title", artifactTitle1, ARTIFACT_MULTI, podmanTest.TempDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, "cannot specify index together with digest or title"))
+	})
+
+	It("podman artifact extract to stdout", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "extract", artifact1Name)
+		Expect(session.Out.Contents()).To(Equal([]byte(readFileToString(artifact1File))))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", artifact1Name, artifact2File)
+		session = podmanTest.PodmanExitCleanly("artifact", "extract", "--title", filepath.Base(artifact2File), artifact1Name, "-")
+		Expect(session.Out.Contents()).To(Equal([]byte(readFileToString(artifact2File))))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", artifact1Name, "-"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: the artifact consists of several blobs and neither digest, title or index was specified to only stream a single blob"))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--all", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: cannot extract all blobs to a stream"))
+	})
+
+	It("podman artifact extract --tar", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "role=weights", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", artifact1Name, artifact2File)
+
+		readTar := func(content []byte) map[string]string {
+			entries := make(map[string]string)
+			tr := tar.NewReader(bytes.NewReader(content))
+			for {
+				hdr, err := tr.Next()
+				if err != nil {
+					Expect(err).To(MatchError(io.EOF))
+					return entries
+				}
+				var buf bytes.Buffer
+				_, err = io.Copy(&buf, tr)
+				Expect(err).ToNot(HaveOccurred())
+				entries[hdr.Name] = buf.String()
+			}
+		}
+
+		// All blobs are written to stdout, named by their titles
+		session := podmanTest.PodmanExitCleanly("artifact", "extract", "--tar", artifact1Name)
+		Expect(readTar(session.Out.Contents())).To(Equal(map[string]string{
+			filepath.Base(artifact1File): readFileToString(artifact1File),
+			filepath.Base(artifact2File): readFileToString(artifact2File),
+		}))
+
+		// Filters select the blobs written to the archive file
+		path := filepath.Join(podmanTest.TempDir, "blobs.tar")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--tar", "--filter", "annotation=role=weights", artifact1Name, path)
+		content, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readTar(content)).To(Equal(map[string]string{
+			filepath.Base(artifact1File): readFileToString(artifact1File),
+		}))
+
+		// Blobs without a title are named by their digest
+		podmanTest.PodmanExitCleanly("artifact", "pull", ARTIFACT_MULTI_NO_TITLE)
+		session = podmanTest.PodmanExitCleanly("artifact", "extract", "--tar", ARTIFACT_MULTI_NO_TITLE, "-")
+		Expect(readTar(session.Out.Contents())).To(Equal(map[string]string{
+			digestToFilename("sha256:8257bba28b9d19ac353c4b713b470860278857767935ef7e139afd596cb1bb2d"): "xuHWedtC0ADST\n",
+			digestToFilename("sha256:63700c54129c6daaafe3a20850079f82d6d658d69de73d6158d81f920c6fbdd7"): "tAyZczFlgFsi4\n",
+		}))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--tar", "--decompress", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: cannot decompress blobs extracted to a tar stream"))
 	})
 
 	It("podman artifact extract evil", func() {
@@ -371,14 +2987,102 @@ var _ = Describe("Podman artifact", func() {
 		podmanTest.PodmanExitCleanly("artifact", "extract", ARTIFACT_EVIL, path)
 		Expect(readFileToString(path)).To(Equal(artifactContent))
 
-		// This must fail for security reasons we do not allow a title with /
-		session := podmanTest.Podman([]string{"artifact", "extract", ARTIFACT_EVIL, podmanTest.TempDir})
+		// This must fail for security reasons we do not allow a title with ..
+		session := podmanTest.Podman([]string{"artifact", "extract", ARTIFACT_EVIL, podmanTest.TempDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, `invalid name: "../../../../tmp/evil" must be a relative path without empty, "." or ".." elements`))
+
+		// Extracting by digest should be fine too
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--digest", artifactDigest, ARTIFACT_EVIL, podmanTest.TempDir)
+		Expect(readFileToString(filepath.Join(podmanTest.TempDir, digestToFilename(artifactDigest)))).To(Equal(artifactContent))
+	})
+
+	It("podman artifact extract --on-conflict", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := "localhost/test/conflict"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifact1File, artifact2File)
+
+		extractDir := filepath.Join(podmanTest.TempDir, "extracted")
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--all", artifactName, extractDir)
+		extracted1 := filepath.Join(extractDir, filepath.Base(artifact1File))
+		extracted2 := filepath.Join(extractDir, filepath.Base(artifact2File))
+		err = os.WriteFile(extracted1, []byte("local edit"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.Remove(extracted2)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Nothing is extracted if a file would be overwritten.
+		session := podmanTest.Podman([]string{"artifact", "extract", artifactName, extractDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, "refusing to overwrite the existing file "+extracted1))
+		Expect(extracted2).ToNot(BeAnExistingFile())
+
+		session = podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "skip", artifactName, extractDir)
+		Expect(session.ErrorToString()).To(Equal("Skipped existing file " + extracted1))
+		Expect(readFileToString(extracted1)).To(Equal("local edit"))
+		Expect(readFileToString(extracted2)).To(Equal(readFileToString(artifact2File)))
+
+		podmanTest.PodmanExitCleanly("artifact", "extract", "--on-conflict", "overwrite", artifactName, extractDir)
+		Expect(readFileToString(extracted1)).To(Equal(readFileToString(artifact1File)))
+
+		session = podmanTest.Podman([]string{"artifact", "extract", "--on-conflict", "merge", artifactName, extractDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, `invalid conflict policy "merge", must be skip, overwrite or fail`))
+	})
+
+	It("podman artifact extract with adversarial titles", func() {
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifactName := "localhost/test/adversarial"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifactName, artifactFile)
+		blobDigest := podmanTest.InspectArtifact(artifactName).Manifest.Layers[0].Digest
+
+		extractDir := filepath.Join(podmanTest.TempDir, "extracted")
+		err = os.MkdirAll(extractDir, 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		outsideDir := filepath.Join(podmanTest.TempDir, "outside")
+		err = os.MkdirAll(outsideDir, 0o755)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, title := range []string{"/tmp/evil", "../evil", "models/../../evil", "..", "models//evil", "./evil"} {
+			podmanTest.PodmanExitCleanly("artifact", "update", "--index", "0", "--annotation", "org.opencontainers.image.title="+title, artifactName)
+			for _, args := range [][]string{{"--all", artifactName, extractDir}, {"--tar", artifactName}} {
+				session := podmanTest.Podman(append([]string{"artifact", "extract"}, args...))
+				session.WaitWithDefaultTimeout()
+				Expect(session).To(ExitWithError(125, fmt.Sprintf(`Error: blob %s: invalid name: %q must be a relative path without empty, "." or ".." elements`, blobDigest, title)))
+			}
+		}
+
+		// Existing symlinks in the target directory are not followed,
+		// neither for a directory of the title nor for the file itself.
+		err = os.Symlink(outsideDir, filepath.Join(extractDir, "models"))
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "update", "--index", "0", "--annotation", "org.opencontainers.image.title=models/evil", artifactName)
+		session := podmanTest.Podman([]string{"artifact", "extract", artifactName, extractDir})
 		session.WaitWithDefaultTimeout()
-		Expect(session).To(ExitWithError(125, `invalid name: "../../../../tmp/evil" cannot contain /`))
+		Expect(session).To(ExitWithError(125, fmt.Sprintf(`Error: blob %s: refusing to extract "models/evil", %s is a symlink`, blobDigest, filepath.Join(extractDir, "models"))))
 
-		// Extracting by digest should be fine too
-		podmanTest.PodmanExitCleanly("artifact", "extract", "--digest", artifactDigest, ARTIFACT_EVIL, podmanTest.TempDir)
-		Expect(readFileToString(filepath.Join(podmanTest.TempDir, digestToFilename(artifactDigest)))).To(Equal(artifactContent))
+		outsideFile := filepath.Join(outsideDir, "evil")
+		err = os.WriteFile(outsideFile, []byte("outside"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.Symlink(outsideFile, filepath.Join(extractDir, "evil"))
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "update", "--index", "0", "--annotation", "org.opencontainers.image.title=evil", artifactName)
+		session = podmanTest.Podman([]string{"artifact", "extract", "--all", artifactName, extractDir})
+		session.WaitWithDefaultTimeout()
+		Expect(session).To(ExitWithError(125, fmt.Sprintf(`Error: blob %s: refusing to extract "evil", %s is a symlink`, blobDigest, filepath.Join(extractDir, "evil"))))
+		Expect(readFileToString(outsideFile)).To(Equal("outside"))
+		dirEntries, err := os.ReadDir(outsideDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dirEntries).To(HaveLen(1))
+
+		// A symlink-like title is just a file name.
+		podmanTest.PodmanExitCleanly("artifact", "update", "--index", "0", "--annotation", "org.opencontainers.image.title=evil -> ..", artifactName)
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifactName, extractDir)
+		Expect(readFileToString(filepath.Join(extractDir, "evil -> .."))).To(Equal(readFileToString(artifactFile)))
 	})
 
 	It("podman artifact simple add --append", func() {
@@ -491,6 +3195,34 @@ var _ = Describe("Podman artifact", func() {
 		Expect(inspectFail).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact does not exist", artifact1Name)))
 	})
 
+	It("podman artifact add --allow-duplicate-titles", func() {
+		dir1 := filepath.Join(podmanTest.TempDir, "dir1")
+		dir2 := filepath.Join(podmanTest.TempDir, "dir2")
+		for i, dir := range []string{dir1, dir2} {
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "a.txt"), []byte(fmt.Sprintf("content %d", i)), 0o644)).To(Succeed())
+		}
+		file1 := filepath.Join(dir1, "a.txt")
+		file2 := filepath.Join(dir2, "a.txt")
+		artifact1Name := "localhost/test/artifact1"
+
+		session := podmanTest.Podman([]string{"artifact", "add", artifact1Name, file1, file2})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: a.txt: file already exists in artifact: %s and %s have the same title", file1, file2)))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, file1)
+		a := podmanTest.InspectArtifact(artifact1Name)
+		session = podmanTest.Podman([]string{"artifact", "add", "--append", artifact1Name, file2})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: a.txt: file already exists in artifact: blob %s of %s and %s have the same title", a.Manifest.Layers[0].Digest, artifact1Name, file2)))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--allow-duplicate-titles", artifact1Name, file2)
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		Expect(a.Manifest.Layers[0].Annotations[specV1.AnnotationTitle]).To(Equal("a.txt"))
+		Expect(a.Manifest.Layers[1].Annotations[specV1.AnnotationTitle]).To(Equal("a.txt"))
+	})
+
 	It("podman artifact add --append file already exists in artifact", func() {
 		artifact1File, err := createArtifactFile(2048)
 		Expect(err).ToNot(HaveOccurred())
@@ -498,15 +3230,93 @@ var _ = Describe("Podman artifact", func() {
 		artifact1Name := "localhost/test/artifact1"
 		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
 
-		appendFail := podmanTest.Podman([]string{"artifact", "add", "--append", artifact1Name, artifact1File})
+		// Appending the unchanged file is deduplicated
+		session := podmanTest.Podman([]string{"artifact", "add", "--append", artifact1Name, artifact1File})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		Expect(session.ErrorToString()).To(ContainSubstring(fmt.Sprintf("Skipping %s: the artifact already contains blob", filepath.Base(artifact1File))))
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+
+		// Allowing duplicates does not allow the same name twice
+		appendFail := podmanTest.Podman([]string{"artifact", "add", "--append", "--allow-duplicate", artifact1Name, artifact1File})
 		appendFail.WaitWithDefaultTimeout()
 		Expect(appendFail).Should(ExitWithError(125, fmt.Sprintf("Error: %s: file already exists in artifact", filepath.Base(artifact1File))))
-		a := podmanTest.InspectArtifact(artifact1Name)
+
+		// A file with the same name and different content is a conflict
+		f, err := os.OpenFile(artifact1File, os.O_APPEND|os.O_WRONLY, 0)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString("more")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		appendFail = podmanTest.Podman([]string{"artifact", "add", "--append", artifact1Name, artifact1File})
+		appendFail.WaitWithDefaultTimeout()
+		Expect(appendFail).Should(ExitWithError(125, fmt.Sprintf("Error: %s: file already exists in artifact", filepath.Base(artifact1File))))
+		a = podmanTest.InspectArtifact(artifact1Name)
 
 		Expect(a.Manifest.Layers).To(HaveLen(1))
 		Expect(a.TotalSizeBytes()).To(Equal(int64(2048)))
 	})
 
+	It("podman artifact add --append deduplicates identical blobs", func() {
+		artifact1File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+
+		// The same content under another name only updates the annotations
+		copyFile := filepath.Join(filepath.Dir(artifact1File), "copy")
+		err = os.WriteFile(copyFile, []byte(readFileToString(artifact1File)), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		session := podmanTest.Podman([]string{"artifact", "add", "--append", "--annotation", "color=blue", artifact1Name, copyFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(Exit(0))
+		Expect(session.ErrorToString()).To(ContainSubstring("Skipping copy: the artifact already contains blob"))
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+		Expect(a.Manifest.Layers[0].Annotations).To(HaveKeyWithValue("color", "blue"))
+		Expect(a.Manifest.Layers[0].Annotations).To(HaveKeyWithValue(specV1.AnnotationTitle, filepath.Base(artifact1File)))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--allow-duplicate", artifact1Name, copyFile)
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(2))
+		Expect(a.Manifest.Layers[1].Digest).To(Equal(a.Manifest.Layers[0].Digest))
+	})
+
+	It("podman artifact add --from-artifact", func() {
+		artifact1File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		srcName := "localhost/test/source"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "color=blue", srcName, artifact1File, artifact2File)
+		src := podmanTest.InspectArtifact(srcName)
+
+		// The blob keeps its title and annotations in the new artifact.
+		destName := "localhost/test/derived"
+		session := podmanTest.PodmanExitCleanly("artifact", "add", "--from-artifact", srcName, destName, filepath.Base(artifact1File))
+		Expect(session.ErrorToString()).To(Equal(fmt.Sprintf("Sharing %s: blob %s is already in the store, no content was copied", filepath.Base(artifact1File), src.Manifest.Layers[0].Digest.Encoded())))
+		dest := podmanTest.InspectArtifact(destName)
+		Expect(dest.Manifest.Layers).To(HaveLen(1))
+		Expect(dest.Manifest.Layers[0]).To(Equal(src.Manifest.Layers[0]))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--from-artifact", srcName, destName, src.Manifest.Layers[1].Digest.String())
+		dest = podmanTest.InspectArtifact(destName)
+		Expect(dest.Manifest.Layers).To(Equal(src.Manifest.Layers))
+
+		// The shared blobs stay in the store for the derived artifact.
+		podmanTest.PodmanExitCleanly("artifact", "rm", srcName)
+		extractDir := filepath.Join(podmanTest.TempDir, "derived")
+		podmanTest.PodmanExitCleanly("artifact", "extract", destName, extractDir)
+		Expect(readFileToString(filepath.Join(extractDir, filepath.Base(artifact1File)))).To(Equal(readFileToString(artifact1File)))
+		Expect(readFileToString(filepath.Join(extractDir, filepath.Base(artifact2File)))).To(Equal(readFileToString(artifact2File)))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--from-artifact", destName, "localhost/test/other", "missing"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, destName+`: no blob with the title "missing"`))
+	})
+
 	It("podman artifact add with --append and --type", func() {
 		artifact1Name := "localhost/test/artifact1"
 		artifact1File, err := createArtifactFile(1024)
@@ -533,9 +3343,569 @@ var _ = Describe("Podman artifact", func() {
 		Expect(a.Manifest.ArtifactType).To(Equal(artifactType))
 		Expect(a.Manifest.Layers).To(HaveLen(2))
 
-		failSession := podmanTest.Podman([]string{"artifact", "add", "--type", artifactType, "--append", artifact1Name, artifact3File})
+		// The type of the existing artifact may be repeated, but not changed
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", artifactType, "--append", artifact1Name, artifact3File)
+		a = podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.ArtifactType).To(Equal(artifactType))
+		Expect(a.Manifest.Layers).To(HaveLen(3))
+
+		artifact4File, err := createArtifactFile(512)
+		Expect(err).ToNot(HaveOccurred())
+		failSession := podmanTest.Podman([]string{"artifact", "add", "--type", "octet/other", "--append", artifact1Name, artifact4File})
+		failSession.WaitWithDefaultTimeout()
+		Expect(failSession).Should(ExitWithError(125, fmt.Sprintf(`Error: artifact type conflicts with the existing artifact: appending to %s with the type "octet/other", it has the type %q`, artifact1Name, artifactType)))
+
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact1File)
+		failSession = podmanTest.Podman([]string{"artifact", "add", "--type", artifactType, "--append", artifact2Name, artifact2File})
 		failSession.WaitWithDefaultTimeout()
-		Expect(failSession).Should(ExitWithError(125, "Error: append option is not compatible with ArtifactType option"))
+		Expect(failSession).Should(ExitWithError(125, fmt.Sprintf(`appending to %s with the type %q, it has no type`, artifact2Name, artifactType)))
+		a = podmanTest.InspectArtifact(artifact2Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+	})
+
+	It("podman artifact prune", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "test/one", artifact1Name, artifact1File)
+		add2 := podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+		artifact2Digest := add2.OutputToString()
+
+		// Named artifacts are not dangling
+		session := podmanTest.PodmanExitCleanly("artifact", "prune", "-f")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		// The dry run reports what would be removed without removing it
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--filter", "type=test/one")
+		lines := session.OutputToStringArray()
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[1]).To(HavePrefix("Total reclaimable space: "))
+		podmanTest.InspectArtifact(artifact1Name)
+
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "-f", "--filter", "type=test/one")
+		Expect(session.OutputToStringArray()[0]).To(Equal(lines[0]))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}", "--noheading")
+		Expect(session.OutputToStringArray()).To(Equal([]string{artifact2Name}))
+
+		session = podmanTest.Podman([]string{"artifact", "prune", "-f", "--filter", "bogus=1"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: "bogus" is an invalid artifact prune filter, supported filters are: annotation, dangling, kind, size, type, until`))
+
+		// All remaining artifacts are pruned
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "-f")
+		Expect(session.OutputToStringArray()[0]).To(Equal(artifact2Digest))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--noheading")
+		Expect(session.OutputToString()).To(BeEmpty())
+	})
+
+	It("podman artifact prune by size and age", func() {
+		smallFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		largeFile, err := createArtifactFile(1024 * 1024)
+		Expect(err).ToNot(HaveOccurred())
+		smallName := "localhost/test/small"
+		largeName := "localhost/test/large"
+		oldName := "localhost/test/old"
+		podmanTest.PodmanExitCleanly("artifact", "add", smallName, smallFile)
+		addLarge := podmanTest.PodmanExitCleanly("artifact", "add", largeName, largeFile)
+		podmanTest.PodmanExitCleanly("artifact", "add", oldName, smallFile)
+		update := podmanTest.PodmanExitCleanly("artifact", "update", "--annotation", specV1.AnnotationCreated+"=2020-01-01T00:00:00Z", oldName)
+		oldDigest := update.OutputToString()
+		update = podmanTest.PodmanExitCleanly("artifact", "update", "--remove-annotation", specV1.AnnotationCreated, smallName)
+		smallDigest := update.OutputToString()
+
+		session := podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--filter", "size=>100kB")
+		lines := session.OutputToStringArray()
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(Equal(addLarge.OutputToString()))
+		Expect(lines[1]).To(Equal("Total reclaimable space: 1.049MB"))
+
+		// Several size filters select a range
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--filter", "size=>=1024", "--filter", "size=<1MB")
+		Expect(session.OutputToStringArray()).To(HaveLen(3))
+
+		session = podmanTest.Podman([]string{"artifact", "prune", "-a", "-f", "--filter", "size=1GB"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: "1GB" is not a valid value for the "size" filter - must be a size prefixed with <, <=, > or >=`))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--filter", "until=24h")
+		lines = session.OutputToStringArray()
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(Equal(oldDigest))
+
+		// The artifact without a creation time is only included by the
+		// time it was stored with --include-undated
+		time.Sleep(2 * time.Second)
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--filter", "until=1s")
+		Expect(session.OutputToStringArray()).To(HaveLen(3))
+		Expect(session.OutputToString()).ToNot(ContainSubstring(smallDigest))
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "--dry-run", "--include-undated", "--filter", "until=1s")
+		Expect(session.OutputToStringArray()).To(HaveLen(4))
+		Expect(session.OutputToString()).To(ContainSubstring(smallDigest))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "prune", "-a", "-f", "--filter", "size=<2kB", "--filter", "until=24h")
+		Expect(session.OutputToStringArray()[0]).To(Equal(oldDigest))
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Digest}}", "--noheading", "--no-trunc")
+		Expect(session.OutputToString()).To(ContainSubstring(smallDigest))
+		Expect(session.OutputToString()).ToNot(ContainSubstring(oldDigest))
+	})
+
+	It("podman artifact export", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "text/yaml", "--annotation", "color=blue", artifact1Name, artifact1File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+		inspect := podmanTest.PodmanExitCleanly("artifact", "inspect", artifact1Name)
+		reports := []entities.ArtifactInspectReport{}
+		err = json.Unmarshal(inspect.Out.Contents(), &reports)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+
+		// The layout holds the unchanged manifest under the artifact name
+		layoutDir := filepath.Join(podmanTest.TempDir, "layout")
+		podmanTest.PodmanExitCleanly("artifact", "export", "--format", "oci-dir", artifact1Name, layoutDir)
+		indexData, err := os.ReadFile(filepath.Join(layoutDir, "index.json"))
+		Expect(err).ToNot(HaveOccurred())
+		index := specV1.Index{}
+		err = json.Unmarshal(indexData, &index)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(index.Manifests).To(HaveLen(1))
+		Expect(index.Manifests[0].Digest.String()).To(Equal(report.Digest))
+		Expect(index.Manifests[0].Annotations[specV1.AnnotationRefName]).To(Equal(artifact1Name))
+		for _, layer := range a.Manifest.Layers {
+			Expect(filepath.Join(layoutDir, "blobs", "sha256", layer.Digest.Encoded())).To(BeARegularFile())
+		}
+
+		// The default is a tar archive of the layout
+		archivePath := filepath.Join(podmanTest.TempDir, "artifact.tar")
+		podmanTest.PodmanExitCleanly("artifact", "export", artifact1Name, archivePath)
+		tarSession := SystemExec("tar", []string{"tf", archivePath})
+		Expect(tarSession).Should(ExitCleanly())
+		Expect(tarSession.OutputToStringArray()).To(ContainElements("index.json", "oci-layout", "blobs/sha256/"+a.Manifest.Layers[0].Digest.Encoded()))
+
+		session := podmanTest.Podman([]string{"artifact", "export", "--format", "docker-archive", artifact1Name, archivePath})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `unsupported export format "docker-archive", must be oci-archive or oci-dir`))
+	})
+
+	It("podman artifact add from oci-archive and dir sources", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "text/yaml", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", "--file-type", "application/x-foo+gzip", artifact1Name, artifact2File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+		archivePath := filepath.Join(podmanTest.TempDir, "artifact.tar")
+		podmanTest.PodmanExitCleanly("artifact", "export", artifact1Name, archivePath)
+
+		// The blobs of the archive keep their media types and titles
+		artifact3File, err := createArtifactFile(512)
+		Expect(err).ToNot(HaveOccurred())
+		repackagedName := "localhost/test/repackaged"
+		podmanTest.PodmanExitCleanly("artifact", "add", repackagedName, "oci-archive:"+archivePath, artifact3File)
+		repackaged := podmanTest.InspectArtifact(repackagedName)
+		Expect(repackaged.Manifest.Layers).To(HaveLen(3))
+		for i, layer := range a.Manifest.Layers {
+			Expect(repackaged.Manifest.Layers[i].Digest).To(Equal(layer.Digest))
+			Expect(repackaged.Manifest.Layers[i].MediaType).To(Equal(layer.MediaType))
+			Expect(repackaged.Manifest.Layers[i].Annotations[specV1.AnnotationTitle]).To(Equal(layer.Annotations[specV1.AnnotationTitle]))
+		}
+		Expect(repackaged.Manifest.Layers[2].Annotations[specV1.AnnotationTitle]).To(Equal(filepath.Base(artifact3File)))
+
+		// Blobs of a dir source without a title are named after their digest
+		dirPath := filepath.Join(podmanTest.TempDir, "dirsource")
+		err = os.MkdirAll(dirPath, 0o755)
+		Expect(err).ToNot(HaveOccurred())
+		content := []byte("layer content")
+		layerDigest := digest.FromBytes(content)
+		err = os.WriteFile(filepath.Join(dirPath, layerDigest.Encoded()), content, 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		mani := specV1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: specV1.MediaTypeImageManifest,
+			Config:    specV1.DescriptorEmptyJSON,
+			Layers:    []specV1.Descriptor{{MediaType: specV1.MediaTypeImageLayerGzip, Digest: layerDigest, Size: int64(len(content))}},
+		}
+		maniData, err := json.Marshal(mani)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(filepath.Join(dirPath, "manifest.json"), maniData, 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(filepath.Join(dirPath, "version"), []byte("Directory Transport Version: 1.1\n"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+
+		dirName := "localhost/test/fromdir"
+		podmanTest.PodmanExitCleanly("artifact", "add", dirName, "dir:"+dirPath)
+		fromDir := podmanTest.InspectArtifact(dirName)
+		Expect(fromDir.Manifest.Layers).To(HaveLen(1))
+		Expect(fromDir.Manifest.Layers[0].Digest).To(Equal(layerDigest))
+		Expect(fromDir.Manifest.Layers[0].MediaType).To(Equal(specV1.MediaTypeImageLayerGzip))
+		Expect(fromDir.Manifest.Layers[0].Annotations[specV1.AnnotationTitle]).To(Equal(strings.ReplaceAll(layerDigest.String(), ":", "-")))
+
+		// Content not matching its digest is rejected
+		err = os.WriteFile(filepath.Join(dirPath, layerDigest.Encoded()), []byte("corrupted"), 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		session := podmanTest.Podman([]string{"artifact", "add", "localhost/test/corrupt", "dir:" + dirPath})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "blob digest does not match the manifest"))
+	})
+
+	It("podman artifact export and import", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		add1 := podmanTest.PodmanExitCleanly("artifact", "add", "--file-type", "text/yaml", "--annotation", "color=blue", artifact1Name, artifact1File)
+		add2 := podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		// A round trip keeps the digest, annotations and media types
+		archivePath := filepath.Join(podmanTest.TempDir, "artifact.tar")
+		podmanTest.PodmanExitCleanly("artifact", "export", artifact1Name, archivePath)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		session := podmanTest.PodmanExitCleanly("artifact", "import", archivePath, artifact1Name)
+		Expect(session.OutputToString()).To(Equal(add1.OutputToString()))
+		imported := podmanTest.InspectArtifact(artifact1Name)
+		Expect(imported.Manifest).To(Equal(a.Manifest))
+
+		session = podmanTest.Podman([]string{"artifact", "import", archivePath, artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact already exists", artifact1Name)))
+
+		// A layout with several manifests needs a digest to select one
+		layoutDir := filepath.Join(podmanTest.TempDir, "layout")
+		podmanTest.PodmanExitCleanly("artifact", "export", "--format", "oci-dir", artifact1Name, layoutDir)
+		podmanTest.PodmanExitCleanly("artifact", "export", "--format", "oci-dir", artifact2Name, layoutDir)
+		session = podmanTest.Podman([]string{"artifact", "import", layoutDir, "localhost/test/artifact3"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s contains 2 manifests, select the one to import by its digest", layoutDir)))
+		session = podmanTest.PodmanExitCleanly("artifact", "import", "--digest", "sha256:"+add2.OutputToString(), layoutDir, "localhost/test/artifact3")
+		Expect(session.OutputToString()).To(Equal(add2.OutputToString()))
+
+		// Container images are not artifacts
+		imageArchive := filepath.Join(podmanTest.TempDir, "image.tar")
+		podmanTest.PodmanExitCleanly("save", "-q", "--format", "oci-archive", "-o", imageArchive, ALPINE)
+		session = podmanTest.Podman([]string{"artifact", "import", imageArchive, "localhost/test/image"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s: the manifest is a container image, not an artifact", imageArchive)))
+	})
+
+	It("podman artifact ls --referrers", func() {
+		subjectFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		sbomFile, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		subjectName := "localhost/test/image"
+		sbomName := "localhost/test/sbom"
+		attachedName := "localhost/test/image-sbom"
+		addSubject := podmanTest.PodmanExitCleanly("artifact", "add", subjectName, subjectFile)
+		podmanTest.PodmanExitCleanly("artifact", "add", sbomName, sbomFile)
+
+		// Attach a copy of the sbom artifact to the subject
+		layoutDir := filepath.Join(podmanTest.TempDir, "layout")
+		podmanTest.PodmanExitCleanly("artifact", "export", "--format", "oci-dir", subjectName, layoutDir)
+		podmanTest.PodmanExitCleanly("artifact", "export", "--format", "oci-dir", sbomName, layoutDir)
+		subjectDigest := digest.NewDigestFromEncoded(digest.SHA256, addSubject.OutputToString())
+		subjectInfo, err := os.Stat(filepath.Join(layoutDir, "blobs", "sha256", subjectDigest.Encoded()))
+		Expect(err).ToNot(HaveOccurred())
+		sbom := podmanTest.InspectArtifact(sbomName)
+		mani := sbom.Manifest
+		mani.Subject = &specV1.Descriptor{MediaType: specV1.MediaTypeImageManifest, Digest: subjectDigest, Size: subjectInfo.Size()}
+		maniData, err := json.Marshal(mani)
+		Expect(err).ToNot(HaveOccurred())
+		attachedDigest := digest.FromBytes(maniData)
+		err = os.WriteFile(filepath.Join(layoutDir, "blobs", "sha256", attachedDigest.Encoded()), maniData, 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		indexData, err := os.ReadFile(filepath.Join(layoutDir, "index.json"))
+		Expect(err).ToNot(HaveOccurred())
+		index := specV1.Index{}
+		err = json.Unmarshal(indexData, &index)
+		Expect(err).ToNot(HaveOccurred())
+		index.Manifests = append(index.Manifests, specV1.Descriptor{MediaType: specV1.MediaTypeImageManifest, Digest: attachedDigest, Size: int64(len(maniData))})
+		indexData, err = json.Marshal(index)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(filepath.Join(layoutDir, "index.json"), indexData, 0o644)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "import", "--digest", attachedDigest.String(), layoutDir, attachedName)
+
+		// The subject can be given by name, by digest or by a reference with a digest
+		for _, subject := range []string{subjectName, subjectDigest.String(), subjectName + "@" + subjectDigest.String()} {
+			session := podmanTest.PodmanExitCleanly("artifact", "ls", "--referrers", subject, "--format", "{{.Repository}}")
+			Expect(session.OutputToStringArray()).To(Equal([]string{attachedName}))
+		}
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--referrers", sbomName, "--format", "{{.Repository}}")
+		Expect(session.OutputToString()).To(BeEmpty())
+
+		session = podmanTest.Podman([]string{"artifact", "ls", "--referrers", "localhost/test/bogus"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: looking up the subject: localhost/test/bogus: image not known"))
+	})
+
+	It("podman artifact ls --stream", func() {
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifactFile)
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/vnd.example+type", artifact2Name, artifactFile)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--stream")
+		lines := session.OutputToStringArray()
+		Expect(lines).To(HaveLen(2))
+		var repositories []string
+		for _, line := range lines {
+			var output map[string]any
+			Expect(json.Unmarshal([]byte(line), &output)).To(Succeed())
+			Expect(output).To(HaveKeyWithValue("TotalSize", BeNumerically("==", 1024)))
+			repositories = append(repositories, output["Repository"].(string))
+		}
+		Expect(repositories).To(ConsistOf(artifact1Name, artifact2Name))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--stream", "--quiet", "--no-trunc", "--filter", "type=application/vnd.example+type")
+		a := podmanTest.InspectArtifact(artifact2Name)
+		artifactDigest, err := a.GetDigest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(session.OutputToString()).To(Equal(artifactDigest.Encoded()))
+
+		session = podmanTest.Podman([]string{"artifact", "ls", "--stream", "--sort", "size"})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: stream cannot be used together with the sort and format flags"))
+	})
+
+	It("podman artifact add --strict-type", func() {
+		artifact1Name := "localhost/test/artifact1"
+		artifactFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		fileName := filepath.Base(artifactFile)
+
+		session := podmanTest.Podman([]string{"artifact", "add", "--strict-type", "--type", "application/vnd.cncf.helm.chart", artifact1Name, artifactFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf(`Error: %s: media type "application/octet-stream" of the blob is not consistent with the artifact type "application/vnd.cncf.helm.chart"`, fileName)))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--strict-type", artifact1Name, artifactFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: strict type checking requires an artifact type"))
+
+		podmanTest.PodmanExitCleanly("artifact", "add", "--strict-type", "--type", "application/vnd.cncf.helm.chart", "--file-type", "application/vnd.cncf.helm.chart.content.v1.tar+gzip", artifact1Name, artifactFile)
+
+		// Appending checks against the type of the existing artifact
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		session = podmanTest.Podman([]string{"artifact", "add", "--append", "--strict-type", artifact1Name, artifact2File})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "is not consistent with the artifact type"))
+		a := podmanTest.InspectArtifact(artifact1Name)
+		Expect(a.Manifest.Layers).To(HaveLen(1))
+
+		// Without --strict-type any media type is accepted
+		podmanTest.PodmanExitCleanly("artifact", "add", "--append", artifact1Name, artifact2File)
+	})
+
+	It("podman artifact add --subject", func() {
+		subjectFile, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		sbomFile, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		subjectName := fmt.Sprintf("localhost:%s/test/image", port)
+		sbomName := fmt.Sprintf("localhost:%s/test/image-sbom", port)
+		addSubject := podmanTest.PodmanExitCleanly("artifact", "add", subjectName, subjectFile)
+		subjectDigest := "sha256:" + addSubject.OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "add", "--type", "application/spdx+json", "--subject", subjectDigest, sbomName, sbomFile)
+
+		a := podmanTest.InspectArtifact(subjectName)
+		subjectManifest, err := json.Marshal(a.Manifest)
+		Expect(err).ToNot(HaveOccurred())
+		sbom := podmanTest.InspectArtifact(sbomName)
+		Expect(sbom.Manifest.ArtifactType).To(Equal("application/spdx+json"))
+		Expect(sbom.Manifest.Subject).ToNot(BeNil())
+		Expect(sbom.Manifest.Subject.Digest.String()).To(Equal(subjectDigest))
+		Expect(sbom.Manifest.Subject.MediaType).To(Equal(specV1.MediaTypeImageManifest))
+		Expect(sbom.Manifest.Subject.Size).To(Equal(int64(len(subjectManifest))))
+
+		session := podmanTest.PodmanExitCleanly("artifact", "ls", "--referrers", subjectName, "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{sbomName}))
+
+		// The subject is kept by a push and pull round trip
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", sbomName)
+		podmanTest.PodmanExitCleanly("artifact", "rm", sbomName)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", sbomName)
+		pulled := podmanTest.InspectArtifact(sbomName)
+		Expect(pulled.Manifest.Subject).To(Equal(sbom.Manifest.Subject))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--subject", "sha256:bogus", "localhost/test/bogus", sbomFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: invalid subject "sha256:bogus", must be a manifest digest`))
+
+		unknownDigest := digest.FromString("unknown").String()
+		session = podmanTest.Podman([]string{"artifact", "add", "--subject", unknownDigest, "localhost/test/bogus", sbomFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: subject %s is not the manifest of an image or artifact in local storage, pull it first", unknownDigest)))
+
+		session = podmanTest.Podman([]string{"artifact", "add", "--append", "--subject", subjectDigest, sbomName, subjectFile})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: append option is not compatible with Subject option"))
+	})
+
+	It("podman artifact events", func() {
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		lock, port, err := setupRegistry(nil)
+		if err == nil {
+			defer lock.Unlock()
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact1Name := "localhost:" + port + "/test/artifact1:latest"
+		session := podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		artifactDigest := session.OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "push", "-q", "--tls-verify=false", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifactDigest)
+		podmanTest.PodmanExitCleanly("artifact", "pull", "-q", "--tls-verify=false", artifact1Name)
+
+		session = podmanTest.PodmanExitCleanly("events", "--stream=false", "--filter", "type=artifact", "--format", "{{.Status}} {{.Name}} {{.ID}}")
+		Expect(session.OutputToStringArray()).To(Equal([]string{
+			fmt.Sprintf("add %s %s", artifact1Name, artifactDigest),
+			fmt.Sprintf("push %s %s", artifact1Name, artifactDigest),
+			fmt.Sprintf("remove %s %s", artifact1Name, artifactDigest),
+			fmt.Sprintf("pull %s %s", artifact1Name, artifactDigest),
+		}))
+	})
+
+	It("podman artifact update", func() {
+		artifact1Name := "localhost/test/artifact1"
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		add := podmanTest.PodmanExitCleanly("artifact", "add", "--annotation", "color=blue", artifact1Name, artifact1File, artifact2File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		// Manifest annotations
+		session := podmanTest.PodmanExitCleanly("artifact", "update", "--annotation", "flavor=vanilla", artifact1Name)
+		Expect(session.OutputToString()).ToNot(Equal(add.OutputToString()))
+		updated := podmanTest.InspectArtifact(artifact1Name)
+		Expect(updated.Manifest.Annotations).To(HaveKeyWithValue("flavor", "vanilla"))
+		Expect(updated.Manifest.Layers).To(Equal(a.Manifest.Layers))
+
+		// The previous manifest is gone
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Digest}}")
+		Expect(session.OutputToStringArray()).To(HaveLen(1))
+
+		// Blob annotations
+		title := filepath.Base(artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "update", "--title", title, "--annotation", "org.opencontainers.image.title=renamed.txt", "--remove-annotation", "color", artifact1Name)
+		updated = podmanTest.InspectArtifact(artifact1Name)
+		Expect(updated.Manifest.Layers[0].Digest).To(Equal(a.Manifest.Layers[0].Digest))
+		Expect(updated.Manifest.Layers[0].Annotations).To(Equal(map[string]string{"org.opencontainers.image.title": "renamed.txt"}))
+		Expect(updated.Manifest.Layers[1].Annotations).To(Equal(a.Manifest.Layers[1].Annotations))
+
+		session = podmanTest.Podman([]string{"artifact", "update", "--index", "1", "--annotation", "org.opencontainers.image.title=renamed.txt", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: renamed.txt: file already exists in artifact"))
+
+		session = podmanTest.Podman([]string{"artifact", "update", "--annotation", "color=red", "--remove-annotation", "color", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, `Error: annotation "color" cannot be both set and removed`))
+
+		session = podmanTest.Podman([]string{"artifact", "update", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, "Error: at least one of --annotation or --remove-annotation must be specified"))
+	})
+
+	It("podman artifact tag", func() {
+		artifact1Name := "localhost/test/artifact1"
+		artifact2Name := "localhost/test/artifact2"
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File)
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact2Name, artifact2File)
+		a := podmanTest.InspectArtifact(artifact1Name)
+
+		// Both names refer to the same manifest
+		session := podmanTest.PodmanExitCleanly("artifact", "tag", artifact1Name, "localhost/test/tagged:v1")
+		Expect(session.OutputToString()).To(Equal("localhost/test/tagged:v1"))
+		tagged := podmanTest.InspectArtifact("localhost/test/tagged:v1")
+		Expect(tagged.Manifest).To(Equal(a.Manifest))
+		podmanTest.InspectArtifact(artifact1Name)
+
+		session = podmanTest.Podman([]string{"artifact", "tag", artifact1Name, artifact2Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: %s: artifact already exists", artifact2Name)))
+
+		// --force replaces the other artifact
+		podmanTest.PodmanExitCleanly("artifact", "tag", "--force", artifact1Name, artifact2Name)
+		tagged = podmanTest.InspectArtifact(artifact2Name)
+		Expect(tagged.Manifest).To(Equal(a.Manifest))
+
+		// --remove-old renames the artifact
+		podmanTest.PodmanExitCleanly("artifact", "tag", "--remove-old", "localhost/test/tagged:v1", "localhost/test/renamed")
+		podmanTest.InspectArtifact("localhost/test/renamed")
+		session = podmanTest.PodmanExitCleanly("artifact", "ls", "--format", "{{.Repository}}")
+		Expect(session.OutputToStringArray()).To(ConsistOf(artifact1Name, artifact2Name, "localhost/test/renamed"))
+
+		// Removing one name keeps the blobs of the others
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		podmanTest.PodmanExitCleanly("artifact", "extract", artifact2Name, podmanTest.TempDir)
+	})
+
+	It("podman artifact mount and unmount", func() {
+		artifact1Name := "localhost/test/artifact1"
+		artifact1File, err := createArtifactFile(1024)
+		Expect(err).ToNot(HaveOccurred())
+		artifact2File, err := createArtifactFile(2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		podmanTest.PodmanExitCleanly("artifact", "add", artifact1Name, artifact1File, artifact2File)
+
+		session := podmanTest.PodmanExitCleanly("artifact", "mount", artifact1Name)
+		mountPoint := session.OutputToString()
+		Expect(readFileToString(filepath.Join(mountPoint, filepath.Base(artifact1File)))).To(Equal(readFileToString(artifact1File)))
+		Expect(readFileToString(filepath.Join(mountPoint, filepath.Base(artifact2File)))).To(Equal(readFileToString(artifact2File)))
+
+		st, err := os.Stat(mountPoint)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode().Perm() & 0o222).To(BeZero())
+		st, err = os.Stat(filepath.Join(mountPoint, filepath.Base(artifact1File)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Mode().Perm()).To(Equal(os.FileMode(0o444)))
+
+		// Mounting again returns the same mountpoint
+		session = podmanTest.PodmanExitCleanly("artifact", "mount", artifact1Name)
+		Expect(session.OutputToString()).To(Equal(mountPoint))
+
+		session = podmanTest.PodmanExitCleanly("artifact", "unmount", artifact1Name)
+		Expect(session.OutputToString()).To(Equal(artifact1Name))
+		Expect(mountPoint).ToNot(BeADirectory())
+
+		session = podmanTest.Podman([]string{"artifact", "umount", artifact1Name})
+		session.WaitWithDefaultTimeout()
+		Expect(session).Should(ExitWithError(125, fmt.Sprintf("Error: artifact %s is not mounted", artifact1Name)))
+
+		// Removing the artifact removes its mount
+		session = podmanTest.PodmanExitCleanly("artifact", "mount", artifact1Name)
+		mountPoint = session.OutputToString()
+		podmanTest.PodmanExitCleanly("artifact", "rm", artifact1Name)
+		Expect(mountPoint).ToNot(BeADirectory())
 	})
 })
 
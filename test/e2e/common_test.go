@@ -497,11 +497,12 @@ func (s *PodmanSessionIntegration) InspectImageJSON() []inspect.ImageData {
 
 // InspectArtifactToJSON takes the session output of an artifact inspect and returns json
 func (s *PodmanSessionIntegration) InspectArtifactToJSON() libartifact.Artifact {
-	a := libartifact.Artifact{}
+	var a []libartifact.Artifact
 	inspectOut := s.OutputToString()
 	err := json.Unmarshal([]byte(inspectOut), &a)
 	Expect(err).ToNot(HaveOccurred())
-	return a
+	Expect(a).To(HaveLen(1))
+	return a[0]
 }
 
 // PodmanExitCleanly runs a podman command with args, and expects it to ExitCleanly within the default timeout.
@@ -1591,6 +1592,28 @@ func UsingCacheRegistry() bool {
 }
 
 func setupRegistry(portOverride *int) (*lockfile.LockFile, string, error) {
+	return startRegistry(portOverride)
+}
+
+// setupAuthRegistry starts a registry which only accepts the given user and
+// password, checked with htpasswd.
+func setupAuthRegistry(user, password string) (*lockfile.LockFile, string, error) {
+	authPath := filepath.Join(podmanTest.TempDir, "auth")
+	if err := os.MkdirAll(authPath, 0o755); err != nil {
+		return nil, "", err
+	}
+	htpasswd := SystemExec("htpasswd", []string{"-Bbn", user, password})
+	Expect(htpasswd).Should(ExitCleanly())
+	if err := os.WriteFile(filepath.Join(authPath, "htpasswd"), []byte(htpasswd.OutputToString()), 0o644); err != nil {
+		return nil, "", err
+	}
+	return startRegistry(nil, "-v", authPath+":/auth:z", "-e", "REGISTRY_AUTH=htpasswd",
+		"-e", "REGISTRY_AUTH_HTPASSWD_REALM=Registry Realm", "-e", "REGISTRY_AUTH_HTPASSWD_PATH=/auth/htpasswd")
+}
+
+// startRegistry runs a registry container named registry, with the run
+// options in args.
+func startRegistry(portOverride *int, args ...string) (*lockfile.LockFile, string, error) {
 	var port string
 	if isRootless() {
 		if err := podmanTest.RestoreArtifact(REGISTRY_IMAGE); err != nil {
@@ -1610,7 +1633,8 @@ func setupRegistry(portOverride *int) (*lockfile.LockFile, string, error) {
 
 	lock := GetPortLock(port)
 
-	session := podmanTest.Podman([]string{"run", "-d", "--name", "registry", "-p", fmt.Sprintf("%s:5000", port), REGISTRY_IMAGE, "/entrypoint.sh", "/etc/docker/registry/config.yml"})
+	runArgs := append([]string{"run", "-d", "--name", "registry", "-p", fmt.Sprintf("%s:5000", port)}, args...)
+	session := podmanTest.Podman(append(runArgs, REGISTRY_IMAGE, "/entrypoint.sh", "/etc/docker/registry/config.yml"))
 	session.WaitWithDefaultTimeout()
 	Expect(session).Should(ExitCleanly())
 